@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/config"
+)
+
+// runConfigCommand prints the effective configuration with the source of each value,
+// as TOML (the default) or JSON. Returns the process exit code.
+func runConfigCommand(w io.Writer, resolved *config.Resolved, format string) int {
+	switch format {
+	case "", "toml":
+		for _, key := range resolved.Keys() {
+			_, _ = fmt.Fprintf(w, "%s = %s # %s\n", key, tomlValue(resolved.Values[key]), resolved.Sources[key])
+		}
+	case "json":
+		type entry struct {
+			Value  interface{} `json:"value"`
+			Source string      `json:"source"`
+		}
+		entries := make(map[string]entry, len(resolved.Values))
+		for key, value := range resolved.Values {
+			entries[key] = entry{Value: value, Source: resolved.Sources[key]}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode configuration: %v\n", err)
+			return 1
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (use toml or json)\n", format)
+		return 2
+	}
+	return 0
+}
+
+// tomlValue formats a configuration value as a TOML value, using inline tables for maps
+func tomlValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return `""`
+	case string:
+		return strconv.Quote(v)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			fields[i] = key + " = " + tomlValue(v[key])
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	}
+
+	// Slices of any element type (flags produce []string, files []interface{})
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice {
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = tomlValue(rv.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
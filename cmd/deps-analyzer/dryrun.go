@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ritzau/deps-analyzer/pkg/analysis"
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/watcher"
+)
+
+// runDryRun prints the analysis options the watcher would produce for the given changed
+// files if they changed together, without running any analysis. Returns the process exit code.
+func runDryRun(w io.Writer, cfg *config.Config, paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: deps-analyzer --dry-run <changed file>...\n")
+		return 2
+	}
+
+	// The watcher's debouncer merges changes made together into one re-analysis
+	events, ignored := watcher.ChangeEventsForPaths(paths)
+	if len(events) > 0 {
+		event := watcher.MergeChangeEvents(events)
+		opts := analysis.OptionsForChange(event, cfg.Workspace, changePolicy(cfg.Reanalysis))
+
+		_, _ = fmt.Fprintf(w, "%s:\n", opts.Reason)
+		for _, path := range event.Paths {
+			_, _ = fmt.Fprintf(w, "  %s\n", path)
+		}
+		_, _ = fmt.Fprintf(w, "  FullAnalysis:    %v\n", opts.FullAnalysis)
+		_, _ = fmt.Fprintf(w, "  SkipBazelQuery:  %v\n", opts.SkipBazelQuery)
+		_, _ = fmt.Fprintf(w, "  SkipCompileDeps: %v\n", opts.SkipCompileDeps)
+		_, _ = fmt.Fprintf(w, "  SkipSymbolDeps:  %v\n", opts.SkipSymbolDeps)
+		_, _ = fmt.Fprintf(w, "  SkipBinaryDeriv: %v\n", opts.SkipBinaryDeriv)
+		_, _ = fmt.Fprintln(w)
+	}
+
+	if len(ignored) > 0 {
+		_, _ = fmt.Fprintf(w, "Ignored (no analysis triggered):\n")
+		for _, path := range ignored {
+			_, _ = fmt.Fprintf(w, "  %s\n", path)
+		}
+	}
+
+	return 0
+}
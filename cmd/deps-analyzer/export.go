@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/ritzau/deps-analyzer/pkg/analysis"
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/output"
+	"github.com/ritzau/deps-analyzer/pkg/web"
+)
+
+// exitIssues is the exit status of a one-shot analysis that found issues at or above the
+// --fail-on-issues severity, telling them apart from a failed analysis (1) in CI
+const exitIssues = 3
+
+// runExport analyzes the workspace and writes the results to w in the --format format: a
+// text report, the module as JSON, the records selected by --emit as JSON Lines, the module
+// graph for Cytoscape.js or Graphviz, or the package scorecard.
+// Logs go to stderr so that w only carries records. Returns the process exit code, which
+// is exitIssues if --fail-on-issues is set and an issue reaches its severity.
+func runExport(w io.Writer, cfg *config.Config, verbose bool) int {
+	logging.SetOutput(os.Stderr)
+	if !verbose {
+		logging.SetLevel(slog.LevelWarn)
+	}
+
+	// The server is only used as a sink for the analysis results
+	server := web.NewServer()
+	runner := newAnalysisRunner(cfg, server)
+
+	// Binary derivation only contributes issues
+	withIssues := cfg.Format == config.FormatText || cfg.Format == config.FormatJSON ||
+		(cfg.Format == config.FormatJSONLines && cfg.Emit == config.EmitIssues) || cfg.FailOnIssues != ""
+
+	err := runner.Run(context.Background(), analysis.AnalysisOptions{
+		FullAnalysis:        true,
+		SkipBinaryDeriv:     !withIssues,
+		SkipDynamicAnalysis: true,
+		Reason:              "export",
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Analysis failed: %v\n", err)
+		return 1
+	}
+
+	module := server.GetModule()
+	if module == nil {
+		fmt.Fprintf(os.Stderr, "Analysis produced no module data\n")
+		return 1
+	}
+
+	buffered := bufio.NewWriter(w)
+	switch cfg.Format {
+	case config.FormatText:
+		opts := output.Options{}
+		if f, ok := w.(*os.File); ok {
+			opts.Color = output.UseColor(f, cfg.NoColor)
+		}
+		output.PrintModuleReport(buffered, module, server.GetUncoveredFiles(), opts)
+	case config.FormatJSON:
+		encoder := json.NewEncoder(buffered)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(module)
+	case config.FormatCytoscape:
+		err = json.NewEncoder(buffered).Encode(server.CytoscapeGraph())
+	case config.FormatScorecardCSV:
+		scores := output.PackageScorecard(module, server.GetFileToTargetMap(), server.GetUncoveredFiles())
+		err = output.WriteScorecardCSV(buffered, scores)
+	case config.FormatDOT:
+		err = output.WriteDOT(buffered, module, output.DOTOptions{EdgeLabels: cfg.EdgeLabels})
+	default:
+		err = output.WriteJSONLines(buffered, module, cfg.Emit)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		return 1
+	}
+	if err := buffered.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		return 1
+	}
+	return issuesExitCode(os.Stderr, module, cfg)
+}
+
+// issuesExitCode returns exitIssues, after listing the issues on w, if the module has issues
+// at or above the --fail-on-issues severity once the configured severity overrides are
+// applied, and 0 otherwise
+func issuesExitCode(w io.Writer, module *model.Module, cfg *config.Config) int {
+	if cfg.FailOnIssues == "" {
+		return 0
+	}
+
+	module.ApplySeverities(cfg.Severity)
+	failing := module.IssuesAtLeast(cfg.FailOnIssues)
+	if len(failing) == 0 {
+		return 0
+	}
+
+	fmt.Fprintf(w, "%d issues with severity %s or above:\n", len(failing), cfg.FailOnIssues)
+	for _, issue := range failing {
+		fmt.Fprintf(w, "  %s %s: %s -> %s\n", issue.Severity, issue.Issue, issue.From, issue.To)
+	}
+	return exitIssues
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestIssuesExitCode(t *testing.T) {
+	newModule := func() *model.Module {
+		return &model.Module{Issues: []model.DependencyIssue{
+			{From: "//ui:ui", To: "//db:db", Issue: "policy_violation", Severity: model.SeverityError},
+			{From: "//main:app", To: "//core:core", Issue: "redundant_dynamic_dep", Severity: model.SeverityWarning},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		want     int
+		reported string
+	}{
+		{"not set", config.Config{}, 0, ""},
+		{"policy violation fails the error gate", config.Config{FailOnIssues: model.SeverityError}, exitIssues, "policy_violation: //ui:ui -> //db:db"},
+		{"warning gate includes errors", config.Config{FailOnIssues: model.SeverityWarning}, exitIssues, "2 issues"},
+		{
+			"overrides are applied first",
+			config.Config{FailOnIssues: model.SeverityWarning, Severity: map[string]string{"policy_violation": "info", "redundant_dynamic_dep": "info"}},
+			0, "",
+		},
+		{
+			"overrides can raise an issue to the gate",
+			config.Config{FailOnIssues: model.SeverityError, Severity: map[string]string{"policy_violation": "info", "redundant_dynamic_dep": "error"}},
+			exitIssues, "error redundant_dynamic_dep",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if got := issuesExitCode(&buf, newModule(), &tt.cfg); got != tt.want {
+				t.Errorf("issuesExitCode() = %d, want %d", got, tt.want)
+			}
+			if tt.reported == "" && buf.Len() > 0 {
+				t.Errorf("expected no output, got %q", buf.String())
+			}
+			if !strings.Contains(buf.String(), tt.reported) {
+				t.Errorf("output %q does not mention %q", buf.String(), tt.reported)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ritzau/deps-analyzer/pkg/deps"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/symbols"
+)
+
+// runInspectCommand prints what a single build artifact says, without analyzing the
+// workspace: the source and workspace dependencies of a .d file, or the symbols an object
+// file defines and needs. The source file of a .d file is found by the extensions of ext.
+// Returns the process exit code.
+func runInspectCommand(w io.Writer, path string, ext model.FileExtensions) int {
+	switch filepath.Ext(path) {
+	case ".d":
+		fileDeps, err := deps.ParseDFile(path, ext)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse %s: %v\n", path, err)
+			return 1
+		}
+		printDFile(w, path, fileDeps)
+	case ".o":
+		syms, err := symbols.RunNM(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read symbols: %v\n", err)
+			return 1
+		}
+		printObjectSymbols(w, path, syms)
+	default:
+		fmt.Fprintf(os.Stderr, "Cannot inspect %s: expected a .d or .o file\n", path)
+		return 2
+	}
+	return 0
+}
+
+// printDFile renders the parsed contents of a .d file
+func printDFile(w io.Writer, path string, fileDeps *deps.FileDependency) {
+	_, _ = fmt.Fprintf(w, "File:   %s\n", path)
+	source := fileDeps.SourceFile
+	if source == "" {
+		source = "(none found)"
+	}
+	_, _ = fmt.Fprintf(w, "Source: %s\n", source)
+	printList(w, "Workspace dependencies", fileDeps.Dependencies)
+}
+
+// printObjectSymbols renders the defined and undefined symbols of an object file,
+// sorted by name
+func printObjectSymbols(w io.Writer, path string, syms []symbols.Symbol) {
+	sort.SliceStable(syms, func(i, j int) bool { return syms[i].Name < syms[j].Name })
+
+	var defined, undefined []string
+	for _, sym := range syms {
+		if sym.IsDefined() {
+			defined = append(defined, fmt.Sprintf("%s %s", sym.Type, sym.Name))
+		} else if sym.Type == "U" {
+			undefined = append(undefined, sym.Name)
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "File: %s\n", path)
+	printList(w, "Defined symbols", defined)
+	printList(w, "Undefined symbols", undefined)
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"runtime"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/ritzau/deps-analyzer/pkg/analysis"
@@ -16,6 +20,9 @@ import (
 	"github.com/ritzau/deps-analyzer/pkg/config"
 	"github.com/ritzau/deps-analyzer/pkg/deps"
 	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/output"
+	"github.com/ritzau/deps-analyzer/pkg/pubsub"
 	"github.com/ritzau/deps-analyzer/pkg/symbols"
 	"github.com/ritzau/deps-analyzer/pkg/watcher"
 	"github.com/ritzau/deps-analyzer/pkg/web"
@@ -24,100 +31,158 @@ import (
 
 func main() {
 	// Parse command-line flags using pflag for POSIX/GNU-style flags
-	workspace := pflag.StringP("workspace", "w", ".", "path to Bazel workspace")
-	webMode := pflag.Bool("web", false, "start web server")
-	port := pflag.IntP("port", "p", 8080, "web server port")
-	watch := pflag.Bool("watch", false, "watch for file changes and re-analyze")
-	open := pflag.Bool("open", true, "auto-open browser when starting server")
-	licenses := pflag.Bool("licenses", false, "list all third-party licenses")
+	// Values are read back through config.Load so the config file and env vars apply too
+	pflag.StringP("workspace", "w", ".", "path to Bazel workspace")
+	pflag.Bool("web", false, "start web server")
+	pflag.IntP("port", "p", 8080, "web server port")
+	pflag.Int("port-auto", 0, "if the port is in use, try up to this many following ports (default 10 when given without a value)")
+	pflag.Lookup("port-auto").NoOptDefVal = "10"
+	pflag.StringSlice("allowed-origins", nil, "origins allowed to make cross-origin requests (host names match any port, default: localhost)")
+	pflag.Bool("watch", false, "watch for file changes and re-analyze")
+	pflag.Bool("open", true, "auto-open browser when starting server")
+	pflag.Bool("licenses", false, "list all third-party licenses")
+	pflag.String("bazel-out", "", "directory with build outputs (.d and .o files) to use instead of the workspace's bazel-out, e.g. downloaded from CI")
+	pflag.Bool("cquery", false, "query targets with bazel cquery so that select() is resolved for one build configuration")
+	pflag.StringSlice("cquery-opts", nil, "options for bazel cquery, e.g. --config=linux (implies --cquery)")
+	pflag.Bool("no-cache", false, "always run bazel query instead of reusing the cached result of an earlier run")
+	pflag.String("symbol-scope", "", "only run symbol (nm) analysis on this target and the targets it links")
+	pflag.StringSlice("skip", nil, "analyses to leave out, e.g. when their tools are not installed: symbols (nm), dynamic (ldd/otool)")
+	pflag.StringSlice("coverage-exclude", nil, "file or directory patterns to leave out when looking for files not covered by any target (e.g. third_party,*/generated)")
+	pflag.Int("max-concurrency", runtime.NumCPU(), "maximum number of parallel workers per analysis phase")
+	pflag.Bool("dry-run", false, "print which analysis phases changes to the given files would re-run, without running anything")
+	pflag.Int("history-size", 100, "number of analyses whose metrics are kept for /api/history (0 disables the history)")
+	pflag.Bool("debug", false, "serve debugging endpoints such as /api/debug/artifacts (the .d and .o files found)")
+	pflag.Bool("direct-includes", false, "read the #include directives of sources to mark which header dependencies are direct and which transitive")
+	pflag.Bool("timings", false, "print the duration of each analysis phase when an analysis completes")
+	pflag.String("format", "", "write the analysis results to stdout instead of starting the web server: text (report, the default without --web), json (the module), jsonl (one JSON object per line), cytoscape (the module graph as Cytoscape.js elements), scorecard-csv (metrics per package) or dot (the target graph for Graphviz)")
+	pflag.String("emit", "deps", "records written with --format: targets, deps or issues")
+	pflag.Bool("edge-labels", false, "label the edges of --format=dot with their dependency type")
+	pflag.String("fail-on-issues", "", "exit with status 3 after a one-shot analysis if an issue has at least this severity: info, warning or error (default warning when given without a value)")
+	pflag.Lookup("fail-on-issues").NoOptDefVal = model.SeverityWarning
+	pflag.Bool("no-color", false, "disable colored report output (also disabled when NO_COLOR is set or output is not a terminal)")
 
 	// Verbosity flags
-	verboseCount := pflag.CountP("verbose", "v", "increase verbosity (can be repeated: -v, -vv, -vvv)")
-	verbosity := pflag.String("verbosity", "", "set log level explicitly: T(race), D(ebug), I(nfo), W(arn), E(rror)")
+	pflag.CountP("verbose", "v", "increase verbosity (can be repeated: -v, -vv, -vvv)")
+	pflag.String("verbosity", "", "set log level explicitly: T(race), D(ebug), I(nfo), W(arn), E(rror)")
+	pflag.BoolP("quiet", "q", false, "suppress progress output, only report warnings, errors and results (for CI)")
 
 	pflag.Parse()
 
+	// Merge defaults, deps-analyzer.toml, environment and flags
+	cfg, resolved, err := config.LoadResolved(pflag.CommandLine)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if err := validateSeverities(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Configure logging level based on verbosity flags
-	configureLogging(*verboseCount, *verbosity)
+	configureLogging(cfg.VerboseCnt, cfg.Verbosity, cfg.Quiet)
 
-	if *licenses {
+	if cfg.Licenses {
 		printLicenses()
 		return
 	}
 
-	if *webMode {
+	// The arguments are changed files rather than a subcommand
+	if cfg.DryRun {
+		os.Exit(runDryRun(os.Stdout, cfg, pflag.Args()))
+	}
+
+	// Subcommands
+	if pflag.NArg() > 0 {
+		switch pflag.Arg(0) {
+		case "target":
+			if pflag.NArg() != 2 {
+				fmt.Fprintf(os.Stderr, "Usage: deps-analyzer target <label>\n")
+				os.Exit(2)
+			}
+			os.Exit(runTargetCommand(cfg, pflag.Arg(1), cfg.VerboseCnt > 0 || cfg.Verbosity != ""))
+		case "inspect":
+			if pflag.NArg() != 2 {
+				fmt.Fprintf(os.Stderr, "Usage: deps-analyzer inspect <file.d|file.o>\n")
+				os.Exit(2)
+			}
+			os.Exit(runInspectCommand(os.Stdout, pflag.Arg(1), fileExtensions(cfg.Files)))
+		case "config":
+			if pflag.NArg() > 2 {
+				fmt.Fprintf(os.Stderr, "Usage: deps-analyzer config [toml|json]\n")
+				os.Exit(2)
+			}
+			os.Exit(runConfigCommand(os.Stdout, resolved, pflag.Arg(1)))
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n", pflag.Arg(0))
+			os.Exit(2)
+		}
+	}
+
+	if cfg.Format != "" {
+		os.Exit(runExport(os.Stdout, cfg, cfg.VerboseCnt > 0 || cfg.Verbosity != ""))
+	}
+
+	if cfg.WebMode {
 		// Start web server and run streamlined analysis
-		startWebServerAsync(*workspace, *port, *watch, *open)
+		startWebServerAsync(cfg)
+	} else if cfg.Watch {
+		// Analyze and watch without a web server, showing the status in the terminal
+		runWatchMode(cfg)
 	} else {
-		// TODO: Add CLI mode back with Module-based output
-		// - Show targets, dependencies by type, packages
-		// - Show dependency issues/warnings
-		// - Optional: coverage analysis (files not in any target)
-		fmt.Fprintf(os.Stderr, "CLI mode not yet implemented. Use --web flag to start web server.\n")
-		os.Exit(1)
+		// Analyze once and print a text report
+		cfg.Format = config.FormatText
+		os.Exit(runExport(os.Stdout, cfg, cfg.VerboseCnt > 0 || cfg.Verbosity != ""))
 	}
 }
 
-func startWebServerAsync(workspace string, port int, watch bool, open bool) {
+func startWebServerAsync(cfg *config.Config) {
+	workspace := cfg.Workspace
+	watch := cfg.Watch
+
 	// Create server
 	server := web.NewServer()
+	server.SetHubThresholds(cfg.Metrics.HubThreshold, cfg.Metrics.GodObjectThreshold)
+	server.SetAllowedOrigins(cfg.AllowedOrigins)
+	server.SetHistorySize(cfg.HistorySize)
+	if cfg.Debug {
+		server.EnableDebug(workspace, cfg.BazelOutPath)
+	}
 
+	// Bind the port up front, so the URL is that of the port actually bound
+	listener, port, err := web.Listen(cfg.Port, cfg.PortAuto)
+	if err != nil {
+		logging.Fatal("failed to start server", "error", err)
+	}
 	url := fmt.Sprintf("http://localhost:%d", port)
-	fmt.Printf("Starting web server on %s\n", url)
 
-	// Start server in background
+	// Start server in background, Server.Serve logs the URL and returns nil on shutdown
 	go func() {
-		if err := server.Start(port); err != nil {
+		if err := server.Serve(listener); err != nil {
 			logging.Fatal("failed to start server", "error", err)
 		}
 	}()
 
-	// Open browser if requested (in background, giving server time to start)
-	if open {
+	// Open browser if requested, once the server accepts requests
+	if cfg.OpenBrowser {
 		go func() {
-			time.Sleep(500 * time.Millisecond)
-			fmt.Println("Opening browser...")
+			if err := waitForServer(url, serverReadyTimeout); err != nil {
+				logging.Warn("server not ready, not opening browser", "url", url, "error", err)
+				return
+			}
+			logging.Info("opening browser", "url", url)
 			openBrowser(url)
 		}()
 	} else {
-		fmt.Printf("Server ready at %s (use --open to auto-open browser)\n", url)
+		logging.Info("server ready (use --open to auto-open browser)", "url", url)
 	}
 
 	// Create analysis runner
-	// Create analysis runner
-	config := &config.Config{
-		Workspace:   workspace,
-		WebMode:     true,
-		Port:        port,
-		Watch:       watch,
-		OpenBrowser: open,
-	}
-	// TODO: Replace manual config construction with config.Load(flagSet) once refactor is complete
-
-	runner := analysis.NewAnalysisRunner(workspace, server, config)
+	cfg.WebMode = true
+	runner := newAnalysisRunner(cfg, server)
 
-	// Inject legacy dependencies to avoid import cycles / decouple implementation
-	runner.FnQueryWorkspace = bazel.QueryWorkspace
-	runner.FnAddCompileDeps = bazel.AddCompileDependencies
-	runner.FnNormalizeSourcePath = bazel.NormalizeSourcePath
-	runner.FnDiscoverSourceFiles = bazel.DiscoverSourceFiles
-	runner.FnFindUncoveredFiles = bazel.FindUncoveredFiles
-	// FnAddSymbolDependencies points to the legacy wrapper in pkg/bazel
-	runner.FnAddSymbolDependencies = bazel.AddSymbolDependencies
-
-	// Inject LDD scanner for dynamic analysis
-	lddScanner := ldd.NewScanner()
-	runner.FnScanBinary = lddScanner.ScanBinary
-
-	// Register new modular sources
-	runner.RegisterSource(deps.NewCompileDepsSource())
-	runner.RegisterSource(symbols.NewSymbolSource())
-	// runner.RegisterSource(bazel.NewTargetSource()) // Not yet enabling to avoid dupes/perf hit, or maybe we should?
-	// For now, let's enable CompileDepsSource as it maps to Graph, while legacy maps to Module.
-	// They don't conflict in data structures (Graph vs Module), but they duplicate work.
-	// We want to eventually remove legacy calls. For now, running both is fine for verification.
-
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Run initial analysis in background
 	go func() {
@@ -136,8 +201,100 @@ func startWebServerAsync(workspace string, port int, watch bool, open bool) {
 		}
 	}()
 
-	// Block forever (server runs in goroutine)
-	select {}
+	// Run until interrupted, then let SSE subscribers and requests drain
+	<-ctx.Done()
+	logging.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logging.Warn("server did not shut down cleanly", "error", err)
+	}
+}
+
+// runWatchMode runs the initial analysis and re-analyzes on file changes without starting
+// the web server. Progress is summarized in a status line instead of scrolling logs.
+func runWatchMode(cfg *config.Config) {
+	// The server is only used as a sink for the analysis results and status events
+	server := web.NewServer()
+	server.SetHubThresholds(cfg.Metrics.HubThreshold, cfg.Metrics.GodObjectThreshold)
+	runner := newAnalysisRunner(cfg, server)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !cfg.Quiet {
+		tty := output.IsTerminal(os.Stdout)
+		if tty && cfg.VerboseCnt == 0 && cfg.Verbosity == "" {
+			// The status line replaces the progress logs
+			logging.SetLevel(slog.LevelWarn)
+		}
+		if err := startStatusDisplay(ctx, server, os.Stdout, tty); err != nil {
+			logging.Warn("could not start status display", "error", err)
+		}
+	}
+
+	err := runner.Run(ctx, analysis.AnalysisOptions{
+		FullAnalysis: true,
+		Reason:       "initial analysis",
+	})
+	if err != nil {
+		logging.Error("initial analysis failed", "error", err)
+		os.Exit(1)
+	}
+
+	startFileWatcher(ctx, cfg.Workspace, runner, server)
+
+	// Run until interrupted (the watcher runs in goroutines)
+	<-ctx.Done()
+}
+
+// newAnalysisRunner creates an analysis runner with all analysis implementations injected.
+// The server receives the analysis results; it does not need to be started.
+func newAnalysisRunner(cfg *config.Config, server *web.Server) *analysis.AnalysisRunner {
+	runner := analysis.NewAnalysisRunner(cfg.Workspace, server, cfg)
+	server.SetAnalyzer(runner)
+
+	// Source and header extensions are shared by the finder, parsers and coverage
+	ext := fileExtensions(cfg.Files)
+	runner.Extensions = ext
+	server.SetFileExtensions(ext)
+
+	// Query results are reused between runs until a BUILD file changes
+	if !cfg.NoCache {
+		bazel.SetQueryCacheDir(bazel.DefaultQueryCacheDir())
+	}
+
+	// Inject legacy dependencies to avoid import cycles / decouple implementation
+	runner.FnQueryWorkspace = func(workspace string) (*model.Module, error) {
+		return bazel.QueryWorkspace(workspace, ext)
+	}
+	if cfg.Cquery || len(cfg.CqueryOpts) > 0 {
+		runner.FnQueryWorkspace = func(workspace string) (*model.Module, error) {
+			return bazel.QueryWorkspaceConfigured(workspace, cfg.CqueryOpts, ext)
+		}
+	}
+	runner.FnAddCompileDeps = bazel.AddFileCompileDependencies
+	runner.FnResolveIncludePaths = bazel.ResolveIncludePaths
+	runner.FnNormalizeSourcePath = bazel.NormalizeSourcePath
+	runner.FnDiscoverSourceFiles = func(workspace string) (map[string]bool, error) {
+		return bazel.DiscoverSourceFilesExcluding(workspace, cfg.CoverageExclude, ext)
+	}
+	runner.FnFindUncoveredFiles = bazel.FindUncoveredFiles
+	runner.FnAddSymbolDependencies = bazel.AddSymbolDependencyEdges
+
+	// Inject LDD scanner for dynamic analysis
+	lddScanner := ldd.NewScanner()
+	runner.FnScanBinary = lddScanner.ScanBinary
+
+	// Register new modular sources
+	runner.RegisterSource(deps.NewCompileDepsSource(ext))
+	runner.RegisterSource(symbols.NewSymbolSource())
+	// runner.RegisterSource(bazel.NewTargetSource()) // Not yet enabling to avoid dupes/perf hit, or maybe we should?
+	// For now, let's enable CompileDepsSource as it maps to Graph, while legacy maps to Module.
+	// They don't conflict in data structures (Graph vs Module), but they duplicate work.
+	// We want to eventually remove legacy calls. For now, running both is fine for verification.
+
+	return runner
 }
 
 func startFileWatcher(ctx context.Context, workspace string, runner *analysis.AnalysisRunner, server *web.Server) {
@@ -148,7 +305,8 @@ func startFileWatcher(ctx context.Context, workspace string, runner *analysis.An
 	_ = server.PublishWorkspaceStatus("watching", "Watching for changes...", 6, 6)
 
 	// Create watcher
-	fw, err := watcher.NewFileWatcher(workspace)
+	// Build outputs may come from somewhere else than the workspace, e.g. a CI artifact
+	fw, err := watcher.NewFileWatcher(workspace, runner.Config.BazelOutPath)
 	if err != nil {
 		logging.Error("failed to create file watcher", "error", err)
 		return
@@ -159,6 +317,20 @@ func startFileWatcher(ctx context.Context, workspace string, runner *analysis.An
 		logging.Error("failed to start file watcher", "error", err)
 		return
 	}
+	go func() {
+		<-ctx.Done()
+		_ = fw.Stop()
+	}()
+
+	// Report watcher coverage so missed changes can be diagnosed
+	health := fw.Health()
+	server.SetWatcherHealth(toWatcherHealth(health))
+	if health.Healthy() {
+		_ = server.PublishWorkspaceStatus("watching", "Watching for changes...", 6, 6)
+	} else {
+		_ = server.PublishWorkspaceStatus("watching",
+			fmt.Sprintf("Watching for changes (%d directories could not be watched)", len(health.Failures)), 6, 6)
+	}
 
 	// Create debouncer
 	debouncer := watcher.NewDebouncer(
@@ -170,28 +342,29 @@ func startFileWatcher(ctx context.Context, workspace string, runner *analysis.An
 
 	logging.Info("file watcher ready - monitoring for changes")
 
+	// Which phases to refresh for each type of change
+	policy := watcher.DefaultChangePolicy()
+	if runner.Config != nil {
+		policy = changePolicy(runner.Config.Reanalysis)
+	}
+
 	// Process debounced events
 	go func() {
 		for event := range debouncer.Output() {
 			logging.Info("file changes detected", "filesChanged", len(event.Paths))
 
-			// Analyze what changed
-			changeAnalysis := watcher.AnalyzeChanges(event, workspace)
-
-			// Determine reason for re-analysis
-			reason := formatReason(event)
-			logging.Info("triggering re-analysis", "reason", reason)
-
-			// Build analysis options
-			opts := analysis.AnalysisOptions{
-				FullAnalysis:    changeAnalysis.NeedFullAnalysis,
-				SkipBazelQuery:  !changeAnalysis.NeedFullAnalysis,
-				SkipCompileDeps: !changeAnalysis.NeedCompileDeps,
-				SkipSymbolDeps:  !changeAnalysis.NeedSymbolDeps,
-				SkipBinaryDeriv: !changeAnalysis.NeedBinaryDeriv,
-				Reason:          reason,
+			// Drop the cached query rather than rely on the BUILD file hash, which misses
+			// edits within the resolution of file modification times
+			if slices.Contains(event.Types(), watcher.ChangeTypeBuildFile) {
+				if err := bazel.InvalidateCache(workspace); err != nil {
+					logging.Warn("could not invalidate the query cache", "error", err)
+				}
 			}
 
+			// Analyze what changed and which phases to re-run
+			opts := analysis.OptionsForChange(event, workspace, policy)
+			logging.Info("triggering re-analysis", "reason", opts.Reason)
+
 			// Run re-analysis
 			err := runner.Run(ctx, opts)
 			if err != nil {
@@ -205,17 +378,23 @@ func startFileWatcher(ctx context.Context, workspace string, runner *analysis.An
 	}()
 }
 
-func formatReason(event watcher.ChangeEvent) string {
-	switch event.Type {
-	case watcher.ChangeTypeBuildFile:
-		return "BUILD files changed"
-	case watcher.ChangeTypeDFile:
-		return "Compile dependencies changed"
-	case watcher.ChangeTypeOFile:
-		return "Symbol dependencies changed"
-	default:
-		return "Files changed"
+// toWatcherHealth converts the watcher health report to its status event form
+func toWatcherHealth(health watcher.Health) *pubsub.WatcherHealth {
+	failed := make([]string, len(health.Failures))
+	for i, failure := range health.Failures {
+		failed[i] = failure.Path
+	}
+
+	result := &pubsub.WatcherHealth{
+		WatchedDirs:       health.WatchedDirs,
+		FailedDirs:        failed,
+		BazelOutWatched:   health.BazelOutWatched,
+		WatchLimitReached: health.WatchLimitReached,
+	}
+	if health.WatchLimitReached {
+		result.Hint = watcher.WatchLimitHint
 	}
+	return result
 }
 
 func openBrowser(url string) {
@@ -237,17 +416,51 @@ func openBrowser(url string) {
 		return
 	}
 
+	// Sandboxes and headless machines often lack the opener
+	if _, err := exec.LookPath(cmd); err != nil {
+		logging.Info("no browser opener available, open the URL manually", "url", url, "command", cmd)
+		return
+	}
+
 	if err := exec.Command(cmd, args...).Start(); err != nil {
 		logging.Warn("failed to open browser", "error", err)
 	}
 }
 
+// serverReadyTimeout is how long to wait for the web server before giving up on opening the browser
+const serverReadyTimeout = 10 * time.Second
+
+// shutdownTimeout is how long requests may take to finish after an interrupt
+const shutdownTimeout = 5 * time.Second
+
+// waitForServer polls the server with HEAD /api/module until it responds or the timeout
+// expires. Any HTTP response counts, as the module is not available until the analysis completes.
+func waitForServer(baseURL string, timeout time.Duration) error {
+	client := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		resp, err := client.Head(baseURL + "/api/module")
+		if err == nil {
+			_ = resp.Body.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no response after %s: %w", timeout, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
 // configureLogging sets the log level based on verbosity flags
-func configureLogging(verboseCount int, verbosityFlag string) {
+// Quiet mode only lets warnings and errors through, regardless of the other flags
+func configureLogging(verboseCount int, verbosityFlag string, quiet bool) {
 	var level slog.Level
 
-	// Explicit verbosity flag takes precedence
-	if verbosityFlag != "" {
+	if quiet {
+		level = slog.LevelWarn
+	} else if verbosityFlag != "" {
+		// Explicit verbosity flag takes precedence
 		switch strings.ToUpper(verbosityFlag)[0] {
 		case 'T':
 			level = slog.LevelDebug - 4 // Trace
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/watcher"
+)
+
+// fileExtensions returns the configured source and header extensions with the defaults
+// and opt-in extensions applied
+func fileExtensions(files config.FilesConfig) model.FileExtensions {
+	ext := model.FileExtensions{
+		Sources: slices.Clone(files.Sources),
+		Headers: slices.Clone(files.Headers),
+	}
+	if len(ext.Sources) == 0 {
+		ext.Sources = slices.Clone(model.DefaultSourceExtensions)
+	}
+	if len(ext.Headers) == 0 {
+		ext.Headers = slices.Clone(model.DefaultHeaderExtensions)
+	}
+	if files.ObjC {
+		ext.Sources = append(ext.Sources, model.ObjCSourceExtensions...)
+	}
+	if files.Assembly {
+		ext.Sources = append(ext.Sources, model.AssemblySourceExtensions...)
+	}
+	return ext
+}
+
+// changePolicy returns the watcher change policy of the reanalysis configuration
+func changePolicy(reanalysis config.ReanalysisConfig) watcher.ChangePolicy {
+	return watcher.ChangePolicy{
+		DFileSymbolDeps:  reanalysis.DFileSymbols,
+		DFileBinaryDeriv: reanalysis.DFileBinaries,
+		OFileBinaryDeriv: reanalysis.OFileBinaries,
+	}
+}
+
+// validateSeverities checks the severity overrides and the fail-on-issues gate against
+// the issue codes and severities of the model
+func validateSeverities(cfg *config.Config) error {
+	for code, severity := range cfg.Severity {
+		if !slices.Contains(model.IssueCodes, code) {
+			return fmt.Errorf("invalid severity override: unknown issue code %q (use %s)", code, strings.Join(model.IssueCodes, ", "))
+		}
+		if !slices.Contains(model.Severities, severity) {
+			return fmt.Errorf("invalid severity %q for %s (use %s)", severity, code, strings.Join(model.Severities, ", "))
+		}
+	}
+
+	if cfg.FailOnIssues != "" && !slices.Contains(model.Severities, cfg.FailOnIssues) {
+		return fmt.Errorf("invalid fail-on-issues severity %q (use %s)", cfg.FailOnIssues, strings.Join(model.Severities, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/output"
+	"github.com/ritzau/deps-analyzer/pkg/watcher"
+)
+
+func TestFileExtensions(t *testing.T) {
+	ext := fileExtensions(config.FilesConfig{})
+	if !reflect.DeepEqual(ext.Sources, model.DefaultSourceExtensions) || !reflect.DeepEqual(ext.Headers, model.DefaultHeaderExtensions) {
+		t.Errorf("expected C++ defaults, got %+v", ext)
+	}
+
+	ext = fileExtensions(config.FilesConfig{Headers: []string{".h", ".inc"}, ObjC: true, Assembly: true})
+	wantSources := []string{".cc", ".cpp", ".cxx", ".m", ".mm", ".S", ".s"}
+	if !reflect.DeepEqual(ext.Sources, wantSources) {
+		t.Errorf("Sources = %v, want %v", ext.Sources, wantSources)
+	}
+	if !reflect.DeepEqual(ext.Headers, []string{".h", ".inc"}) {
+		t.Errorf("Headers = %v", ext.Headers)
+	}
+
+	// The opt-in extensions must not leak into the defaults
+	if len(model.DefaultSourceExtensions) != 3 {
+		t.Errorf("defaults modified: %v", model.DefaultSourceExtensions)
+	}
+}
+
+func TestChangePolicyDefaults(t *testing.T) {
+	t.Chdir(t.TempDir()) // No deps-analyzer.toml
+
+	cfg, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if got := changePolicy(cfg.Reanalysis); got != watcher.DefaultChangePolicy() {
+		t.Errorf("default change policy = %+v, want %+v", got, watcher.DefaultChangePolicy())
+	}
+}
+
+func TestValidateSeverities(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr bool
+	}{
+		{"none", config.Config{}, false},
+		{"overrides", config.Config{Severity: map[string]string{"redundant_dynamic_dep": "info", "define_skew": "error"}}, false},
+		{"unknown issue code", config.Config{Severity: map[string]string{"unused_dep": "info"}}, true},
+		{"unknown severity", config.Config{Severity: map[string]string{"define_skew": "fatal"}}, true},
+		{"fail-on-issues", config.Config{FailOnIssues: "error"}, false},
+		{"unknown fail-on-issues severity", config.Config{FailOnIssues: "fatal"}, true},
+	}
+	for _, tt := range tests {
+		if err := validateSeverities(&tt.cfg); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateSeverities() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEmitValuesMatchOutput(t *testing.T) {
+	if !reflect.DeepEqual(config.EmitValues, output.EmitValues) {
+		t.Errorf("config.EmitValues = %v, want the record streams of output.WriteJSONLines %v", config.EmitValues, output.EmitValues)
+	}
+}
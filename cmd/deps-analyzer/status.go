@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/pubsub"
+	"github.com/ritzau/deps-analyzer/pkg/web"
+)
+
+// statusDisplay follows the workspace_status stream and summarizes the analysis state.
+// On a terminal it keeps a single status line updated in place, otherwise it logs one
+// line per state change.
+type statusDisplay struct {
+	w      io.Writer
+	tty    bool
+	server *web.Server
+
+	state        string
+	message      string
+	started      time.Time     // Start of the analysis in progress
+	lastFinished time.Time     // End of the last completed analysis
+	lastDuration time.Duration // Duration of the last completed analysis
+}
+
+// startStatusDisplay subscribes to the server's workspace status events and renders them until ctx is done
+func startStatusDisplay(ctx context.Context, server *web.Server, w io.Writer, tty bool) error {
+	sub, err := server.Subscribe(ctx, pubsub.WorkspaceStatusTopic.Name())
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to workspace status: %w", err)
+	}
+
+	d := &statusDisplay{w: w, tty: tty, server: server}
+	go func() {
+		defer func() { _ = sub.Close() }()
+		for event := range sub.Events() {
+			status, err := pubsub.WorkspaceStatusTopic.Decode(event)
+			if err != nil {
+				logging.Warn("invalid workspace status event", "error", err)
+				continue
+			}
+			d.update(status, time.Now())
+		}
+		if d.tty {
+			_, _ = fmt.Fprintln(d.w)
+		}
+	}()
+	return nil
+}
+
+// update records a status event and renders the new state
+func (d *statusDisplay) update(status pubsub.WorkspaceStatus, now time.Time) {
+	idle := isIdleState(status.State)
+	if !idle && (d.started.IsZero() || isIdleState(d.state)) {
+		d.started = now
+	}
+	if status.State == "ready" && !d.started.IsZero() {
+		d.lastFinished = now
+		d.lastDuration = now.Sub(d.started)
+		d.started = time.Time{}
+	}
+
+	changed := status.State != d.state
+	d.state = status.State
+	d.message = status.Message
+
+	if d.tty {
+		// Return to the start of the line and clear it before redrawing
+		_, _ = fmt.Fprintf(d.w, "\r\033[K%s", d.summary())
+	} else if changed {
+		logging.Info("workspace status", "state", d.state, "message", d.message)
+	}
+}
+
+// summary formats the compact one-line status
+func (d *statusDisplay) summary() string {
+	line := fmt.Sprintf("[%s] %s", d.state, d.message)
+
+	if !d.lastFinished.IsZero() {
+		line += fmt.Sprintf(" | last analysis %s (%s)",
+			d.lastFinished.Format("15:04:05"), d.lastDuration.Round(time.Millisecond))
+	}
+
+	if module := d.server.GetModule(); module != nil {
+		line += fmt.Sprintf(" | targets=%d deps=%d issues=%d",
+			len(module.Targets), len(module.Dependencies), len(module.Issues))
+	}
+
+	return line
+}
+
+// isIdleState reports whether the workspace state means no analysis is running
+func isIdleState(state string) bool {
+	switch state {
+	case "", "ready", "watching", "error":
+		return true
+	}
+	return false
+}
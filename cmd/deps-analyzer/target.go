@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/analysis"
+	"github.com/ritzau/deps-analyzer/pkg/binaries"
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/web"
+)
+
+// dependencyTypeOrder is the order in which dependency types are printed
+var dependencyTypeOrder = []model.DependencyType{
+	model.DependencyStatic,
+	model.DependencyDynamic,
+	model.DependencyData,
+	model.DependencyCompile,
+	model.DependencySymbol,
+}
+
+// runTargetCommand analyzes the workspace and prints everything known about a single target.
+// Returns the process exit code.
+func runTargetCommand(cfg *config.Config, label string, verbose bool) int {
+	// Keep the report readable: only show warnings unless verbosity was requested
+	if !verbose {
+		logging.SetLevel(slog.LevelWarn)
+	}
+
+	// Accept short forms such as "util" or "//util" for "//util:util"
+	label = model.CanonicalizeLabel(label)
+
+	// The server is only used as a sink for the analysis results
+	server := web.NewServer()
+	runner := newAnalysisRunner(cfg, server)
+
+	err := runner.Run(context.Background(), analysis.AnalysisOptions{
+		FullAnalysis:        true,
+		SkipBinaryDeriv:     true,
+		SkipDynamicAnalysis: true,
+		Reason:              "target inspection",
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Analysis failed: %v\n", err)
+		return 1
+	}
+
+	module := server.GetModule()
+	if module == nil {
+		fmt.Fprintf(os.Stderr, "Analysis produced no module data\n")
+		return 1
+	}
+
+	target, exists := module.Targets[label]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "Target not found: %s\n", label)
+		return 1
+	}
+
+	printTargetInfo(os.Stdout, module, target)
+	return 0
+}
+
+// printTargetInfo renders a text report for a single target
+func printTargetInfo(w io.Writer, module *model.Module, target *model.Target) {
+	_, _ = fmt.Fprintf(w, "Target:  %s\n", target.Label)
+	_, _ = fmt.Fprintf(w, "Kind:    %s\n", target.Kind)
+	_, _ = fmt.Fprintf(w, "Package: %s\n", target.Package)
+	if len(target.Visibility) > 0 {
+		_, _ = fmt.Fprintf(w, "Visibility: %s\n", strings.Join(target.Visibility, ", "))
+	}
+
+	printList(w, "Sources", target.Sources)
+	printList(w, "Headers", target.Headers)
+
+	// Direct dependencies grouped by type
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Dependencies:")
+	printGroupedLabels(w, module.GetDependenciesFrom(target.Label), func(dep model.Dependency) string { return dep.To })
+
+	// Transitive cc_library closure
+	libraries := binaries.GetTransitiveLibraries(module, target.Label)
+	sort.Strings(libraries)
+	printList(w, "Transitive libraries", libraries)
+
+	// Reverse dependencies grouped by type
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "Reverse dependencies:")
+	printGroupedLabels(w, module.GetDependenciesTo(target.Label), func(dep model.Dependency) string { return dep.From })
+
+	// Linker options and the system libraries they pull in. Binaries, shared libraries and
+	// tests link those of their whole static closure, libraries only declare their own.
+	printList(w, "Linkopts", target.Linkopts)
+	systemLibraries := target.DeclaredSystemLibraries()
+	if target.HasLinkLine() {
+		systemLibraries = module.SystemLibraries(target.Label)
+	}
+	printList(w, "System libraries", systemLibraries)
+
+	// Issues involving this target
+	issues := module.GetIssuesFor(target.Label)
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintf(w, "Issues (%d):\n", len(issues))
+	for _, issue := range issues {
+		_, _ = fmt.Fprintf(w, "  [%s] %s: %s -> %s (%s)\n",
+			issue.Severity, issue.Issue, issue.From, issue.To, strings.Join(issue.Types, ", "))
+	}
+}
+
+// printList prints a titled list of values with its count
+func printList(w io.Writer, title string, values []string) {
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintf(w, "%s (%d):\n", title, len(values))
+	for _, value := range values {
+		_, _ = fmt.Fprintf(w, "  %s\n", value)
+	}
+}
+
+// printGroupedLabels prints the labels of dependencies grouped by dependency type.
+// Inferred dependencies without a declared dependency between the same targets are marked.
+func printGroupedLabels(w io.Writer, dependencies []model.Dependency, labelOf func(model.Dependency) string) {
+	byType := make(map[model.DependencyType][]string)
+	for _, dep := range dependencies {
+		label := labelOf(dep)
+		if len(dep.Provenance) > 0 && !dep.HasProvenance(model.ProvenanceDeclared) {
+			label += " (undeclared)"
+		}
+		byType[dep.Type] = append(byType[dep.Type], label)
+	}
+
+	if len(byType) == 0 {
+		_, _ = fmt.Fprintln(w, "  (none)")
+		return
+	}
+
+	for _, depType := range dependencyTypeOrder {
+		labels := byType[depType]
+		if len(labels) == 0 {
+			continue
+		}
+		sort.Strings(labels)
+		_, _ = fmt.Fprintf(w, "  %s (%d):\n", depType, len(labels))
+		for _, label := range labels {
+			_, _ = fmt.Fprintf(w, "    %s\n", label)
+		}
+	}
+}
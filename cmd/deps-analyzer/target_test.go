@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestPrintTargetInfoSystemLibraries(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary, Linkopts: []string{"-lpthread"}},
+			"//math:math": {Label: "//math:math", Kind: model.TargetKindLibrary, Linkopts: []string{"-lm"}},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary, Linkopts: []string{"-ldl"}},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//math:math", Type: model.DependencyStatic},
+			{From: "//math:math", To: "//util:util", Type: model.DependencyStatic},
+		},
+	}
+
+	tests := []struct {
+		label string
+		want  string
+	}{
+		// A binary links the system libraries of its whole static closure
+		{"//main:app", "System libraries (3):\n  dl\n  m\n  pthread\n"},
+		// A library only declares its own
+		{"//math:math", "System libraries (1):\n  m\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		printTargetInfo(&buf, module, module.Targets[tt.label])
+		if !strings.Contains(buf.String(), tt.want) {
+			t.Errorf("%s: output does not contain %q:\n%s", tt.label, tt.want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package analysis
+
+import "github.com/ritzau/deps-analyzer/pkg/watcher"
+
+// OptionsForChange returns the analysis options used to re-analyze the workspace after
+// a batch of file changes reported by the watcher
+func OptionsForChange(event watcher.ChangeEvent, workspace string, policy watcher.ChangePolicy) AnalysisOptions {
+	changeAnalysis := watcher.AnalyzeChangesWithPolicy(event, workspace, policy)
+
+	var changedDFiles, changedObjectFiles []string
+	for _, path := range event.Paths {
+		changeType, ok := watcher.ClassifyPath(path)
+		if !ok {
+			continue
+		}
+		switch changeType {
+		case watcher.ChangeTypeDFile:
+			changedDFiles = append(changedDFiles, path)
+		case watcher.ChangeTypeOFile:
+			changedObjectFiles = append(changedObjectFiles, path)
+		}
+	}
+
+	return AnalysisOptions{
+		FullAnalysis:       changeAnalysis.NeedFullAnalysis,
+		SkipBazelQuery:     !changeAnalysis.NeedFullAnalysis,
+		SkipCompileDeps:    !changeAnalysis.NeedCompileDeps,
+		SkipSymbolDeps:     !changeAnalysis.NeedSymbolDeps,
+		SkipBinaryDeriv:    !changeAnalysis.NeedBinaryDeriv,
+		ChangedDFiles:      changedDFiles,
+		ChangedObjectFiles: changedObjectFiles,
+		Reason:             ChangeReason(event),
+	}
+}
+
+// ChangeReason describes why a batch of file changes triggers a re-analysis
+func ChangeReason(event watcher.ChangeEvent) string {
+	switch event.Type {
+	case watcher.ChangeTypeBuildFile:
+		return "BUILD files changed"
+	case watcher.ChangeTypeDFile:
+		return "Compile dependencies changed"
+	case watcher.ChangeTypeOFile:
+		return "Symbol dependencies changed"
+	default:
+		return "Files changed"
+	}
+}
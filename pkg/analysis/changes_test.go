@@ -0,0 +1,64 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/watcher"
+)
+
+func TestOptionsForChange(t *testing.T) {
+	tests := []struct {
+		name  string
+		event watcher.ChangeEvent
+		want  AnalysisOptions
+	}{
+		{
+			name:  "BUILD file",
+			event: watcher.ChangeEvent{Type: watcher.ChangeTypeBuildFile, Paths: []string{"util/BUILD"}},
+			want: AnalysisOptions{
+				FullAnalysis: true,
+				Reason:       "BUILD files changed",
+			},
+		},
+		{
+			name:  ".d file",
+			event: watcher.ChangeEvent{Type: watcher.ChangeTypeDFile, Paths: []string{"bazel-out/bin/util/_objs/util/a.d"}},
+			want: AnalysisOptions{
+				SkipBazelQuery: true,
+				ChangedDFiles:  []string{"bazel-out/bin/util/_objs/util/a.d"},
+				Reason:         "Compile dependencies changed",
+			},
+		},
+		{
+			name:  ".o file",
+			event: watcher.ChangeEvent{Type: watcher.ChangeTypeOFile, Paths: []string{"bazel-out/bin/util/_objs/util/a.o"}},
+			want: AnalysisOptions{
+				SkipBazelQuery:     true,
+				SkipCompileDeps:    true,
+				ChangedObjectFiles: []string{"bazel-out/bin/util/_objs/util/a.o"},
+				Reason:             "Symbol dependencies changed",
+			},
+		},
+		{
+			name: "BUILD and .o files changed together",
+			event: watcher.MergeChangeEvents([]watcher.ChangeEvent{
+				{Type: watcher.ChangeTypeOFile, Paths: []string{"bazel-out/bin/util/_objs/util/a.o"}},
+				{Type: watcher.ChangeTypeBuildFile, Paths: []string{"util/BUILD"}},
+			}),
+			want: AnalysisOptions{
+				FullAnalysis:       true,
+				ChangedObjectFiles: []string{"bazel-out/bin/util/_objs/util/a.o"},
+				Reason:             "BUILD files changed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OptionsForChange(tt.event, "/workspace", watcher.DefaultChangePolicy()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OptionsForChange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/ritzau/deps-analyzer/pkg/pubsub"
+)
+
+// Human-readable names of the phases, used in error messages
+var phaseNames = map[string]string{
+	PhaseSources:     "Sources",
+	PhaseBazelQuery:  "Bazel query",
+	PhaseCompileDeps: "Compile dependency analysis",
+	PhaseSymbolDeps:  "Symbol analysis",
+	PhasePolicy:      "Policy check",
+	PhaseBinaryDeriv: "Binary derivation",
+	PhaseDynamic:     "Dynamic analysis",
+}
+
+// PhaseError is returned when an analysis phase fails and the analysis cannot continue.
+// Results published by earlier analyses and phases stay available.
+type PhaseError struct {
+	Phase       string // One of the Phase constants
+	Err         error
+	Recoverable bool // Retrying may succeed, e.g. after fixing a BUILD file
+}
+
+func (e *PhaseError) Error() string {
+	name, ok := phaseNames[e.Phase]
+	if !ok {
+		name = e.Phase
+	}
+	return fmt.Sprintf("%s failed: %v", name, e.Err)
+}
+
+func (e *PhaseError) Unwrap() error {
+	return e.Err
+}
+
+// AnalysisError converts the error to the form published to the web UI
+func (e *PhaseError) AnalysisError() *pubsub.AnalysisError {
+	return &pubsub.AnalysisError{
+		Phase:       e.Phase,
+		Message:     e.Err.Error(),
+		Recoverable: e.Recoverable,
+	}
+}
@@ -65,6 +65,10 @@ func (s *Scanner) scanLinux(path string) ([]string, error) {
 			} else {
 				libPath = right
 			}
+			// case: libname => not found, keep the name of the unresolved library
+			if libPath == "not found" {
+				libPath = strings.TrimSpace(parts[0])
+			}
 		} else {
 			// case: /path/to/lib (addr)
 			// or: statically linked
@@ -10,6 +10,7 @@ func TestScanLinux(t *testing.T) {
 	linux-vdso.so.1 (0x00007ffc5d7dd000)
 	libpthread.so.0 => /lib/x86_64-linux-gnu/libpthread.so.0 (0x00007f0c2a559000)
 	libc.so.6 => /lib/x86_64-linux-gnu/libc.so.6 (0x00007f0c2a367000)
+	libmissing.so => not found
 	/lib64/ld-linux-x86-64.so.2 (0x00007f0c2a57e000)
 	`)
 
@@ -27,6 +28,7 @@ func TestScanLinux(t *testing.T) {
 	expected := []string{
 		"/lib/x86_64-linux-gnu/libpthread.so.0",
 		"/lib/x86_64-linux-gnu/libc.so.6",
+		"libmissing.so",
 		"/lib64/ld-linux-x86-64.so.2",
 	}
 
@@ -2,9 +2,14 @@ package analysis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ritzau/deps-analyzer/pkg/analysis/api"
 	"github.com/ritzau/deps-analyzer/pkg/binaries"
@@ -12,6 +17,8 @@ import (
 	"github.com/ritzau/deps-analyzer/pkg/deps"
 	"github.com/ritzau/deps-analyzer/pkg/logging"
 	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/owners"
+	"github.com/ritzau/deps-analyzer/pkg/policy"
 	"github.com/ritzau/deps-analyzer/pkg/symbols"
 	"github.com/ritzau/deps-analyzer/pkg/web"
 )
@@ -25,16 +32,38 @@ type AnalysisRunner struct {
 	Config    *config.Config
 	Graph     *model.Graph
 
+	// Classify files as sources and headers, the defaults if zero
+	Extensions model.FileExtensions
+
+	// State of the running or last analysis, read by the web server while an analysis runs
+	stateMu sync.Mutex
+	state   web.AnalysisState
+	cancel  context.CancelFunc // Cancels the running analysis, nil when idle
+
+	// External tools not found, looked up once on the first analysis (see checkTools)
+	toolsOnce    sync.Once
+	missingTools []ToolRequirement
+
+	// Parsed .d files, kept between analyses so only changed ones are parsed again
+	dFiles *deps.Cache
+
+	// Symbol tables of the object files, kept between analyses so only changed objects are
+	// read again
+	symbolGraph *symbols.SymbolGraph
+
 	// Dependency Injection functions to break import cycles
 	// These placeholders allow main.go to inject implementations from pkg/bazel
 	// without this package depending on pkg/bazel.
 	FnQueryWorkspace        func(workspace string) (*model.Module, error)
-	FnAddCompileDeps        func(module *model.Module, workspace string) error
+	FnAddCompileDeps        func(module *model.Module, fileDeps []*deps.FileDependency)
+	FnResolveIncludePaths   func(module *model.Module, fileDeps []*deps.FileDependency)
 	FnNormalizeSourcePath   func(path string) string
 	FnDiscoverSourceFiles   func(workspace string) (map[string]bool, error)
 	FnFindUncoveredFiles    func(discovered map[string]bool, fileToTarget map[string]string) []string
-	FnAddSymbolDependencies func(module *model.Module, workspace string) error
+	FnAddSymbolDependencies func(module *model.Module, symbolDeps []symbols.SymbolDependency)
+	FnNewSymbolClient       func() symbols.Client // Runs nm on object files, symbols.NewClient if nil
 	FnScanBinary            func(path string) ([]string, error)
+	FnLookPath              func(file string) (string, error) // Finds external tools, exec.LookPath if nil
 }
 
 // AnalysisOptions configures which analysis phases to run
@@ -45,7 +74,9 @@ type AnalysisOptions struct {
 	SkipSymbolDeps      bool
 	SkipBinaryDeriv     bool
 	SkipDynamicAnalysis bool
-	Reason              string // e.g., "initial analysis", "BUILD changed"
+	ChangedDFiles       []string // .d files reported changed; only these are parsed again unless FullAnalysis
+	ChangedObjectFiles  []string // .o files reported changed; only these are read again unless FullAnalysis
+	Reason              string   // e.g., "initial analysis", "BUILD changed"
 }
 
 // NewAnalysisRunner creates a new analysis runner
@@ -70,38 +101,232 @@ func (ar *AnalysisRunner) Run(ctx context.Context, opts AnalysisOptions) error {
 	ar.mu.Lock()
 	defer ar.mu.Unlock()
 
+	return ar.run(ctx, opts)
+}
+
+// StartFullAnalysis starts a full analysis in the background unless one is already
+// running, in which case it returns web.ErrAnalysisRunning. It implements web.Analyzer.
+func (ar *AnalysisRunner) StartFullAnalysis(reason string) error {
+	if !ar.mu.TryLock() {
+		return web.ErrAnalysisRunning
+	}
+
+	go func() {
+		defer ar.mu.Unlock()
+
+		err := ar.run(context.Background(), AnalysisOptions{
+			FullAnalysis: true,
+			Reason:       reason,
+		})
+		if err != nil {
+			logging.Error("analysis failed", "reason", reason, "error", err)
+		}
+	}()
+	return nil
+}
+
+// CancelAnalysis cancels the running analysis, if any. The analysis stops at the next
+// phase boundary. Returns false if no analysis is running. It implements web.Analyzer.
+func (ar *AnalysisRunner) CancelAnalysis() bool {
+	ar.stateMu.Lock()
+	defer ar.stateMu.Unlock()
+
+	if ar.cancel == nil {
+		return false
+	}
+	ar.cancel()
+	return true
+}
+
+// AnalysisState reports whether an analysis is running and how the last one ended.
+// It implements web.Analyzer.
+func (ar *AnalysisRunner) AnalysisState() web.AnalysisState {
+	ar.stateMu.Lock()
+	defer ar.stateMu.Unlock()
+
+	state := ar.state
+	state.Timings = slices.Clone(ar.state.Timings)
+	state.Warnings = slices.Clone(ar.state.Warnings)
+	if state.State == "" {
+		state.State = web.AnalysisIdle
+	}
+	return state
+}
+
+// addWarning records a problem that leaves the running analysis incomplete
+func (ar *AnalysisRunner) addWarning(warning string) {
+	ar.stateMu.Lock()
+	defer ar.stateMu.Unlock()
+	ar.state.Warnings = append(ar.state.Warnings, warning)
+}
+
+// run executes the analysis phases and tracks the analysis state. The caller must hold ar.mu.
+func (ar *AnalysisRunner) run(ctx context.Context, opts AnalysisOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ar.stateMu.Lock()
+	ar.cancel = cancel
+	ar.state.State = web.AnalysisRunning
+	ar.state.Reason = opts.Reason
+	ar.state.StartedAt = time.Now()
+	ar.state.Timings = nil
+	ar.state.Warnings = nil
+	ar.stateMu.Unlock()
+
+	err := ar.runPhases(ctx, opts)
+
+	ar.stateMu.Lock()
+	ar.cancel = nil
+	ar.state.State = web.AnalysisIdle
+	ar.state.CompletedAt = time.Now()
+	ar.state.LastError = ""
+	ar.state.Error = nil
+	var phaseErr *PhaseError
+	if err != nil {
+		ar.state.LastError = err.Error()
+	}
+	if errors.As(err, &phaseErr) {
+		ar.state.Error = phaseErr.AnalysisError()
+	}
+	startedAt, completedAt := ar.state.StartedAt, ar.state.CompletedAt
+	ar.stateMu.Unlock()
+
+	if module := ar.server.GetModule(); err == nil && module != nil {
+		ar.server.RecordHistory(web.HistoryEntry{
+			Time:         completedAt,
+			Reason:       opts.Reason,
+			Targets:      len(module.Targets),
+			Dependencies: len(module.Dependencies),
+			Issues:       len(module.Issues),
+			DurationMs:   completedAt.Sub(startedAt).Milliseconds(),
+		})
+	}
+
+	if errors.Is(err, context.Canceled) {
+		logging.Info("analysis cancelled", "reason", opts.Reason)
+		_ = ar.server.PublishWorkspaceStatus("ready", "Analysis cancelled", 6, 6)
+	} else if phaseErr != nil {
+		logging.Error("analysis phase failed", "phase", phaseErr.Phase, "error", phaseErr.Err)
+		_ = ar.server.PublishAnalysisError(phaseErr.Error(), phaseErr.AnalysisError())
+	}
+	return err
+}
+
+// runPhases runs the analysis phases in order, stopping between phases if ctx is cancelled
+func (ar *AnalysisRunner) runPhases(ctx context.Context, opts AnalysisOptions) error {
 	logging.Info("starting analysis", "reason", opts.Reason)
 
+	// Remember the previous results to tell clients what a re-analysis changed
+	previous := ar.server.GetModule().Snapshot()
+
 	// Run registered sources
+	start := time.Now()
 	ar.runRegisteredSources(ctx, opts.Reason)
+	ar.recordTiming(PhaseSources, start)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	// Phase 1: Bazel Query
+	start = time.Now()
 	module, err := ar.runBazelQueryPhase(opts)
+	ar.recordTiming(PhaseBazelQuery, start)
 	if err != nil {
 		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Phases whose tools are missing are left out; say which rather than leaving the user
+	// to wonder about the missing edges
+	if missing := ar.checkTools(); len(missing) > 0 {
+		message := MissingToolsMessage(missing)
+		logging.Warn("analysis tools missing", "message", message)
+		ar.addWarning(message)
+	}
+
+	// The dependency phases find nothing in an unbuilt workspace; say so rather than
+	// reporting no dependencies
+	if !opts.SkipCompileDeps || !opts.SkipSymbolDeps {
+		if err := model.CheckBuildOutputs(ar.workspace, ar.bazelOut()); err != nil {
+			logging.Warn("no build outputs, compile and symbol dependencies will be missing", "error", err)
+			ar.addWarning(err.Error())
+		}
+	}
 
 	// Phase 2: Compile Dependencies
+	start = time.Now()
 	ar.runCompileDepsPhase(opts, module)
+	ar.recordTiming(PhaseCompileDeps, start)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	// Phase 3: Symbol Dependencies
+	start = time.Now()
 	ar.runSymbolDepsPhase(opts, module)
+	ar.recordTiming(PhaseSymbolDeps, start)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Check dependencies against the configured policy
+	start = time.Now()
+	ar.runPolicyPhase(module)
+	ar.recordTiming(PhasePolicy, start)
 
 	// Phase 4: Binary Derivation
+	start = time.Now()
 	ar.runBinaryDerivationPhase(opts, module)
+	ar.recordTiming(PhaseBinaryDeriv, start)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	// Phase 5: Dynamic Analysis (LDD)
+	start = time.Now()
 	ar.runDynamicAnalysisPhase(opts)
+	ar.recordTiming(PhaseDynamic, start)
 
-	// Publish final ready state
-	_ = ar.server.PublishWorkspaceStatus("ready", "Analysis complete", 6, 6)
+	if module != nil {
+		ar.server.UpdateModule(module, func(m *model.Module) {
+			ar.applySeverities(m)
+			m.AnalyzedAt = time.Now()
+		})
+	}
+
+	if previous != nil && module != nil {
+		diff := model.DiffModules(previous, module)
+		logging.Debug("module changes", "targets", len(diff.AffectedTargets()))
+		_ = ar.server.PublishModuleChanges(opts.Reason, diff)
+	}
+
+	// Publish final ready state with the phase timings
+	state := ar.AnalysisState()
+	timings := state.Timings
+	_ = ar.server.PublishAnalysisComplete("Analysis complete", timings, state.Warnings)
+	if ar.Config != nil && ar.Config.Timings {
+		WriteTimings(os.Stderr, timings)
+	}
 
 	logging.Info("analysis complete", "reason", opts.Reason)
 	return nil
 }
 
+// applySeverities overrides the severity of the issues found with the configured ones
+func (ar *AnalysisRunner) applySeverities(module *model.Module) {
+	if ar.Config == nil || len(ar.Config.Severity) == 0 {
+		return
+	}
+	if changed := module.ApplySeverities(ar.Config.Severity); changed > 0 {
+		logging.Debug("applied severity overrides", "issues", changed)
+	}
+}
+
 func (ar *AnalysisRunner) runDynamicAnalysisPhase(opts AnalysisOptions) {
-	if !opts.SkipDynamicAnalysis && ar.FnScanBinary != nil {
+	if !opts.SkipDynamicAnalysis && ar.FnScanBinary != nil && ar.phaseAvailable(PhaseDynamic) {
 		_ = ar.server.PublishWorkspaceStatus("analyzing_dynamic", "Scanning binaries (ldd)...", 6, 6)
 		logging.Info("running dynamic analysis on binaries")
 
@@ -111,58 +336,133 @@ func (ar *AnalysisRunner) runDynamicAnalysisPhase(opts AnalysisOptions) {
 			return
 		}
 
-		// Iterate over binaries and scan them
+		// Scan binaries in parallel, limiting the number of concurrent ldd/otool processes.
+		// The binaries are already published, so the results are collected here and set on
+		// fresh copies.
+		sem := make(chan struct{}, ar.concurrencyLimit(config.PhaseLDD))
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		found := make(map[string][]string)
 		for _, bin := range bins {
 			// Construct path: prefer explicit OutputFile from cquery
 			// Otherwise fall back to guessing (legacy behavior)
-			var fullPath string
-			if bin.OutputFile != "" {
-				// cquery --output=files returns absolute path or relative to execroot?
-				// Usually relative to workspace/execroot. Ideally absolute if in bazel-bin.
-				// However, if we run bazel from workspace, it might be outputting relative path?
-				// Let's assume it's relative to workspace if it doesn't start with /
-				if strings.HasPrefix(bin.OutputFile, "/") {
-					fullPath = bin.OutputFile
-				} else {
-					fullPath = fmt.Sprintf("%s/%s", ar.workspace, bin.OutputFile)
-				}
-			} else {
-				// Fallback logic
-				label := bin.Label
-				if label == "" {
-					continue
-				}
+			fullPath := ar.binaryPath(bin)
+			if fullPath == "" {
+				continue
+			}
 
-				// Remove // prefix
-				path := label
-				if len(path) > 2 && path[:2] == "//" {
-					path = path[2:]
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				// Scan
+				libs, err := ar.FnScanBinary(fullPath)
+				if err != nil {
+					// Don't fail the whole analysis, just log
+					logging.Debug("failed to scan binary", "label", bin.Label, "path", fullPath, "error", err)
+					return
 				}
 
-				// Replace : with /
-				path = strings.ReplaceAll(path, ":", "/")
+				if len(libs) > 0 {
+					logging.Info("found dynamic dependencies", "label", bin.Label, "count", len(libs))
+					mu.Lock()
+					found[bin.Label] = libs
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
 
-				// Full path
-				fullPath = fmt.Sprintf("%s/bazel-bin/%s", ar.workspace, path)
+		scanned := make([]*binaries.BinaryInfo, len(bins))
+		for i, bin := range bins {
+			updated := *bin
+			if libs, ok := found[bin.Label]; ok {
+				updated.LddDependencies = libs
 			}
+			scanned[i] = &updated
+		}
+		binaries.FindUndeclaredLibraries(scanned)
 
-			// Scan
-			libs, err := ar.FnScanBinary(fullPath)
-			if err != nil {
-				// Don't fail the whole analysis, just log
-				logging.Debug("failed to scan binary", "label", bin.Label, "path", fullPath, "error", err)
-				continue
-			}
+		// Update server with the scanned binaries
+		ar.server.SetBinaries(scanned)
+	}
+}
 
-			if len(libs) > 0 {
-				logging.Info("found dynamic dependencies", "label", bin.Label, "count", len(libs))
-				bin.LddDependencies = libs
-			}
+// binaryPath returns the path of the built file for a binary, or "" if it cannot be determined
+func (ar *AnalysisRunner) binaryPath(bin *binaries.BinaryInfo) string {
+	if bin.OutputFile != "" {
+		// cquery --output=files returns absolute path or relative to execroot?
+		// Usually relative to workspace/execroot. Ideally absolute if in bazel-bin.
+		// However, if we run bazel from workspace, it might be outputting relative path?
+		// Let's assume it's relative to workspace if it doesn't start with /
+		if strings.HasPrefix(bin.OutputFile, "/") {
+			return bin.OutputFile
 		}
+		return fmt.Sprintf("%s/%s", ar.workspace, bin.OutputFile)
+	}
+
+	// Fallback logic
+	label := bin.Label
+	if label == "" {
+		return ""
+	}
 
-		// Update server with modified binaries
-		ar.server.SetBinaries(bins)
+	// Remove // prefix
+	path := label
+	if len(path) > 2 && path[:2] == "//" {
+		path = path[2:]
 	}
+
+	// Replace : with /
+	path = strings.ReplaceAll(path, ":", "/")
+
+	// Full path
+	return fmt.Sprintf("%s/bazel-bin/%s", ar.workspace, path)
+}
+
+// concurrencyLimit returns the configured number of parallel workers for a phase
+func (ar *AnalysisRunner) concurrencyLimit(phase string) int {
+	if ar.Config == nil {
+		return 1
+	}
+	return ar.Config.ConcurrencyLimit(phase)
+}
+
+// bazelOut returns the configured directory searched for build outputs instead of the
+// workspace's bazel-out, "" if none
+func (ar *AnalysisRunner) bazelOut() string {
+	if ar.Config == nil {
+		return ""
+	}
+	return ar.Config.BazelOutPath
+}
+
+// systemLibraryResolver returns a resolver for the built-in and configured system library symbols
+func (ar *AnalysisRunner) systemLibraryResolver() *symbols.SystemLibraryResolver {
+	if ar.Config == nil {
+		return symbols.NewSystemLibraryResolver(nil)
+	}
+	return symbols.NewSystemLibraryResolver(ar.Config.Symbols.SystemLibraries)
+}
+
+// symbolScope returns the targets whose object files are analyzed with nm: the configured
+// symbol scope target and everything it links, or nil for all targets
+func (ar *AnalysisRunner) symbolScope(module *model.Module) symbols.Scope {
+	if ar.Config == nil || ar.Config.SymbolScope == "" {
+		return nil
+	}
+
+	label := model.CanonicalizeLabel(ar.Config.SymbolScope)
+	if _, exists := module.Targets[label]; !exists {
+		logging.Warn("symbol scope target not found, analyzing all targets", "target", label)
+		return nil
+	}
+
+	closure := module.LinkClosure(label)
+	logging.Info("limiting symbol analysis", "target", label, "targets", len(closure))
+	return symbols.NewScope(closure)
 }
 
 func (ar *AnalysisRunner) runRegisteredSources(ctx context.Context, reason string) {
@@ -178,6 +478,19 @@ func (ar *AnalysisRunner) runRegisteredSources(ctx context.Context, reason strin
 	}
 }
 
+// annotateOwners sets the owner of each target from the workspace's CODEOWNERS file, if any
+func (ar *AnalysisRunner) annotateOwners(module *model.Module) {
+	codeOwners, err := owners.Load(ar.workspace)
+	if err != nil {
+		logging.Warn("could not read CODEOWNERS", "error", err)
+		return
+	}
+	if codeOwners != nil {
+		codeOwners.Annotate(module)
+		logging.Debug("annotated target owners", "rules", len(codeOwners.Rules))
+	}
+}
+
 func (ar *AnalysisRunner) runBazelQueryPhase(opts AnalysisOptions) (*model.Module, error) {
 	module := ar.server.GetModule()
 	if !opts.SkipBazelQuery {
@@ -188,12 +501,11 @@ func (ar *AnalysisRunner) runBazelQueryPhase(opts AnalysisOptions) (*model.Modul
 			var err error
 			module, err = ar.FnQueryWorkspace(ar.workspace)
 			if err != nil {
-				logging.Error("bazel query failed", "error", err)
-				_ = ar.server.PublishWorkspaceStatus("error", fmt.Sprintf("Error querying workspace: %v", err), 1, 6)
-				return nil, fmt.Errorf("bazel query failed: %w", err)
+				return nil, &PhaseError{Phase: PhaseBazelQuery, Err: err, Recoverable: true}
 			}
 
 			logging.Info("bazel query complete", "targets", len(module.Targets), "dependencies", len(module.Dependencies))
+			ar.annotateOwners(module)
 			ar.server.SetModule(module)
 			_ = ar.server.PublishTargetGraph("partial_data", false)
 		} else {
@@ -209,19 +521,23 @@ func (ar *AnalysisRunner) runCompileDepsPhase(opts AnalysisOptions, module *mode
 		logging.Info("adding compile dependencies from .d files")
 
 		// Parse file-level dependencies and store them
-		fileDeps, err := deps.ParseAllDFiles(ar.workspace)
+		fileDeps, err := ar.parseDFiles(opts)
 		if err != nil {
 			logging.Warn("could not parse .d files", "error", err)
 		} else {
 			logging.Info("parsed file dependencies", "count", len(fileDeps))
+			if module != nil && ar.FnResolveIncludePaths != nil {
+				ar.FnResolveIncludePaths(module, fileDeps)
+			}
+			if ar.Config != nil && ar.Config.DirectIncludes {
+				marked := deps.MarkDirectIncludes(fileDeps, ar.workspace)
+				logging.Info("marked direct includes", "sources", marked)
+			}
 			ar.server.SetFileDependencies(fileDeps)
-		}
 
-		// Add target-level compile dependencies
-		if ar.FnAddCompileDeps != nil {
-			if err := ar.FnAddCompileDeps(module, ar.workspace); err != nil {
-				logging.Warn("could not add compile dependencies", "error", err)
-			} else {
+			// Add target-level compile dependencies
+			if module != nil && ar.FnAddCompileDeps != nil {
+				ar.FnAddCompileDeps(module, fileDeps)
 				logging.Info("added compile dependencies", "totalDependencies", len(module.Dependencies))
 			}
 		}
@@ -229,6 +545,29 @@ func (ar *AnalysisRunner) runCompileDepsPhase(opts AnalysisOptions, module *mode
 	}
 }
 
+// parseDFiles returns the file dependencies of all .d files, parsing only those that changed
+// since the previous analysis. The .d files reported changed in opts are updated without
+// searching the build outputs, unless a full analysis is requested.
+func (ar *AnalysisRunner) parseDFiles(opts AnalysisOptions) ([]*deps.FileDependency, error) {
+	if ar.dFiles == nil {
+		ar.dFiles = deps.NewCache(ar.concurrencyLimit(config.PhaseDFiles), ar.Extensions)
+	}
+	if len(opts.ChangedDFiles) > 0 && !opts.FullAnalysis {
+		changed := make([]string, len(opts.ChangedDFiles))
+		for i, path := range opts.ChangedDFiles {
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(ar.workspace, path)
+			}
+			changed[i] = path
+		}
+		fileDeps, err := ar.dFiles.ParseDFilesIncremental(changed)
+		if !errors.Is(err, deps.ErrNoDFileScan) {
+			return fileDeps, err
+		}
+	}
+	return ar.dFiles.ParseAllDFiles(ar.workspace, ar.bazelOut())
+}
+
 func (ar *AnalysisRunner) runSymbolDepsPhase(opts AnalysisOptions, module *model.Module) {
 	if !opts.SkipSymbolDeps {
 		_ = ar.server.PublishWorkspaceStatus("analyzing_symbols", "Adding symbol dependencies...", 3, 6)
@@ -285,28 +624,9 @@ func (ar *AnalysisRunner) runSymbolDepsPhase(opts AnalysisOptions, module *model
 			ar.server.SetUncoveredFiles(uncoveredFiles)
 		}
 
-		// Build symbol graph and store file-level symbol dependencies
-		symbolDeps, err := symbols.BuildSymbolGraph(ar.workspace, fileToTarget, targetToKind)
-		if err != nil {
-			logging.Warn("could not build symbol graph", "error", err)
-		} else {
-			logging.Info("found symbol dependencies", "count", len(symbolDeps))
-			ar.server.SetSymbolDependencies(symbolDeps)
-		}
-
-		// Add target-level symbol dependencies
-		if ar.FnAddSymbolDependencies != nil {
-			if err := ar.FnAddSymbolDependencies(module, ar.workspace); err != nil {
-				logging.Warn("could not add symbol dependencies", "error", err)
-			} else {
-				logging.Info("module analysis complete", "totalDependencies", len(module.Dependencies))
-				if len(module.Issues) > 0 {
-					logging.Warn("found dependency issues", "count", len(module.Issues))
-					for _, issue := range module.Issues {
-						logging.Debug("dependency issue detail", "severity", issue.Severity, "from", issue.From, "to", issue.To, "types", issue.Types)
-					}
-				}
-			}
+		// Symbol dependencies need nm
+		if ar.phaseAvailable(PhaseSymbolDeps) {
+			ar.addSymbolDependencies(opts, module, fileToTarget, targetToKind)
 		}
 
 		// Store module in server and publish targets ready
@@ -316,12 +636,131 @@ func (ar *AnalysisRunner) runSymbolDepsPhase(opts AnalysisOptions, module *model
 	}
 }
 
+// addSymbolDependencies analyzes the object files with nm for the symbol dependencies of
+// the module and the symbol tables of its files
+func (ar *AnalysisRunner) addSymbolDependencies(opts AnalysisOptions, module *model.Module, fileToTarget, targetToKind map[string]string) {
+	scope := ar.symbolScope(module)
+
+	// Build or update the symbol graph and store file-level symbol dependencies
+	graph, err := ar.updateSymbolGraph(opts, fileToTarget, targetToKind, scope)
+	if err != nil {
+		logging.Warn("could not build symbol graph", "error", err)
+		return
+	}
+
+	symbolDeps, fileSymbols := graph.Dependencies(), graph.FileSymbols()
+	logging.Info("found symbol dependencies", "count", len(symbolDeps), "files", len(fileSymbols))
+	if resolved := symbols.ResolveSystemSymbols(fileSymbols, module.SystemLibraries, ar.systemLibraryResolver()); resolved > 0 {
+		logging.Debug("resolved undefined symbols to system libraries", "count", resolved)
+	}
+	ar.server.SetSymbolDependencies(symbolDeps)
+	ar.server.SetFileSymbols(fileSymbols)
+
+	// Unused symbols can only be told apart when every object was analyzed
+	if scope == nil {
+		shared := module.SharedLibraryTargets()
+		unused := symbols.FindUnusedSymbols(fileSymbols, func(target string) bool { return shared[target] })
+		logging.Info("found dead-code candidates", "symbols", len(unused))
+		ar.server.SetUnusedSymbols(unused)
+	} else {
+		ar.server.SetUnusedSymbols(nil)
+	}
+
+	// Add target-level symbol dependencies
+	if ar.FnAddSymbolDependencies != nil {
+		ar.FnAddSymbolDependencies(module, symbolDeps)
+		logging.Info("module analysis complete", "totalDependencies", len(module.Dependencies))
+		if len(module.Issues) > 0 {
+			logging.Warn("found dependency issues", "count", len(module.Issues))
+			for _, issue := range module.Issues {
+				logging.Debug("dependency issue detail", "severity", issue.Severity, "from", issue.From, "to", issue.To, "types", issue.Types)
+			}
+		}
+	}
+}
+
+// updateSymbolGraph returns the symbol graph of the object files in scope. The graph of the
+// previous analysis is updated if it was built for the same targets: only the object files
+// reported changed in opts are read again, or, if none are reported or one is new to the
+// graph, those whose modification time changed. A full analysis reads every object file.
+func (ar *AnalysisRunner) updateSymbolGraph(opts AnalysisOptions, fileToTarget, targetToKind map[string]string, scope symbols.Scope) (*symbols.SymbolGraph, error) {
+	if graph := ar.symbolGraph; graph != nil && !opts.FullAnalysis && graph.BuiltFor(fileToTarget, targetToKind, scope) {
+		changed, err := ar.updateObjectFiles(graph, opts.ChangedObjectFiles)
+		if err != nil {
+			return nil, err
+		}
+		logging.Debug("updated symbol graph", "objects", len(changed))
+		return graph, nil
+	}
+
+	client := symbols.NewClient()
+	if ar.FnNewSymbolClient != nil {
+		client = ar.FnNewSymbolClient()
+	}
+	graph := symbols.NewSymbolGraph(client, ar.workspace, fileToTarget, targetToKind, scope)
+	graph.SetWorkers(ar.concurrencyLimit(config.PhaseNM))
+	graph.SetFileExtensions(ar.Extensions)
+	graph.SetBazelOutPath(ar.bazelOut())
+	if err := graph.Build(); err != nil {
+		ar.symbolGraph = nil
+		return nil, err
+	}
+	ar.symbolGraph = graph
+	return graph, nil
+}
+
+// updateObjectFiles reads the given object files of the graph again, dropping those that
+// are gone, and returns them. Relative paths are taken relative to the workspace. Without
+// paths, or with one the graph does not know, e.g. a new object, the graph is refreshed
+// from the object files found in the build outputs instead.
+func (ar *AnalysisRunner) updateObjectFiles(graph *symbols.SymbolGraph, changedPaths []string) ([]string, error) {
+	changed := make([]string, 0, len(changedPaths))
+	for _, path := range changedPaths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(ar.workspace, path)
+		}
+		if !graph.HasObject(path) {
+			return graph.Refresh()
+		}
+		changed = append(changed, path)
+	}
+	if len(changed) == 0 {
+		return graph.Refresh()
+	}
+
+	for _, objFile := range changed {
+		graph.UpdateObject(objFile)
+	}
+	return changed, nil
+}
+
+func (ar *AnalysisRunner) runPolicyPhase(module *model.Module) {
+	if module == nil || ar.Config == nil || len(ar.Config.Policy.Rules) == 0 {
+		return
+	}
+
+	// The module is already published, so the violations are found on the side and swapped
+	// in under the server's lock
+	violations := policy.Check(module, ar.Config.Policy)
+	ar.server.UpdateModule(module, func(m *model.Module) {
+		m.ReplaceIssues(policy.IssueViolation, violations)
+	})
+	if len(violations) > 0 {
+		logging.Warn("found dependency policy violations", "count", len(violations))
+		for _, issue := range violations {
+			logging.Debug("policy violation", "from", issue.From, "to", issue.To, "types", issue.Types)
+		}
+	} else {
+		logging.Info("all dependencies satisfy the dependency policy", "rules", len(ar.Config.Policy.Rules))
+	}
+}
+
 func (ar *AnalysisRunner) runBinaryDerivationPhase(opts AnalysisOptions, module *model.Module) {
 	if !opts.SkipBinaryDeriv {
 		_ = ar.server.PublishWorkspaceStatus("analyzing_binaries", "Deriving binary info...", 6, 6)
 		logging.Info("deriving binary information from module")
 
-		binaryInfos := binaries.DeriveBinaryInfoFromModule(module, ar.workspace)
+		binaryInfos := binaries.DeriveBinaryInfoFromModule(module, ar.workspace, ar.concurrencyLimit(config.PhaseBinaries))
 		logging.Info("found binaries", "count", len(binaryInfos))
 		for _, bin := range binaryInfos {
 			logging.Debug("binary", "label", bin.Label, "kind", bin.Kind)
@@ -334,7 +773,21 @@ func (ar *AnalysisRunner) runBinaryDerivationPhase(opts AnalysisOptions, module
 			if len(bin.SystemLibraries) > 0 {
 				logging.Debug("binary system libraries", "label", bin.Label, "libs", bin.SystemLibraries)
 			}
+			for sharedLib, libs := range bin.RedundantDynamicDeps {
+				logging.Warn("dynamic dependency already linked statically",
+					"binary", bin.Label, "sharedLibrary", sharedLib, "libraries", libs)
+			}
+		}
+		redundant := binaries.RedundantDynamicDepIssues(binaryInfos)
+		overlapping := binaries.OverlappingLinkageIssues(binaryInfos)
+		for _, issue := range overlapping {
+			logging.Warn("library linked statically and through a shared library",
+				"binary", issue.From, "sharedLibrary", issue.To)
 		}
+		ar.server.UpdateModule(module, func(m *model.Module) {
+			m.ReplaceIssues(binaries.IssueRedundantDynamicDep, redundant)
+			m.ReplaceIssues(binaries.IssueOverlappingLinkage, overlapping)
+		})
 		ar.server.SetBinaries(binaryInfos)
 
 		logging.Info("analysis complete",
@@ -0,0 +1,451 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/binaries"
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/policy"
+	"github.com/ritzau/deps-analyzer/pkg/pubsub"
+	"github.com/ritzau/deps-analyzer/pkg/symbols"
+	"github.com/ritzau/deps-analyzer/pkg/web"
+)
+
+func TestStartFullAnalysisRejectsConcurrentRuns(t *testing.T) {
+	runner := NewAnalysisRunner(t.TempDir(), web.NewServer(), nil)
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		return nil, errors.New("no bazel in tests")
+	}
+
+	// Hold the lock as a running analysis would
+	runner.mu.Lock()
+	if err := runner.StartFullAnalysis("test"); !errors.Is(err, web.ErrAnalysisRunning) {
+		t.Errorf("StartFullAnalysis() while running = %v, want ErrAnalysisRunning", err)
+	}
+	runner.mu.Unlock()
+
+	if err := runner.StartFullAnalysis("test"); err != nil {
+		t.Fatalf("StartFullAnalysis() unexpected error: %v", err)
+	}
+
+	// Wait for the background analysis to release the lock
+	runner.mu.Lock()
+	runner.mu.Unlock()
+}
+
+func TestAnalysisStateRecordsLastError(t *testing.T) {
+	runner := NewAnalysisRunner(t.TempDir(), web.NewServer(), nil)
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		return nil, errors.New("no bazel in tests")
+	}
+
+	if state := runner.AnalysisState(); state.State != web.AnalysisIdle || !state.StartedAt.IsZero() {
+		t.Errorf("initial state = %+v, want idle", state)
+	}
+	if runner.CancelAnalysis() {
+		t.Error("CancelAnalysis() = true with no analysis running")
+	}
+
+	err := runner.Run(context.Background(), AnalysisOptions{FullAnalysis: true, Reason: "test"})
+	if err == nil {
+		t.Fatal("expected the failing query to fail the analysis")
+	}
+
+	state := runner.AnalysisState()
+	if state.State != web.AnalysisIdle || state.Reason != "test" || state.LastError != err.Error() {
+		t.Errorf("state after failed analysis = %+v", state)
+	}
+	if state.CompletedAt.Before(state.StartedAt) {
+		t.Errorf("CompletedAt %v before StartedAt %v", state.CompletedAt, state.StartedAt)
+	}
+}
+
+func TestFailedPhaseReportsStructuredError(t *testing.T) {
+	publisher := pubsub.NewMemoryPublisher()
+	server := web.NewServerWith(publisher)
+	previous := &model.Module{Targets: map[string]*model.Target{"//util:util": {Label: "//util:util"}}}
+	server.SetModule(previous)
+
+	runner := NewAnalysisRunner(t.TempDir(), server, nil)
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		return nil, errors.New("syntax error in //util/BUILD")
+	}
+
+	err := runner.Run(context.Background(), AnalysisOptions{FullAnalysis: true, Reason: "test"})
+	var phaseErr *PhaseError
+	if !errors.As(err, &phaseErr) || phaseErr.Phase != PhaseBazelQuery || !phaseErr.Recoverable {
+		t.Fatalf("Run() = %v, want a recoverable bazel query PhaseError", err)
+	}
+	if err.Error() != "Bazel query failed: syntax error in //util/BUILD" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	want := &pubsub.AnalysisError{Phase: PhaseBazelQuery, Message: "syntax error in //util/BUILD", Recoverable: true}
+	if state := runner.AnalysisState(); !reflect.DeepEqual(state.Error, want) {
+		t.Errorf("state error = %+v, want %+v", state.Error, want)
+	}
+
+	events := publisher.EventsFor(pubsub.WorkspaceStatusTopic.Name())
+	status, err := pubsub.WorkspaceStatusTopic.Decode(events[len(events)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "error" || !reflect.DeepEqual(status.Error, want) {
+		t.Errorf("last status = %+v, want error with %+v", status, want)
+	}
+
+	// The results of the previous analysis stay available
+	if server.GetModule() != previous {
+		t.Error("expected the previous module to be kept after the failure")
+	}
+}
+
+func TestCancelledAnalysisStopsBetweenPhases(t *testing.T) {
+	runner := NewAnalysisRunner(t.TempDir(), web.NewServer(), nil)
+	queried := false
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		queried = true
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runner.Run(ctx, AnalysisOptions{FullAnalysis: true, Reason: "test"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() with a cancelled context = %v, want context.Canceled", err)
+	}
+	if queried {
+		t.Error("expected the cancelled analysis to stop before the bazel query")
+	}
+	if state := runner.AnalysisState(); state.LastError == "" {
+		t.Errorf("expected the cancellation to be recorded, got %+v", state)
+	}
+}
+
+func TestAnalysisRecordsPhaseTimings(t *testing.T) {
+	publisher := pubsub.NewMemoryPublisher()
+	runner := NewAnalysisRunner(t.TempDir(), web.NewServerWith(publisher), nil)
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		return &model.Module{Targets: map[string]*model.Target{}}, nil
+	}
+
+	err := runner.Run(context.Background(), AnalysisOptions{
+		SkipCompileDeps:     true,
+		SkipSymbolDeps:      true,
+		SkipBinaryDeriv:     true,
+		SkipDynamicAnalysis: true,
+		Reason:              "test",
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	var phases []string
+	for _, timing := range runner.AnalysisState().Timings {
+		phases = append(phases, timing.Phase)
+	}
+	want := []string{PhaseSources, PhaseBazelQuery, PhaseCompileDeps, PhaseSymbolDeps, PhasePolicy, PhaseBinaryDeriv, PhaseDynamic}
+	if !reflect.DeepEqual(phases, want) {
+		t.Errorf("timed phases = %v, want %v", phases, want)
+	}
+
+	// The ready event carries the timings
+	events := publisher.EventsFor(pubsub.WorkspaceStatusTopic.Name())
+	status, err := pubsub.WorkspaceStatusTopic.Decode(events[len(events)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "ready" || len(status.Timings) != len(want) {
+		t.Errorf("last status = %+v, want ready with %d timings", status, len(want))
+	}
+}
+
+func TestAnalysisWarnsAboutMissingBuildOutputs(t *testing.T) {
+	workspace := t.TempDir()
+	// Left dangling by bazel clean
+	if err := os.Symlink(filepath.Join(t.TempDir(), "execroot", "bazel-out"), filepath.Join(workspace, "bazel-out")); err != nil {
+		t.Fatal(err)
+	}
+
+	publisher := pubsub.NewMemoryPublisher()
+	runner := NewAnalysisRunner(workspace, web.NewServerWith(publisher), nil)
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		return &model.Module{Targets: map[string]*model.Target{}}, nil
+	}
+	runner.FnLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	err := runner.Run(context.Background(), AnalysisOptions{
+		SkipSymbolDeps:      true,
+		SkipBinaryDeriv:     true,
+		SkipDynamicAnalysis: true,
+		Reason:              "test",
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	warnings := runner.AnalysisState().Warnings
+	if len(warnings) != 1 || !strings.Contains(warnings[0], model.ErrNoBuildOutputs.Error()) {
+		t.Fatalf("warnings = %v, want one about missing build outputs", warnings)
+	}
+
+	events := publisher.EventsFor(pubsub.WorkspaceStatusTopic.Name())
+	status, err := pubsub.WorkspaceStatusTopic.Decode(events[len(events)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "ready" || !reflect.DeepEqual(status.Warnings, warnings) {
+		t.Errorf("last status = %+v, want ready with warnings %v", status, warnings)
+	}
+}
+
+func TestWriteTimings(t *testing.T) {
+	var out strings.Builder
+	WriteTimings(&out, []pubsub.PhaseTiming{
+		{Phase: PhaseBazelQuery, DurationMs: 1500},
+		{Phase: PhaseSymbolDeps, DurationMs: 250},
+	})
+
+	want := "Analysis timings:\n" +
+		"  bazel_query              1.5s\n" +
+		"  symbol_deps             250ms\n" +
+		"  total                   1.75s\n"
+	if out.String() != want {
+		t.Errorf("WriteTimings() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+// countingSymbolClient serves fixed symbol tables and counts the nm runs on each object
+type countingSymbolClient struct {
+	objects map[string][]symbols.Symbol
+	runs    map[string]int
+}
+
+func (c *countingSymbolClient) FindObjectFiles(string, string) ([]string, error) {
+	return slices.Sorted(maps.Keys(c.objects)), nil
+}
+
+func (c *countingSymbolClient) RunNM(objectFile string) ([]symbols.Symbol, error) {
+	c.runs[objectFile]++
+	return c.objects[objectFile], nil
+}
+
+func (c *countingSymbolClient) RunNMDynamic(string) ([]symbols.Symbol, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (c *countingSymbolClient) RunRelocations(string) ([]symbols.Relocation, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (c *countingSymbolClient) BuildSymbolGraph(string, string, map[string]string, map[string]string) ([]symbols.SymbolDependency, error) {
+	return nil, errors.New("not used")
+}
+
+func TestSymbolGraphKeptBetweenAnalyses(t *testing.T) {
+	workspace := t.TempDir()
+	mainObj := filepath.Join(workspace, "bazel-out/k8-fastbuild/bin/main/_objs/app/main.o")
+	utilObj := filepath.Join(workspace, "bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o")
+	client := &countingSymbolClient{
+		objects: map[string][]symbols.Symbol{
+			mainObj: {{Name: "main", Type: "T"}, {Name: "util::Join()", Type: "U"}},
+			utilObj: {{Name: "util::Join()", Type: "T"}},
+		},
+		runs: make(map[string]int),
+	}
+	runner := NewAnalysisRunner(workspace, web.NewServer(), nil)
+	runner.FnNewSymbolClient = func() symbols.Client { return client }
+	fileToTarget := map[string]string{"main/main.cc": "//main:app", "util/strings.cc": "//util:util"}
+	targetToKind := map[string]string{"//main:app": "cc_binary", "//util:util": "cc_library"}
+
+	update := func(opts AnalysisOptions) []symbols.SymbolDependency {
+		t.Helper()
+		graph, err := runner.updateSymbolGraph(opts, fileToTarget, targetToKind, nil)
+		if err != nil {
+			t.Fatalf("updateSymbolGraph() unexpected error: %v", err)
+		}
+		return graph.Dependencies()
+	}
+
+	if deps := update(AnalysisOptions{FullAnalysis: true}); len(deps) != 1 || deps[0].TargetTarget != "//util:util" {
+		t.Fatalf("Dependencies() = %+v, want main.o on strings.o", deps)
+	}
+
+	// A reported object is read again on its own
+	client.objects[mainObj] = append(client.objects[mainObj], symbols.Symbol{Name: "util::Split()", Type: "U"})
+	client.objects[utilObj] = append(client.objects[utilObj], symbols.Symbol{Name: "util::Split()", Type: "T"})
+	deps := update(AnalysisOptions{ChangedObjectFiles: []string{utilObj}})
+	if client.runs[mainObj] != 1 || client.runs[utilObj] != 2 {
+		t.Errorf("nm runs = %v, want main.o once and strings.o twice", client.runs)
+	}
+	if len(deps) != 1 {
+		t.Errorf("Dependencies() = %+v, want the unchanged main.o dependency", deps)
+	}
+
+	// Relative paths from the watcher are resolved against the workspace
+	update(AnalysisOptions{ChangedObjectFiles: []string{"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o"}})
+	if client.runs[mainObj] != 2 {
+		t.Errorf("nm runs = %v, want main.o read again", client.runs)
+	}
+	if deps := update(AnalysisOptions{ChangedObjectFiles: []string{mainObj}}); len(deps) != 2 {
+		t.Errorf("Dependencies() = %+v, want both symbols of main.o", deps)
+	}
+
+	// A full analysis or different targets build the graph again
+	runs := client.runs[utilObj]
+	update(AnalysisOptions{FullAnalysis: true})
+	targetToKind = maps.Clone(targetToKind)
+	targetToKind["//util:util"] = "cc_shared_library"
+	update(AnalysisOptions{ChangedObjectFiles: []string{mainObj}})
+	if client.runs[utilObj] != runs+2 {
+		t.Errorf("nm runs of strings.o = %d, want %d after two rebuilds", client.runs[utilObj], runs+2)
+	}
+}
+
+func TestPolicyViolationsPublished(t *testing.T) {
+	cfg := &config.Config{
+		Policy: config.PolicyConfig{Rules: []config.PolicyRule{{From: "//ui", Deny: []string{"//db"}}}},
+	}
+	server := web.NewServer()
+	runner := NewAnalysisRunner(t.TempDir(), server, cfg)
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		return &model.Module{
+			Targets: map[string]*model.Target{
+				"//ui:ui": {Label: "//ui:ui", Kind: model.TargetKindLibrary, Package: "//ui"},
+				"//db:db": {Label: "//db:db", Kind: model.TargetKindLibrary, Package: "//db"},
+			},
+			Dependencies: []model.Dependency{{From: "//ui:ui", To: "//db:db", Type: model.DependencyStatic}},
+		}, nil
+	}
+
+	// The second analysis updates the published module
+	for _, skipQuery := range []bool{false, true} {
+		err := runner.Run(context.Background(), AnalysisOptions{
+			SkipBazelQuery:      skipQuery,
+			SkipCompileDeps:     true,
+			SkipSymbolDeps:      true,
+			SkipBinaryDeriv:     true,
+			SkipDynamicAnalysis: true,
+			Reason:              "test",
+		})
+		if err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+	}
+
+	issues := server.GetModule().Issues
+	if len(issues) != 1 || issues[0].Issue != policy.IssueViolation {
+		t.Errorf("issues = %+v, want one policy violation", issues)
+	}
+}
+
+func TestSeveritiesAppliedToPublishedModule(t *testing.T) {
+	cfg := &config.Config{Severity: map[string]string{model.IssueDuplicateLinkage: model.SeverityError}}
+	server := web.NewServer()
+	runner := NewAnalysisRunner(t.TempDir(), server, cfg)
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		return &model.Module{
+			Targets: map[string]*model.Target{},
+			Issues: []model.DependencyIssue{
+				{From: "//main:app", To: "//core:core", Issue: model.IssueDuplicateLinkage, Severity: model.SeverityWarning},
+			},
+		}, nil
+	}
+
+	err := runner.Run(context.Background(), AnalysisOptions{
+		SkipCompileDeps:     true,
+		SkipSymbolDeps:      true,
+		SkipBinaryDeriv:     true,
+		SkipDynamicAnalysis: true,
+		Reason:              "test",
+	})
+	if err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+
+	module := server.GetModule()
+	if module.Issues[0].Severity != model.SeverityError {
+		t.Errorf("severity = %q, want the configured %q", module.Issues[0].Severity, model.SeverityError)
+	}
+	if module.AnalyzedAt.IsZero() {
+		t.Error("expected the published module to record the analysis time")
+	}
+}
+
+func TestBinaryIssuesPublished(t *testing.T) {
+	server := web.NewServer()
+	runner := NewAnalysisRunner(t.TempDir(), server, nil)
+	runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+		return &model.Module{
+			Targets: map[string]*model.Target{
+				"//main:app":        {Label: "//main:app", Kind: model.TargetKindBinary},
+				"//plugins:plugins": {Label: "//plugins:plugins", Kind: model.TargetKindSharedLibrary},
+				"//plugins:core":    {Label: "//plugins:core", Kind: model.TargetKindLibrary},
+			},
+			Dependencies: []model.Dependency{
+				{From: "//main:app", To: "//plugins:plugins", Type: model.DependencyDynamic},
+				{From: "//main:app", To: "//plugins:core", Type: model.DependencyStatic},
+				{From: "//plugins:plugins", To: "//plugins:core", Type: model.DependencyStatic},
+			},
+		}, nil
+	}
+
+	// The second analysis updates the published module
+	for _, skipQuery := range []bool{false, true} {
+		err := runner.Run(context.Background(), AnalysisOptions{
+			SkipBazelQuery:      skipQuery,
+			SkipCompileDeps:     true,
+			SkipSymbolDeps:      true,
+			SkipDynamicAnalysis: true,
+			Reason:              "test",
+		})
+		if err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+	}
+
+	issues := server.GetModule().Issues
+	if len(issues) != 1 || issues[0].Issue != binaries.IssueRedundantDynamicDep || issues[0].To != "//plugins:plugins" {
+		t.Errorf("issues = %+v, want one redundant dynamic dep on //plugins:plugins", issues)
+	}
+}
+
+func TestDynamicAnalysisPublishesScannedCopies(t *testing.T) {
+	server := web.NewServer()
+	app := &binaries.BinaryInfo{Label: "//main:app", Kind: "cc_binary", OutputFile: "/out/app"}
+	plugins := &binaries.BinaryInfo{Label: "//plugins:plugins", Kind: "cc_shared_library", OutputFile: "/out/libplugins.so"}
+	server.SetBinaries([]*binaries.BinaryInfo{app, plugins})
+
+	runner := NewAnalysisRunner(t.TempDir(), server, nil)
+	runner.FnLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	runner.FnScanBinary = func(path string) ([]string, error) {
+		if path == "/out/app" {
+			return []string{"/out/libplugins.so"}, nil
+		}
+		return nil, nil
+	}
+
+	runner.runDynamicAnalysisPhase(AnalysisOptions{})
+
+	if app.LddDependencies != nil || app.UndeclaredLibraries != nil {
+		t.Errorf("expected the published binary to be left alone, got %+v", app)
+	}
+	scanned := server.GetBinaries()
+	if len(scanned) != 2 || scanned[0] == app {
+		t.Fatalf("expected fresh copies of the binaries, got %+v", scanned)
+	}
+	if !reflect.DeepEqual(scanned[0].LddDependencies, []string{"/out/libplugins.so"}) ||
+		!reflect.DeepEqual(scanned[0].UndeclaredLibraries, []string{"//plugins:plugins"}) {
+		t.Errorf("scanned app = %+v, want libplugins.so found and undeclared", scanned[0])
+	}
+}
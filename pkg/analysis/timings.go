@@ -0,0 +1,45 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/pubsub"
+)
+
+// Analysis phases reported in the timings
+const (
+	PhaseSources     = "sources"
+	PhaseBazelQuery  = "bazel_query"
+	PhaseCompileDeps = "compile_deps"
+	PhaseSymbolDeps  = "symbol_deps"
+	PhasePolicy      = "policy"
+	PhaseBinaryDeriv = "binary_derivation"
+	PhaseDynamic     = "dynamic_analysis"
+)
+
+// recordTiming records the duration of a phase that started at start
+func (ar *AnalysisRunner) recordTiming(phase string, start time.Time) {
+	ar.stateMu.Lock()
+	defer ar.stateMu.Unlock()
+	ar.state.Timings = append(ar.state.Timings, pubsub.PhaseTiming{
+		Phase:      phase,
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+}
+
+// WriteTimings prints the duration of each phase and the total as a table
+func WriteTimings(w io.Writer, timings []pubsub.PhaseTiming) {
+	var total int64
+	_, _ = fmt.Fprintln(w, "Analysis timings:")
+	for _, timing := range timings {
+		_, _ = fmt.Fprintf(w, "  %-18s %10s\n", timing.Phase, formatMillis(timing.DurationMs))
+		total += timing.DurationMs
+	}
+	_, _ = fmt.Fprintf(w, "  %-18s %10s\n", "total", formatMillis(total))
+}
+
+func formatMillis(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).String()
+}
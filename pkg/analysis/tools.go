@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/config"
+)
+
+// ToolRequirement is an external tool an analysis phase runs
+type ToolRequirement struct {
+	Phase    string // PhaseSymbolDeps or PhaseDynamic
+	Skip     string // The config.SkipValues value leaving the phase out
+	Tool     string
+	Analysis string // What is unavailable without the tool
+}
+
+// RequiredTools returns the external tools the analysis phases run on the given platform
+// (a runtime.GOOS value)
+func RequiredTools(goos string) []ToolRequirement {
+	scanner := "ldd"
+	if goos == "darwin" {
+		scanner = "otool"
+	}
+	return []ToolRequirement{
+		{Phase: PhaseSymbolDeps, Skip: config.SkipSymbols, Tool: "nm", Analysis: "symbol dependencies"},
+		{Phase: PhaseSymbolDeps, Skip: config.SkipSymbols, Tool: "find", Analysis: "symbol dependencies"},
+		{Phase: PhaseDynamic, Skip: config.SkipDynamic, Tool: scanner, Analysis: "dynamic library scanning"},
+	}
+}
+
+// MissingTools returns the requirements whose tool lookPath cannot find
+func MissingTools(requirements []ToolRequirement, lookPath func(file string) (string, error)) []ToolRequirement {
+	var missing []ToolRequirement
+	for _, req := range requirements {
+		if _, err := lookPath(req.Tool); err != nil {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// MissingToolsMessage describes the analyses that are unavailable for lack of tools, as
+// one status line
+func MissingToolsMessage(missing []ToolRequirement) string {
+	parts := make([]string, 0, len(missing))
+	var skips []string
+	for _, req := range missing {
+		parts = append(parts, fmt.Sprintf("%s (%s not found)", req.Analysis, req.Tool))
+		if !slices.Contains(skips, req.Skip) {
+			skips = append(skips, req.Skip)
+		}
+	}
+	return fmt.Sprintf("Unavailable: %s. Install the tools, or leave the analyses out with --skip=%s",
+		strings.Join(parts, ", "), strings.Join(skips, ","))
+}
+
+// checkTools looks up the tools of the phases not skipped in the configuration, once.
+// The phases of missing tools are left out of every analysis.
+func (ar *AnalysisRunner) checkTools() []ToolRequirement {
+	ar.toolsOnce.Do(func() {
+		lookPath := ar.FnLookPath
+		if lookPath == nil {
+			lookPath = exec.LookPath
+		}
+
+		var required []ToolRequirement
+		for _, req := range RequiredTools(runtime.GOOS) {
+			if ar.Config == nil || !ar.Config.Skips(req.Skip) {
+				required = append(required, req)
+			}
+		}
+		ar.missingTools = MissingTools(required, lookPath)
+	})
+	return ar.missingTools
+}
+
+// phaseAvailable returns false if the phase is skipped in the configuration or lacks a tool
+func (ar *AnalysisRunner) phaseAvailable(phase string) bool {
+	for _, req := range RequiredTools(runtime.GOOS) {
+		if req.Phase == phase && ar.Config != nil && ar.Config.Skips(req.Skip) {
+			return false
+		}
+	}
+	for _, req := range ar.checkTools() {
+		if req.Phase == phase {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/pubsub"
+	"github.com/ritzau/deps-analyzer/pkg/symbols"
+	"github.com/ritzau/deps-analyzer/pkg/web"
+)
+
+// lookPathWithout returns a lookPath that finds every tool but the given ones
+func lookPathWithout(missing ...string) func(string) (string, error) {
+	return func(file string) (string, error) {
+		for _, tool := range missing {
+			if file == tool {
+				return "", exec.ErrNotFound
+			}
+		}
+		return "/usr/bin/" + file, nil
+	}
+}
+
+func TestMissingTools(t *testing.T) {
+	missing := MissingTools(RequiredTools("linux"), lookPathWithout("nm", "ldd"))
+	var tools []string
+	for _, req := range missing {
+		tools = append(tools, req.Tool)
+	}
+	if want := []string{"nm", "ldd"}; !reflect.DeepEqual(tools, want) {
+		t.Fatalf("MissingTools() = %v, want %v", tools, want)
+	}
+
+	want := "Unavailable: symbol dependencies (nm not found), dynamic library scanning (ldd not found). " +
+		"Install the tools, or leave the analyses out with --skip=symbols,dynamic"
+	if got := MissingToolsMessage(missing); got != want {
+		t.Errorf("MissingToolsMessage() = %q, want %q", got, want)
+	}
+
+	if missing := MissingTools(RequiredTools("darwin"), lookPathWithout("ldd")); len(missing) != 0 {
+		t.Errorf("macOS scans with otool, got missing %+v", missing)
+	}
+}
+
+func TestAnalysisLeavesOutPhasesWithoutTools(t *testing.T) {
+	run := func(cfg *config.Config) (warnings []string, symbolsAdded bool) {
+		t.Helper()
+		publisher := pubsub.NewMemoryPublisher()
+		runner := NewAnalysisRunner(t.TempDir(), web.NewServerWith(publisher), cfg)
+		runner.FnLookPath = lookPathWithout("nm")
+		runner.FnQueryWorkspace = func(string) (*model.Module, error) {
+			return &model.Module{Targets: map[string]*model.Target{}}, nil
+		}
+		runner.FnAddSymbolDependencies = func(*model.Module, []symbols.SymbolDependency) {
+			symbolsAdded = true
+		}
+
+		err := runner.Run(context.Background(), AnalysisOptions{
+			SkipCompileDeps:     true,
+			SkipBinaryDeriv:     true,
+			SkipDynamicAnalysis: true,
+			Reason:              "test",
+		})
+		if err != nil {
+			t.Fatalf("Run() unexpected error: %v", err)
+		}
+
+		events := publisher.EventsFor(pubsub.WorkspaceStatusTopic.Name())
+		status, err := pubsub.WorkspaceStatusTopic.Decode(events[len(events)-1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status.Warnings, symbolsAdded
+	}
+
+	warnings, symbolsAdded := run(nil)
+	if symbolsAdded {
+		t.Error("expected the symbol analysis to be left out without nm")
+	}
+	want := "Unavailable: symbol dependencies (nm not found). Install the tools, or leave the analyses out with --skip=symbols"
+	if !slices.Contains(warnings, want) {
+		t.Errorf("warnings = %v, want %q", warnings, want)
+	}
+
+	// Skipping the analysis silences the warning
+	warnings, symbolsAdded = run(&config.Config{Skip: []string{config.SkipSymbols}})
+	if symbolsAdded {
+		t.Error("expected the skipped symbol analysis to be left out")
+	}
+	for _, warning := range warnings {
+		if strings.HasPrefix(warning, "Unavailable") {
+			t.Errorf("expected no missing tools warning when skipped, got %q", warning)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package bazel
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// queryCacheVersion changes whenever the cached module format does, so that older entries
+// are ignored
+const queryCacheVersion = 2
+
+// queryCacheDir is where query results are cached, "" if caching is disabled
+var queryCacheDir string
+
+// queryCacheEntry is the content of a cache file
+type queryCacheEntry struct {
+	Version int           `json:"version"`
+	Hash    string        `json:"hash"` // BuildFilesHash of the workspace when it was queried
+	Module  *model.Module `json:"module"`
+}
+
+// SetQueryCacheDir sets the directory bazel query results are cached in between runs, or
+// disables the cache for "" (the default)
+func SetQueryCacheDir(dir string) {
+	queryCacheDir = dir
+}
+
+// DefaultQueryCacheDir returns the query cache directory in the user's cache directory
+// (e.g. ~/.cache/deps-analyzer/queries), or "" if there is none
+func DefaultQueryCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "deps-analyzer", "queries")
+}
+
+// CachedQuery returns the module of an earlier query of the same kind (e.g. "query", or
+// "cquery" with its options) if the files defining the targets of the workspace have not
+// changed since (see BuildFilesHash). Otherwise it runs query and caches the result.
+// Without a cache directory (see SetQueryCacheDir) query is always run.
+func CachedQuery(workspacePath, kind string, query func(workspacePath string) (*model.Module, error)) (*model.Module, error) {
+	if queryCacheDir == "" {
+		return query(workspacePath)
+	}
+
+	hash, err := BuildFilesHash(workspacePath)
+	if err != nil {
+		logging.Warn("could not hash BUILD files, not caching bazel query", "error", err)
+		return query(workspacePath)
+	}
+
+	cacheFile := queryCacheFile(workspacePath, kind)
+	if module := readQueryCache(cacheFile, hash); module != nil {
+		logging.Info("using cached bazel query result", "kind", kind, "targets", len(module.Targets))
+		return module, nil
+	}
+
+	module, err := query(workspacePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeQueryCache(cacheFile, hash, module); err != nil {
+		logging.Warn("could not cache bazel query result", "error", err)
+	}
+	return module, nil
+}
+
+// InvalidateCache removes the cached query results of a workspace, e.g. when the file
+// watcher sees a BUILD file change
+func InvalidateCache(workspacePath string) error {
+	if queryCacheDir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(queryCacheDir, workspaceCacheKey(workspacePath)+"-*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing cached query: %w", err)
+		}
+	}
+	return nil
+}
+
+// BuildFilesHash returns a hash of the path, size and modification time of every file
+// that can change the targets of a workspace: BUILD and BUILD.bazel files, .bzl files,
+// and the MODULE.bazel and WORKSPACE files of the workspace root
+func BuildFilesHash(workspacePath string) (string, error) {
+	files, err := walkWorkspaceFiles(workspacePath)
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		if !isBuildDefinitionFile(file) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(workspacePath, filepath.FromSlash(file)))
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// isBuildDefinitionFile reports whether a workspace-relative file defines targets or
+// external repositories
+func isBuildDefinitionFile(file string) bool {
+	name := path.Base(file)
+	switch {
+	case name == "BUILD" || name == "BUILD.bazel" || strings.HasSuffix(name, ".bzl"):
+		return true
+	case file == "MODULE.bazel" || file == "MODULE.bazel.lock" || file == "WORKSPACE" || file == "WORKSPACE.bazel":
+		return true
+	}
+	return false
+}
+
+// queryCacheFile returns the cache file of a kind of query of a workspace
+func queryCacheFile(workspacePath, kind string) string {
+	kindHash := sha256.Sum256([]byte(kind))
+	return filepath.Join(queryCacheDir, fmt.Sprintf("%s-%x.json", workspaceCacheKey(workspacePath), kindHash[:4]))
+}
+
+// workspaceCacheKey identifies a workspace in cache file names
+func workspaceCacheKey(workspacePath string) string {
+	if abs, err := filepath.Abs(workspacePath); err == nil {
+		workspacePath = abs
+	}
+	hash := sha256.Sum256([]byte(workspacePath))
+	return fmt.Sprintf("%x", hash[:8])
+}
+
+// readQueryCache returns the cached module if the cache file exists and matches the hash
+func readQueryCache(cacheFile, hash string) *model.Module {
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return nil
+	}
+	var entry queryCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		logging.Debug("ignoring unreadable query cache", "file", cacheFile, "error", err)
+		return nil
+	}
+	if entry.Version != queryCacheVersion || entry.Hash != hash || entry.Module == nil {
+		return nil
+	}
+	return entry.Module
+}
+
+// writeQueryCache stores a module in a cache file. The file is replaced atomically, so
+// concurrent runs never read a partial entry.
+func writeQueryCache(cacheFile, hash string, module *model.Module) error {
+	data, err := json.Marshal(queryCacheEntry{Version: queryCacheVersion, Hash: hash, Module: module})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cacheFile), ".query-*.json")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cacheFile)
+}
@@ -0,0 +1,91 @@
+package bazel
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestCachedQuery(t *testing.T) {
+	workspace := t.TempDir()
+	buildFile := filepath.Join(workspace, "util", "BUILD.bazel")
+	if err := os.MkdirAll(filepath.Dir(buildFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{buildFile, filepath.Join(workspace, "util", "util.cc")} {
+		if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetQueryCacheDir(t.TempDir())
+	t.Cleanup(func() { SetQueryCacheDir("") })
+
+	queries := 0
+	query := func(workspacePath string) (*model.Module, error) {
+		queries++
+		return &model.Module{
+			Name:         "ws",
+			Targets:      map[string]*model.Target{"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary, Package: "//util", Name: "util"}},
+			Dependencies: []model.Dependency{},
+			Issues:       []model.DependencyIssue{},
+		}, nil
+	}
+	run := func(kind string) *model.Module {
+		t.Helper()
+		module, err := CachedQuery(workspace, kind, query)
+		if err != nil {
+			t.Fatalf("CachedQuery() unexpected error: %v", err)
+		}
+		return module
+	}
+
+	first := run("query")
+	if cached := run("query"); queries != 1 || !reflect.DeepEqual(cached, first) {
+		t.Errorf("second query: %d queries, module %+v, want the cached %+v", queries, cached, first)
+	}
+
+	// Source files do not change the targets
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(workspace, "util", "util.cc"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	run("query")
+	if queries != 1 {
+		t.Errorf("source file change: %d queries, want the cached result", queries)
+	}
+
+	// Each kind of query is cached separately
+	run("cquery --config=linux")
+	if queries != 2 {
+		t.Errorf("cquery: %d queries, want 2", queries)
+	}
+
+	if err := os.Chtimes(buildFile, later, later); err != nil {
+		t.Fatal(err)
+	}
+	run("query")
+	if queries != 3 {
+		t.Errorf("BUILD file change: %d queries, want 3", queries)
+	}
+
+	if err := InvalidateCache(workspace); err != nil {
+		t.Fatalf("InvalidateCache() unexpected error: %v", err)
+	}
+	run("query")
+	run("cquery --config=linux")
+	if queries != 5 {
+		t.Errorf("after InvalidateCache: %d queries, want 5", queries)
+	}
+
+	// Without a cache directory every call queries
+	SetQueryCacheDir("")
+	run("query")
+	if queries != 6 {
+		t.Errorf("cache disabled: %d queries, want 6", queries)
+	}
+}
@@ -0,0 +1,131 @@
+package bazel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// cqueryResult represents the --output=jsonproto output of bazel cquery
+type cqueryResult struct {
+	Results []struct {
+		Target struct {
+			Rule *cqueryRule `json:"rule"`
+		} `json:"target"`
+	} `json:"results"`
+}
+
+// cqueryRule represents a configured rule in the jsonproto output
+type cqueryRule struct {
+	Name      string            `json:"name"`
+	RuleClass string            `json:"ruleClass"`
+	Location  string            `json:"location"`
+	Attribute []cqueryAttribute `json:"attribute"`
+}
+
+// cqueryAttribute represents an attribute in the jsonproto output. select() is already
+// resolved for the configuration, so only the chosen branch is present.
+type cqueryAttribute struct {
+	Name            string   `json:"name"`
+	Type            string   `json:"type"`
+	StringListValue []string `json:"stringListValue"`
+	BooleanValue    bool     `json:"booleanValue"`
+}
+
+// QueryWorkspaceConfigured queries the same targets as QueryWorkspace with bazel cquery,
+// which resolves select() for one build configuration. configOpts are passed to bazel
+// (e.g. ["--config=linux"] or ["--platforms=//platforms:macos"]), so deps, dynamic_deps
+// and linkopts only hold the branches of that configuration. QueryWorkspace gives the
+// configuration-agnostic view with every branch. Results are cached like those of
+// QueryWorkspace, separately for each set of configOpts.
+func QueryWorkspaceConfigured(workspacePath string, configOpts []string, ext model.FileExtensions) (*model.Module, error) {
+	kind := strings.Join(append([]string{"cquery"}, configOpts...), " ")
+	return CachedQuery(workspacePath, queryKind(kind, ext), func(workspacePath string) (*model.Module, error) {
+		return queryWorkspaceConfigured(workspacePath, configOpts, ext)
+	})
+}
+
+// queryWorkspaceConfigured runs the bazel cquery of QueryWorkspaceConfigured
+func queryWorkspaceConfigured(workspacePath string, configOpts []string, ext model.FileExtensions) (*model.Module, error) {
+	rules, err := runCQuery(workspacePath, ccTargetsQuery, configOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildModule(workspacePath, rules, ext, func(labels []string) ([]*model.Target, []RuleXML, error) {
+		rules, err := runCQuery(workspacePath, strings.Join(labels, " + "), configOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("bazel cquery for external targets failed: %w", err)
+		}
+		targets := make([]*model.Target, 0, len(rules))
+		for _, rule := range rules {
+			if target := parseTarget(rule, ext); target != nil {
+				targets = append(targets, target)
+			}
+		}
+		return targets, rules, nil
+	}), nil
+}
+
+// runCQuery runs bazel cquery with --output=jsonproto and returns the rules in the form
+// of the query XML output
+func runCQuery(workspacePath, expr string, configOpts []string) ([]RuleXML, error) {
+	args := append([]string{"cquery"}, configOpts...)
+	args = append(args, expr, "--output=jsonproto")
+	cmd := exec.Command("bazel", args...)
+	cmd.Dir = workspacePath
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("bazel cquery failed: %w\nOutput: %s", err, stderr.String())
+	}
+	return parseCQueryOutput(output)
+}
+
+// parseCQueryOutput converts the jsonproto output of bazel cquery to rules. A target built
+// in several configurations (e.g. also as a tool) is only kept once.
+func parseCQueryOutput(data []byte) ([]RuleXML, error) {
+	var result cqueryResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse cquery output: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	rules := make([]RuleXML, 0, len(result.Results))
+	for _, res := range result.Results {
+		rule := res.Target.Rule
+		if rule == nil || seen[rule.Name] {
+			continue
+		}
+		seen[rule.Name] = true
+
+		converted := RuleXML{Class: rule.RuleClass, Name: rule.Name, Location: rule.Location}
+		for _, attr := range rule.Attribute {
+			switch {
+			case strings.HasSuffix(attr.Type, "LABEL_LIST"):
+				list := ListXML{Name: attr.Name}
+				for _, value := range attr.StringListValue {
+					list.Labels = append(list.Labels, LabelXML{Value: value})
+				}
+				converted.Lists = append(converted.Lists, list)
+			case attr.Type == "STRING_LIST":
+				list := ListXML{Name: attr.Name}
+				for _, value := range attr.StringListValue {
+					list.Strings = append(list.Strings, StringXML{Value: value})
+				}
+				converted.Lists = append(converted.Lists, list)
+			case attr.Type == "BOOLEAN":
+				converted.Booleans = append(converted.Booleans, BooleanXML{Name: attr.Name, Value: strconv.FormatBool(attr.BooleanValue)})
+			}
+		}
+		rules = append(rules, converted)
+	}
+	return rules, nil
+}
@@ -3,42 +3,41 @@ package bazel
 import (
 	"bufio"
 	"fmt"
+	"io/fs"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
-// DiscoverSourceFiles finds all .cc and .h files using git ls-files
-// It respects .gitignore and includes both tracked and untracked-but-not-ignored files
-func DiscoverSourceFiles(workspaceRoot string) (map[string]bool, error) {
-	discovered := make(map[string]bool)
+// DiscoverSourceFiles finds all source and header files in package directories.
+// In a git repository it uses git ls-files, which respects .gitignore and includes both
+// tracked and untracked-but-not-ignored files. Otherwise the workspace is walked.
+func DiscoverSourceFiles(workspaceRoot string, ext model.FileExtensions) (map[string]bool, error) {
+	return DiscoverSourceFilesExcluding(workspaceRoot, nil, ext)
+}
 
-	// Get tracked files
-	trackedFiles, err := runGitLsFiles(workspaceRoot, false)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get tracked files: %w", err)
-	}
+// DiscoverSourceFilesExcluding is like DiscoverSourceFiles but leaves out files matching
+// any of the exclude patterns (see isExcluded), such as vendored or generated code
+func DiscoverSourceFilesExcluding(workspaceRoot string, excludes []string, ext model.FileExtensions) (map[string]bool, error) {
+	discovered := make(map[string]bool)
 
-	// Get untracked but not ignored files
-	untrackedFiles, err := runGitLsFiles(workspaceRoot, true)
+	allFiles, err := listWorkspaceFiles(workspaceRoot)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get untracked files: %w", err)
+		return nil, err
 	}
 
-	// Merge both lists
-	allFiles := append(trackedFiles, untrackedFiles...)
-
 	// Find all package directories (directories with BUILD files)
-	packageDirs, err := findPackageDirectories(workspaceRoot)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find package directories: %w", err)
-	}
+	packageDirs := findPackageDirectories(allFiles)
 
 	// Filter for C++ source files in package directories
 	for _, file := range allFiles {
-		// Check if it's a C++ source file
-		if !isCppSourceFile(file) {
+		// Check if it's a source or header file
+		if !(ext.IsSource(file) || ext.IsHeader(file)) || isExcluded(file, excludes) {
 			continue
 		}
 
@@ -56,6 +55,55 @@ func DiscoverSourceFiles(workspaceRoot string) (map[string]bool, error) {
 	return discovered, nil
 }
 
+// listWorkspaceFiles returns the workspace-relative paths of the files in the workspace,
+// using git if possible and walking the file system if not
+func listWorkspaceFiles(workspaceRoot string) ([]string, error) {
+	// Get tracked files
+	trackedFiles, err := runGitLsFiles(workspaceRoot, false)
+	if err != nil {
+		logging.Warn("git unavailable or workspace is not a git repository, walking the workspace instead (.gitignore is not respected)", "error", err)
+		return walkWorkspaceFiles(workspaceRoot)
+	}
+
+	// Get untracked but not ignored files
+	untrackedFiles, err := runGitLsFiles(workspaceRoot, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get untracked files: %w", err)
+	}
+
+	return append(trackedFiles, untrackedFiles...), nil
+}
+
+// walkWorkspaceFiles lists the files in the workspace by walking it. Like the file watcher,
+// it skips the bazel-* output symlinks, and it also skips hidden directories such as .git.
+func walkWorkspaceFiles(workspaceRoot string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(workspaceRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip files we can't access
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if path != workspaceRoot && (strings.HasPrefix(name, "bazel-") || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(workspaceRoot, path)
+		if err != nil {
+			return nil
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk workspace: %w", err)
+	}
+	return files, nil
+}
+
 // FindUncoveredFiles compares discovered files against tracked files
 // Returns files that exist in the workspace but are not included in any target
 func FindUncoveredFiles(discovered map[string]bool, fileToTarget map[string]string) []string {
@@ -101,41 +149,39 @@ func runGitLsFiles(workspaceRoot string, untrackedOnly bool) ([]string, error) {
 	return files, scanner.Err()
 }
 
-// findPackageDirectories finds all directories containing BUILD or BUILD.bazel files
-func findPackageDirectories(workspaceRoot string) (map[string]bool, error) {
+// findPackageDirectories returns the directories containing BUILD or BUILD.bazel files
+func findPackageDirectories(files []string) map[string]bool {
 	packages := make(map[string]bool)
 
-	// Find all BUILD files using git ls-files (faster than walking filesystem)
-	cmd := exec.Command("git", "ls-files", "BUILD", "BUILD.bazel", "**/BUILD", "**/BUILD.bazel")
-	cmd.Dir = workspaceRoot
-
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to find BUILD files: %w", err)
-	}
-
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
-	for scanner.Scan() {
-		buildFile := strings.TrimSpace(scanner.Text())
-		if buildFile == "" {
+	for _, file := range files {
+		name := path.Base(file)
+		if name != "BUILD" && name != "BUILD.bazel" {
 			continue
 		}
 
 		// Get directory containing BUILD file
-		dir := filepath.Dir(buildFile)
+		dir := path.Dir(file)
 		if dir == "." {
 			dir = ""
 		}
 		packages[dir] = true
 	}
 
-	return packages, scanner.Err()
+	return packages
 }
 
-// isCppSourceFile checks if a file has a C++ source extension
-func isCppSourceFile(file string) bool {
-	ext := strings.ToLower(filepath.Ext(file))
-	return ext == ".cc" || ext == ".h" || ext == ".hpp"
+// isExcluded reports whether a workspace-relative file path or any of its parent
+// directories matches one of the patterns (path.Match syntax), so "third_party" and
+// "*/generated" exclude everything below those directories
+func isExcluded(file string, patterns []string) bool {
+	for p := file; p != "." && p != "/"; p = path.Dir(p) {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, p); matched {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // isInPackage checks if a directory is in a package or its subdirectories
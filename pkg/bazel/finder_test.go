@@ -0,0 +1,105 @@
+package bazel
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestDiscoverSourceFilesWithoutGit(t *testing.T) {
+	workspace := t.TempDir()
+	// Keep git from finding a repository above the temp directory
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(workspace))
+
+	for _, file := range []string{
+		"MODULE.bazel",
+		"util/BUILD.bazel",
+		"util/strings.cc",
+		"util/internal/strings_impl.h",
+		"tools/codegen.cc",            // Not in a package
+		"bazel-out/util/generated.cc", // Build outputs are skipped
+		".cache/util/cached.cc",       // Hidden directories are skipped
+		"util/README.md",
+	} {
+		path := filepath.Join(workspace, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	discovered, err := DiscoverSourceFiles(workspace, model.FileExtensions{})
+	if err != nil {
+		t.Fatalf("DiscoverSourceFiles() error: %v", err)
+	}
+
+	want := map[string]bool{
+		"util/strings.cc":              true,
+		"util/internal/strings_impl.h": true,
+	}
+	if !reflect.DeepEqual(discovered, want) {
+		t.Errorf("DiscoverSourceFiles() = %v, want %v", discovered, want)
+	}
+}
+
+func TestDiscoverSourceFilesExcluding(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(workspace))
+
+	for _, file := range []string{
+		"BUILD.bazel",
+		"util/strings.cc",
+		"third_party/zlib/zlib.h",
+		"util/generated/table.cc",
+		"examples/demo.cc",
+	} {
+		path := filepath.Join(workspace, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	discovered, err := DiscoverSourceFilesExcluding(workspace, []string{"third_party", "*/generated", "examples/*.cc"}, model.FileExtensions{})
+	if err != nil {
+		t.Fatalf("DiscoverSourceFilesExcluding() error: %v", err)
+	}
+
+	want := map[string]bool{"util/strings.cc": true}
+	if !reflect.DeepEqual(discovered, want) {
+		t.Errorf("DiscoverSourceFilesExcluding() = %v, want %v", discovered, want)
+	}
+}
+
+func TestDiscoverSourceFilesExtensions(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(workspace))
+
+	for _, file := range []string{"BUILD.bazel", "gpu/kernel.cu", "util/strings.cc", "util/strings.h"} {
+		path := filepath.Join(workspace, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only the configured source extensions, and the default headers
+	discovered, err := DiscoverSourceFiles(workspace, model.FileExtensions{Sources: []string{".cu"}})
+	if err != nil {
+		t.Fatalf("DiscoverSourceFiles() error: %v", err)
+	}
+
+	want := map[string]bool{"gpu/kernel.cu": true, "util/strings.h": true}
+	if !reflect.DeepEqual(discovered, want) {
+		t.Errorf("DiscoverSourceFiles() = %v, want %v", discovered, want)
+	}
+}
@@ -0,0 +1,43 @@
+package bazel
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// QueryTargetKinds returns the rule kind of each target matching a query expression, e.g.
+// "kind('cc_binary', //...)". It uses --output=label_kind, which is much cheaper than the
+// XML output of QueryWorkspace when sources and dependencies are not needed.
+func QueryTargetKinds(workspacePath, pattern string) (map[string]model.TargetKind, error) {
+	cmd := exec.Command("bazel", "query", "--output=label_kind", pattern)
+	cmd.Dir = workspacePath
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("bazel query failed: %w\nOutput: %s", err, string(output))
+	}
+	return parseLabelKind(string(output)), nil
+}
+
+// parseLabelKind parses --output=label_kind lines such as "cc_binary rule //main:app" or
+// "source file //main:app.cc". Lines not ending in a label, like Bazel's Loading: and INFO:
+// messages, are skipped.
+func parseLabelKind(output string) map[string]model.TargetKind {
+	kinds := make(map[string]model.TargetKind)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		label := fields[len(fields)-1]
+		if !strings.HasPrefix(label, "//") && !strings.HasPrefix(label, "@") {
+			continue
+		}
+		kind := strings.TrimSuffix(strings.Join(fields[:len(fields)-1], " "), " rule")
+		kinds[label] = model.TargetKind(kind)
+	}
+	return kinds
+}
@@ -0,0 +1,28 @@
+package bazel
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestParseLabelKind(t *testing.T) {
+	output := `Loading: 0 packages loaded
+INFO: Invocation ID: 0b7f3c1e
+cc_binary rule //main:app
+cc_shared_library rule //graphics:renderer_shared
+cc_library rule @abseil//absl/strings:strings
+source file //main:main.cc
+INFO: Elapsed time: 0.120s
+`
+	want := map[string]model.TargetKind{
+		"//main:app":                    model.TargetKindBinary,
+		"//graphics:renderer_shared":    model.TargetKindSharedLibrary,
+		"@abseil//absl/strings:strings": model.TargetKindLibrary,
+		"//main:main.cc":                "source file",
+	}
+	if got := parseLabelKind(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLabelKind() = %v, want %v", got, want)
+	}
+}
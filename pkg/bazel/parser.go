@@ -9,7 +9,9 @@ import (
 )
 
 // Parser handles parsing of Bazel output into the unified graph model
-type Parser struct{}
+type Parser struct {
+	Extensions model.FileExtensions // Classify the files of targets as sources and headers
+}
 
 // NewParser creates a new Bazel parser
 func NewParser() *Parser {
@@ -43,18 +45,18 @@ func (p *Parser) ParseQueryOutput(data []byte) (*model.Graph, error) {
 			Label: rule.Name,
 			Type:  string(kind),
 			Metadata: map[string]interface{}{
-				"package": extractPackage(rule.Name),
-				"kind":    rule.Class,
+				model.MetadataPackage: extractPackage(rule.Name),
+				model.MetadataKind:    rule.Class,
 			},
 		}
 
 		// Parse attributes for metadata
-		sources, headers := extractSources(rule)
+		sources, headers := extractSources(rule, p.Extensions)
 		if len(sources) > 0 {
-			node.Metadata["sources"] = sources
+			node.Metadata[model.MetadataSources] = sources
 		}
 		if len(headers) > 0 {
-			node.Metadata["headers"] = headers
+			node.Metadata[model.MetadataHeaders] = headers
 		}
 
 		graph.AddNode(node)
@@ -109,7 +111,8 @@ func (p *Parser) ParseQueryOutput(data []byte) (*model.Graph, error) {
 func isRelevantKind(kind model.TargetKind) bool {
 	return kind == model.TargetKindBinary ||
 		kind == model.TargetKindSharedLibrary ||
-		kind == model.TargetKindLibrary
+		kind == model.TargetKindLibrary ||
+		kind == model.TargetKindTest
 }
 
 func extractPackage(label string) string {
@@ -120,14 +123,14 @@ func extractPackage(label string) string {
 	return label
 }
 
-func extractSources(rule RuleXML) ([]string, []string) {
+func extractSources(rule RuleXML, ext model.FileExtensions) ([]string, []string) {
 	var sources, headers []string
 	for _, list := range rule.Lists {
 		if list.Name == "srcs" || list.Name == "hdrs" {
 			for _, label := range list.Labels {
-				if strings.HasSuffix(label.Value, ".cc") {
+				if ext.IsSource(label.Value) {
 					sources = append(sources, label.Value)
-				} else if strings.HasSuffix(label.Value, ".h") || strings.HasSuffix(label.Value, ".hpp") {
+				} else if ext.IsHeader(label.Value) {
 					headers = append(headers, label.Value)
 				}
 			}
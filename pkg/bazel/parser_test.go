@@ -1,7 +1,13 @@
 package bazel
 
 import (
+	"encoding/xml"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
 func TestParseXML(t *testing.T) {
@@ -149,3 +155,97 @@ func TestParseQueryOutput_RuleDetails(t *testing.T) {
 		t.Errorf("Expected edge //pkg:lib -> //other:dep, got %s -> %s", edge.Source, edge.Target)
 	}
 }
+
+// TestParseQueryOutputMatchesModule checks that the Graph produced for the sources
+// converts to the same targets and dependencies as the Module built from the same query
+func TestParseQueryOutputMatchesModule(t *testing.T) {
+	xmlOutput := `<?xml version="1.1" encoding="UTF-8" standalone="no"?>
+<query version="2">
+    <rule class="cc_library" name="//util:util">
+        <list name="srcs"><label value="//util:util.cc"/></list>
+    </rule>
+    <rule class="cc_shared_library" name="//plugin:so">
+        <list name="deps"><label value="//util:util"/></list>
+    </rule>
+    <rule class="cc_binary" name="//main:app">
+        <list name="deps"><label value="//util:util"/><label value="//plugin:so"/></list>
+        <list name="data"><label value="//plugin:so"/></list>
+    </rule>
+</query>`
+
+	graph, err := NewParser().ParseQueryOutput([]byte(xmlOutput))
+	if err != nil {
+		t.Fatalf("ParseQueryOutput() unexpected error: %v", err)
+	}
+	fromGraph := model.ModuleFromGraph(graph)
+
+	var result QueryResult
+	xmlStr := strings.Replace(xmlOutput, `<?xml version="1.1"`, `<?xml version="1.0"`, 1)
+	if err := xml.Unmarshal([]byte(xmlStr), &result); err != nil {
+		t.Fatalf("failed to parse XML: %v", err)
+	}
+	targets := make(map[string]*model.Target)
+	for _, rule := range result.Rules {
+		if target := parseTarget(rule, model.FileExtensions{}); target != nil {
+			targets[target.Label] = target
+		}
+	}
+	var deps []string
+	for _, rule := range result.Rules {
+		for _, dep := range parseDependencies(rule, targets) {
+			deps = append(deps, dep.From+" -> "+dep.To+" ("+string(dep.Type)+")")
+		}
+	}
+
+	for label, target := range targets {
+		got := fromGraph.Targets[label]
+		if got == nil || got.Kind != target.Kind || got.Package != target.Package || got.Name != target.Name {
+			t.Errorf("target %s from graph = %+v, want kind %s in %s", label, got, target.Kind, target.Package)
+		}
+	}
+	if len(fromGraph.Targets) != len(targets) {
+		t.Errorf("graph has %d targets, module has %d", len(fromGraph.Targets), len(targets))
+	}
+
+	var graphDeps []string
+	for _, dep := range fromGraph.Dependencies {
+		graphDeps = append(graphDeps, dep.From+" -> "+dep.To+" ("+string(dep.Type)+")")
+	}
+	sort.Strings(deps)
+	sort.Strings(graphDeps)
+	if !reflect.DeepEqual(graphDeps, deps) {
+		t.Errorf("graph dependencies = %v\nmodule dependencies = %v", graphDeps, deps)
+	}
+}
+
+func TestParseQueryOutputObjCSources(t *testing.T) {
+	xmlOutput := `
+		<query version="2">
+			<rule class="cc_library" location="/workspace/BUILD:1:1" name="//ios:view">
+				<list name="srcs">
+					<label value="//ios:view.mm"/>
+					<label value="//ios:view.h"/>
+				</list>
+			</rule>
+		</query>`
+
+	sources := func(ext model.FileExtensions) interface{} {
+		parser := NewParser()
+		parser.Extensions = ext
+		graph, err := parser.ParseQueryOutput([]byte(xmlOutput))
+		if err != nil {
+			t.Fatalf("ParseQueryOutput() unexpected error: %v", err)
+		}
+		return graph.Nodes["//ios:view"].Metadata["sources"]
+	}
+
+	// Objective-C++ sources are ignored by default
+	if got := sources(model.FileExtensions{}); got != nil {
+		t.Errorf("expected no sources with default extensions, got %v", got)
+	}
+
+	objc := model.FileExtensions{Sources: append([]string{".cc"}, model.ObjCSourceExtensions...)}
+	if got, ok := sources(objc).([]string); !ok || !reflect.DeepEqual(got, []string{"//ios:view.mm"}) {
+		t.Errorf("expected //ios:view.mm as source, got %v", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/ritzau/deps-analyzer/pkg/deps"
+	"github.com/ritzau/deps-analyzer/pkg/logging"
 	"github.com/ritzau/deps-analyzer/pkg/model"
 	"github.com/ritzau/deps-analyzer/pkg/symbols"
 )
@@ -20,11 +21,12 @@ type QueryResult struct {
 
 // RuleXML represents a single rule in the XML output
 type RuleXML struct {
-	Class    string      `xml:"class,attr"`
-	Name     string      `xml:"name,attr"`
-	Location string      `xml:"location,attr"`
-	Lists    []ListXML   `xml:"list"`
-	Strings  []StringXML `xml:"string"`
+	Class    string       `xml:"class,attr"`
+	Name     string       `xml:"name,attr"`
+	Location string       `xml:"location,attr"`
+	Lists    []ListXML    `xml:"list"`
+	Strings  []StringXML  `xml:"string"`
+	Booleans []BooleanXML `xml:"boolean"`
 }
 
 // ListXML represents a list attribute in the XML
@@ -44,12 +46,34 @@ type StringXML struct {
 	Value string `xml:"value,attr"`
 }
 
-// QueryWorkspace queries all cc_* targets and their dependencies
-func QueryWorkspace(workspacePath string) (*model.Module, error) {
-	// Query all cc_binary, cc_shared_library, and cc_library targets
-	cmd := exec.Command("bazel", "query",
-		"kind('cc_binary|cc_shared_library|cc_library', //...)",
-		"--output=xml")
+// BooleanXML represents a boolean attribute in the XML
+type BooleanXML struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// ccTargetsQuery selects the targets of the workspace that make up the module
+const ccTargetsQuery = "kind('cc_binary|cc_shared_library|cc_library|cc_test', //...)"
+
+// QueryWorkspace queries all cc_* targets and their dependencies, classifying their files
+// by ext. With a query cache (see SetQueryCacheDir) the result of an earlier run is reused
+// while no BUILD file changed.
+func QueryWorkspace(workspacePath string, ext model.FileExtensions) (*model.Module, error) {
+	return CachedQuery(workspacePath, queryKind("query", ext), func(workspacePath string) (*model.Module, error) {
+		return queryWorkspace(workspacePath, ext)
+	})
+}
+
+// queryKind returns the cache kind of a query, which includes the file extensions, as they
+// decide the sources and headers of the cached targets
+func queryKind(kind string, ext model.FileExtensions) string {
+	return kind + " " + strings.Join(ext.SourceExtensions(), ",") + " " + strings.Join(ext.HeaderExtensions(), ",")
+}
+
+// queryWorkspace runs the bazel query of QueryWorkspace
+func queryWorkspace(workspacePath string, ext model.FileExtensions) (*model.Module, error) {
+	// Query all cc_binary, cc_shared_library, cc_library and cc_test targets
+	cmd := exec.Command("bazel", "query", ccTargetsQuery, "--output=xml")
 	cmd.Dir = workspacePath
 
 	output, err := cmd.CombinedOutput()
@@ -68,6 +92,14 @@ func QueryWorkspace(workspacePath string) (*model.Module, error) {
 		return nil, fmt.Errorf("failed to parse XML: %w", err)
 	}
 
+	return buildModule(workspacePath, result.Rules, ext, func(labels []string) ([]*model.Target, []RuleXML, error) {
+		return queryExternalTargets(workspacePath, labels, ext)
+	}), nil
+}
+
+// buildModule creates the module of the queried rules. queryExternal looks up the external
+// targets the rules depend on.
+func buildModule(workspacePath string, rules []RuleXML, ext model.FileExtensions, queryExternal func(labels []string) ([]*model.Target, []RuleXML, error)) *model.Module {
 	// Build module structure
 	module := &model.Module{
 		Targets:      make(map[string]*model.Target),
@@ -79,7 +111,7 @@ func QueryWorkspace(workspacePath string) (*model.Module, error) {
 	workspaceName, err := GetWorkspaceName(workspacePath)
 	if err != nil {
 		// Log warning but don't fail - use default
-		fmt.Printf("Warning: could not determine workspace name: %v\n", err)
+		logging.Warn("could not determine workspace name", "error", err)
 		workspaceName = filepath.Base(workspacePath)
 	}
 	module.Name = workspaceName
@@ -92,34 +124,42 @@ func QueryWorkspace(workspacePath string) (*model.Module, error) {
 	module.WorkspacePath = absPath
 
 	// First pass: create all targets
-	for _, rule := range result.Rules {
-		target := parseTarget(rule)
+	for _, rule := range rules {
+		target := parseTarget(rule, ext)
 		if target != nil {
 			module.Targets[target.Label] = target
 		}
 	}
 
 	// Collect all external dependencies referenced by workspace targets
-	externalDeps := collectExternalDependencies(result.Rules)
+	externalDeps := collectExternalDependencies(rules)
 
 	// Query external dependencies and add them to the module
 	var externalRules []RuleXML
 	if len(externalDeps) > 0 {
-		externalTargets, rules, err := queryExternalTargets(workspacePath, externalDeps)
+		externalTargets, externalResult, err := queryExternal(externalDeps)
 		if err != nil {
 			// Log warning but don't fail - external deps are optional
-			fmt.Printf("Warning: failed to query external dependencies: %v\n", err)
+			logging.Warn("failed to query external dependencies", "error", err)
 		} else {
 			// Add external targets to module
 			for _, target := range externalTargets {
 				module.Targets[target.Label] = target
 			}
-			externalRules = rules
+			externalRules = externalResult
+		}
+
+		// External dependencies that could not be queried (or are not C++ rules) are still
+		// targets of the module, so their dependencies are not dangling
+		for _, label := range externalDeps {
+			if _, ok := module.Targets[label]; !ok {
+				module.Targets[label] = newExternalTarget(label)
+			}
 		}
 	}
 
 	// Second pass: create typed dependencies from workspace targets
-	for _, rule := range result.Rules {
+	for _, rule := range rules {
 		deps := parseDependencies(rule, module.Targets)
 		module.Dependencies = append(module.Dependencies, deps...)
 	}
@@ -130,7 +170,11 @@ func QueryWorkspace(workspacePath string) (*model.Module, error) {
 		module.Dependencies = append(module.Dependencies, deps...)
 	}
 
-	return module, nil
+	// Diamonds whose paths are compiled with conflicting defines
+	module.Issues = append(module.Issues, module.FindDefineSkew()...)
+
+	module.UpdateProvenance()
+	return module
 }
 
 // collectExternalDependencies extracts all external dependency labels from rules
@@ -142,8 +186,7 @@ func collectExternalDependencies(rules []RuleXML) []string {
 			// Check deps, dynamic_deps, and data lists
 			if list.Name == "deps" || list.Name == "dynamic_deps" || list.Name == "data" {
 				for _, label := range list.Labels {
-					// External dependencies start with @
-					if strings.HasPrefix(label.Value, "@") {
+					if model.IsExternalLabel(label.Value) {
 						// Skip bazel_tools and other system repos
 						if !strings.HasPrefix(label.Value, "@bazel_tools//") &&
 							!strings.HasPrefix(label.Value, "@local_config_") &&
@@ -166,7 +209,7 @@ func collectExternalDependencies(rules []RuleXML) []string {
 
 // queryExternalTargets queries Bazel for details about external targets
 // Returns targets, rules, and error
-func queryExternalTargets(workspacePath string, externalLabels []string) ([]*model.Target, []RuleXML, error) {
+func queryExternalTargets(workspacePath string, externalLabels []string, ext model.FileExtensions) ([]*model.Target, []RuleXML, error) {
 	if len(externalLabels) == 0 {
 		return nil, nil, nil
 	}
@@ -194,7 +237,7 @@ func queryExternalTargets(workspacePath string, externalLabels []string) ([]*mod
 	// Parse targets
 	targets := make([]*model.Target, 0, len(result.Rules))
 	for _, rule := range result.Rules {
-		target := parseTarget(rule)
+		target := parseTarget(rule, ext)
 		if target != nil {
 			targets = append(targets, target)
 		}
@@ -204,10 +247,10 @@ func queryExternalTargets(workspacePath string, externalLabels []string) ([]*mod
 }
 
 // parseTarget converts RuleXML to Target
-func parseTarget(rule RuleXML) *model.Target {
-	// Only process cc_binary, cc_shared_library, cc_library
+func parseTarget(rule RuleXML, ext model.FileExtensions) *model.Target {
+	// Only process cc_binary, cc_shared_library, cc_library, cc_test
 	kind := model.TargetKind(rule.Class)
-	if kind != model.TargetKindBinary && kind != model.TargetKindSharedLibrary && kind != model.TargetKindLibrary {
+	if !isRelevantKind(kind) {
 		return nil
 	}
 
@@ -225,11 +268,25 @@ func parseTarget(rule RuleXML) *model.Target {
 		Kind:    kind,
 		Package: packagePath,
 		Name:    targetName,
+		// cc_binary links its deps statically by default, cc_library builds a shared library by
+		// default and cc_test links against the shared libraries of its deps
+		Linkstatic: kind == model.TargetKindBinary,
+	}
+
+	// Extract linking attributes (present when set or when default values are included in the output)
+	for _, b := range rule.Booleans {
+		switch b.Name {
+		case "alwayslink":
+			target.Alwayslink = b.Value == "true"
+		case "linkstatic":
+			target.Linkstatic = b.Value == "true"
+		}
 	}
 
 	// Skip file parsing for external targets (labels starting with @)
 	// External packages don't have file-level dependency information
-	isExternalTarget := strings.HasPrefix(label, "@")
+	isExternalTarget := model.IsExternalLabel(label)
+	target.External = isExternalTarget
 
 	// Extract attributes from lists
 	for _, list := range rule.Lists {
@@ -237,9 +294,9 @@ func parseTarget(rule RuleXML) *model.Target {
 		case "srcs":
 			if !isExternalTarget {
 				for _, label := range list.Labels {
-					if strings.HasSuffix(label.Value, ".cc") {
+					if ext.IsSource(label.Value) {
 						target.Sources = append(target.Sources, label.Value)
-					} else if strings.HasSuffix(label.Value, ".h") || strings.HasSuffix(label.Value, ".hpp") {
+					} else if ext.IsHeader(label.Value) {
 						target.Headers = append(target.Headers, label.Value)
 					}
 				}
@@ -247,7 +304,7 @@ func parseTarget(rule RuleXML) *model.Target {
 		case "hdrs":
 			if !isExternalTarget {
 				for _, label := range list.Labels {
-					if strings.HasSuffix(label.Value, ".h") || strings.HasSuffix(label.Value, ".hpp") {
+					if ext.IsHeader(label.Value) {
 						target.Headers = append(target.Headers, label.Value)
 					}
 				}
@@ -256,6 +313,26 @@ func parseTarget(rule RuleXML) *model.Target {
 			for _, str := range list.Strings {
 				target.Linkopts = append(target.Linkopts, str.Value)
 			}
+		case "includes":
+			for _, str := range list.Strings {
+				target.Includes = append(target.Includes, str.Value)
+			}
+		case "copts":
+			for _, str := range list.Strings {
+				target.Copts = append(target.Copts, str.Value)
+			}
+		case "defines":
+			for _, str := range list.Strings {
+				target.Defines = append(target.Defines, str.Value)
+			}
+		case "local_defines":
+			for _, str := range list.Strings {
+				target.LocalDefines = append(target.LocalDefines, str.Value)
+			}
+		case "tags":
+			for _, str := range list.Strings {
+				target.Tags = append(target.Tags, str.Value)
+			}
 		case "visibility":
 			for _, label := range list.Labels {
 				target.Visibility = append(target.Visibility, label.Value)
@@ -263,9 +340,26 @@ func parseTarget(rule RuleXML) *model.Target {
 		}
 	}
 
+	// External targets have no files recorded, so they can't be told apart
+	target.HeaderOnly = kind == model.TargetKindLibrary && !isExternalTarget &&
+		len(target.Sources) == 0 && len(target.Headers) > 0
+
 	return target
 }
 
+// newExternalTarget returns the placeholder target of an external dependency whose rule is
+// unknown
+func newExternalTarget(label string) *model.Target {
+	pkg, name, _ := strings.Cut(label, ":")
+	return &model.Target{
+		Label:    label,
+		Kind:     model.TargetKindExternal,
+		Package:  pkg,
+		Name:     name,
+		External: true,
+	}
+}
+
 // parseDependencies creates typed dependency edges for a target
 func parseDependencies(rule RuleXML, targets map[string]*model.Target) []model.Dependency {
 	fromLabel := rule.Name
@@ -322,22 +416,37 @@ func determineDependencyType(depLabel string, targets map[string]*model.Target)
 		return model.DependencyStatic
 	case model.TargetKindSharedLibrary:
 		return model.DependencyDynamic
-	case model.TargetKindBinary:
-		// Depending on a binary is unusual, treat as data
+	case model.TargetKindBinary, model.TargetKindTest:
+		// Depending on a binary or test is unusual, treat as data
 		return model.DependencyData
+	case model.TargetKindExternal:
+		// The rule is unknown, but the deps of a C++ rule can only be linked as libraries,
+		// which are linked statically unless they are cc_shared_library targets
+		return model.DependencyStatic
 	default:
 		return model.DependencyStatic
 	}
 }
 
-// AddCompileDependencies adds compile-time dependencies from .d files to the module
-func AddCompileDependencies(module *model.Module, workspacePath string) error {
+// AddCompileDependencies adds compile-time dependencies from the .d files in the workspace's
+// bazel-out to the module
+func AddCompileDependencies(module *model.Module, workspacePath string, ext model.FileExtensions) error {
 	// Parse all .d files
-	fileDeps, err := deps.ParseAllDFiles(workspacePath)
+	fileDeps, err := deps.ParseAllDFiles(workspacePath, "", ext)
 	if err != nil {
 		return fmt.Errorf("parsing .d files: %w", err)
 	}
 
+	// Map include-relative header paths to their workspace paths
+	ResolveIncludePaths(module, fileDeps)
+
+	AddFileCompileDependencies(module, fileDeps)
+	return nil
+}
+
+// AddFileCompileDependencies adds the compile-time dependencies between targets of parsed
+// .d files to the module. Header paths should have been resolved by ResolveIncludePaths.
+func AddFileCompileDependencies(module *model.Module, fileDeps []*deps.FileDependency) {
 	// Build a map from file paths to targets
 	fileToTarget := make(map[string]*model.Target)
 	for _, target := range module.Targets {
@@ -395,7 +504,60 @@ func AddCompileDependencies(module *model.Module, workspacePath string) error {
 		}
 	}
 
-	return nil
+	module.UpdateProvenance()
+}
+
+// ResolveIncludePaths rewrites header paths in fileDeps that are relative to an include
+// directory (e.g., "strings.h" found via -Iutil) to their canonical workspace path
+// (e.g., "util/strings.h"), using the include directories visible to the target owning
+// each source file: its own includes/copts plus the includes of its transitive deps
+func ResolveIncludePaths(module *model.Module, fileDeps []*deps.FileDependency) {
+	knownFiles := make(map[string]bool)
+	includeDirs := make(map[string][]string) // source file -> include dirs visible to it
+
+	for _, target := range module.Targets {
+		dirs := collectIncludeDirs(module, target)
+		for _, src := range target.Sources {
+			filePath := NormalizeSourcePath(src)
+			knownFiles[filePath] = true
+			if len(dirs) > 0 {
+				includeDirs[filePath] = dirs
+			}
+		}
+		for _, hdr := range target.Headers {
+			knownFiles[NormalizeSourcePath(hdr)] = true
+		}
+	}
+
+	deps.ResolveHeaderPaths(fileDeps, includeDirs, knownFiles)
+}
+
+// collectIncludeDirs returns the include directories used when compiling a target
+func collectIncludeDirs(module *model.Module, target *model.Target) []string {
+	dirs := target.IncludeDirs()
+
+	// The includes attribute propagates through linked dependencies
+	visited := map[string]bool{target.Label: true}
+	queue := []string{target.Label}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dep := range module.Dependencies {
+			if dep.From != current || visited[dep.To] {
+				continue
+			}
+			if dep.Type != model.DependencyStatic && dep.Type != model.DependencyDynamic {
+				continue
+			}
+			visited[dep.To] = true
+			queue = append(queue, dep.To)
+			if depTarget := module.Targets[dep.To]; depTarget != nil {
+				dirs = append(dirs, depTarget.ExportedIncludeDirs()...)
+			}
+		}
+	}
+
+	return dirs
 }
 
 // NormalizeSourcePath converts a Bazel label source path to a workspace-relative path
@@ -445,6 +607,12 @@ func findTargetForFile(filePath string, fileToTarget map[string]*model.Target) *
 // AddSymbolDependencies adds symbol-level dependencies from nm analysis to the module
 // It also detects and reports issues like duplicate symbols (both static and dynamic linkage)
 func AddSymbolDependencies(module *model.Module, workspacePath string) error {
+	return AddSymbolDependenciesInScope(module, workspacePath, nil)
+}
+
+// AddSymbolDependenciesInScope is like AddSymbolDependencies but only analyzes the object
+// files of the targets in scope (all targets if scope is nil)
+func AddSymbolDependenciesInScope(module *model.Module, workspacePath string, scope symbols.Scope) error {
 	// Build file-to-target and target-to-kind maps
 	fileToTarget := make(map[string]string)
 	targetToKind := make(map[string]string)
@@ -460,14 +628,25 @@ func AddSymbolDependencies(module *model.Module, workspacePath string) error {
 	}
 
 	// Run symbol analysis
-	symbolDeps, err := symbols.BuildSymbolGraph(workspacePath, fileToTarget, targetToKind)
+	symbolDeps, _, err := symbols.BuildSymbolGraphInScope(workspacePath, fileToTarget, targetToKind, scope, 0)
 	if err != nil {
 		return fmt.Errorf("building symbol graph: %w", err)
 	}
 
+	AddSymbolDependencyEdges(module, symbolDeps)
+	return nil
+}
+
+// AddSymbolDependencyEdges adds the target-level symbol dependencies of file-level ones, e.g.
+// those of a symbols.SymbolGraph kept between analyses, to the module and reports targets
+// linked both statically and dynamically as duplicate linkage issues
+func AddSymbolDependencyEdges(module *model.Module, symbolDeps []symbols.SymbolDependency) {
 	// Track dependencies by source->target pair to detect conflicts
 	depPairs := make(map[string][]model.DependencyType) // "from->to" -> list of types
 
+	// Collect the symbols crossing each target boundary
+	symbolsByEdge := make(map[model.InternalEdge][]string)
+
 	// Add symbol dependencies to module
 	for _, symDep := range symbolDeps {
 		if symDep.SourceTarget == "" || symDep.TargetTarget == "" {
@@ -500,7 +679,11 @@ func AddSymbolDependencies(module *model.Module, workspacePath string) error {
 		// Track this dependency type for conflict detection
 		key := symDep.SourceTarget + " -> " + symDep.TargetTarget
 		depPairs[key] = append(depPairs[key], model.DependencySymbol)
+
+		edge := model.InternalEdge{FromTarget: symDep.SourceTarget, ToTarget: symDep.TargetTarget}
+		symbolsByEdge[edge] = append(symbolsByEdge[edge], symDep.Symbol)
 	}
+	module.SymbolCrossings = model.NewSymbolCrossings(symbolsByEdge)
 
 	// Detect conflicts: Check if any dependency pair has both static/symbol and dynamic types
 	for _, dep := range module.Dependencies {
@@ -543,28 +726,36 @@ func AddSymbolDependencies(module *model.Module, workspacePath string) error {
 					typeList = append(typeList, "dynamic")
 				}
 
-				module.Issues = append(module.Issues, model.DependencyIssue{
+				issue := model.DependencyIssue{
 					From:     parts[0],
 					To:       parts[1],
-					Issue:    "duplicate_linkage",
+					Issue:    model.IssueDuplicateLinkage,
 					Types:    typeList,
 					Severity: "warning",
 					Description: fmt.Sprintf("Target %s has both static and dynamic linkage to %s. "+
 						"This can cause duplicate symbols and runtime issues. "+
 						"Symbols may be included both statically (via deps) and dynamically (via dynamic_deps/shared library).",
 						parts[0], parts[1]),
-				})
+				}
+
+				// An alwayslink library is linked in full, so its symbols are certainly duplicated
+				if toTarget := module.Targets[parts[1]]; toTarget != nil && toTarget.Alwayslink {
+					issue.Severity = "error"
+					issue.Description += " " + parts[1] + " is alwayslink, so all of its symbols are duplicated."
+				}
+
+				module.Issues = append(module.Issues, issue)
 			}
 		}
 	}
 
-	return nil
+	module.UpdateProvenance()
 }
 
 // QueryAllSourceFiles returns all source files covered by Bazel targets
 // This is a compatibility function for the old code
-func QueryAllSourceFiles(workspacePath string) ([]string, error) {
-	module, err := QueryWorkspace(workspacePath)
+func QueryAllSourceFiles(workspacePath string, ext model.FileExtensions) ([]string, error) {
+	module, err := QueryWorkspace(workspacePath, ext)
 	if err != nil {
 		return nil, err
 	}
@@ -588,8 +779,8 @@ func QueryAllSourceFiles(workspacePath string) ([]string, error) {
 
 // BuildFileToTargetMap creates a mapping from file paths to target labels
 // This is a compatibility function for the old code
-func BuildFileToTargetMap(workspacePath string) (map[string]string, error) {
-	module, err := QueryWorkspace(workspacePath)
+func BuildFileToTargetMap(workspacePath string, ext model.FileExtensions) (map[string]string, error) {
+	module, err := QueryWorkspace(workspacePath, ext)
 	if err != nil {
 		return nil, err
 	}
@@ -1,10 +1,14 @@
 package bazel
 
 import (
+	"encoding/xml"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/ritzau/deps-analyzer/pkg/deps"
 	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
@@ -16,7 +20,7 @@ func TestQueryWorkspace(t *testing.T) {
 	workspacePath := findExampleWorkspace(t)
 
 	// Query the module
-	module, err := QueryWorkspace(workspacePath)
+	module, err := QueryWorkspace(workspacePath, model.FileExtensions{})
 	if err != nil {
 		t.Fatalf("QueryWorkspace failed: %v", err)
 	}
@@ -239,3 +243,337 @@ func contains(slice []string, item string) bool {
 	}
 	return false
 }
+
+func TestResolveIncludePaths(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//app:app": {
+				Label: "//app:app", Package: "//app", Name: "app", Kind: model.TargetKindBinary,
+				Sources: []string{"//app:main.cc"},
+				Copts:   []string{"-I", "third_party/include"},
+			},
+			"//util:util": {
+				Label: "//util:util", Package: "//util", Name: "util", Kind: model.TargetKindLibrary,
+				Headers:  []string{"//util:strings.h"},
+				Includes: []string{"."},
+			},
+			"//third_party:vector": {
+				Label: "//third_party:vector", Package: "//third_party", Name: "vector", Kind: model.TargetKindLibrary,
+				Headers: []string{"//third_party:include/math/vector.h"},
+			},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//app:app", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//app:app", To: "//third_party:vector", Type: model.DependencyStatic},
+		},
+	}
+
+	fileDeps := []*deps.FileDependency{
+		{SourceFile: "app/main.cc", Dependencies: []string{"strings.h", "math/vector.h"}},
+	}
+
+	ResolveIncludePaths(module, fileDeps)
+
+	want := []string{"util/strings.h", "third_party/include/math/vector.h"}
+	for i, got := range fileDeps[0].Dependencies {
+		if got != want[i] {
+			t.Errorf("Dependencies[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestParseTargetIncludes(t *testing.T) {
+	rule := RuleXML{
+		Class: "cc_library",
+		Name:  "//util:util",
+		Lists: []ListXML{
+			{Name: "includes", Strings: []StringXML{{Value: "include"}}},
+			{Name: "copts", Strings: []StringXML{{Value: "-Iutil/internal"}, {Value: "-Wall"}}},
+		},
+	}
+
+	target := parseTarget(rule, model.FileExtensions{})
+	if target == nil {
+		t.Fatal("parseTarget returned nil")
+	}
+
+	want := []string{"util/include", "util/internal"}
+	got := target.IncludeDirs()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("IncludeDirs() = %v, want %v", got, want)
+	}
+}
+
+func TestParseTargetHeaderOnly(t *testing.T) {
+	library := func(name string, lists ...ListXML) RuleXML {
+		return RuleXML{Class: "cc_library", Name: name, Lists: lists}
+	}
+	hdrs := ListXML{Name: "hdrs", Labels: []LabelXML{{Value: "//util:strings.h"}}}
+
+	tests := []struct {
+		rule RuleXML
+		want bool
+	}{
+		{library("//util:strings", hdrs), true},
+		{library("//util:inline", ListXML{Name: "srcs", Labels: []LabelXML{{Value: "//util:inline.h"}}}), true},
+		{library("//util:util", hdrs, ListXML{Name: "srcs", Labels: []LabelXML{{Value: "//util:util.cc"}}}), false},
+		{library("//util:empty"), false},
+		{library("@abseil//absl/strings:strings", hdrs), false},
+		{RuleXML{Class: "cc_binary", Name: "//main:app", Lists: []ListXML{hdrs}}, false},
+	}
+
+	for _, tt := range tests {
+		target := parseTarget(tt.rule, model.FileExtensions{})
+		if target == nil {
+			t.Fatalf("%s: parseTarget returned nil", tt.rule.Name)
+		}
+		if target.HeaderOnly != tt.want {
+			t.Errorf("%s: HeaderOnly = %v, want %v", tt.rule.Name, target.HeaderOnly, tt.want)
+		}
+	}
+}
+
+func TestParseTargetLinkingAttributes(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "linking.xml"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	xmlStr := strings.Replace(string(data), `<?xml version="1.1"`, `<?xml version="1.0"`, 1)
+	var result QueryResult
+	if err := xml.Unmarshal([]byte(xmlStr), &result); err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+
+	tests := []struct {
+		label      string
+		alwayslink bool
+		linkstatic bool
+	}{
+		{"//plugins:registry", true, false},
+		{"//util:util", false, true},
+		{"//core:core", false, false}, // cc_library default
+		{"//main:app", false, true},   // cc_binary default
+		{"//main:dynamic_app", false, false},
+	}
+
+	targets := make(map[string]*model.Target)
+	for _, rule := range result.Rules {
+		if target := parseTarget(rule, model.FileExtensions{}); target != nil {
+			targets[target.Label] = target
+		}
+	}
+
+	for _, tt := range tests {
+		target := targets[tt.label]
+		if target == nil {
+			t.Fatalf("target %s not parsed", tt.label)
+		}
+		if target.Alwayslink != tt.alwayslink || target.Linkstatic != tt.linkstatic {
+			t.Errorf("%s: alwayslink=%v linkstatic=%v, want alwayslink=%v linkstatic=%v",
+				tt.label, target.Alwayslink, target.Linkstatic, tt.alwayslink, tt.linkstatic)
+		}
+	}
+}
+
+func TestAddSymbolDependencyEdgesDuplicateLinkage(t *testing.T) {
+	tests := []struct {
+		name       string
+		alwayslink bool
+		severity   string
+	}{
+		{"alwayslink library", true, model.SeverityError},
+		{"plain library", false, model.SeverityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// //main:app links //plugins:registry statically and through //plugins:shared
+			module := &model.Module{
+				Targets: map[string]*model.Target{
+					"//main:app":         {Label: "//main:app", Kind: model.TargetKindBinary},
+					"//plugins:shared":   {Label: "//plugins:shared", Kind: model.TargetKindSharedLibrary},
+					"//plugins:registry": {Label: "//plugins:registry", Kind: model.TargetKindLibrary, Alwayslink: tt.alwayslink},
+				},
+				Dependencies: []model.Dependency{
+					{From: "//main:app", To: "//plugins:registry", Type: model.DependencyStatic},
+					{From: "//main:app", To: "//plugins:shared", Type: model.DependencyDynamic},
+					{From: "//main:app", To: "//plugins:registry", Type: model.DependencyDynamic},
+				},
+			}
+
+			AddSymbolDependencyEdges(module, nil)
+
+			var issues []model.DependencyIssue
+			for _, issue := range module.Issues {
+				if issue.Issue == model.IssueDuplicateLinkage {
+					issues = append(issues, issue)
+				}
+			}
+			if len(issues) != 1 {
+				t.Fatalf("got %d duplicate_linkage issues, want 1: %+v", len(issues), issues)
+			}
+			if issues[0].From != "//main:app" || issues[0].To != "//plugins:registry" {
+				t.Errorf("issue is %s -> %s, want //main:app -> //plugins:registry", issues[0].From, issues[0].To)
+			}
+			if issues[0].Severity != tt.severity {
+				t.Errorf("severity = %q, want %q", issues[0].Severity, tt.severity)
+			}
+		})
+	}
+}
+
+func TestParseTargetTags(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "tags.xml"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	xmlStr := strings.Replace(string(data), `<?xml version="1.1"`, `<?xml version="1.0"`, 1)
+	var result QueryResult
+	if err := xml.Unmarshal([]byte(xmlStr), &result); err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+
+	targets := make(map[string]*model.Target)
+	for _, rule := range result.Rules {
+		if target := parseTarget(rule, model.FileExtensions{}); target != nil {
+			targets[target.Label] = target
+		}
+	}
+
+	tests := []struct {
+		label string
+		tags  []string
+	}{
+		{"//graphics:renderer", []string{"team:graphics", "no-ide"}},
+		{"//tools:codegen", []string{"manual"}},
+		{"//main:app", nil},
+	}
+	for _, tt := range tests {
+		target := targets[tt.label]
+		if target == nil {
+			t.Fatalf("target %s not parsed", tt.label)
+		}
+		if !reflect.DeepEqual(target.Tags, tt.tags) {
+			t.Errorf("%s: Tags = %v, want %v", tt.label, target.Tags, tt.tags)
+		}
+	}
+}
+
+func TestParseCQueryOutput(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "cquery.json"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	rules, err := parseCQueryOutput(data)
+	if err != nil {
+		t.Fatalf("parseCQueryOutput() error = %v", err)
+	}
+	// The second configuration of //platform:sys and the source file are dropped
+	if len(rules) != 2 {
+		t.Fatalf("parseCQueryOutput() returned %d rules, want 2", len(rules))
+	}
+
+	targets := make(map[string]*model.Target)
+	for _, rule := range rules {
+		if target := parseTarget(rule, model.FileExtensions{}); target != nil {
+			targets[target.Label] = target
+		}
+	}
+
+	sys := targets["//platform:sys"]
+	if sys == nil {
+		t.Fatal("//platform:sys not parsed")
+	}
+	if !reflect.DeepEqual(sys.Sources, []string{"//platform:sys_linux.cc"}) {
+		t.Errorf("Sources = %v, want only the resolved branch", sys.Sources)
+	}
+	if !reflect.DeepEqual(sys.Linkopts, []string{"-ldl"}) {
+		t.Errorf("Linkopts = %v, want [-ldl]", sys.Linkopts)
+	}
+	if !sys.Alwayslink {
+		t.Error("Alwayslink = false, want true")
+	}
+	if !sys.IsPublic() {
+		t.Errorf("Visibility = %v, want public", sys.Visibility)
+	}
+
+	deps := parseDependencies(rules[1], targets)
+	want := []model.Dependency{{From: "//main:app", To: "//platform:sys", Type: model.DependencyStatic}}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("parseDependencies() = %v, want %v", deps, want)
+	}
+}
+
+func TestParseCQueryOutputInvalid(t *testing.T) {
+	if _, err := parseCQueryOutput([]byte("INFO: Analyzed 3 targets")); err == nil {
+		t.Error("parseCQueryOutput() of non-JSON output: expected an error")
+	}
+}
+
+func TestParseTargetTest(t *testing.T) {
+	rules := []RuleXML{
+		{Class: "cc_library", Name: "//core:core"},
+		{Class: "cc_test", Name: "//core:core_test", Lists: []ListXML{{Name: "deps", Labels: []LabelXML{{Value: "//core:core"}}}}},
+		{Class: "cc_binary", Name: "//main:app", Lists: []ListXML{{Name: "data", Labels: []LabelXML{{Value: "//core:core_test"}}}}},
+	}
+
+	module := buildModule(t.TempDir(), rules, model.FileExtensions{}, nil)
+
+	test := module.Targets["//core:core_test"]
+	if test == nil || test.Kind != model.TargetKindTest || test.Package != "//core" || test.Linkstatic {
+		t.Fatalf("test target = %+v, want a dynamically linked cc_test in //core", test)
+	}
+	if got := determineDependencyType("//core:core_test", module.Targets); got != model.DependencyData {
+		t.Errorf("dependency type on a cc_test = %s, want data", got)
+	}
+
+	found := false
+	for _, dep := range module.Dependencies {
+		if dep.From == "//core:core_test" && dep.To == "//core:core" && dep.Type == model.DependencyStatic {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("dependencies = %+v, want //core:core_test -> //core:core (static)", module.Dependencies)
+	}
+}
+
+func TestBuildModuleExternalTargets(t *testing.T) {
+	rules := []RuleXML{{Class: "cc_binary", Name: "//main:app", Lists: []ListXML{{Name: "deps", Labels: []LabelXML{
+		{Value: "@abseil//absl/strings:strings"},
+		{Value: "@zlib//:zlib"},
+		{Value: "@bazel_tools//tools/cpp:malloc"},
+	}}}}}
+	queryExternal := func(labels []string) ([]*model.Target, []RuleXML, error) {
+		// Only zlib is known, as a shared library
+		rule := RuleXML{Class: "cc_shared_library", Name: "@zlib//:zlib"}
+		return []*model.Target{parseTarget(rule, model.FileExtensions{})}, []RuleXML{rule}, nil
+	}
+
+	module := buildModule(t.TempDir(), rules, model.FileExtensions{}, queryExternal)
+
+	abseil := module.Targets["@abseil//absl/strings:strings"]
+	if abseil == nil || abseil.Kind != model.TargetKindExternal || !abseil.External || abseil.Package != "@abseil//absl/strings" {
+		t.Errorf("abseil target = %+v, want an external placeholder", abseil)
+	}
+	if zlib := module.Targets["@zlib//:zlib"]; zlib == nil || zlib.Kind != model.TargetKindSharedLibrary || !zlib.External {
+		t.Errorf("zlib target = %+v, want an external cc_shared_library", zlib)
+	}
+	if module.Targets["@bazel_tools//tools/cpp:malloc"] != nil {
+		t.Error("toolchain repositories should not become targets")
+	}
+	if module.Targets["//main:app"].External {
+		t.Error("workspace target marked external")
+	}
+
+	types := make(map[string]model.DependencyType)
+	for _, dep := range module.Dependencies {
+		types[dep.To] = dep.Type
+	}
+	if types["@abseil//absl/strings:strings"] != model.DependencyStatic || types["@zlib//:zlib"] != model.DependencyDynamic {
+		t.Errorf("dependency types = %v, want abseil static and zlib dynamic", types)
+	}
+}
@@ -31,11 +31,8 @@ func (s *TargetSource) Run(ctx context.Context, cfg *config.Config) (*model.Grap
 	logger := logging.New("source.bazel")
 	logger.Info("Starting Bazel query analysis", "workspace", cfg.Workspace)
 
-	// Define the query
-	query := "kind('cc_binary|cc_shared_library|cc_library', //...)"
-
 	// Execute query
-	output, err := s.executor.RunQuery(ctx, cfg.Workspace, query)
+	output, err := s.executor.RunQuery(ctx, cfg.Workspace, ccTargetsQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -1,16 +1,27 @@
 package bazel
 
 import (
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
 )
 
+// moduleNameRegex matches the name argument of module() in MODULE.bazel
+// and of workspace() in WORKSPACE files
+var moduleNameRegex = regexp.MustCompile(`(?s)\b(?:module|workspace)\s*\([^)]*?\bname\s*=\s*["']([^"']+)["']`)
+
 // GetWorkspaceName attempts to determine the workspace/module name from:
-// 1. `bazel mod graph` command (if using Bazel modules/bzlmod)
-// 2. Directory name as fallback
+// 1. module(name = ...) in MODULE.bazel, or workspace(name = ...) in WORKSPACE
+// 2. `bazel mod graph` command (if using Bazel modules/bzlmod)
+// 3. Directory name as fallback
 func GetWorkspaceName(workspacePath string) (string, error) {
+	// Read the name directly from the workspace files, which needs no Bazel server
+	if name := readWorkspaceFileName(workspacePath); name != "" {
+		return name, nil
+	}
+
 	// Try to get module name from `bazel mod graph`
 	moduleName, err := extractModuleNameFromBazel(workspacePath)
 	if err == nil && moduleName != "" {
@@ -35,6 +46,37 @@ func GetWorkspaceName(workspacePath string) (string, error) {
 	return dirName, nil
 }
 
+// readWorkspaceFileName returns the name declared in MODULE.bazel, WORKSPACE.bazel or
+// WORKSPACE, or "" if none of them declares one
+func readWorkspaceFileName(workspacePath string) string {
+	for _, file := range []string{"MODULE.bazel", "WORKSPACE.bazel", "WORKSPACE"} {
+		content, err := os.ReadFile(filepath.Join(workspacePath, file))
+		if err != nil {
+			continue
+		}
+		if name := parseDeclaredName(string(content)); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseDeclaredName extracts the name argument of module() or workspace(), ignoring comments
+func parseDeclaredName(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		lines = append(lines, line)
+	}
+
+	if matches := moduleNameRegex.FindStringSubmatch(strings.Join(lines, "\n")); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
 // extractModuleNameFromBazel runs `bazel mod graph` and extracts the root module name
 // Output format: <root> (module_name@version)
 func extractModuleNameFromBazel(workspacePath string) (string, error) {
@@ -0,0 +1,47 @@
+package bazel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetWorkspaceNameFromModuleFile(t *testing.T) {
+	name, err := GetWorkspaceName("testdata/module")
+	if err != nil {
+		t.Fatalf("GetWorkspaceName() unexpected error: %v", err)
+	}
+	if name != "sample_workspace" {
+		t.Errorf("GetWorkspaceName() = %q, want %q", name, "sample_workspace")
+	}
+}
+
+func TestParseDeclaredName(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`module(name = "foo", version = "1.0")`, "foo"},
+		{"module(\n    version = \"1.0\",\n    name = 'bar',\n)", "bar"},
+		{`workspace(name = "legacy")`, "legacy"},
+		{"# module(name = \"hidden\")\nbazel_dep(name = \"rules_cc\")", ""},
+		{`bazel_dep(name = "rules_cc", version = "0.1.1")`, ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseDeclaredName(tt.content); got != tt.want {
+			t.Errorf("parseDeclaredName(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestGetWorkspaceNameFallsBackToWorkspaceFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "WORKSPACE"), []byte(`workspace(name = "old_style")`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readWorkspaceFileName(dir); got != "old_style" {
+		t.Errorf("readWorkspaceFileName() = %q, want %q", got, "old_style")
+	}
+}
@@ -2,10 +2,15 @@ package binaries
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
+	"path"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/ritzau/deps-analyzer/pkg/bazel"
+	"github.com/ritzau/deps-analyzer/pkg/logging"
 	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
@@ -19,75 +24,122 @@ type BinaryInfo struct {
 	RegularDeps     []string            `json:"regularDeps"`     // Direct cc_library dependencies
 	InternalTargets []string            `json:"internalTargets"` // All cc_library targets this binary depends on
 	OverlappingDeps map[string][]string `json:"overlappingDeps"` // Map of binary -> overlapping cc_library targets (potential duplicate symbols)
-	LddDependencies []string            `json:"lddDependencies"` // Shared libraries found via ldd/otool
-	OutputFile      string              `json:"outputFile"`      // The actual build output file (absolute or relative to execroot)
+	// Subset of OverlappingDeps whose symbols are always present in both (alwayslink or linked as a whole shared library)
+	CertainOverlaps map[string][]string `json:"certainOverlaps,omitempty"`
+	// Map of dynamic dep -> libraries the shared library is built from that the binary also links statically
+	RedundantDynamicDeps map[string][]string `json:"redundantDynamicDeps,omitempty"`
+	LddDependencies      []string            `json:"lddDependencies"` // Shared libraries found via ldd/otool
+	// Workspace shared libraries loaded at run time but not declared (see FindUndeclaredLibraries)
+	UndeclaredLibraries []string `json:"undeclaredLibraries,omitempty"`
+	OutputFile          string   `json:"outputFile"` // The actual build output file (absolute or relative to execroot)
 }
 
-// QueryAllBinaries finds all cc_binary and cc_shared_library targets
-func QueryAllBinaries(workspace string) ([]string, error) {
-	cmd := exec.Command("bazel", "query", "--output=label",
-		"kind('cc_binary|cc_shared_library', //...)")
-	cmd.Dir = workspace
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("bazel query failed: %w\nOutput: %s", err, string(output))
+// FindUndeclaredLibraries sets the UndeclaredLibraries of each binary: the workspace
+// cc_shared_library targets that ldd/otool found loaded at run time (LddDependencies) but
+// that neither its dynamic_deps nor, transitively, those of the shared libraries it declares
+// account for. Such a library is pulled in by accident, e.g. through linkopts or a prebuilt
+// library's own dependencies. Shared libraries are recognized by their output file name:
+// lib<name>.so or lib<name>.dylib, or the name of their OutputFile.
+func FindUndeclaredLibraries(bins []*BinaryInfo) {
+	byLabel := make(map[string]*BinaryInfo)
+	byFile := make(map[string]string) // Output file name -> cc_shared_library label
+	for _, bin := range bins {
+		byLabel[bin.Label] = bin
+		if bin.Kind != string(model.TargetKindSharedLibrary) {
+			continue
+		}
+		_, name, _ := strings.Cut(bin.Label, ":")
+		byFile["lib"+name+".so"] = bin.Label
+		byFile["lib"+name+".dylib"] = bin.Label
+		if bin.OutputFile != "" {
+			byFile[path.Base(bin.OutputFile)] = bin.Label
+		}
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var binaries []string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		// Skip empty lines and Bazel status messages
-		if line != "" && strings.HasPrefix(line, "//") {
-			binaries = append(binaries, line)
+	for _, bin := range bins {
+		bin.UndeclaredLibraries = nil
+		if len(bin.LddDependencies) == 0 {
+			continue
 		}
+
+		declared := map[string]bool{bin.Label: true}
+		queue := []string{bin.Label}
+		for len(queue) > 0 {
+			current := byLabel[queue[0]]
+			queue = queue[1:]
+			if current == nil {
+				continue
+			}
+			for _, dep := range current.DynamicDeps {
+				if !declared[dep] {
+					declared[dep] = true
+					queue = append(queue, dep)
+				}
+			}
+		}
+
+		for _, lib := range bin.LddDependencies {
+			label, ok := byFile[path.Base(lib)]
+			if ok && !declared[label] && !slices.Contains(bin.UndeclaredLibraries, label) {
+				bin.UndeclaredLibraries = append(bin.UndeclaredLibraries, label)
+			}
+		}
+		sort.Strings(bin.UndeclaredLibraries)
 	}
+}
 
-	return binaries, nil
+// binaryKindsQuery matches the targets analyzed as binaries
+const binaryKindsQuery = "kind('cc_binary|cc_shared_library', //...)"
+
+// QueryAllBinaries finds all cc_binary and cc_shared_library targets, sorted by label
+func QueryAllBinaries(workspace string) ([]string, error) {
+	kinds, err := bazel.QueryTargetKinds(workspace, binaryKindsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return sortedLabels(kinds), nil
+}
+
+// sortedLabels returns the labels of a QueryTargetKinds result in order
+func sortedLabels(kinds map[string]model.TargetKind) []string {
+	labels := make([]string, 0, len(kinds))
+	for label := range kinds {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
 }
 
 // GetBinaryInfo retrieves detailed information about a binary or shared library
 func GetBinaryInfo(workspace string, label string) (*BinaryInfo, error) {
 	// Query for rule kind
-	fmt.Printf("  - Querying rule kind...\n")
-	cmd := exec.Command("bazel", "query", "--output=label_kind", label)
-	cmd.Dir = workspace
-	output, err := cmd.CombinedOutput()
+	logging.Debug("querying rule kind", "label", label)
+	kinds, err := bazel.QueryTargetKinds(workspace, label)
 	if err != nil {
 		return nil, fmt.Errorf("bazel query failed for %s: %w", label, err)
 	}
-
-	// Parse kind from output (format: "cc_binary rule //label")
-	outputStr := string(output)
-	// Filter out Loading/INFO lines, get only the result line
-	lines := strings.Split(outputStr, "\n")
-	var resultLine string
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && !strings.HasPrefix(line, "Loading:") && !strings.HasPrefix(line, "INFO:") {
-			resultLine = line
-			break
-		}
+	kind, ok := kinds[label]
+	if !ok {
+		return nil, fmt.Errorf("no rule kind reported for %s", label)
 	}
+	return getBinaryInfo(workspace, label, kind), nil
+}
 
-	parts := strings.Fields(resultLine)
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("unexpected query output format: %s", resultLine)
-	}
-	kind := parts[0] // First field is the rule kind (e.g., "cc_binary", "cc_shared_library")
-
+// getBinaryInfo retrieves detailed information about a binary or shared library of a known kind
+func getBinaryInfo(workspace string, label string, kind model.TargetKind) *BinaryInfo {
 	info := &BinaryInfo{
 		Label: label,
-		Kind:  kind,
+		Kind:  string(kind),
 	}
 
 	// Get shared library dependencies (both dynamic_deps and from data)
-	fmt.Printf("  - Querying shared library dependencies...\n")
+	logging.Debug("querying shared library dependencies", "label", label)
 	sharedLibDeps := querySharedLibraryDeps(workspace, label)
 
 	// Separate into dynamic_deps and data_deps based on how they're referenced
 	// For now, we'll use a heuristic: query deps to see what's linked
-	fmt.Printf("  - Querying linked dependencies...\n")
+	logging.Debug("querying linked dependencies", "label", label)
 	linkedDeps := queryLinkedDeps(workspace, label)
 
 	for _, dep := range sharedLibDeps {
@@ -99,33 +151,32 @@ func GetBinaryInfo(workspace string, label string) (*BinaryInfo, error) {
 	}
 
 	// Get system libraries from linkopts
-	fmt.Printf("  - Querying system libraries...\n")
+	logging.Debug("querying system libraries", "label", label)
 	info.SystemLibraries = querySystemLibraries(workspace, label)
 
 	// Get all cc_library targets this binary depends on (excluding shared libraries)
-	fmt.Printf("  - Querying internal cc_library targets...\n")
+	logging.Debug("querying internal cc_library targets", "label", label)
 	info.InternalTargets = queryInternalTargets(workspace, label)
 
 	// Get direct cc_library dependencies (depth 1)
-	fmt.Printf("  - Querying direct dependencies...\n")
+	logging.Debug("querying direct dependencies", "label", label)
 	info.RegularDeps = queryDirectDeps(workspace, label)
 
 	// Get output file path
-	fmt.Printf("  - Querying output file...\n")
+	logging.Debug("querying output file", "label", label)
 	info.OutputFile = queryOutputFile(workspace, label)
 
-	return info, nil
+	return info
 }
 
 // queryOutputFile finds the output file path for a target
 func queryOutputFile(workspace string, label string) string {
-	fmt.Fprintf(os.Stderr, "DEBUG BINARIES: queryOutputFile called for label=%s\n", label)
 	// Use cquery --output=files to get the actual output path
 	cmd := exec.Command("bazel", "cquery", "--output=files", label)
 	cmd.Dir = workspace
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		fmt.Printf("Warning: failed to query output file for %s: %v\n", label, err)
+		logging.Warn("failed to query output file", "label", label, "error", err)
 		return ""
 	}
 
@@ -270,42 +321,42 @@ func contains(slice []string, value string) bool {
 
 // GetAllBinariesInfo retrieves information for all binaries
 func GetAllBinariesInfo(workspace string) ([]*BinaryInfo, error) {
-	fmt.Println("Querying for all cc_binary and cc_shared_library targets...")
-	labels, err := QueryAllBinaries(workspace)
+	logging.Info("querying for all cc_binary and cc_shared_library targets")
+	// The kinds come with the labels, so each binary needs no query of its own for its kind
+	kinds, err := bazel.QueryTargetKinds(workspace, binaryKindsQuery)
 	if err != nil {
 		return nil, err
 	}
+	labels := sortedLabels(kinds)
 
-	fmt.Printf("Found %d binaries to analyze\n", len(labels))
+	logging.Info("found binaries to analyze", "count", len(labels))
 
 	var binaries []*BinaryInfo
 	for i, label := range labels {
-		fmt.Printf("[%d/%d] Analyzing binary: %s\n", i+1, len(labels), label)
-		info, err := GetBinaryInfo(workspace, label)
-		if err != nil {
-			// Log error but continue
-			fmt.Printf("Warning: failed to get info for %s: %v\n", label, err)
-			continue
-		}
-		binaries = append(binaries, info)
+		logging.Info("analyzing binary", "label", label, "progress", fmt.Sprintf("%d/%d", i+1, len(labels)))
+		binaries = append(binaries, getBinaryInfo(workspace, label, kinds[label]))
 	}
 
 	// Compute overlapping dependencies (potential duplicate symbols)
-	fmt.Println("Computing overlapping dependencies...")
-	computeOverlappingDeps(binaries)
+	logging.Info("computing overlapping dependencies")
+	computeOverlappingDeps(binaries, nil)
 
 	return binaries, nil
 }
 
 // computeOverlappingDeps finds cc_library targets that are linked into multiple binaries
-// This can cause duplicate symbols if a binary loads a shared library that both depend on the same cc_library
-func computeOverlappingDeps(binaries []*BinaryInfo) {
+// This can cause duplicate symbols if a binary loads a shared library that both depend on the same cc_library.
+// Normally only the object files that resolve a reference are linked, so an overlap is a potential problem.
+// If the module is known, overlaps where all of the library's symbols are always linked are also
+// recorded in CertainOverlaps (see linksAllSymbols).
+func computeOverlappingDeps(binaries []*BinaryInfo, module *model.Module) {
 	for i, binary := range binaries {
 		if binary.Kind != "cc_binary" {
 			continue // Only check for cc_binary loading shared libraries
 		}
 
 		binary.OverlappingDeps = make(map[string][]string)
+		binary.CertainOverlaps = make(map[string][]string)
 
 		// Check each dynamic dependency
 		for _, depLabel := range binary.DynamicDeps {
@@ -324,23 +375,44 @@ func computeOverlappingDeps(binaries []*BinaryInfo) {
 
 			// Find overlapping cc_library targets
 			binaryTargets := toSet(binary.InternalTargets)
-			var overlapping []string
+			var overlapping, certain []string
 
 			for _, target := range sharedLib.InternalTargets {
 				if binaryTargets[target] {
 					overlapping = append(overlapping, target)
+					if module != nil && linksAllSymbols(module, binary.Label, target) {
+						certain = append(certain, target)
+					}
 				}
 			}
 
 			if len(overlapping) > 0 {
 				binary.OverlappingDeps[depLabel] = overlapping
 			}
+			if len(certain) > 0 {
+				binary.CertainOverlaps[depLabel] = certain
+			}
 		}
 
 		binaries[i] = binary
 	}
 }
 
+// linksAllSymbols reports whether all symbols of a cc_library end up in the binary.
+// This is the case for alwayslink libraries, and for libraries that the binary links
+// dynamically as a whole (binary with linkstatic = False, library with linkstatic = False).
+func linksAllSymbols(module *model.Module, binaryLabel, libraryLabel string) bool {
+	library := module.Targets[libraryLabel]
+	if library == nil {
+		return false
+	}
+	if library.Alwayslink {
+		return true
+	}
+	binary := module.Targets[binaryLabel]
+	return binary != nil && !binary.Linkstatic && !library.Linkstatic
+}
+
 // toSet converts a slice to a set (map[string]bool)
 func toSet(slice []string) map[string]bool {
 	set := make(map[string]bool)
@@ -352,13 +424,14 @@ func toSet(slice []string) map[string]bool {
 
 // DeriveBinaryInfoFromModule creates BinaryInfo for all binaries and shared libraries from the Module
 // This is much faster than running separate Bazel queries for each binary.
-// It also queries for the output file path for each binary to ensure correct LDD scanning.
-func DeriveBinaryInfoFromModule(module *model.Module, workspace string) []*BinaryInfo {
+// It also queries for the output file path for each binary to ensure correct LDD scanning,
+// running at most workers queries in parallel.
+func DeriveBinaryInfoFromModule(module *model.Module, workspace string, workers int) []*BinaryInfo {
 	var result []*BinaryInfo
 
 	// Process each binary and shared library target
 	for _, target := range module.Targets {
-		if target.Kind != model.TargetKindBinary && target.Kind != model.TargetKindSharedLibrary {
+		if !isShippedBinary(target) {
 			continue
 		}
 
@@ -367,15 +440,12 @@ func DeriveBinaryInfoFromModule(module *model.Module, workspace string) []*Binar
 			Kind:            string(target.Kind),
 			DynamicDeps:     make([]string, 0),
 			DataDeps:        make([]string, 0),
-			SystemLibraries: extractSystemLibrariesFromLinkopts(target.Linkopts),
+			SystemLibraries: module.SystemLibraries(target.Label), // Including those of its static closure
 			RegularDeps:     make([]string, 0),
 			InternalTargets: make([]string, 0),
 			OverlappingDeps: make(map[string][]string),
 		}
 
-		// Query for the actual output file path
-		info.OutputFile = queryOutputFile(workspace, target.Label)
-
 		// Collect dependencies from module.Dependencies
 		allLibraries := make(map[string]bool)    // All transitive cc_library dependencies
 		dynamicLibs := make(map[string][]string) // Track which libraries are in which dynamic deps
@@ -395,7 +465,7 @@ func DeriveBinaryInfoFromModule(module *model.Module, workspace string) []*Binar
 			case model.DependencyDynamic:
 				info.DynamicDeps = append(info.DynamicDeps, dep.To)
 				// Collect libraries from this dynamic dep for overlap detection
-				dynamicLibs[dep.To] = getTransitiveLibraries(module, dep.To)
+				dynamicLibs[dep.To] = GetTransitiveLibraries(module, dep.To)
 			case model.DependencyData:
 				info.DataDeps = append(info.DataDeps, dep.To)
 			case model.DependencyStatic:
@@ -414,35 +484,163 @@ func DeriveBinaryInfoFromModule(module *model.Module, workspace string) []*Binar
 			}
 		}
 
+		// A dynamic dep is redundant if the libraries it is built from are linked statically as well
+		if target.Kind == model.TargetKindBinary {
+			for _, dynamicDep := range info.DynamicDeps {
+				if redundant := staticallyLinkedRoots(module, dynamicDep, allLibraries); len(redundant) > 0 {
+					if info.RedundantDynamicDeps == nil {
+						info.RedundantDynamicDeps = make(map[string][]string)
+					}
+					info.RedundantDynamicDeps[dynamicDep] = redundant
+				}
+			}
+		}
+
 		result = append(result, info)
 	}
 
+	// Query for the actual output file paths
+	sem := make(chan struct{}, max(workers, 1))
+	var wg sync.WaitGroup
+	for _, info := range result {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			info.OutputFile = queryOutputFile(workspace, info.Label)
+		}()
+	}
+	wg.Wait()
+
 	// Compute overlapping dependencies
-	computeOverlappingDeps(result)
+	computeOverlappingDeps(result, module)
 
 	return result
 }
 
-// extractSystemLibrariesFromLinkopts extracts system libraries from linkopts
-func extractSystemLibrariesFromLinkopts(linkopts []string) []string {
-	seen := make(map[string]bool)
-	var result []string
-
-	for _, opt := range linkopts {
-		if strings.HasPrefix(opt, "-l") {
-			lib := strings.TrimPrefix(opt, "-l")
-			if lib != "" && !seen[lib] {
-				seen[lib] = true
-				result = append(result, lib)
+// staticallyLinkedRoots returns the sorted cc_library targets a shared library is built from
+// (its direct deps) that are also in the given static closure of a binary
+func staticallyLinkedRoots(module *model.Module, sharedLibLabel string, staticLibraries map[string]bool) []string {
+	var roots []string
+	for _, dep := range module.Dependencies {
+		if dep.From == sharedLibLabel && dep.Type == model.DependencyStatic && staticLibraries[dep.To] {
+			if !contains(roots, dep.To) {
+				roots = append(roots, dep.To)
 			}
 		}
 	}
+	sort.Strings(roots)
+	return roots
+}
 
-	return result
+// IssueRedundantDynamicDep is the DependencyIssue.Issue of a dynamic_dep whose libraries the
+// binary already links statically
+const IssueRedundantDynamicDep = "redundant_dynamic_dep"
+
+// RedundantDynamicDepIssues returns a warning for each binary dynamic_dep listed in
+// RedundantDynamicDeps, sorted by binary and shared library
+func RedundantDynamicDepIssues(binaries []*BinaryInfo) []model.DependencyIssue {
+	var issues []model.DependencyIssue
+	for _, binary := range binaries {
+		for sharedLib, libraries := range binary.RedundantDynamicDeps {
+			issues = append(issues, model.DependencyIssue{
+				From:     binary.Label,
+				To:       sharedLib,
+				Issue:    IssueRedundantDynamicDep,
+				Types:    []string{string(model.DependencyStatic), string(model.DependencyDynamic)},
+				Severity: "warning",
+				Description: fmt.Sprintf("Binary %s lists %s in dynamic_deps, but already links %s statically "+
+					"through its deps. Their symbols are then duplicated and the dynamic dependency adds nothing.",
+					binary.Label, sharedLib, strings.Join(libraries, ", ")),
+			})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].From != issues[j].From {
+			return issues[i].From < issues[j].From
+		}
+		return issues[i].To < issues[j].To
+	})
+	return issues
+}
+
+// ApplyRedundantDynamicDeps replaces any previous redundant dynamic_dep warnings in the module
+// with those of the given binaries, so repeated analysis runs don't accumulate duplicates
+func ApplyRedundantDynamicDeps(module *model.Module, binaries []*BinaryInfo) []model.DependencyIssue {
+	if module == nil {
+		return nil
+	}
+
+	redundant := RedundantDynamicDepIssues(binaries)
+	module.ReplaceIssues(IssueRedundantDynamicDep, redundant)
+	return redundant
+}
+
+// IssueOverlappingLinkage is the DependencyIssue.Issue of a binary that links a library
+// statically and also loads it through a shared library
+const IssueOverlappingLinkage = "overlapping_linkage"
+
+// OverlappingLinkageIssues returns a warning for each shared library in OverlappingDeps,
+// sorted by binary and shared library. Shared libraries already reported as redundant
+// dynamic_deps are left out, as that warning covers the same libraries.
+func OverlappingLinkageIssues(binaries []*BinaryInfo) []model.DependencyIssue {
+	var issues []model.DependencyIssue
+	for _, binary := range binaries {
+		for sharedLib, libraries := range binary.OverlappingDeps {
+			if _, redundant := binary.RedundantDynamicDeps[sharedLib]; redundant {
+				continue
+			}
+			description := fmt.Sprintf("Binary %s links %s statically and also loads them through %s. "+
+				"Their symbols can then exist both in the binary and in the shared library.",
+				binary.Label, strings.Join(libraries, ", "), sharedLib)
+			if certain := binary.CertainOverlaps[sharedLib]; len(certain) > 0 {
+				description += fmt.Sprintf(" All symbols of %s are linked into both.", strings.Join(certain, ", "))
+			}
+			issues = append(issues, model.DependencyIssue{
+				From:        binary.Label,
+				To:          sharedLib,
+				Issue:       IssueOverlappingLinkage,
+				Types:       []string{string(model.DependencyStatic), string(model.DependencyDynamic)},
+				Severity:    model.SeverityWarning,
+				Description: description,
+			})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].From != issues[j].From {
+			return issues[i].From < issues[j].From
+		}
+		return issues[i].To < issues[j].To
+	})
+	return issues
+}
+
+// ApplyOverlappingLinkage replaces any previous overlapping linkage warnings in the module
+// with those of the given binaries, like ApplyRedundantDynamicDeps
+func ApplyOverlappingLinkage(module *model.Module, binaries []*BinaryInfo) []model.DependencyIssue {
+	if module == nil {
+		return nil
+	}
+
+	overlapping := OverlappingLinkageIssues(binaries)
+	module.ReplaceIssues(IssueOverlappingLinkage, overlapping)
+	return overlapping
+}
+
+// isShippedBinary reports whether a target produces a binary that is part of the product.
+// Tests stay in the dependency graph but are not shipped, so they are excluded from binary analysis.
+func isShippedBinary(target *model.Target) bool {
+	if target.Kind == model.TargetKindTest {
+		return false
+	}
+	return target.Kind == model.TargetKindBinary || target.Kind == model.TargetKindSharedLibrary
 }
 
-// getTransitiveLibraries gets all transitive cc_library dependencies of a target
-func getTransitiveLibraries(module *model.Module, targetLabel string) []string {
+// GetTransitiveLibraries gets all transitive cc_library dependencies of a target
+func GetTransitiveLibraries(module *model.Module, targetLabel string) []string {
 	visited := make(map[string]bool)
 	libraries := make(map[string]bool)
 	collectAllLibraries(module, targetLabel, visited, libraries)
@@ -0,0 +1,222 @@
+package binaries
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestComputeOverlappingDepsLinking(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":         {Label: "//main:app", Kind: model.TargetKindBinary, Linkstatic: true},
+			"//main:dynamic_app": {Label: "//main:dynamic_app", Kind: model.TargetKindBinary},
+			"//plugins:plugins":  {Label: "//plugins:plugins", Kind: model.TargetKindSharedLibrary},
+			"//plugins:registry": {Label: "//plugins:registry", Kind: model.TargetKindLibrary, Alwayslink: true},
+			"//util:util":        {Label: "//util:util", Kind: model.TargetKindLibrary},
+			"//core:core":        {Label: "//core:core", Kind: model.TargetKindLibrary, Linkstatic: true},
+		},
+	}
+
+	shared := &BinaryInfo{
+		Label:           "//plugins:plugins",
+		Kind:            "cc_shared_library",
+		InternalTargets: []string{"//plugins:registry", "//util:util", "//core:core"},
+	}
+	app := &BinaryInfo{
+		Label:           "//main:app",
+		Kind:            "cc_binary",
+		DynamicDeps:     []string{"//plugins:plugins"},
+		InternalTargets: []string{"//plugins:registry", "//util:util", "//core:core"},
+	}
+	dynamicApp := &BinaryInfo{
+		Label:           "//main:dynamic_app",
+		Kind:            "cc_binary",
+		DynamicDeps:     []string{"//plugins:plugins"},
+		InternalTargets: []string{"//plugins:registry", "//util:util", "//core:core"},
+	}
+
+	computeOverlappingDeps([]*BinaryInfo{shared, app, dynamicApp}, module)
+
+	all := []string{"//plugins:registry", "//util:util", "//core:core"}
+	tests := []struct {
+		binary  *BinaryInfo
+		certain []string
+	}{
+		// Statically linked: only the alwayslink library is linked in full
+		{app, []string{"//plugins:registry"}},
+		// Dynamically linked: also libraries that are built as shared libraries
+		{dynamicApp, []string{"//plugins:registry", "//util:util"}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.binary.OverlappingDeps["//plugins:plugins"]; !reflect.DeepEqual(got, all) {
+			t.Errorf("%s: OverlappingDeps = %v, want %v", tt.binary.Label, got, all)
+		}
+		if got := tt.binary.CertainOverlaps["//plugins:plugins"]; !reflect.DeepEqual(got, tt.certain) {
+			t.Errorf("%s: CertainOverlaps = %v, want %v", tt.binary.Label, got, tt.certain)
+		}
+	}
+}
+
+func TestDeriveBinaryInfoExcludesTests(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":      {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//main:app_test": {Label: "//main:app_test", Kind: model.TargetKindTest},
+			"//util:util":     {Label: "//util:util", Kind: model.TargetKindLibrary},
+			"//plugin:plugin": {Label: "//plugin:plugin", Kind: model.TargetKindSharedLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//main:app_test", To: "//util:util", Type: model.DependencyStatic},
+		},
+	}
+
+	var labels []string
+	for _, info := range DeriveBinaryInfoFromModule(module, t.TempDir(), 1) {
+		labels = append(labels, info.Label)
+	}
+	sort.Strings(labels)
+
+	if want := []string{"//main:app", "//plugin:plugin"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("derived binaries = %v, want %v", labels, want)
+	}
+}
+
+func TestRedundantDynamicDeps(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":        {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//plugins:plugins": {Label: "//plugins:plugins", Kind: model.TargetKindSharedLibrary},
+			"//plugins:core":    {Label: "//plugins:core", Kind: model.TargetKindLibrary},
+			"//plugins:extra":   {Label: "//plugins:extra", Kind: model.TargetKindLibrary},
+			"//util:util":       {Label: "//util:util", Kind: model.TargetKindLibrary},
+			"//audio:audio":     {Label: "//audio:audio", Kind: model.TargetKindSharedLibrary},
+			"//audio:mixer":     {Label: "//audio:mixer", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//plugins:plugins", Type: model.DependencyDynamic},
+			{From: "//main:app", To: "//audio:audio", Type: model.DependencyDynamic},
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//util:util", To: "//plugins:core", Type: model.DependencyStatic},
+			{From: "//plugins:plugins", To: "//plugins:core", Type: model.DependencyStatic},
+			{From: "//plugins:plugins", To: "//plugins:extra", Type: model.DependencyStatic},
+			// Shared transitively only, which is an overlap but not a redundant declaration
+			{From: "//audio:audio", To: "//audio:mixer", Type: model.DependencyStatic},
+			{From: "//audio:mixer", To: "//util:util", Type: model.DependencyStatic},
+		},
+		Issues: []model.DependencyIssue{
+			{From: "//main:app", To: "//old:lib", Issue: IssueRedundantDynamicDep},
+		},
+	}
+
+	infos := DeriveBinaryInfoFromModule(module, t.TempDir(), 1)
+	var app *BinaryInfo
+	for _, info := range infos {
+		if info.Label == "//main:app" {
+			app = info
+		}
+	}
+	want := map[string][]string{"//plugins:plugins": {"//plugins:core"}}
+	if app == nil || !reflect.DeepEqual(app.RedundantDynamicDeps, want) {
+		t.Fatalf("RedundantDynamicDeps = %v, want %v", app, want)
+	}
+
+	issues := ApplyRedundantDynamicDeps(module, infos)
+	if len(issues) != 1 || issues[0].From != "//main:app" || issues[0].To != "//plugins:plugins" ||
+		issues[0].Severity != "warning" {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+	if !reflect.DeepEqual(module.Issues, issues) {
+		t.Errorf("expected previous warnings replaced, got %+v", module.Issues)
+	}
+}
+
+func TestOverlappingLinkage(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":        {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//plugins:plugins": {Label: "//plugins:plugins", Kind: model.TargetKindSharedLibrary},
+			"//plugins:core":    {Label: "//plugins:core", Kind: model.TargetKindLibrary},
+			"//audio:audio":     {Label: "//audio:audio", Kind: model.TargetKindSharedLibrary},
+			"//audio:mixer":     {Label: "//audio:mixer", Kind: model.TargetKindLibrary},
+			"//util:util":       {Label: "//util:util", Kind: model.TargetKindLibrary, Alwayslink: true},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//plugins:plugins", Type: model.DependencyDynamic},
+			{From: "//main:app", To: "//audio:audio", Type: model.DependencyDynamic},
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//main:app", To: "//plugins:core", Type: model.DependencyStatic},
+			// Redundant, already reported by RedundantDynamicDepIssues
+			{From: "//plugins:plugins", To: "//plugins:core", Type: model.DependencyStatic},
+			// Overlaps through //audio:mixer
+			{From: "//audio:audio", To: "//audio:mixer", Type: model.DependencyStatic},
+			{From: "//audio:mixer", To: "//util:util", Type: model.DependencyStatic},
+		},
+		Issues: []model.DependencyIssue{
+			{From: "//main:app", To: "//old:lib", Issue: IssueOverlappingLinkage},
+			{From: "//main:app", To: "//plugins:plugins", Issue: IssueRedundantDynamicDep},
+		},
+	}
+
+	issues := ApplyOverlappingLinkage(module, DeriveBinaryInfoFromModule(module, t.TempDir(), 1))
+	if len(issues) != 1 {
+		t.Fatalf("expected one issue, got %+v", issues)
+	}
+	issue := issues[0]
+	if issue.From != "//main:app" || issue.To != "//audio:audio" || issue.Severity != model.SeverityWarning ||
+		!reflect.DeepEqual(issue.Types, []string{"static", "dynamic"}) {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+	if !strings.Contains(issue.Description, "All symbols of //util:util") {
+		t.Errorf("expected the alwayslink library in the description: %s", issue.Description)
+	}
+
+	// The stale warning is replaced, other issues are kept
+	if len(module.Issues) != 2 || module.Issues[0].Issue != IssueRedundantDynamicDep || !reflect.DeepEqual(module.Issues[1], issue) {
+		t.Errorf("unexpected module issues: %+v", module.Issues)
+	}
+}
+
+func TestFindUndeclaredLibraries(t *testing.T) {
+	bins := []*BinaryInfo{
+		{
+			Label:       "//main:app",
+			Kind:        string(model.TargetKindBinary),
+			DynamicDeps: []string{"//core:core_so"},
+			LddDependencies: []string{
+				"/work/bazel-bin/core/libcore_so.so",
+				"/work/bazel-bin/util/libutil_so.so",   // Declared by core_so
+				"/work/bazel-bin/extra/libplugin.so.1", // Not declared
+				"/lib/x86_64-linux-gnu/libc.so.6",      // System library
+			},
+		},
+		{Label: "//core:core_so", Kind: string(model.TargetKindSharedLibrary), DynamicDeps: []string{"//util:util_so"}},
+		{Label: "//util:util_so", Kind: string(model.TargetKindSharedLibrary)},
+		{Label: "//extra:plugin", Kind: string(model.TargetKindSharedLibrary), OutputFile: "bazel-out/k8-fastbuild/bin/extra/libplugin.so.1"},
+	}
+
+	FindUndeclaredLibraries(bins)
+
+	if want := []string{"//extra:plugin"}; !reflect.DeepEqual(bins[0].UndeclaredLibraries, want) {
+		t.Errorf("UndeclaredLibraries = %v, want %v", bins[0].UndeclaredLibraries, want)
+	}
+	for _, bin := range bins[1:] {
+		if bin.UndeclaredLibraries != nil {
+			t.Errorf("%s: UndeclaredLibraries = %v, want none without ldd results", bin.Label, bin.UndeclaredLibraries)
+		}
+	}
+}
+
+func TestIssueCodeKnown(t *testing.T) {
+	for _, code := range []string{IssueRedundantDynamicDep, IssueOverlappingLinkage} {
+		if !slices.Contains(model.IssueCodes, code) {
+			t.Errorf("model.IssueCodes %v does not list %s", model.IssueCodes, code)
+		}
+	}
+}
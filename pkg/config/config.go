@@ -1,7 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/knadh/koanf/parsers/toml/v2"
@@ -17,60 +24,390 @@ type Config struct {
 	Workspace   string `koanf:"workspace"`
 	WebMode     bool   `koanf:"web"`
 	Port        int    `koanf:"port"`
+	PortAuto    int    `koanf:"port-auto"` // Number of following ports to try if Port is in use
 	Watch       bool   `koanf:"watch"`
 	OpenBrowser bool   `koanf:"open"`
 	Licenses    bool   `koanf:"licenses"`
 	Verbosity   string `koanf:"verbosity"`
 	VerboseCnt  int    `koanf:"verbose"`
+	Quiet       bool   `koanf:"quiet"`
+	NoColor     bool   `koanf:"no-color"`
+	DryRun      bool   `koanf:"dry-run"`
+	Timings     bool   `koanf:"timings"` // Print the duration of each analysis phase
+
+	// Write the analysis results to stdout in this format (see FormatValues) instead of
+	// serving them
+	Format string `koanf:"format"`
+	// Records written with Format: "targets", "deps" or "issues"
+	Emit string `koanf:"emit"`
+	// Label the edges of the dot format with their dependency type
+	EdgeLabels bool `koanf:"edge-labels"`
+	// Fail a one-shot analysis (exit status 3) if an issue has at least this severity, "" to
+	// never fail on issues
+	FailOnIssues string `koanf:"fail-on-issues"`
+
+	// Directory searched for .d and .o files instead of the workspace's bazel-out and
+	// bazel-bin symlinks, e.g. a bazel-out directory downloaded from CI
+	BazelOutPath string `koanf:"bazel-out"`
+
+	// Query targets with bazel cquery, which resolves select() for one configuration
+	// instead of keeping every branch. CqueryOpts are passed to it (e.g. "--config=linux").
+	Cquery     bool     `koanf:"cquery"`
+	CqueryOpts []string `koanf:"cquery-opts"`
+
+	// Always run bazel query instead of reusing the cached result of an earlier run while
+	// no BUILD file changed
+	NoCache bool `koanf:"no-cache"`
+
+	// Read the #include directives of each source file to tell the headers it includes
+	// directly from those pulled in transitively
+	DirectIncludes bool `koanf:"direct-includes"`
+
+	// Target whose link closure limits symbol (nm) analysis (empty for all targets)
+	SymbolScope string `koanf:"symbol-scope"`
+
+	// Analyses left out, e.g. on machines without the tools they need (see SkipValues)
+	Skip []string `koanf:"skip"`
+
+	// Patterns (path.Match syntax) of files and directories left out of the search for
+	// files not covered by any target, e.g. vendored or generated code
+	CoverageExclude []string `koanf:"coverage-exclude"`
+
+	// Number of analyses whose metrics are kept for GET /api/history
+	HistorySize int `koanf:"history-size"`
+
+	// Serve the debugging endpoints (/api/debug/...)
+	Debug bool `koanf:"debug"`
+
+	// Origins allowed to make cross-origin requests to the web server (empty for localhost only)
+	AllowedOrigins []string `koanf:"allowed-origins"`
+
+	// Maximum number of parallel workers (and subprocesses) per analysis phase
+	MaxConcurrency int               `koanf:"max-concurrency"`
+	Concurrency    ConcurrencyConfig `koanf:"concurrency"`
+
+	// Severity of each issue code overriding the one the analysis assigns, e.g.
+	// {"redundant_dynamic_dep": "info"} (only settable from the config file)
+	Severity map[string]string `koanf:"severity"`
+
+	// Architectural dependency rules (only settable from the config file)
+	Policy PolicyConfig `koanf:"policy"`
+
+	// Thresholds for flagging highly connected targets
+	Metrics MetricsConfig `koanf:"metrics"`
+
+	// File extensions recognized as sources and headers
+	Files FilesConfig `koanf:"files"`
+
+	// Which phases are refreshed when the watcher sees changes
+	Reanalysis ReanalysisConfig `koanf:"reanalysis"`
+
+	// Symbols provided by system libraries
+	Symbols SymbolsConfig `koanf:"symbols"`
+}
+
+// Output formats selectable with --format
+const (
+	FormatText         = "text"          // Human-readable report (see output.PrintModuleReport)
+	FormatJSON         = "json"          // The module as one JSON document, like GET /api/module
+	FormatJSONLines    = "jsonl"         // One JSON object per line (see output.WriteJSONLines)
+	FormatCytoscape    = "cytoscape"     // The module graph as Cytoscape.js elements (see web.ToCytoscape)
+	FormatScorecardCSV = "scorecard-csv" // One CSV row of metrics per package (see output.ScorecardColumns)
+	FormatDOT          = "dot"           // The target graph in Graphviz DOT (see output.WriteDOT)
+)
+
+// FormatValues lists the valid --format values
+var FormatValues = []string{FormatText, FormatJSON, FormatJSONLines, FormatCytoscape, FormatScorecardCSV, FormatDOT}
+
+// Record streams selectable with --emit for the jsonl format (see output.EmitValues)
+const (
+	EmitTargets = "targets" // One target per line
+	EmitDeps    = "deps"    // One dependency per line
+	EmitIssues  = "issues"  // One issue per line
+)
+
+// EmitValues lists the valid --emit values
+var EmitValues = []string{EmitTargets, EmitDeps, EmitIssues}
+
+// Analyses that can be left out with --skip
+const (
+	SkipSymbols = "symbols" // Symbol dependencies, needs nm
+	SkipDynamic = "dynamic" // Dynamic library scanning, needs ldd (otool on macOS)
+)
+
+// SkipValues lists the valid --skip values
+var SkipValues = []string{SkipSymbols, SkipDynamic}
+
+// Skips returns true if the analysis is left out with --skip
+func (c *Config) Skips(analysis string) bool {
+	return slices.Contains(c.Skip, analysis)
+}
+
+// Analysis phases with their own concurrency limit
+const (
+	PhaseNM       = "nm"       // nm runs on object files
+	PhaseDFiles   = "dfiles"   // .d file parsing
+	PhaseBinaries = "binaries" // Per-binary bazel queries
+	PhaseLDD      = "ldd"      // ldd/otool scans of built binaries
+)
+
+// ConcurrencyConfig holds per-phase overrides of MaxConcurrency.
+// A value of zero uses MaxConcurrency.
+//
+// Example (deps-analyzer.toml):
+//
+//	max-concurrency = 8
+//
+//	[concurrency]
+//	binaries = 1
+//	ldd = 4
+type ConcurrencyConfig struct {
+	NM       int `koanf:"nm"`
+	DFiles   int `koanf:"dfiles"`
+	Binaries int `koanf:"binaries"`
+	LDD      int `koanf:"ldd"`
+}
+
+// ConcurrencyLimit returns the number of parallel workers to use for a phase (always at least 1)
+func (c *Config) ConcurrencyLimit(phase string) int {
+	var override int
+	switch phase {
+	case PhaseNM:
+		override = c.Concurrency.NM
+	case PhaseDFiles:
+		override = c.Concurrency.DFiles
+	case PhaseBinaries:
+		override = c.Concurrency.Binaries
+	case PhaseLDD:
+		override = c.Concurrency.LDD
+	}
+
+	switch {
+	case override > 0:
+		return override
+	case c.MaxConcurrency > 0:
+		return c.MaxConcurrency
+	default:
+		return runtime.NumCPU()
+	}
+}
+
+// MetricsConfig holds thresholds for graph metrics.
+// A threshold of zero disables the corresponding flag.
+//
+// Example (deps-analyzer.toml):
+//
+//	[metrics]
+//	hub_threshold = 10
+//	god_object_threshold = 15
+type MetricsConfig struct {
+	HubThreshold       int `koanf:"hub_threshold"`        // Minimum number of direct dependents for a hub
+	GodObjectThreshold int `koanf:"god_object_threshold"` // Minimum number of direct dependencies for a god object
+}
+
+// FilesConfig holds the file extensions recognized as sources and headers in cc_* targets.
+// Empty lists use the C++ defaults. Objective-C(++) and assembly sources are opt-in.
+//
+// Example (deps-analyzer.toml):
+//
+//	[files]
+//	headers = [".h", ".hpp", ".inc"]
+//	objc = true      # also .m and .mm
+//	assembly = true  # also .S and .s
+type FilesConfig struct {
+	Sources  []string `koanf:"sources"`  // Source extensions, including the dot
+	Headers  []string `koanf:"headers"`  // Header extensions, including the dot
+	ObjC     bool     `koanf:"objc"`     // Recognize Objective-C and Objective-C++ sources
+	Assembly bool     `koanf:"assembly"` // Recognize assembly sources
+}
+
+// ReanalysisConfig controls which analysis phases are refreshed in watch mode when build
+// outputs change. Turning phases off makes re-analysis faster but leaves their results
+// stale until the next full analysis.
+//
+// Example (deps-analyzer.toml):
+//
+//	[reanalysis]
+//	dfile_symbols = true    # .d changes also refresh symbol dependencies
+//	dfile_binaries = false  # .d changes do not refresh binary derivation
+//	ofile_binaries = false  # .o changes do not refresh binary derivation
+type ReanalysisConfig struct {
+	DFileSymbols  bool `koanf:"dfile_symbols"`
+	DFileBinaries bool `koanf:"dfile_binaries"`
+	OFileBinaries bool `koanf:"ofile_binaries"`
+}
+
+// SymbolsConfig extends the built-in mapping from system libraries to the symbols they
+// provide. Undefined symbols provided by a library in the target's linkopts (-l<name>) are
+// not reported as unresolved. Patterns may use wildcards.
+//
+// Example (deps-analyzer.toml):
+//
+//	[symbols.system_libraries]
+//	crypto = ["EVP_*", "RAND_bytes"]
+//	pthread = ["__pthread_*"]
+type SymbolsConfig struct {
+	SystemLibraries map[string][]string `koanf:"system_libraries"` // Library name -> symbol patterns
+}
+
+// Sources of configuration values, in increasing priority
+const (
+	SourceDefault = "default"
+	SourceFile    = "deps-analyzer.toml"
+	SourceEnv     = "environment"
+	SourceFlag    = "flag"
+)
+
+// Resolved holds the merged configuration values by key (e.g. "metrics.hub_threshold")
+// and the source that set each of them
+type Resolved struct {
+	Values  map[string]interface{}
+	Sources map[string]string
+}
+
+// Keys returns the resolved keys in sorted order
+func (r *Resolved) Keys() []string {
+	keys := make([]string, 0, len(r.Values))
+	for key := range r.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // Load loads configuration from defaults, config file, environment variables, and flags.
 // Priority: Flags > Env > Config File > Defaults
 func Load(f *pflag.FlagSet) (*Config, error) {
+	cfg, _, err := LoadResolved(f)
+	return cfg, err
+}
+
+// LoadResolved is like Load but also reports which source set each value
+func LoadResolved(f *pflag.FlagSet) (*Config, *Resolved, error) {
 	k := koanf.New(".")
+	sources := make(map[string]string)
+
+	// Each source is loaded on its own to record the keys it sets, then merged
+	load := func(source func(key string) string, p koanf.Provider, parser koanf.Parser) error {
+		layer := koanf.New(".")
+		if err := layer.Load(p, parser); err != nil {
+			return err
+		}
+		for _, key := range layer.Keys() {
+			sources[key] = source(key)
+		}
+		return k.Merge(layer)
+	}
+	from := func(source string) func(string) string {
+		return func(string) string { return source }
+	}
 
 	// 1. Defaults
 	defaults := map[string]interface{}{
-		"workspace": ".",
-		"web":       false,
-		"port":      8080,
-		"watch":     false,
-		"open":      true,
-		"licenses":  false,
-		"verbosity": "",
-		"verbose":   0,
+		"workspace":       ".",
+		"web":             false,
+		"port":            8080,
+		"port-auto":       0,
+		"watch":           false,
+		"open":            true,
+		"licenses":        false,
+		"verbosity":       "",
+		"verbose":         0,
+		"quiet":           false,
+		"no-color":        false,
+		"dry-run":         false,
+		"timings":         false,
+		"format":          "",
+		"emit":            EmitDeps,
+		"edge-labels":     false,
+		"fail-on-issues":  "",
+		"max-concurrency": runtime.NumCPU(),
+		"history-size":    100,
+		"debug":           false,
+		"direct-includes": false,
+		"no-cache":        false,
+		"metrics": map[string]interface{}{
+			"hub_threshold":        10,
+			"god_object_threshold": 10,
+		},
+		"reanalysis": map[string]interface{}{
+			"dfile_symbols":  true,
+			"dfile_binaries": true,
+			"ofile_binaries": true,
+		},
 	}
-	if err := k.Load(makeMapProvider(defaults), nil); err != nil {
-		return nil, fmt.Errorf("failed to load defaults: %w", err)
+	if err := load(from(SourceDefault), makeMapProvider(defaults), nil); err != nil {
+		return nil, nil, fmt.Errorf("failed to load defaults: %w", err)
 	}
 
 	// 2. Config File (optional) - deps-analyzer.toml
-	// We ignore errors here as the file might not exist
-	_ = k.Load(file.Provider("deps-analyzer.toml"), toml.Parser())
+	// A missing file is fine, but a malformed one is reported
+	if err := load(from(SourceFile), file.Provider("deps-analyzer.toml"), toml.Parser()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to load deps-analyzer.toml: %w", err)
+	}
 
 	// 3. Environment Variables
 	// Prefix: DEPS_ANALYZER_ (e.g., DEPS_ANALYZER_PORT=9090)
-	if err := k.Load(env.Provider("DEPS_ANALYZER_", ".", func(s string) string {
+	if err := load(from(SourceEnv), env.Provider("DEPS_ANALYZER_", ".", func(s string) string {
 		return strings.ReplaceAll(strings.ToLower(
 			strings.TrimPrefix(s, "DEPS_ANALYZER_")), "_", ".")
 	}), nil); err != nil {
-		return nil, fmt.Errorf("failed to load env vars: %w", err)
+		return nil, nil, fmt.Errorf("failed to load env vars: %w", err)
 	}
 
 	// 4. Flags
+	// Unchanged flags only provide values no other source set, which count as defaults
 	if f != nil {
-		if err := k.Load(posflag.Provider(f, ".", k), nil); err != nil {
-			return nil, fmt.Errorf("failed to load flags: %w", err)
+		flagSource := func(key string) string {
+			if flag := f.Lookup(key); flag != nil && flag.Changed {
+				return SourceFlag
+			}
+			return SourceDefault
+		}
+		if err := load(flagSource, posflag.Provider(f, ".", k), nil); err != nil {
+			return nil, nil, fmt.Errorf("failed to load flags: %w", err)
 		}
 	}
 
 	// Unmarshal into struct
 	var cfg Config
 	if err := k.Unmarshal("", &cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if err := cfg.Policy.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid policy: %w", err)
+	}
+
+	for _, pattern := range cfg.CoverageExclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, nil, fmt.Errorf("invalid coverage-exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	if cfg.Format != "" && !slices.Contains(FormatValues, cfg.Format) {
+		return nil, nil, fmt.Errorf("invalid format %q (use %s)", cfg.Format, strings.Join(FormatValues, ", "))
+	}
+	for _, analysis := range cfg.Skip {
+		if !slices.Contains(SkipValues, analysis) {
+			return nil, nil, fmt.Errorf("invalid skip %q (use %s)", analysis, strings.Join(SkipValues, ", "))
+		}
+	}
+	if !slices.Contains(EmitValues, cfg.Emit) {
+		return nil, nil, fmt.Errorf("invalid emit %q (use %s)", cfg.Emit, strings.Join(EmitValues, ", "))
+	}
+
+	if cfg.BazelOutPath != "" {
+		info, err := os.Stat(cfg.BazelOutPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid bazel-out path: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, nil, fmt.Errorf("invalid bazel-out path: %s is not a directory", cfg.BazelOutPath)
+		}
 	}
 
-	return &cfg, nil
+	return &cfg, &Resolved{Values: k.All(), Sources: sources}, nil
 }
 
 // Helper to use map as a provider
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	cfg := &Config{
+		MaxConcurrency: 4,
+		Concurrency:    ConcurrencyConfig{Binaries: 1, LDD: 8},
+	}
+
+	tests := []struct {
+		phase string
+		want  int
+	}{
+		{PhaseBinaries, 1},
+		{PhaseLDD, 8},
+		{PhaseNM, 4},
+		{PhaseDFiles, 4},
+	}
+	for _, tt := range tests {
+		if got := cfg.ConcurrencyLimit(tt.phase); got != tt.want {
+			t.Errorf("ConcurrencyLimit(%q) = %d, want %d", tt.phase, got, tt.want)
+		}
+	}
+
+	// Without any limit, fall back to the number of CPUs
+	if got := (&Config{}).ConcurrencyLimit(PhaseNM); got != runtime.NumCPU() {
+		t.Errorf("ConcurrencyLimit() = %d, want NumCPU (%d)", got, runtime.NumCPU())
+	}
+}
+
+func TestLoadValidatesBazelOutPath(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	load := func(bazelOut string) error {
+		toml := fmt.Sprintf("bazel-out = %q\n", bazelOut)
+		if err := os.WriteFile("deps-analyzer.toml", []byte(toml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(nil)
+		return err
+	}
+
+	if err := load(t.TempDir()); err != nil {
+		t.Errorf("Load() with an existing bazel-out directory: unexpected error: %v", err)
+	}
+	if err := load("does-not-exist"); err == nil {
+		t.Error("Load() with a missing bazel-out directory: expected an error")
+	}
+}
+
+func TestLoadValidatesCoverageExclude(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	load := func(pattern string) (*Config, error) {
+		toml := fmt.Sprintf("coverage-exclude = [%q]\n", pattern)
+		if err := os.WriteFile("deps-analyzer.toml", []byte(toml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return Load(nil)
+	}
+
+	cfg, err := load("third_party/*")
+	if err != nil {
+		t.Fatalf("Load() with a valid pattern: unexpected error: %v", err)
+	}
+	if len(cfg.CoverageExclude) != 1 || cfg.CoverageExclude[0] != "third_party/*" {
+		t.Errorf("CoverageExclude = %v", cfg.CoverageExclude)
+	}
+	if _, err := load("third_party/["); err == nil {
+		t.Error("Load() with a malformed pattern: expected an error")
+	}
+}
+
+func TestLoadValidatesFormat(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	load := func(toml string) error {
+		if err := os.WriteFile("deps-analyzer.toml", []byte(toml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(nil)
+		return err
+	}
+
+	if err := load("format = \"jsonl\"\nemit = \"issues\"\n"); err != nil {
+		t.Errorf("Load() with jsonl issues: unexpected error: %v", err)
+	}
+	if err := load("format = \"cytoscape\"\n"); err != nil {
+		t.Errorf("Load() with cytoscape: unexpected error: %v", err)
+	}
+	if err := load("format = \"scorecard-csv\"\n"); err != nil {
+		t.Errorf("Load() with scorecard-csv: unexpected error: %v", err)
+	}
+	if err := load("format = \"text\"\n"); err != nil {
+		t.Errorf("Load() with text: unexpected error: %v", err)
+	}
+	if err := load("format = \"json\"\n"); err != nil {
+		t.Errorf("Load() with json: unexpected error: %v", err)
+	}
+	if err := load("format = \"dot\"\nedge-labels = true\n"); err != nil {
+		t.Errorf("Load() with dot: unexpected error: %v", err)
+	}
+	if err := load("format = \"csv\"\n"); err == nil {
+		t.Error("Load() with an unknown format: expected an error")
+	}
+	if err := load("format = \"jsonl\"\nemit = \"packages\"\n"); err == nil {
+		t.Error("Load() with an unknown emit: expected an error")
+	}
+	if err := load("skip = [\"symbols\", \"dynamic\"]\n"); err != nil {
+		t.Errorf("Load() with skipped analyses: unexpected error: %v", err)
+	}
+	if err := load("skip = [\"compile\"]\n"); err == nil {
+		t.Error("Load() with an unknown skip: expected an error")
+	}
+}
+
+func TestLoadSeverity(t *testing.T) {
+	t.Chdir(t.TempDir())
+	toml := "fail-on-issues = \"error\"\n[severity]\nredundant_dynamic_dep = \"info\"\ndefine_skew = \"error\"\n"
+	if err := os.WriteFile("deps-analyzer.toml", []byte(toml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() with severity overrides: unexpected error: %v", err)
+	}
+	want := map[string]string{"redundant_dynamic_dep": "info", "define_skew": "error"}
+	if !reflect.DeepEqual(cfg.Severity, want) {
+		t.Errorf("Severity = %v, want %v", cfg.Severity, want)
+	}
+	if cfg.FailOnIssues != "error" {
+		t.Errorf("FailOnIssues = %q, want error", cfg.FailOnIssues)
+	}
+}
+
+func TestLoadResolvedSources(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("deps-analyzer.toml", []byte("port = 7070\n[metrics]\nhub_threshold = 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DEPS_ANALYZER_PORT", "9090")
+
+	f := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	f.Bool("watch", false, "")
+	f.Bool("web", false, "")
+	if err := f.Parse([]string{"--web"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, resolved, err := LoadResolved(f)
+	if err != nil {
+		t.Fatalf("LoadResolved() unexpected error: %v", err)
+	}
+	if cfg.Port != 9090 || cfg.Metrics.HubThreshold != 5 || !cfg.WebMode {
+		t.Errorf("unexpected config: port=%d hub_threshold=%d web=%v", cfg.Port, cfg.Metrics.HubThreshold, cfg.WebMode)
+	}
+
+	want := map[string]string{
+		"port":                         SourceEnv,
+		"metrics.hub_threshold":        SourceFile,
+		"metrics.god_object_threshold": SourceDefault,
+		"web":                          SourceFlag,
+		"watch":                        SourceDefault, // Unchanged flag
+	}
+	for key, source := range want {
+		if got := resolved.Sources[key]; got != source {
+			t.Errorf("source of %s = %q, want %q", key, got, source)
+		}
+	}
+}
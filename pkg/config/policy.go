@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PolicyConfig holds architectural rules that restrict which packages may depend on which
+//
+// Example (deps-analyzer.toml):
+//
+//	[[policy.rules]]
+//	from = "//ui/..."
+//	deny = ["//db/..."]
+//
+//	[[policy.rules]]
+//	from = "//core"
+//	allow = ["//util", "@fmt//..."]
+type PolicyConfig struct {
+	Rules []PolicyRule `koanf:"rules"`
+}
+
+// PolicyRule restricts the dependencies of all packages matching From.
+// If Deny is set, depending on a matching package is a violation.
+// If Allow is set, depending on any package not matching it is a violation.
+type PolicyRule struct {
+	From  string   `koanf:"from"`  // Package pattern the rule applies to (e.g., "//ui/...")
+	Allow []string `koanf:"allow"` // Package patterns that may be depended on
+	Deny  []string `koanf:"deny"`  // Package patterns that may not be depended on
+}
+
+// Validate checks that all rules are complete and use valid package patterns
+func (p PolicyConfig) Validate() error {
+	for i, rule := range p.Rules {
+		if rule.From == "" {
+			return fmt.Errorf("rule %d: missing 'from' pattern", i+1)
+		}
+		if len(rule.Allow) == 0 && len(rule.Deny) == 0 {
+			return fmt.Errorf("rule %d (%s): needs at least one 'allow' or 'deny' pattern", i+1, rule.From)
+		}
+
+		patterns := append([]string{rule.From}, rule.Allow...)
+		patterns = append(patterns, rule.Deny...)
+		for _, pattern := range patterns {
+			if err := validatePackagePattern(pattern); err != nil {
+				return fmt.Errorf("rule %d (%s): %w", i+1, rule.From, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validatePackagePattern checks the syntax of a single package pattern
+func validatePackagePattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty package pattern")
+	}
+	if !strings.HasPrefix(pattern, "//") && !strings.HasPrefix(pattern, "@") {
+		return fmt.Errorf("invalid package pattern %q: must start with // or @", pattern)
+	}
+	if _, err := path.Match(strings.TrimSuffix(pattern, "/..."), ""); err != nil {
+		return fmt.Errorf("invalid package pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// MatchPackage reports whether a package path matches a package pattern.
+// Patterns are package paths that may contain glob wildcards ("//ui/*").
+// A trailing "/..." also matches all subpackages, like in Bazel target patterns.
+func MatchPackage(pattern, pkg string) bool {
+	prefix, recursive := strings.CutSuffix(pattern, "/...")
+	if !recursive {
+		matched, _ := path.Match(pattern, pkg)
+		return matched
+	}
+
+	// "//..." and "@repo//..." match every package in the repository
+	if strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(pkg, prefix+"/")
+	}
+
+	// Match the package itself and each of its parent packages
+	for candidate := pkg; candidate != ""; {
+		if matched, _ := path.Match(prefix, candidate); matched {
+			return true
+		}
+		idx := strings.LastIndex(candidate, "/")
+		if idx < 0 || strings.HasSuffix(candidate[:idx+1], "//") {
+			break
+		}
+		candidate = candidate[:idx]
+	}
+	return false
+}
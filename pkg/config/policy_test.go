@@ -0,0 +1,73 @@
+package config
+
+import "testing"
+
+func TestMatchPackage(t *testing.T) {
+	tests := []struct {
+		pattern string
+		pkg     string
+		want    bool
+	}{
+		{"//ui", "//ui", true},
+		{"//ui", "//ui/widgets", false},
+		{"//ui/...", "//ui", true},
+		{"//ui/...", "//ui/widgets/buttons", true},
+		{"//ui/...", "//uikit", false},
+		{"//ui/*", "//ui/widgets", true},
+		{"//ui/*", "//ui/widgets/buttons", false},
+		{"//*/internal/...", "//core/internal/impl", true},
+		{"//...", "//anything/at/all", true},
+		{"//...", "@fmt//", false},
+		{"@fmt//...", "@fmt//", true},
+		{"@fmt//...", "@fmt//include", true},
+		{"@fmt//...", "@json//", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchPackage(tt.pattern, tt.pkg); got != tt.want {
+			t.Errorf("MatchPackage(%q, %q) = %v, want %v", tt.pattern, tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestPolicyConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []PolicyRule
+		wantErr bool
+	}{
+		{
+			name:  "valid rules",
+			rules: []PolicyRule{{From: "//ui/...", Deny: []string{"//db/..."}}, {From: "//core", Allow: []string{"//util"}}},
+		},
+		{
+			name:    "missing from",
+			rules:   []PolicyRule{{Deny: []string{"//db"}}},
+			wantErr: true,
+		},
+		{
+			name:    "no allow or deny",
+			rules:   []PolicyRule{{From: "//ui"}},
+			wantErr: true,
+		},
+		{
+			name:    "not a package pattern",
+			rules:   []PolicyRule{{From: "ui", Deny: []string{"//db"}}},
+			wantErr: true,
+		},
+		{
+			name:    "malformed glob",
+			rules:   []PolicyRule{{From: "//ui", Deny: []string{"//db/[a-"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := PolicyConfig{Rules: tt.rules}.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
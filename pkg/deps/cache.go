@@ -0,0 +1,200 @@
+package deps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// ErrNoDFileScan is returned by Cache.ParseDFilesIncremental before the .d files of a
+// workspace have been found by Cache.ParseAllDFiles
+var ErrNoDFileScan = errors.New("no .d files scanned yet")
+
+// Cache remembers parsed .d files along with the modification time and size they had, so
+// that repeated analyses, e.g. in watch mode, only parse the .d files that changed. The
+// returned FileDependency values are copies, which callers may modify (see
+// ResolveHeaderPaths and MarkDirectIncludes).
+type Cache struct {
+	mu            sync.Mutex
+	workers       int
+	ext           model.FileExtensions
+	workspaceRoot string            // Of the last ParseAllDFiles, "" before
+	paths         []string          // .d files in the order of FindDFiles
+	entries       map[string]dEntry // .d file -> parsed content
+}
+
+// dEntry is a parsed .d file and the file state it was parsed from
+type dEntry struct {
+	modTime time.Time
+	size    int64
+	dep     *FileDependency // nil if the file could not be parsed
+}
+
+// NewCache creates an empty cache that parses .d files with at most the given number of
+// parallel workers, finding source files by the extensions of ext
+func NewCache(workers int, ext model.FileExtensions) *Cache {
+	return &Cache{workers: workers, ext: ext, entries: make(map[string]dEntry)}
+}
+
+// ParseAllDFiles finds all .d files of the workspace like ParseAllDFilesParallel, but only
+// parses those that are new or changed since the last call. Cached .d files that no longer
+// exist are dropped.
+func (c *Cache) ParseAllDFiles(workspaceRoot, bazelOut string) ([]*FileDependency, error) {
+	dfiles, err := FindDFiles(workspaceRoot, bazelOut)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[string]string)
+	for i, dfile := range dfiles {
+		dir, ok := dirs[filepath.Dir(dfile)]
+		if !ok {
+			dir = filepath.Dir(canonicalPath(dfile))
+			dirs[filepath.Dir(dfile)] = dir
+		}
+		dfiles[i] = filepath.Join(dir, filepath.Base(dfile))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.workspaceRoot = workspaceRoot
+	c.paths = dfiles
+
+	entries := make(map[string]dEntry, len(dfiles))
+	for _, dfile := range dfiles {
+		if entry, ok := c.entries[dfile]; ok {
+			entries[dfile] = entry
+		}
+	}
+	c.entries = entries
+
+	parsed := c.update(dfiles)
+	logging.Debug("updated .d file cache", "files", len(dfiles), "parsed", parsed)
+	return c.fileDependencies(), nil
+}
+
+// ParseDFilesIncremental updates the cache for the .d files reported changed, e.g. by the
+// file watcher, without searching the build outputs again: changed files are parsed, new
+// ones added and deleted ones dropped. Other paths are ignored. It returns the file
+// dependencies of all cached .d files, like ParseAllDFiles, or ErrNoDFileScan if
+// ParseAllDFiles has not found the .d files yet.
+func (c *Cache) ParseDFilesIncremental(changedPaths []string) ([]*FileDependency, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.workspaceRoot == "" {
+		return nil, ErrNoDFileScan
+	}
+
+	var changed []string
+	for _, path := range changedPaths {
+		stem, variant, ok := dFileStem(filepath.Base(path))
+		if !ok {
+			continue
+		}
+		path = canonicalPath(path)
+
+		if _, err := os.Stat(path); err != nil {
+			c.remove(path)
+			continue
+		}
+		if _, known := c.entries[path]; known {
+			changed = append(changed, path)
+			continue
+		}
+
+		// Keep one .d file per object, preferring the plain one over variants like PIC
+		// (see FindDFiles)
+		dir := filepath.Dir(path)
+		if variant {
+			if _, plain := c.entries[filepath.Join(dir, stem+".d")]; plain {
+				continue
+			}
+		} else {
+			for _, infix := range objectVariants {
+				c.remove(filepath.Join(dir, stem+infix+".d"))
+			}
+		}
+		c.paths = append(c.paths, path)
+		changed = append(changed, path)
+	}
+
+	parsed := c.update(changed)
+	logging.Debug("updated .d file cache incrementally", "changed", len(changedPaths), "parsed", parsed)
+	return c.fileDependencies(), nil
+}
+
+// update parses those of the given .d files whose modification time or size differ from
+// their cache entries, in parallel, and returns how many it parsed. c.mu must be held.
+func (c *Cache) update(dfiles []string) int {
+	var stale []string
+	states := make(map[string]os.FileInfo, len(dfiles))
+	for _, dfile := range dfiles {
+		info, err := os.Stat(dfile)
+		if err != nil {
+			c.remove(dfile)
+			continue
+		}
+		states[dfile] = info
+		if entry, ok := c.entries[dfile]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+			continue
+		}
+		stale = append(stale, dfile)
+	}
+
+	parsed := parseDFiles(stale, c.workers, c.ext)
+	for i, dfile := range stale {
+		info := states[dfile]
+		c.entries[dfile] = dEntry{modTime: info.ModTime(), size: info.Size(), dep: parsed[i]}
+	}
+	return len(stale)
+}
+
+// remove drops a .d file from the cache. c.mu must be held.
+func (c *Cache) remove(dfile string) {
+	delete(c.entries, dfile)
+	for i, path := range c.paths {
+		if path == dfile {
+			c.paths = append(c.paths[:i], c.paths[i+1:]...)
+			break
+		}
+	}
+}
+
+// fileDependencies returns copies of the cached file dependencies with a source file, in
+// the order of c.paths. c.mu must be held.
+func (c *Cache) fileDependencies() []*FileDependency {
+	var deps []*FileDependency
+	for _, dfile := range c.paths {
+		dep := c.entries[dfile].dep
+		if dep == nil || dep.SourceFile == "" {
+			continue
+		}
+		deps = append(deps, &FileDependency{
+			SourceFile:         dep.SourceFile,
+			Dependencies:       append([]string(nil), dep.Dependencies...),
+			DirectDependencies: append([]string(nil), dep.DirectDependencies...),
+		})
+	}
+	return deps
+}
+
+// canonicalPath returns the absolute path of a .d file with the symlinks of its directory
+// resolved, so that the paths found by FindDFiles and those reported by the watcher, e.g.
+// through the bazel-out symlink, match. The file itself may not exist.
+func canonicalPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return path
+	}
+	return filepath.Join(dir, filepath.Base(path))
+}
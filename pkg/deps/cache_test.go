@@ -0,0 +1,115 @@
+package deps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// writeDFile writes a .d file with the given modification time
+func writeDFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// sourceFiles returns the source files of file dependencies, in order
+func sourceFiles(deps []*FileDependency) []string {
+	var sources []string
+	for _, dep := range deps {
+		sources = append(sources, dep.SourceFile)
+	}
+	return sources
+}
+
+func TestCache(t *testing.T) {
+	workspace := t.TempDir()
+	objs := filepath.Join(workspace, "bazel-out", "k8-fastbuild", "bin", "util", "_objs", "util")
+	if err := os.MkdirAll(objs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	stringsFile := filepath.Join(objs, "strings.d")
+	formatFile := filepath.Join(objs, "format.d")
+	writeDFile(t, stringsFile, "strings.o: util/strings.cc util/strings.h\n", modTime)
+	writeDFile(t, formatFile, "format.o: util/format.cc util/format.h\n", modTime)
+
+	cache := NewCache(2, model.FileExtensions{})
+	if _, err := cache.ParseDFilesIncremental([]string{stringsFile}); !errors.Is(err, ErrNoDFileScan) {
+		t.Errorf("ParseDFilesIncremental() before a scan: error = %v, want ErrNoDFileScan", err)
+	}
+
+	deps, err := cache.ParseAllDFiles(workspace, "")
+	if err != nil {
+		t.Fatalf("ParseAllDFiles() error = %v", err)
+	}
+	if got := sourceFiles(deps); !reflect.DeepEqual(got, []string{"util/format.cc", "util/strings.cc"}) {
+		t.Fatalf("sources = %v, want format.cc and strings.cc", got)
+	}
+
+	// Callers may rewrite the returned dependencies without affecting the cache
+	deps[0].Dependencies[0] = "rewritten.h"
+
+	// Same size and modification time: the cached content is kept
+	writeDFile(t, formatFile, "format.o: util/format.cc util/format.x\n", modTime)
+	deps, err = cache.ParseAllDFiles(workspace, "")
+	if err != nil {
+		t.Fatalf("ParseAllDFiles() error = %v", err)
+	}
+	if got := deps[0].Dependencies; !reflect.DeepEqual(got, []string{"util/format.h"}) {
+		t.Errorf("unchanged format.d dependencies = %v, want the cached util/format.h", got)
+	}
+
+	// A changed file, a new file and a deleted one, reported by the watcher
+	writeDFile(t, stringsFile, "strings.o: util/strings.cc util/strings.h util/ascii.h\n", modTime.Add(time.Minute))
+	mathFile := filepath.Join(objs, "math.d")
+	writeDFile(t, mathFile, "math.o: util/math.cc util/math.h\n", modTime)
+	if err := os.Remove(formatFile); err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err = cache.ParseDFilesIncremental([]string{stringsFile, mathFile, formatFile, filepath.Join(objs, "math.o")})
+	if err != nil {
+		t.Fatalf("ParseDFilesIncremental() error = %v", err)
+	}
+	want := []*FileDependency{
+		{SourceFile: "util/strings.cc", Dependencies: []string{"util/strings.h", "util/ascii.h"}},
+		{SourceFile: "util/math.cc", Dependencies: []string{"util/math.h"}},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("ParseDFilesIncremental() = %+v, want %+v", deps, want)
+	}
+
+	// A PIC variant of an object with a plain .d file is ignored, like by FindDFiles
+	pic := filepath.Join(objs, "math.pic.d")
+	writeDFile(t, pic, "math.pic.o: util/math.cc util/math.h\n", modTime)
+	deps, err = cache.ParseDFilesIncremental([]string{pic})
+	if err != nil {
+		t.Fatalf("ParseDFilesIncremental() error = %v", err)
+	}
+	if got := sourceFiles(deps); !reflect.DeepEqual(got, []string{"util/strings.cc", "util/math.cc"}) {
+		t.Errorf("sources after adding math.pic.d = %v, want strings.cc and math.cc once", got)
+	}
+
+	// Paths reported through the bazel-bin symlink match those found in bazel-out
+	if err := os.Symlink(filepath.Join("bazel-out", "k8-fastbuild", "bin"), filepath.Join(workspace, "bazel-bin")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	writeDFile(t, mathFile, "math.o: util/math.cc util/math.h util/ascii.h\n", modTime.Add(time.Minute))
+	deps, err = cache.ParseDFilesIncremental([]string{filepath.Join(workspace, "bazel-bin", "util", "_objs", "util", "math.d")})
+	if err != nil {
+		t.Fatalf("ParseDFilesIncremental() error = %v", err)
+	}
+	if len(deps) != 2 || len(deps[1].Dependencies) != 2 {
+		t.Errorf("ParseDFilesIncremental() through bazel-bin = %+v, want math.cc updated in place", deps)
+	}
+}
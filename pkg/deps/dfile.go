@@ -7,17 +7,23 @@ import (
 	"strings"
 
 	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
 // FileDependency represents dependencies for a single source file
 type FileDependency struct {
 	SourceFile   string   // e.g., "util/math.cc"
 	Dependencies []string // e.g., ["util/math.h", "util/strings.h"]
+
+	// Dependencies #included by the source file itself rather than through another header,
+	// set by MarkDirectIncludes (nil if not determined)
+	DirectDependencies []string
 }
 
-// ParseDFile parses a Makefile-style .d dependency file
+// ParseDFile parses a Makefile-style .d dependency file. The first dependency with a
+// source extension of ext is taken as the source file.
 // Format: target.o: dep1.cc dep2.h dep3.h ...
-func ParseDFile(path string) (*FileDependency, error) {
+func ParseDFile(path string, ext model.FileExtensions) (*FileDependency, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -62,7 +68,7 @@ func ParseDFile(path string) (*FileDependency, error) {
 				}
 
 				// The first workspace file is typically the source file
-				if sourceFile == "" && (strings.HasSuffix(dep, ".cc") || strings.HasSuffix(dep, ".cpp")) {
+				if sourceFile == "" && ext.IsSource(dep) {
 					sourceFile = dep
 				} else {
 					// Add to dependencies (headers and other files)
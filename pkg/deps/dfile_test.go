@@ -1,9 +1,12 @@
 package deps
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
 func TestParseDFile(t *testing.T) {
@@ -11,7 +14,7 @@ func TestParseDFile(t *testing.T) {
 	examplePath := filepath.Join("..", "..", "example")
 	dfilePath := filepath.Join(examplePath, "bazel-out", "darwin_x86_64-fastbuild", "bin", "util", "_objs", "util", "math.d")
 
-	dep, err := ParseDFile(dfilePath)
+	dep, err := ParseDFile(dfilePath, model.FileExtensions{})
 	if err != nil {
 		t.Fatalf("ParseDFile() error = %v", err)
 	}
@@ -50,7 +53,7 @@ func TestParseDFileCrossPackage(t *testing.T) {
 	examplePath := filepath.Join("..", "..", "example")
 	dfilePath := filepath.Join(examplePath, "bazel-out", "darwin_x86_64-fastbuild", "bin", "core", "_objs", "core", "engine.d")
 
-	dep, err := ParseDFile(dfilePath)
+	dep, err := ParseDFile(dfilePath, model.FileExtensions{})
 	if err != nil {
 		t.Fatalf("ParseDFile() error = %v", err)
 	}
@@ -102,7 +105,7 @@ func TestIsWorkspaceFile(t *testing.T) {
 func TestFindDFiles(t *testing.T) {
 	examplePath := filepath.Join("..", "..", "example")
 
-	dfiles, err := FindDFiles(examplePath)
+	dfiles, err := FindDFiles(examplePath, "")
 	if err != nil {
 		t.Fatalf("FindDFiles() error = %v", err)
 	}
@@ -123,7 +126,7 @@ func TestFindDFiles(t *testing.T) {
 func TestParseAllDFiles(t *testing.T) {
 	examplePath := filepath.Join("..", "..", "example")
 
-	deps, err := ParseAllDFiles(examplePath)
+	deps, err := ParseAllDFiles(examplePath, "", model.FileExtensions{})
 	if err != nil {
 		t.Fatalf("ParseAllDFiles() error = %v", err)
 	}
@@ -150,3 +153,111 @@ func TestParseAllDFiles(t *testing.T) {
 		t.Error("Expected to find at least one cross-package dependency (core -> util)")
 	}
 }
+
+func TestFindDFilesInBazelBin(t *testing.T) {
+	// testdata/bazel_bin has only a bazel-bin directory, no bazel-out
+	deps, err := ParseAllDFiles(filepath.Join("testdata", "bazel_bin"), "", model.FileExtensions{})
+	if err != nil {
+		t.Fatalf("ParseAllDFiles() error = %v", err)
+	}
+
+	if len(deps) != 1 || deps[0].SourceFile != "util/strings.cc" {
+		t.Fatalf("expected util/strings.cc from bazel-bin, got %+v", deps)
+	}
+	if len(deps[0].Dependencies) != 1 || deps[0].Dependencies[0] != "util/strings.h" {
+		t.Errorf("unexpected dependencies: %v", deps[0].Dependencies)
+	}
+}
+
+func TestFindDFilesPIC(t *testing.T) {
+	// testdata/pic has a PIC-only object, an object compiled both plain and as PIC,
+	// and the .d file of a preprocessed file
+	deps, err := ParseAllDFiles(filepath.Join("testdata", "pic"), "", model.FileExtensions{})
+	if err != nil {
+		t.Fatalf("ParseAllDFiles() error = %v", err)
+	}
+
+	var sources []string
+	for _, dep := range deps {
+		sources = append(sources, dep.SourceFile)
+	}
+	if len(sources) != 2 || sources[0] != "util/format.cc" || sources[1] != "util/strings.cc" {
+		t.Fatalf("expected util/format.cc and util/strings.cc once each, got %v", sources)
+	}
+
+	dfiles, err := FindDFiles(filepath.Join("testdata", "pic"), "")
+	if err != nil {
+		t.Fatalf("FindDFiles() error = %v", err)
+	}
+	if len(dfiles) != 2 || filepath.Base(dfiles[0]) != "format.d" || filepath.Base(dfiles[1]) != "strings.pic.d" {
+		t.Errorf("expected format.d and strings.pic.d, got %v", dfiles)
+	}
+}
+
+func TestDFileStem(t *testing.T) {
+	tests := []struct {
+		base    string
+		stem    string
+		variant bool
+		ok      bool
+	}{
+		{"strings.d", "strings", false, true},
+		{"strings.pic.d", "strings", true, true},
+		{"strings.ii.d", "", false, false},
+		{"strings.s.d", "", false, false},
+		{"strings.pic.ii.d", "", false, false},
+		{"strings.o", "", false, false},
+		{".pic.d", "", false, false},
+	}
+	for _, tt := range tests {
+		stem, variant, ok := dFileStem(tt.base)
+		if stem != tt.stem || variant != tt.variant || ok != tt.ok {
+			t.Errorf("dFileStem(%q) = %q, %v, %v; want %q, %v, %v",
+				tt.base, stem, variant, ok, tt.stem, tt.variant, tt.ok)
+		}
+	}
+}
+
+func TestFindDFilesDedupesBazelBinSymlink(t *testing.T) {
+	// Mirror the usual layout where bazel-bin points into bazel-out
+	workspace := t.TempDir()
+	objs := filepath.Join(workspace, "bazel-out", "k8-fastbuild", "bin", "util", "_objs", "util")
+	if err := os.MkdirAll(objs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(objs, "strings.d"), []byte("strings.o: util/strings.cc util/strings.h\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("bazel-out", "k8-fastbuild", "bin"), filepath.Join(workspace, "bazel-bin")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	dfiles, err := FindDFiles(workspace, "")
+	if err != nil {
+		t.Fatalf("FindDFiles() error = %v", err)
+	}
+	if len(dfiles) != 1 {
+		t.Errorf("expected the .d file once, got %v", dfiles)
+	}
+}
+
+func TestFindDFilesBazelOutOverride(t *testing.T) {
+	// A bazel-out directory unpacked somewhere else, e.g. from a CI artifact
+	bazelOut := t.TempDir()
+	objs := filepath.Join(bazelOut, "k8-fastbuild", "bin", "util", "_objs", "util")
+	if err := os.MkdirAll(objs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(objs, "strings.d"), []byte("strings.o: util/strings.cc util/strings.h\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The workspace has no bazel-out of its own
+	deps, err := ParseAllDFiles(t.TempDir(), bazelOut, model.FileExtensions{})
+	if err != nil {
+		t.Fatalf("ParseAllDFiles() error = %v", err)
+	}
+	if len(deps) != 1 || deps[0].SourceFile != "util/strings.cc" {
+		t.Fatalf("expected util/strings.cc from the bazel-out override, got %+v", deps)
+	}
+}
@@ -0,0 +1,99 @@
+package deps
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+)
+
+// includeDirective matches #include "path" and #include <path>, allowing whitespace after
+// the # as in "#  include"
+var includeDirective = regexp.MustCompile(`^\s*#\s*(?:include|import)\s*["<]([^">]+)[">]`)
+
+// ParseIncludeDirectives returns the paths named by the #include (and Objective-C #import)
+// directives of a source file, as written. Includes in /* */ comments are skipped;
+// conditional compilation is not evaluated.
+func ParseIncludeDirectives(r io.Reader) ([]string, error) {
+	var includes []string
+	inComment := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inComment {
+			end := strings.Index(line, "*/")
+			if end < 0 {
+				continue
+			}
+			line = line[end+2:]
+			inComment = false
+		}
+		if start := strings.Index(line, "/*"); start >= 0 && !strings.Contains(line[start:], "*/") {
+			line = line[:start]
+			inComment = true
+		}
+
+		if match := includeDirective.FindStringSubmatch(line); match != nil {
+			includes = append(includes, match[1])
+		}
+	}
+	return includes, scanner.Err()
+}
+
+// MarkDirectIncludes sets the DirectDependencies of each file dependency to those of its
+// dependencies that the source file #includes itself. The others are pulled in
+// transitively. An include matches a dependency with the same path or ending in "/" and
+// the include path, as an include directory may be prepended. Sources that cannot be read
+// are left undetermined (nil). Returns the number of sources read.
+func MarkDirectIncludes(fileDeps []*FileDependency, workspaceRoot string) int {
+	marked := 0
+	for _, fileDep := range fileDeps {
+		file, err := os.Open(filepath.Join(workspaceRoot, fileDep.SourceFile))
+		if err != nil {
+			logging.Debug("cannot read source for direct includes", "source", fileDep.SourceFile, "error", err)
+			continue
+		}
+		includes, err := ParseIncludeDirectives(file)
+		_ = file.Close()
+		if err != nil {
+			logging.Debug("cannot read source for direct includes", "source", fileDep.SourceFile, "error", err)
+			continue
+		}
+
+		fileDep.DirectDependencies = directDependencies(fileDep.Dependencies, includes)
+		marked++
+	}
+	return marked
+}
+
+// directDependencies returns the dependencies named by an include, in dependency order
+func directDependencies(dependencies, includes []string) []string {
+	direct := make([]string, 0, len(includes))
+	seen := make(map[string]bool)
+	for _, dep := range dependencies {
+		if seen[dep] {
+			continue
+		}
+		for _, include := range includes {
+			include = filepath.ToSlash(filepath.Clean(include))
+			if dep == include || strings.HasSuffix(dep, "/"+include) {
+				direct = append(direct, dep)
+				seen[dep] = true
+				break
+			}
+		}
+	}
+	return direct
+}
+
+// IsDirect reports whether a dependency of the file is #included directly. It returns true
+// when the direct dependencies were not determined.
+func (d *FileDependency) IsDirect(dep string) bool {
+	return d.DirectDependencies == nil || slices.Contains(d.DirectDependencies, dep)
+}
@@ -0,0 +1,58 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseIncludeDirectives(t *testing.T) {
+	source := `#include "app/app.h"
+#  include <util/strings.h>
+// #include "commented/out.h"
+/* #include "block/comment.h"
+   #include "block/comment2.h" */
+#include <vector>
+int main() { return 0; }
+`
+	includes, err := ParseIncludeDirectives(strings.NewReader(source))
+	if err != nil {
+		t.Fatalf("ParseIncludeDirectives() error = %v", err)
+	}
+	want := []string{"app/app.h", "util/strings.h", "vector"}
+	if !reflect.DeepEqual(includes, want) {
+		t.Errorf("ParseIncludeDirectives() = %v, want %v", includes, want)
+	}
+}
+
+func TestMarkDirectIncludes(t *testing.T) {
+	workspace := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workspace, "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	source := "#include \"app/app.h\"\n#include \"strings.h\"\n"
+	if err := os.WriteFile(filepath.Join(workspace, "app", "main.cc"), []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// strings.h is found through -Iutil; util/math.h is only included by util/strings.h
+	main := &FileDependency{SourceFile: "app/main.cc", Dependencies: []string{"app/app.h", "util/strings.h", "util/math.h"}}
+	missing := &FileDependency{SourceFile: "app/missing.cc", Dependencies: []string{"app/app.h"}}
+
+	if marked := MarkDirectIncludes([]*FileDependency{main, missing}, workspace); marked != 1 {
+		t.Errorf("MarkDirectIncludes() = %d, want 1", marked)
+	}
+	if want := []string{"app/app.h", "util/strings.h"}; !reflect.DeepEqual(main.DirectDependencies, want) {
+		t.Errorf("DirectDependencies = %v, want %v", main.DirectDependencies, want)
+	}
+	if main.IsDirect("util/math.h") {
+		t.Error("util/math.h should be a transitive include")
+	}
+
+	// Undetermined direct includes count every dependency as direct
+	if missing.DirectDependencies != nil || !missing.IsDirect("app/app.h") {
+		t.Errorf("unreadable source should leave direct includes undetermined: %v", missing.DirectDependencies)
+	}
+}
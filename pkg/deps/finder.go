@@ -4,74 +4,116 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
-// FindDFiles finds all .d dependency files in the bazel-out directory
-func FindDFiles(workspaceRoot string) ([]string, error) {
-	var dfiles []string
-
-	// Search in bazel-out directory
-	bazelOutPath := filepath.Join(workspaceRoot, "bazel-out")
-
-	// Resolve symlink if bazel-out is a symlink
-	resolvedPath, err := filepath.EvalSymlinks(bazelOutPath)
-	if err != nil {
-		// If bazel-out doesn't exist or can't be resolved, return empty list (not an error)
-		if os.IsNotExist(err) {
-			return dfiles, nil
+// objectVariants are the infixes of .d files written for variants of an object file compiled
+// from the same source, e.g. "strings.pic.d" for the position-independent "strings.pic.o"
+var objectVariants = []string{".pic"}
+
+// dFileStem returns the name of a .d file without the .d suffix and any object variant
+// infix, e.g. "strings" for both "strings.d" and "strings.pic.d", and whether the name has
+// a variant infix. It reports false for the .d files of other outputs, such as preprocessed
+// ("strings.ii.d") or assembly ("strings.s.d") files.
+func dFileStem(base string) (stem string, variant bool, ok bool) {
+	stem, found := strings.CutSuffix(base, ".d")
+	if !found {
+		return "", false, false
+	}
+	for _, infix := range objectVariants {
+		if trimmed, found := strings.CutSuffix(stem, infix); found {
+			stem, variant = trimmed, true
+			break
 		}
-		return nil, fmt.Errorf("resolving bazel-out symlink: %w", err)
 	}
+	if stem == "" || strings.Contains(stem, ".") {
+		return "", false, false
+	}
+	return stem, variant, true
+}
 
-	logging.Debug("searching for .d files", "path", resolvedPath)
+// FindDFiles finds all .d dependency files in the bazel-out and bazel-bin directories,
+// or in bazelOut if set (see model.OutputRoots). Files reachable through both
+// are returned once. Where an object is compiled both plain and as a variant such as PIC,
+// only the plain object's .d file is returned, as both list the same dependencies.
+func FindDFiles(workspaceRoot, bazelOut string) ([]string, error) {
+	var dfiles []string
+	objects := make(map[string]int) // Directory and stem of each .d file -> index in dfiles
 
-	err = filepath.Walk(resolvedPath, func(path string, info os.FileInfo, err error) error {
+	for _, root := range model.OutputRoots(workspaceRoot, bazelOut) {
+		// Resolve symlink if the output directory is a symlink
+		resolvedPath, err := filepath.EvalSymlinks(root)
 		if err != nil {
-			return nil // Skip errors for individual files
+			// If the directory doesn't exist or can't be resolved, skip it (not an error)
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("resolving %s symlink: %w", root, err)
 		}
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
+		logging.Debug("searching for .d files", "path", resolvedPath)
 
-		// Only include .d files that don't have extra suffixes
-		// We want "math.d" but not "math.ii.d" or "math.s.d"
-		if filepath.Ext(path) == ".d" {
-			base := filepath.Base(path)
-			// Check if it's a simple .d file (e.g., "math.d" not "math.ii.d")
-			if strings.Count(base, ".") == 1 {
-				dfiles = append(dfiles, path)
+		err = filepath.Walk(resolvedPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil // Skip errors for individual files
 			}
-		}
 
-		return nil
-	})
+			// Skip directories
+			if info.IsDir() {
+				return nil
+			}
 
-	if err != nil {
-		return nil, fmt.Errorf("walking bazel-out directory: %w", err)
+			// We want "math.d" and "math.pic.d" but not "math.ii.d" or "math.s.d"
+			stem, variant, ok := dFileStem(filepath.Base(path))
+			if !ok {
+				return nil
+			}
+			object := filepath.Join(filepath.Dir(path), stem)
+			if i, exists := objects[object]; exists {
+				if !variant {
+					dfiles[i] = path
+				}
+				return nil
+			}
+			objects[object] = len(dfiles)
+			dfiles = append(dfiles, path)
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("walking %s directory: %w", root, err)
+		}
 	}
 
 	logging.Debug("found .d files", "count", len(dfiles))
 	return dfiles, nil
 }
 
-// ParseAllDFiles finds and parses all .d files in the workspace
-func ParseAllDFiles(workspaceRoot string) ([]*FileDependency, error) {
-	dfiles, err := FindDFiles(workspaceRoot)
+// ParseAllDFiles finds and parses all .d files of the workspace (see FindDFiles), using one
+// worker per CPU
+func ParseAllDFiles(workspaceRoot, bazelOut string, ext model.FileExtensions) ([]*FileDependency, error) {
+	return ParseAllDFilesParallel(workspaceRoot, bazelOut, runtime.NumCPU(), ext)
+}
+
+// ParseAllDFilesParallel finds and parses all .d files in the workspace using at most
+// the given number of parallel workers. The result order does not depend on the worker count.
+func ParseAllDFilesParallel(workspaceRoot, bazelOut string, workers int, ext model.FileExtensions) ([]*FileDependency, error) {
+	dfiles, err := FindDFiles(workspaceRoot, bazelOut)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse
+	parsed := parseDFiles(dfiles, workers, ext)
+
 	var deps []*FileDependency
-	for _, dfile := range dfiles {
-		dep, err := ParseDFile(dfile)
-		if err != nil {
-			logging.Debug("failed to parse dfile", "path", dfile, "error", err)
+	for i, dep := range parsed {
+		if dep == nil {
 			continue
 		}
 
@@ -79,7 +121,7 @@ func ParseAllDFiles(workspaceRoot string) ([]*FileDependency, error) {
 		if dep.SourceFile != "" {
 			deps = append(deps, dep)
 		} else {
-			logging.Debug("parsed dfile but no source file found", "path", dfile)
+			logging.Debug("parsed dfile but no source file found", "path", dfiles[i])
 		}
 	}
 
@@ -87,19 +129,46 @@ func ParseAllDFiles(workspaceRoot string) ([]*FileDependency, error) {
 	return deps, nil
 }
 
+// parseDFiles parses .d files using at most the given number of parallel workers. The
+// result has a slot per file, in the order of dfiles, which is nil if parsing failed.
+func parseDFiles(dfiles []string, workers int, ext model.FileExtensions) []*FileDependency {
+	parsed := make([]*FileDependency, len(dfiles))
+	sem := make(chan struct{}, max(workers, 1))
+	var wg sync.WaitGroup
+	for i, dfile := range dfiles {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			dep, err := ParseDFile(dfile, ext)
+			if err != nil {
+				logging.Debug("failed to parse dfile", "path", dfile, "error", err)
+				return
+			}
+			parsed[i] = dep
+		}()
+	}
+	wg.Wait()
+	return parsed
+}
+
 // Client abstracts the finding and parsing of .d files
 type Client interface {
-	ParseAllDFiles(workspaceRoot string) ([]*FileDependency, error)
+	ParseAllDFiles(workspaceRoot, bazelOut string) ([]*FileDependency, error)
 }
 
 // DefaultClient uses the actual filesystem
-type DefaultClient struct{}
+type DefaultClient struct {
+	ext model.FileExtensions
+}
 
-// NewClient creates a new default client
-func NewClient() Client {
-	return &DefaultClient{}
+// NewClient creates a new default client that finds source files by the extensions of ext
+func NewClient(ext model.FileExtensions) Client {
+	return &DefaultClient{ext: ext}
 }
 
-func (c *DefaultClient) ParseAllDFiles(workspaceRoot string) ([]*FileDependency, error) {
-	return ParseAllDFiles(workspaceRoot)
+func (c *DefaultClient) ParseAllDFiles(workspaceRoot, bazelOut string) ([]*FileDependency, error) {
+	return ParseAllDFiles(workspaceRoot, bazelOut, c.ext)
 }
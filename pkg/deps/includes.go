@@ -0,0 +1,44 @@
+package deps
+
+import (
+	"path"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+)
+
+// ResolveHeaderPaths rewrites dependency paths that were recorded relative to an include
+// directory into their canonical workspace-relative paths.
+//
+// includeDirs maps a source file to the workspace-relative include directories used to
+// compile it, and knownFiles is the set of workspace files owned by targets. A dependency
+// that is already a known file is left alone; otherwise the first include directory
+// that yields a known file wins. Unresolvable paths are kept as they are.
+// Returns the number of rewritten paths.
+func ResolveHeaderPaths(fileDeps []*FileDependency, includeDirs map[string][]string, knownFiles map[string]bool) int {
+	resolved := 0
+
+	for _, fileDep := range fileDeps {
+		dirs := includeDirs[fileDep.SourceFile]
+		if len(dirs) == 0 {
+			continue
+		}
+
+		for i, depFile := range fileDep.Dependencies {
+			if knownFiles[depFile] {
+				continue
+			}
+
+			for _, dir := range dirs {
+				candidate := path.Join(dir, depFile)
+				if knownFiles[candidate] {
+					logging.Debug("resolved include-relative header", "source", fileDep.SourceFile, "header", depFile, "resolved", candidate)
+					fileDep.Dependencies[i] = candidate
+					resolved++
+					break
+				}
+			}
+		}
+	}
+
+	return resolved
+}
@@ -0,0 +1,49 @@
+package deps
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestResolveHeaderPaths(t *testing.T) {
+	// main.d records headers found via -Iutil and -Ithird_party/include by their
+	// include-relative paths
+	dep, err := ParseDFile(filepath.Join("testdata", "include_dirs", "main.d"), model.FileExtensions{})
+	if err != nil {
+		t.Fatalf("ParseDFile() error = %v", err)
+	}
+
+	includeDirs := map[string][]string{
+		"app/main.cc": {"util", "third_party/include"},
+	}
+	knownFiles := map[string]bool{
+		"app/main.cc":                       true,
+		"app/app.h":                         true,
+		"util/strings.h":                    true,
+		"third_party/include/math/vector.h": true,
+	}
+
+	resolved := ResolveHeaderPaths([]*FileDependency{dep}, includeDirs, knownFiles)
+	if resolved != 2 {
+		t.Errorf("ResolveHeaderPaths() resolved %d paths, want 2", resolved)
+	}
+
+	want := []string{"app/app.h", "util/strings.h", "third_party/include/math/vector.h", "unknown.h"}
+	if !reflect.DeepEqual(dep.Dependencies, want) {
+		t.Errorf("Dependencies = %v, want %v", dep.Dependencies, want)
+	}
+}
+
+func TestResolveHeaderPathsWithoutIncludeDirs(t *testing.T) {
+	dep := &FileDependency{SourceFile: "app/main.cc", Dependencies: []string{"strings.h"}}
+
+	if resolved := ResolveHeaderPaths([]*FileDependency{dep}, nil, map[string]bool{"util/strings.h": true}); resolved != 0 {
+		t.Errorf("ResolveHeaderPaths() resolved %d paths, want 0", resolved)
+	}
+	if dep.Dependencies[0] != "strings.h" {
+		t.Errorf("expected path to be left unchanged, got %s", dep.Dependencies[0])
+	}
+}
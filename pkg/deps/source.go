@@ -14,10 +14,11 @@ type CompileDepsSource struct {
 	client Client
 }
 
-// NewCompileDepsSource creates a new compile dependencies source
-func NewCompileDepsSource() api.Source {
+// NewCompileDepsSource creates a new compile dependencies source that finds source files
+// by the extensions of ext
+func NewCompileDepsSource(ext model.FileExtensions) api.Source {
 	return &CompileDepsSource{
-		client: NewClient(),
+		client: NewClient(ext),
 	}
 }
 
@@ -30,7 +31,7 @@ func (s *CompileDepsSource) Run(ctx context.Context, cfg *config.Config) (*model
 	logger.Info("Starting compile dependencies analysis", "workspace", cfg.Workspace)
 
 	// Reuse existing logic to parse all .d files via client
-	deps, err := s.client.ParseAllDFiles(cfg.Workspace)
+	deps, err := s.client.ParseAllDFiles(cfg.Workspace, cfg.BazelOutPath)
 	if err != nil {
 		return nil, err
 	}
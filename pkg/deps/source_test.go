@@ -13,7 +13,7 @@ type MockClient struct {
 	MockErr  error
 }
 
-func (m *MockClient) ParseAllDFiles(workspaceRoot string) ([]*FileDependency, error) {
+func (m *MockClient) ParseAllDFiles(workspaceRoot, bazelOut string) ([]*FileDependency, error) {
 	return m.MockDeps, m.MockErr
 }
 
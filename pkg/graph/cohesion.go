@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"sort"
+)
+
+// DefaultSplitMinFiles is the number of files a target needs before it is checked for
+// disconnected file groups: smaller targets are cheap to keep together
+const DefaultSplitMinFiles = 4
+
+// SplitCandidate is a target whose files form groups with no compile dependencies between
+// them, a sign that it bundles unrelated code and could be split along the groups
+type SplitCandidate struct {
+	Target     string     `json:"target"`     // e.g., "//util:util"
+	Components [][]string `json:"components"` // File groups, largest first, each sorted by path
+}
+
+// FindSplitCandidates finds the targets with at least minFiles files in the graph whose
+// files fall into more than one connected component of the compile edges between the
+// target's own files. Edge direction is ignored. The result is sorted by target.
+func FindSplitCandidates(fg *FileGraph, fileToTarget map[string]string, minFiles int) []SplitCandidate {
+	// Union-find over the files of each target
+	parent := make(map[string]string)
+	var find func(file string) string
+	find = func(file string) string {
+		if parent[file] != file {
+			parent[file] = find(parent[file])
+		}
+		return parent[file]
+	}
+
+	filesByTarget := make(map[string][]string)
+	for _, node := range fg.Nodes() {
+		if target := fileToTarget[node.Path]; target != "" {
+			filesByTarget[target] = append(filesByTarget[target], node.Path)
+			parent[node.Path] = node.Path
+		}
+	}
+	for _, edge := range fg.Edges() {
+		target := fileToTarget[edge[0]]
+		if target == "" || fileToTarget[edge[1]] != target {
+			continue
+		}
+		parent[find(edge[0])] = find(edge[1])
+	}
+
+	result := make([]SplitCandidate, 0)
+	for target, files := range filesByTarget {
+		if len(files) < minFiles {
+			continue
+		}
+
+		groups := make(map[string][]string)
+		for _, file := range files {
+			root := find(file)
+			groups[root] = append(groups[root], file)
+		}
+		if len(groups) < 2 {
+			continue
+		}
+
+		components := make([][]string, 0, len(groups))
+		for _, group := range groups {
+			sort.Strings(group)
+			components = append(components, group)
+		}
+		sort.Slice(components, func(i, j int) bool {
+			if len(components[i]) != len(components[j]) {
+				return len(components[i]) > len(components[j])
+			}
+			return components[i][0] < components[j][0]
+		})
+		result = append(result, SplitCandidate{Target: target, Components: components})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Target < result[j].Target })
+	return result
+}
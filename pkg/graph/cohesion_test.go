@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/deps"
+)
+
+func TestFindSplitCandidates(t *testing.T) {
+	fg := BuildFileGraph([]*deps.FileDependency{
+		{SourceFile: "util/strings.cc", Dependencies: []string{"util/strings.h"}},
+		{SourceFile: "util/split.cc", Dependencies: []string{"util/strings.h", "util/split.h"}},
+		{SourceFile: "util/clock.cc", Dependencies: []string{"util/clock.h", "core/types.h"}},
+		{SourceFile: "core/types.cc", Dependencies: []string{"core/types.h", "util/strings.h"}},
+	})
+	fileToTarget := map[string]string{
+		"util/strings.cc": "//util:util",
+		"util/strings.h":  "//util:util",
+		"util/split.cc":   "//util:util",
+		"util/split.h":    "//util:util",
+		"util/clock.cc":   "//util:util",
+		"util/clock.h":    "//util:util",
+		"core/types.cc":   "//core:core",
+		"core/types.h":    "//core:core",
+	}
+
+	want := []SplitCandidate{{
+		Target: "//util:util",
+		Components: [][]string{
+			{"util/split.cc", "util/split.h", "util/strings.cc", "util/strings.h"},
+			{"util/clock.cc", "util/clock.h"},
+		},
+	}}
+	// Edges through files of other targets do not connect the groups
+	if got := FindSplitCandidates(fg, fileToTarget, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindSplitCandidates() = %+v, want %+v", got, want)
+	}
+
+	// Targets with fewer files than the minimum are not checked
+	if got := FindSplitCandidates(fg, fileToTarget, 7); len(got) != 0 {
+		t.Errorf("FindSplitCandidates() with minFiles 7 = %+v, want none", got)
+	}
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// CrossPackageDep is a file-level dependency whose files belong to different packages
+type CrossPackageDep struct {
+	SourceFile    string `json:"sourceFile"`             // e.g., "core/engine.cc"
+	TargetFile    string `json:"targetFile"`             // e.g., "util/strings.h"
+	SourcePackage string `json:"sourcePackage"`          // e.g., "//core"
+	TargetPackage string `json:"targetPackage"`          // e.g., "//util"
+	SourceTarget  string `json:"sourceTarget,omitempty"` // Target owning the source file (if known)
+	TargetTarget  string `json:"targetTarget,omitempty"` // Target owning the target file (if known)
+}
+
+// FindCrossPackageDepsWithTargets returns all file dependencies in the graph that cross a
+// package boundary, resolving the owning target of each file through fileToTarget.
+// Files without an owning target are assigned to the package of their directory.
+// The result is sorted by source file, then target file.
+func FindCrossPackageDepsWithTargets(fg *FileGraph, fileToTarget map[string]string) []CrossPackageDep {
+	result := make([]CrossPackageDep, 0)
+
+	for _, edge := range fg.Edges() {
+		sourceTarget := fileToTarget[edge[0]]
+		targetTarget := fileToTarget[edge[1]]
+		sourcePackage := packageOf(edge[0], sourceTarget)
+		targetPackage := packageOf(edge[1], targetTarget)
+
+		if sourcePackage == targetPackage {
+			continue
+		}
+
+		result = append(result, CrossPackageDep{
+			SourceFile:    edge[0],
+			TargetFile:    edge[1],
+			SourcePackage: sourcePackage,
+			TargetPackage: targetPackage,
+			SourceTarget:  sourceTarget,
+			TargetTarget:  targetTarget,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].SourceFile != result[j].SourceFile {
+			return result[i].SourceFile < result[j].SourceFile
+		}
+		return result[i].TargetFile < result[j].TargetFile
+	})
+
+	return result
+}
+
+// packageOf returns the package of a file: the package of its owning target if known,
+// otherwise the directory of the file
+func packageOf(filePath, targetLabel string) string {
+	if pkg, _, found := strings.Cut(targetLabel, ":"); found {
+		return pkg
+	}
+
+	dir := path.Dir(filePath)
+	if dir == "." {
+		return "//"
+	}
+	return "//" + dir
+}
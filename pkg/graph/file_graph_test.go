@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/ritzau/deps-analyzer/pkg/deps"
+	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
 func TestNewFileGraph(t *testing.T) {
@@ -88,7 +89,7 @@ func TestFileGetDependencies(t *testing.T) {
 func TestBuildFileGraph(t *testing.T) {
 	examplePath := filepath.Join("..", "..", "example")
 
-	fileDeps, err := deps.ParseAllDFiles(examplePath)
+	fileDeps, err := deps.ParseAllDFiles(examplePath, "", model.FileExtensions{})
 	if err != nil {
 		t.Fatalf("ParseAllDFiles() error = %v", err)
 	}
@@ -116,3 +117,31 @@ func TestBuildFileGraph(t *testing.T) {
 		t.Error("Expected core/engine.cc to depend on util headers")
 	}
 }
+
+func TestFindCrossPackageDepsWithTargets(t *testing.T) {
+	fg := BuildFileGraph([]*deps.FileDependency{
+		{SourceFile: "core/engine.cc", Dependencies: []string{"core/engine.h", "util/strings.h", "third_party/json.h"}},
+		{SourceFile: "util/strings.cc", Dependencies: []string{"util/strings.h"}},
+	})
+	fileToTarget := map[string]string{
+		"core/engine.cc":  "//core:core",
+		"core/engine.h":   "//core:core",
+		"util/strings.h":  "//util:util",
+		"util/strings.cc": "//util:util",
+	}
+
+	got := FindCrossPackageDepsWithTargets(fg, fileToTarget)
+
+	want := []CrossPackageDep{
+		{SourceFile: "core/engine.cc", TargetFile: "third_party/json.h", SourcePackage: "//core", TargetPackage: "//third_party", SourceTarget: "//core:core"},
+		{SourceFile: "core/engine.cc", TargetFile: "util/strings.h", SourcePackage: "//core", TargetPackage: "//util", SourceTarget: "//core:core", TargetTarget: "//util:util"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d cross-package deps, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("dep %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -12,7 +12,7 @@ type GraphDiff struct {
 	RemovedNodes  []string    `json:"removedNodes"`  // Node IDs
 	ModifiedNodes []GraphNode `json:"modifiedNodes"` // Nodes with changed properties
 	AddedEdges    []GraphEdge `json:"addedEdges"`
-	RemovedEdges  []string    `json:"removedEdges"` // Edge IDs (source|target|type)
+	RemovedEdges  []string    `json:"removedEdges"` // Edge IDs (see EdgeID)
 	FullGraph     bool        `json:"fullGraph"`    // True if this is a full graph, not a diff
 }
 
@@ -20,20 +20,22 @@ type GraphDiff struct {
 type GraphSnapshot struct {
 	Hash  string
 	Nodes map[string]GraphNode // nodeID -> node
-	Edges map[string]GraphEdge // edgeKey -> edge
+	Edges map[string]GraphEdge // edge ID -> edge
 }
 
 // ComputeHash generates a hash for the request to identify cache entries
-func ComputeHash(defaultLens, detailLens *LensConfig, selectedNodes []string) string {
+func ComputeHash(defaultLens, detailLens *LensConfig, selectedNodes []string, focusMode FocusMode) string {
 	// Serialize the request to JSON for hashing
 	data := struct {
 		DefaultLens   *LensConfig
 		DetailLens    *LensConfig
 		SelectedNodes []string
+		FocusMode     FocusMode
 	}{
 		DefaultLens:   defaultLens,
 		DetailLens:    detailLens,
 		SelectedNodes: selectedNodes,
+		FocusMode:     focusMode,
 	}
 
 	jsonData, err := json.Marshal(data)
@@ -57,10 +59,9 @@ func CreateSnapshot(graph *GraphData) *GraphSnapshot {
 		snapshot.Nodes[node.ID] = node
 	}
 
-	// Index edges by key (source|target|type)
+	// Index edges by ID
 	for _, edge := range graph.Edges {
-		key := edgeKey(edge.Source, edge.Target, edge.Type)
-		snapshot.Edges[key] = edge
+		snapshot.Edges[edge.ID] = edge
 	}
 
 	// Compute hash of the graph
@@ -100,8 +101,7 @@ func ComputeDiff(oldSnapshot *GraphSnapshot, newGraph *GraphData) *GraphDiff {
 	}
 
 	for _, edge := range newGraph.Edges {
-		key := edgeKey(edge.Source, edge.Target, edge.Type)
-		newEdges[key] = edge
+		newEdges[edge.ID] = edge
 	}
 
 	// Find added and modified nodes
@@ -141,9 +141,11 @@ func ComputeDiff(oldSnapshot *GraphSnapshot, newGraph *GraphData) *GraphDiff {
 	return diff
 }
 
-// edgeKey creates a unique key for an edge
-func edgeKey(source, target, edgeType string) string {
-	return fmt.Sprintf("%s|%s|%s", source, target, edgeType)
+// EdgeID returns the identity of an edge: "<source>|<target>|<type>", e.g.
+// "//main:app|//util:util|static". Rendered graphs have at most one edge per ID. The format
+// is stable, clients use it to apply diffs to the edges they hold.
+func EdgeID(source, target, edgeType string) string {
+	return source + "|" + target + "|" + edgeType
 }
 
 // nodesEqual checks if two nodes are equal (excluding position)
@@ -1,6 +1,7 @@
 package lens
 
 import (
+	"slices"
 	"strings"
 )
 
@@ -11,10 +12,14 @@ type GraphNode struct {
 	Type            string
 	Parent          string
 	LddDependencies []string
+	Tags            []string
+	Owner           string
+	External        bool // Target in an external repository
 }
 
 // GraphEdge represents an edge in the dependency graph (temporary, mirrors web.GraphEdge)
 type GraphEdge struct {
+	ID     string // See EdgeID
 	Source string
 	Target string
 	Type   string
@@ -91,9 +96,28 @@ func expandPackagesToTargets(selectedNodes []string, graph *GraphData) []string
 	return result
 }
 
+// FocusMode controls how multiple selected nodes combine when computing distances
+type FocusMode string
+
+const (
+	// FocusUnion measures the distance to the nearest selected node (the default)
+	FocusUnion FocusMode = "union"
+
+	// FocusIntersection measures the distance to the farthest selected node, so only the
+	// nodes close to all of them are near. This shows what lies between subsystems.
+	FocusIntersection FocusMode = "intersection"
+)
+
 // ComputeDistances calculates shortest distance from each node to nearest selected node
 // Returns a map of nodeID -> distance (int or "infinite")
 func ComputeDistances(graph *GraphData, selectedNodes []string) map[string]interface{} {
+	return ComputeDistancesWithMode(graph, selectedNodes, FocusUnion)
+}
+
+// ComputeDistancesWithMode is like ComputeDistances, but with FocusIntersection the distance
+// of a node is its distance to the farthest selected node (infinite if any of them cannot be
+// reached). Selected nodes are always at distance 0. A selected package counts as one focus.
+func ComputeDistancesWithMode(graph *GraphData, selectedNodes []string, mode FocusMode) map[string]interface{} {
 	distances := make(map[string]interface{})
 
 	// If no selected nodes, all distances are infinite
@@ -107,13 +131,82 @@ func ComputeDistances(graph *GraphData, selectedNodes []string) map[string]inter
 	// Build adjacency list (undirected graph for distance computation)
 	adjacency := buildAdjacencyList(graph)
 
-	// Expand selected nodes: if a package is selected (e.g., "//main"), include all its targets
-	// This ensures that clicking on a package selects all targets within it
-	expandedSelectedNodes := expandPackagesToTargets(selectedNodes, graph)
+	if mode == FocusIntersection && len(selectedNodes) > 1 {
+		// Distances from each focus separately, expanding packages to their targets
+		focusDistances := make([]map[string]int, len(selectedNodes))
+		for i, nodeID := range selectedNodes {
+			focusDistances[i] = bfsDistances(adjacency, expandPackagesToTargets([]string{nodeID}, graph))
+		}
+
+		for nodeID, distance := range focusDistances[0] {
+			farthest := distance
+			for _, other := range focusDistances[1:] {
+				d, reached := other[nodeID]
+				if !reached {
+					farthest = -1
+					break
+				}
+				farthest = max(farthest, d)
+			}
+			if farthest >= 0 {
+				distances[nodeID] = farthest
+			}
+		}
+
+		// Selected nodes stay in focus even if far from the others
+		for _, focus := range focusDistances {
+			for nodeID, distance := range focus {
+				if distance == 0 {
+					distances[nodeID] = 0
+				}
+			}
+		}
+	} else {
+		// Expand selected nodes: if a package is selected (e.g., "//main"), include all its targets
+		// This ensures that clicking on a package selects all targets within it
+		for nodeID, distance := range bfsDistances(adjacency, expandPackagesToTargets(selectedNodes, graph)) {
+			distances[nodeID] = distance
+		}
+	}
 
-	// Initialize BFS queue with selected nodes at distance 0
+	// When focusing on files, show each file reached within its target and package: these
+	// are as close as the file, but no closer than one step from a selected file
+	if slices.ContainsFunc(selectedNodes, isFileNodeID) {
+		parents := make(map[string]string, len(graph.Nodes))
+		for _, node := range graph.Nodes {
+			parents[node.ID] = node.Parent
+		}
+		for _, node := range graph.Nodes {
+			distance, reached := distances[node.ID].(int)
+			if !reached || !isFileNodeID(node.ID) {
+				continue
+			}
+			distance = max(distance, 1)
+			for ancestor := parentOf(node.ID, parents); ancestor != ""; ancestor = parentOf(ancestor, parents) {
+				if d, ok := distances[ancestor].(int); !ok || d > distance {
+					distances[ancestor] = distance
+				}
+			}
+		}
+	}
+
+	// Handle nodes not reached by BFS - inherit from parent or mark as infinite
+	for _, node := range graph.Nodes {
+		if _, exists := distances[node.ID]; !exists {
+			distances[node.ID] = getInheritedDistance(node.ID, node.Parent, distances)
+		}
+	}
+
+	return distances
+}
+
+// bfsDistances returns the shortest distance from any of the sources to each reachable node
+func bfsDistances(adjacency map[string][]string, sources []string) map[string]int {
+	distances := make(map[string]int)
+
+	// Initialize BFS queue with the sources at distance 0
 	queue := []distanceQueueNode{}
-	for _, nodeID := range expandedSelectedNodes {
+	for _, nodeID := range sources {
 		distances[nodeID] = 0
 		queue = append(queue, distanceQueueNode{nodeID: nodeID, distance: 0})
 	}
@@ -132,13 +225,6 @@ func ComputeDistances(graph *GraphData, selectedNodes []string) map[string]inter
 		}
 	}
 
-	// Handle nodes not reached by BFS - inherit from parent or mark as infinite
-	for _, node := range graph.Nodes {
-		if _, exists := distances[node.ID]; !exists {
-			distances[node.ID] = getInheritedDistance(node.ID, node.Parent, distances)
-		}
-	}
-
 	return distances
 }
 
@@ -155,6 +241,23 @@ func buildAdjacencyList(graph *GraphData) map[string][]string {
 	return adjacency
 }
 
+// isFileNodeID reports whether a node ID is that of a file, e.g. "//util:util:util/strings.cc"
+// or "uncovered:util/orphaned.cc"
+func isFileNodeID(nodeID string) bool {
+	return strings.HasPrefix(nodeID, "uncovered:") || getNodeHierarchyLevel(nodeID, "") == 3
+}
+
+// parentOf returns the parent of a node, from the graph if set there and otherwise from its ID
+func parentOf(nodeID string, parents map[string]string) string {
+	if parent := parents[nodeID]; parent != "" {
+		return parent
+	}
+	if parent := extractParentID(nodeID); parent != nodeID {
+		return parent
+	}
+	return ""
+}
+
 // getInheritedDistance recursively inherits distance from parent nodes
 // This handles cases where child nodes (like files) should inherit the distance of their parent (target/package)
 func getInheritedDistance(nodeID string, parentID string, distances map[string]interface{}) interface{} {
@@ -0,0 +1,97 @@
+package lens
+
+import (
+	"reflect"
+	"testing"
+)
+
+// focusTestGraph is a chain between two subsystems, with a branch hanging off each end:
+//
+//	//audio:mixer - //audio:codec - //core:buffer - //video:decoder - //video:scaler
+func focusTestGraph() *GraphData {
+	return &GraphData{
+		Nodes: []GraphNode{
+			{ID: "//audio:mixer", Type: "cc_library"},
+			{ID: "//audio:codec", Type: "cc_library"},
+			{ID: "//core:buffer", Type: "cc_library"},
+			{ID: "//video:decoder", Type: "cc_library"},
+			{ID: "//video:scaler", Type: "cc_library"},
+			{ID: "//core:buffer:buffer.cc", Type: "source", Parent: "//core:buffer"},
+			{ID: "//tools:lint", Type: "cc_binary"},
+		},
+		Edges: []GraphEdge{
+			{Source: "//audio:mixer", Target: "//audio:codec", Type: "static"},
+			{Source: "//audio:codec", Target: "//core:buffer", Type: "static"},
+			{Source: "//video:decoder", Target: "//core:buffer", Type: "static"},
+			{Source: "//video:scaler", Target: "//video:decoder", Type: "static"},
+		},
+	}
+}
+
+func TestComputeDistancesUnion(t *testing.T) {
+	distances := ComputeDistancesWithMode(focusTestGraph(), []string{"//audio:mixer", "//video:scaler"}, FocusUnion)
+
+	want := map[string]interface{}{
+		"//audio:mixer":           0,
+		"//audio:codec":           1,
+		"//core:buffer":           2,
+		"//video:decoder":         1,
+		"//video:scaler":          0,
+		"//core:buffer:buffer.cc": 2,
+		"//tools:lint":            "infinite",
+	}
+	if !reflect.DeepEqual(distances, want) {
+		t.Errorf("union distances = %v, want %v", distances, want)
+	}
+}
+
+func TestComputeDistancesIntersection(t *testing.T) {
+	distances := ComputeDistancesWithMode(focusTestGraph(), []string{"//audio:mixer", "//video:scaler"}, FocusIntersection)
+
+	// Distance to the farthest focus: the shared //core:buffer is the closest to both
+	want := map[string]interface{}{
+		"//audio:mixer":           0,
+		"//audio:codec":           3,
+		"//core:buffer":           2,
+		"//video:decoder":         3,
+		"//video:scaler":          0,
+		"//core:buffer:buffer.cc": 2,
+		"//tools:lint":            "infinite",
+	}
+	if !reflect.DeepEqual(distances, want) {
+		t.Errorf("intersection distances = %v, want %v", distances, want)
+	}
+}
+
+func TestComputeDistancesIntersectionOfPackages(t *testing.T) {
+	// A selected package is one focus: its targets are all at distance 0
+	distances := ComputeDistancesWithMode(focusTestGraph(), []string{"//audio", "//video"}, FocusIntersection)
+
+	if distances["//core:buffer"] != 1 {
+		t.Errorf("//core:buffer distance = %v, want 1", distances["//core:buffer"])
+	}
+	if distances["//audio:mixer"] != 0 || distances["//video:decoder"] != 0 {
+		t.Errorf("expected selected package targets at distance 0, got %v", distances)
+	}
+}
+
+func TestComputeDistancesFocusedFile(t *testing.T) {
+	graph := focusTestGraph()
+	graph.Nodes = append(graph.Nodes, GraphNode{ID: "//audio:codec:codec.cc", Type: "source", Parent: "//audio:codec"})
+	graph.Edges = append(graph.Edges, GraphEdge{Source: "//audio:codec:codec.cc", Target: "//core:buffer:buffer.cc", Type: "symbol"})
+
+	distances := ComputeDistances(graph, []string{"//audio:codec:codec.cc"})
+
+	// The file's symbol dependency is next to it, and so is its own target even though
+	// the lens graph has no edge between a file and its target
+	want := map[string]interface{}{
+		"//audio:codec:codec.cc":  0,
+		"//core:buffer:buffer.cc": 1,
+		"//audio:codec":           1,
+	}
+	for nodeID, distance := range want {
+		if distances[nodeID] != distance {
+			t.Errorf("%s distance = %v, want %v", nodeID, distances[nodeID], distance)
+		}
+	}
+}
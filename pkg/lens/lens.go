@@ -7,8 +7,34 @@ type LensConfig struct {
 	DistanceRules []DistanceRule   `json:"distanceRules"`
 	GlobalFilters GlobalFilters    `json:"globalFilters"`
 	EdgeRules     EdgeDisplayRules `json:"edgeRules"`
+
+	// How top-level nodes are clustered: "" keeps packages at the top, GroupByOwner puts
+	// them in one node per CODEOWNERS owner. Only the default lens' setting is used.
+	GroupBy string `json:"groupBy,omitempty"`
+
+	// Targets showing more files than this, spread over subdirectories of their package, get
+	// one node per subdirectory grouping its files. 0 never groups. Only the default lens'
+	// setting is used.
+	GroupFilesAbove int `json:"groupFilesAbove,omitempty"`
+
+	// Collapse states set by the user, overriding those of the distance rules. Only the
+	// default lens' overrides are used.
+	Overrides []ManualOverride `json:"overrides,omitempty"`
+}
+
+// ManualOverride fixes whether a node is collapsed, regardless of its distance. With
+// Package set, the node is a package and the override applies to the package, its
+// subpackages and everything in them, e.g. {"nodeId": "//third_party", "collapsed": true,
+// "package": true} shows each third-party package as an opaque black box.
+type ManualOverride struct {
+	NodeID    string `json:"nodeId"`
+	Collapsed bool   `json:"collapsed"`
+	Package   bool   `json:"package,omitempty"`
 }
 
+// GroupByOwner clusters packages and other top-level nodes by CODEOWNERS owner
+const GroupByOwner = "owner"
+
 // BaseSetConfig determines the base set of nodes to consider
 type BaseSetConfig struct {
 	Type        string  `json:"type"` // "full-graph", "reachable-from-binary", "package-level"
@@ -40,6 +66,13 @@ type GlobalFilters struct {
 	HideUncovered   bool `json:"hideUncovered,omitempty"`
 	HideSystemLibs  bool `json:"hideSystemLibs,omitempty"`
 	HideNonBinaries bool `json:"hideNonBinaries,omitempty"`
+	HideTests       bool `json:"hideTests,omitempty"` // Hide cc_test targets and their files
+
+	// Hide targets no cc_binary or cc_test depends on, directly or transitively, and their files
+	HideUnreachable bool `json:"hideUnreachable,omitempty"`
+
+	// Hide targets carrying any of these Bazel tags (e.g., "manual") and their files
+	HideTags []string `json:"hideTags,omitempty"`
 }
 
 // EdgeDisplayRules control which edges are shown
@@ -0,0 +1,134 @@
+package lens
+
+// allEdgeTypes lists every edge type the renderer knows about
+var allEdgeTypes = []string{"static", "dynamic", "system_link", "data", "compile", "symbol"}
+
+// allTargetTypes lists the target kinds shown by the built-in lenses
+var allTargetTypes = []string{"cc_binary", "cc_shared_library", "cc_library", "cc_test"}
+
+// PackageLens returns a lens showing the targets of one package (distance 0) and the
+// targets they are directly connected to in other packages (distance 1). Files and the
+// rest of the graph are hidden. Render it with the package path as the selected node.
+func PackageLens(packagePath string) *LensConfig {
+	targets := func() NodeVisibility {
+		return NodeVisibility{
+			TargetTypes:         append([]string(nil), allTargetTypes...),
+			FileTypes:           []string{"none"},
+			ShowExternal:        true,
+			ShowSystemLibraries: true,
+		}
+	}
+
+	return &LensConfig{
+		Name: "Package " + packagePath,
+		BaseSet: BaseSetConfig{
+			Type:        "package-level",
+			PackagePath: &packagePath,
+		},
+		DistanceRules: []DistanceRule{
+			{
+				Distance:       0, // Targets in the package
+				NodeVisibility: targets(),
+				CollapseLevel:  2, // Show targets but hide files
+				ShowEdges:      true,
+				EdgeTypes:      append([]string(nil), allEdgeTypes...),
+			},
+			{
+				Distance:       1, // Direct neighbors in other packages
+				NodeVisibility: targets(),
+				CollapseLevel:  2,
+				ShowEdges:      true,
+				EdgeTypes:      append([]string(nil), allEdgeTypes...),
+			},
+			{
+				Distance: "infinite", // Rest of the graph is hidden
+				NodeVisibility: NodeVisibility{
+					TargetTypes: []string{},
+					FileTypes:   []string{"none"},
+				},
+				ShowEdges: false,
+				EdgeTypes: []string{},
+			},
+		},
+		EdgeRules: EdgeDisplayRules{
+			Types:              append([]string(nil), allEdgeTypes...),
+			AggregateCollapsed: true,
+		},
+	}
+}
+
+// FileLens returns a lens showing one file (distance 0), its target and the files it
+// includes, is included by or shares symbols with (distance 1). Everything further away is
+// hidden. Render it with the file's node ID as the selected node.
+func FileLens(filePath string) *LensConfig {
+	files := func() NodeVisibility {
+		return NodeVisibility{
+			TargetTypes:         append([]string(nil), allTargetTypes...),
+			FileTypes:           []string{"all"},
+			ShowUncovered:       true,
+			ShowExternal:        true,
+			ShowSystemLibraries: true,
+		}
+	}
+
+	return &LensConfig{
+		Name:    "File " + filePath,
+		BaseSet: BaseSetConfig{Type: "full-graph"},
+		DistanceRules: []DistanceRule{
+			{
+				Distance:       0, // The file
+				NodeVisibility: files(),
+				CollapseLevel:  3,
+				ShowEdges:      true,
+				EdgeTypes:      append([]string(nil), allEdgeTypes...),
+			},
+			{
+				Distance:       1, // Its target and the files it touches
+				NodeVisibility: files(),
+				CollapseLevel:  3,
+				ShowEdges:      true,
+				EdgeTypes:      append([]string(nil), allEdgeTypes...),
+			},
+			{
+				Distance: "infinite", // Rest of the graph is hidden
+				NodeVisibility: NodeVisibility{
+					TargetTypes: []string{},
+					FileTypes:   []string{"none"},
+				},
+				ShowEdges: false,
+				EdgeTypes: []string{},
+			},
+		},
+		EdgeRules: EdgeDisplayRules{
+			Types:              append([]string(nil), allEdgeTypes...),
+			AggregateCollapsed: true,
+		},
+	}
+}
+
+// OverviewLens returns a lens showing all targets of the graph without their files, grouped
+// by package. It matches the web UI's default lens (DEFAULT_PACKAGE_LENS).
+func OverviewLens() *LensConfig {
+	return &LensConfig{
+		Name:    "Package View",
+		BaseSet: BaseSetConfig{Type: "full-graph"},
+		DistanceRules: []DistanceRule{
+			{
+				Distance: "infinite",
+				NodeVisibility: NodeVisibility{
+					TargetTypes:         append([]string(nil), allTargetTypes...),
+					FileTypes:           []string{"none"},
+					ShowExternal:        true,
+					ShowSystemLibraries: true,
+				},
+				CollapseLevel: 2, // Show targets but hide files
+				ShowEdges:     true,
+				EdgeTypes:     append([]string(nil), allEdgeTypes...),
+			},
+		},
+		EdgeRules: EdgeDisplayRules{
+			Types:              append([]string(nil), allEdgeTypes...),
+			AggregateCollapsed: true,
+		},
+	}
+}
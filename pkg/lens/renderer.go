@@ -1,7 +1,6 @@
 package lens
 
 import (
-	"fmt"
 	"sort"
 	"strings"
 
@@ -10,12 +9,13 @@ import (
 
 // RenderGraph applies lens transformations to raw graph data
 // This is the main entry point for the lens rendering pipeline
-func RenderGraph(rawGraph *GraphData, defaultLens, detailLens *LensConfig, selectedNodes []string) (*GraphData, error) {
+// focusMode controls how distances to multiple selected nodes combine ("" means FocusUnion)
+func RenderGraph(rawGraph *GraphData, defaultLens, detailLens *LensConfig, selectedNodes []string, focusMode FocusMode) (*GraphData, error) {
 	logging.Debug("rendering graph", "nodeCount", len(rawGraph.Nodes))
-	logging.Debug("selected nodes", "nodes", selectedNodes)
+	logging.Debug("selected nodes", "nodes", selectedNodes, "focusMode", focusMode)
 
 	// 1. Compute distances from selected nodes using BFS
-	distances := ComputeDistances(rawGraph, selectedNodes)
+	distances := ComputeDistancesWithMode(rawGraph, selectedNodes, focusMode)
 
 	// 2. Assign which lens controls each node (default or detail)
 	nodeLensMap := assignLensesToNodes(distances, selectedNodes)
@@ -95,6 +95,9 @@ func RenderGraph(rawGraph *GraphData, defaultLens, detailLens *LensConfig, selec
 		}
 	}
 
+	// Manual overrides take precedence over the distance rules
+	applyManualOverrides(nodeStates, defaultLens.Overrides)
+
 	// 5. Combine raw nodes with package nodes for visibility filtering
 	allNodes := append([]GraphNode{}, rawGraph.Nodes...)
 	allNodes = append(allNodes, allPackageNodes...)
@@ -127,6 +130,16 @@ func RenderGraph(rawGraph *GraphData, defaultLens, detailLens *LensConfig, selec
 	// 12. Aggregate edges for collapsed nodes
 	visibleEdges := aggregateEdgesForCollapsedNodes(rawGraph, nodeStates, defaultLens, detailLens, nodeLensMap, includedNodeIds, childToParentMap)
 
+	// Optionally cluster the top-level nodes by owner
+	if defaultLens.GroupBy == GroupByOwner {
+		finalNodes = groupNodesByOwner(finalNodes, rawGraph)
+	}
+
+	// Optionally split the files of large targets by subdirectory
+	if defaultLens.GroupFilesAbove > 0 {
+		finalNodes = groupFilesByDirectory(finalNodes, defaultLens.GroupFilesAbove)
+	}
+
 	// 13. Sort nodes for deterministic ordering (Dagre layout stability)
 	sort.Slice(finalNodes, func(i, j int) bool {
 		return finalNodes[i].ID < finalNodes[j].ID
@@ -167,6 +180,19 @@ func assignLensesToNodes(distances map[string]interface{}, selectedNodes []strin
 func applyLensRules(graph *GraphData, nodeLensMap map[string]string, distances map[string]interface{}, defaultLens, detailLens *LensConfig, neededPackages map[string]bool) map[string]*NodeState {
 	nodeStates := make(map[string]*NodeState)
 
+	var testNodes map[string]bool
+	if defaultLens.GlobalFilters.HideTests || detailLens.GlobalFilters.HideTests {
+		testNodes = findTestNodes(graph)
+	}
+	var nodeTags map[string][]string
+	if len(defaultLens.GlobalFilters.HideTags) > 0 || len(detailLens.GlobalFilters.HideTags) > 0 {
+		nodeTags = findNodeTags(graph)
+	}
+	var unreachableNodes map[string]bool
+	if defaultLens.GlobalFilters.HideUnreachable || detailLens.GlobalFilters.HideUnreachable {
+		unreachableNodes = findUnreachableNodes(graph)
+	}
+
 	for _, node := range graph.Nodes {
 		lensType := nodeLensMap[node.ID]
 		if lensType == "" {
@@ -190,6 +216,15 @@ func applyLensRules(graph *GraphData, nodeLensMap map[string]string, distances m
 
 		// Check visibility
 		visible := isNodeVisibleByRule(&node, rule, lens, neededPackages)
+		if lens.GlobalFilters.HideTests && testNodes[node.ID] {
+			visible = false
+		}
+		if hasAnyTag(nodeTags[node.ID], lens.GlobalFilters.HideTags) {
+			visible = false
+		}
+		if lens.GlobalFilters.HideUnreachable && unreachableNodes[node.ID] {
+			visible = false
+		}
 
 		// TEMPORARY DEBUG: Log package visibility decisions
 		if node.Type == "package" {
@@ -302,7 +337,7 @@ func isNodeVisibleByRule(node *GraphNode, rule *DistanceRule, lens *LensConfig,
 		if lens.GlobalFilters.HideSystemLibs && node.Type == "system_library" {
 			return false
 		}
-		if lens.GlobalFilters.HideExternal && (node.Type == "external" || strings.Contains(node.ID, "@")) {
+		if lens.GlobalFilters.HideExternal && isExternalNode(node) {
 			return false
 		}
 		if lens.GlobalFilters.HideUncovered && (node.Type == "uncovered_source" || node.Type == "uncovered_header") {
@@ -312,7 +347,7 @@ func isNodeVisibleByRule(node *GraphNode, rule *DistanceRule, lens *LensConfig,
 		return true
 	}
 
-	if lens.GlobalFilters.HideExternal && (node.Type == "external" || strings.Contains(node.ID, "@")) {
+	if lens.GlobalFilters.HideExternal && isExternalNode(node) {
 		return false
 	}
 	if lens.GlobalFilters.HideUncovered && (node.Type == "uncovered_source" || node.Type == "uncovered_header") {
@@ -355,7 +390,7 @@ func isNodeVisibleByRule(node *GraphNode, rule *DistanceRule, lens *LensConfig,
 		}
 	}
 
-	if node.Type == "external" || strings.Contains(node.ID, "@") {
+	if isExternalNode(node) {
 		if !vis.ShowExternal {
 			return false
 		}
@@ -370,6 +405,34 @@ func isNodeVisibleByRule(node *GraphNode, rule *DistanceRule, lens *LensConfig,
 	return true
 }
 
+// applyManualOverrides sets the collapse state of the nodes named by the overrides. Later
+// overrides win, so a node can be expanded inside a collapsed package.
+func applyManualOverrides(nodeStates map[string]*NodeState, overrides []ManualOverride) {
+	for _, override := range overrides {
+		for nodeID, state := range nodeStates {
+			if nodeID == override.NodeID || (override.Package && isInPackage(nodeID, override.NodeID)) {
+				state.Collapsed = override.Collapsed
+			}
+		}
+	}
+}
+
+// isInPackage reports whether a node is, or is contained in, the package or one of its
+// subpackages
+func isInPackage(nodeID, pkg string) bool {
+	for nodeID != "" {
+		if nodeID == pkg || strings.HasPrefix(nodeID, strings.TrimSuffix(pkg, "/")+"/") {
+			return true
+		}
+		parentID := extractParentID(nodeID)
+		if parentID == nodeID {
+			break
+		}
+		nodeID = parentID
+	}
+	return false
+}
+
 // shouldNodeBeCollapsed determines if a node should be collapsed
 func shouldNodeBeCollapsed(node GraphNode, rule *DistanceRule) bool {
 	// Use lens rule
@@ -475,6 +538,100 @@ func extractPackageNodes(graph *GraphData) []GraphNode {
 	return packageNodes
 }
 
+// groupNodesByOwner puts each top-level node with an owner into a synthetic owner node,
+// e.g. "owner:@org/graphics". Package nodes take the owner of their targets.
+func groupNodesByOwner(nodes []GraphNode, rawGraph *GraphData) []GraphNode {
+	owners := make(map[string]string)
+	for _, node := range rawGraph.Nodes {
+		if node.Owner == "" {
+			continue
+		}
+		owners[node.ID] = node.Owner
+		if pkgID := extractPackageID(node.ID); pkgID != "" && owners[pkgID] == "" {
+			owners[pkgID] = node.Owner
+		}
+	}
+
+	included := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		included[node.ID] = true
+	}
+
+	result := append([]GraphNode{}, nodes...)
+	ownerNodes := make(map[string]bool)
+	for i, node := range nodes {
+		owner := owners[node.ID]
+		if owner == "" || (node.Parent != "" && included[node.Parent]) {
+			continue
+		}
+		ownerID := "owner:" + owner
+		result[i].Parent = ownerID
+		if !ownerNodes[ownerID] {
+			ownerNodes[ownerID] = true
+			result = append(result, GraphNode{ID: ownerID, Label: owner, Type: "owner"})
+		}
+	}
+	return result
+}
+
+// groupFilesByDirectory puts the files of each target showing more than threshold files
+// into synthetic directory nodes, e.g. "dir://util:util/strings" for the files under
+// util/strings of //util:util. Files directly in the package directory stay in the target,
+// and targets whose files all share one subdirectory are left alone.
+func groupFilesByDirectory(nodes []GraphNode, threshold int) []GraphNode {
+	filesByTarget := make(map[string][]int)
+	for i, node := range nodes {
+		if node.Parent != "" && (node.Type == "source_file" || node.Type == "header_file") {
+			filesByTarget[node.Parent] = append(filesByTarget[node.Parent], i)
+		}
+	}
+
+	result := append([]GraphNode{}, nodes...)
+	for target, files := range filesByTarget {
+		if len(files) <= threshold {
+			continue
+		}
+
+		// Directory of each file relative to the package, "" for the package directory
+		pkgDir := strings.TrimPrefix(extractPackageID(target), "//")
+		dirs := make(map[int]string, len(files))
+		distinct := make(map[string]bool)
+		for _, i := range files {
+			path := strings.TrimPrefix(nodes[i].ID, target+":")
+			if pkgDir != "" {
+				path = strings.TrimPrefix(path, pkgDir+"/")
+			}
+			dir := ""
+			if idx := strings.LastIndex(path, "/"); idx >= 0 {
+				dir = path[:idx]
+			}
+			dirs[i] = dir
+			distinct[dir] = true
+		}
+		if len(distinct) < 2 {
+			continue
+		}
+
+		for dir := range distinct {
+			if dir == "" {
+				continue
+			}
+			result = append(result, GraphNode{
+				ID:     "dir:" + target + "/" + dir,
+				Label:  dir + "/",
+				Type:   "directory",
+				Parent: target,
+			})
+		}
+		for _, i := range files {
+			if dirs[i] != "" {
+				result[i].Parent = "dir:" + target + "/" + dirs[i]
+			}
+		}
+	}
+	return result
+}
+
 // extractPackageID extracts the package ID from a target or file ID
 // Examples: //util:util -> //util, //foo/bar:baz -> //foo/bar
 func extractPackageID(nodeID string) string {
@@ -643,22 +800,18 @@ func aggregateEdgesForCollapsedNodes(rawGraph *GraphData, nodeStates map[string]
 			continue
 		}
 
-		// Create edge key for aggregation
 		// If CollapseEdgeTypes is true, collapse all edge types between same node pair
-		var edgeKey string
-		var edgeType string
+		edgeType := edge.Type
 		if lens.EdgeRules.CollapseEdgeTypes {
-			edgeKey = fmt.Sprintf("%s|%s", actualSource, actualTarget)
 			edgeType = "multi" // Special type for collapsed edges
-		} else {
-			edgeKey = fmt.Sprintf("%s|%s|%s", actualSource, actualTarget, edge.Type)
-			edgeType = edge.Type
 		}
+		id := EdgeID(actualSource, actualTarget, edgeType)
 
 		// Aggregate edges (for collapsed nodes, multiple edges may map to same aggregated edge)
-		if _, exists := edgeMap[edgeKey]; !exists {
+		if _, exists := edgeMap[id]; !exists {
 			// Create new aggregated edge (just the key fields - metadata will be added by web layer)
-			edgeMap[edgeKey] = &GraphEdge{
+			edgeMap[id] = &GraphEdge{
+				ID:     id,
 				Source: actualSource,
 				Target: actualTarget,
 				Type:   edgeType,
@@ -736,10 +889,118 @@ func findVisibleAncestor(nodeID string, includedNodeIds map[string]bool, childTo
 	return ""
 }
 
+// findTestNodes returns the IDs of all cc_test targets and the file nodes they own
+func findTestNodes(graph *GraphData) map[string]bool {
+	testNodes := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		if node.Type == "cc_test" {
+			testNodes[node.ID] = true
+		}
+	}
+	for _, node := range graph.Nodes {
+		if testNodes[node.Parent] {
+			testNodes[node.ID] = true
+		}
+	}
+	return testNodes
+}
+
+// findUnreachableNodes returns the target nodes that no cc_binary or cc_test reaches by
+// following edges, and the file nodes they own. Edges between files count for the targets
+// owning them. Nodes other than targets and their files, such as system libraries, are
+// never returned.
+func findUnreachableNodes(graph *GraphData) map[string]bool {
+	targets := make(map[string]bool)
+	var roots []string
+	for _, node := range graph.Nodes {
+		if strings.HasPrefix(node.Type, "cc_") {
+			targets[node.ID] = true
+			if node.Type == "cc_binary" || node.Type == "cc_test" {
+				roots = append(roots, node.ID)
+			}
+		}
+	}
+
+	// Owning target of each target and file node
+	owner := make(map[string]string)
+	for _, node := range graph.Nodes {
+		if targets[node.ID] {
+			owner[node.ID] = node.ID
+		} else if targets[node.Parent] {
+			owner[node.ID] = node.Parent
+		}
+	}
+
+	adjacency := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		from, to := owner[edge.Source], owner[edge.Target]
+		if from != "" && to != "" && from != to {
+			adjacency[from] = append(adjacency[from], to)
+		}
+	}
+
+	reachable := make(map[string]bool)
+	queue := roots
+	for _, root := range roots {
+		reachable[root] = true
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range adjacency[current] {
+			if !reachable[next] {
+				reachable[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	unreachable := make(map[string]bool)
+	for nodeID, target := range owner {
+		if !reachable[target] {
+			unreachable[nodeID] = true
+		}
+	}
+	return unreachable
+}
+
+// findNodeTags returns the tags of all tagged target nodes and the file nodes they own,
+// keyed by node ID. File nodes have the tags of their target.
+func findNodeTags(graph *GraphData) map[string][]string {
+	nodeTags := make(map[string][]string)
+	for _, node := range graph.Nodes {
+		if len(node.Tags) > 0 {
+			nodeTags[node.ID] = node.Tags
+		}
+	}
+	for _, node := range graph.Nodes {
+		if tags, ok := nodeTags[node.Parent]; ok && len(node.Tags) == 0 {
+			nodeTags[node.ID] = tags
+		}
+	}
+	return nodeTags
+}
+
+func hasAnyTag(nodeTags, tags []string) bool {
+	for _, tag := range tags {
+		if contains(nodeTags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper functions
 
 func isTargetType(nodeType string) bool {
-	return nodeType == "cc_library" || nodeType == "cc_binary" || nodeType == "cc_shared_library"
+	return nodeType == "cc_library" || nodeType == "cc_binary" || nodeType == "cc_shared_library" || nodeType == "cc_test"
+}
+
+// isExternalNode reports whether a node belongs to an external repository. Target nodes
+// carry this from the model; package and file nodes derived from them are told by their
+// @repo ID prefix.
+func isExternalNode(node *GraphNode) bool {
+	return node.External || node.Type == "external" || strings.HasPrefix(node.ID, "@")
 }
 
 func isFileType(nodeType string) bool {
@@ -0,0 +1,75 @@
+package lens
+
+import "testing"
+
+// defaultPackageLens mirrors DEFAULT_PACKAGE_LENS of the web UI (static/lens-config.js)
+func defaultPackageLens() *LensConfig {
+	edgeTypes := []string{"static", "dynamic", "system_link", "data", "compile", "symbol"}
+	return &LensConfig{
+		Name:    "Package View",
+		BaseSet: BaseSetConfig{Type: "full-graph"},
+		DistanceRules: []DistanceRule{{
+			Distance: "infinite",
+			NodeVisibility: NodeVisibility{
+				TargetTypes:         []string{"cc_binary", "cc_shared_library", "cc_library", "cc_test"},
+				FileTypes:           []string{"none"},
+				ShowExternal:        true,
+				ShowSystemLibraries: true,
+			},
+			CollapseLevel: 2,
+			ShowEdges:     true,
+			EdgeTypes:     edgeTypes,
+		}},
+		GlobalFilters: GlobalFilters{HideUnreachable: true},
+		EdgeRules:     EdgeDisplayRules{Types: edgeTypes, AggregateCollapsed: true},
+	}
+}
+
+func TestRenderGraphShowsTests(t *testing.T) {
+	raw := &GraphData{
+		Nodes: []GraphNode{
+			{ID: "//util", Type: "package"},
+			{ID: "//util:util", Type: "cc_library", Parent: "//util"},
+			{ID: "//util:util_test", Type: "cc_test", Parent: "//util"},
+			{ID: "//util:util_test:util_test.cc", Type: "source", Parent: "//util:util_test"},
+		},
+		Edges: []GraphEdge{
+			{Source: "//util:util_test", Target: "//util:util", Type: "static"},
+		},
+	}
+
+	graph, err := RenderGraph(raw, defaultPackageLens(), defaultPackageLens(), nil, FocusUnion)
+	if err != nil {
+		t.Fatalf("RenderGraph() error = %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		ids[node.ID] = true
+	}
+	// The test is visible and, being a root, keeps the library it tests reachable
+	for _, id := range []string{"//util:util_test", "//util:util"} {
+		if !ids[id] {
+			t.Errorf("expected %s with the default lens, got %+v", id, graph.Nodes)
+		}
+	}
+	if ids["//util:util_test:util_test.cc"] {
+		t.Error("expected the test's files hidden by the default lens")
+	}
+	if len(graph.Edges) != 1 {
+		t.Errorf("expected the edge from the test to the library, got %+v", graph.Edges)
+	}
+
+	// Hiding tests drops the test and, with it, the only reason to show the library
+	lens := defaultPackageLens()
+	lens.GlobalFilters.HideTests = true
+	graph, err = RenderGraph(raw, lens, lens, nil, FocusUnion)
+	if err != nil {
+		t.Fatalf("RenderGraph() error = %v", err)
+	}
+	for _, node := range graph.Nodes {
+		if node.ID == "//util:util_test" {
+			t.Errorf("expected //util:util_test hidden by hideTests, got %+v", graph.Nodes)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package logging
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 )
@@ -35,6 +36,15 @@ func SetLevel(level slog.Level) {
 	programLevel.Set(level)
 }
 
+// SetOutput redirects the compact console output to w, e.g. os.Stderr when stdout carries
+// results. Like SetJSONOutput, this replaces the root logger.
+func SetOutput(w io.Writer) {
+	handler := NewCompactHandler(w, &slog.HandlerOptions{
+		Level: programLevel,
+	})
+	logger = slog.New(handler)
+}
+
 // SetJSONOutput switches to JSON format output
 // Note: This replaces the root logger, so derived loggers created before this
 // will continue to use the old handler. Call this early!
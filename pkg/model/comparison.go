@@ -0,0 +1,89 @@
+package model
+
+import (
+	"slices"
+	"sort"
+)
+
+// Status of an edge in a GraphComparison
+const (
+	ComparisonDeclaredOnly = "declared-only" // Declared but not used: a candidate for removal
+	ComparisonInferredOnly = "inferred-only" // Used but not declared: a hidden dependency
+	ComparisonBoth         = "both"          // Declared and used
+)
+
+// GraphComparison holds the declared and the inferred dependency graph of a module, so
+// that they can be overlaid
+type GraphComparison struct {
+	Declared []ComparedEdge `json:"declared"` // Edges from deps, dynamic_deps and data
+	Inferred []ComparedEdge `json:"inferred"` // Edges from compile and symbol evidence
+}
+
+// ComparedEdge is a target pair in one of the graphs of a GraphComparison
+type ComparedEdge struct {
+	From   string           `json:"from"`
+	To     string           `json:"to"`
+	Types  []DependencyType `json:"types"`  // Dependency types of the pair in this graph, sorted
+	Status string           `json:"status"` // ComparisonDeclaredOnly, ComparisonInferredOnly or ComparisonBoth
+}
+
+// CompareGraphs splits the dependencies into the declared and the inferred graph, with one
+// edge per target pair, tagging each edge by whether the other graph has it too. The status
+// is taken from the provenance of the dependencies, which must be up to date (see
+// UpdateProvenance). Edges are sorted by From and To.
+func (m *Module) CompareGraphs() *GraphComparison {
+	type side struct {
+		edges map[InternalEdge]*ComparedEdge
+		list  []*ComparedEdge
+	}
+	declared := &side{edges: make(map[InternalEdge]*ComparedEdge)}
+	inferred := &side{edges: make(map[InternalEdge]*ComparedEdge)}
+
+	for _, dep := range m.Dependencies {
+		s := inferred
+		if dep.Type.Provenance() == ProvenanceDeclared {
+			s = declared
+		}
+
+		key := InternalEdge{FromTarget: dep.From, ToTarget: dep.To}
+		edge := s.edges[key]
+		if edge == nil {
+			edge = &ComparedEdge{From: dep.From, To: dep.To, Status: comparisonStatus(dep)}
+			s.edges[key] = edge
+			s.list = append(s.list, edge)
+		}
+		if !slices.Contains(edge.Types, dep.Type) {
+			edge.Types = append(edge.Types, dep.Type)
+		}
+	}
+
+	sorted := func(s *side) []ComparedEdge {
+		edges := make([]ComparedEdge, 0, len(s.list))
+		for _, edge := range s.list {
+			slices.Sort(edge.Types)
+			edges = append(edges, *edge)
+		}
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i].From != edges[j].From {
+				return edges[i].From < edges[j].From
+			}
+			return edges[i].To < edges[j].To
+		})
+		return edges
+	}
+	return &GraphComparison{Declared: sorted(declared), Inferred: sorted(inferred)}
+}
+
+// comparisonStatus returns the GraphComparison status of a dependency's target pair
+func comparisonStatus(dep Dependency) string {
+	declared := dep.HasProvenance(ProvenanceDeclared)
+	inferred := dep.HasProvenance(ProvenanceCompile) || dep.HasProvenance(ProvenanceSymbol)
+	switch {
+	case declared && inferred:
+		return ComparisonBoth
+	case inferred:
+		return ComparisonInferredOnly
+	default:
+		return ComparisonDeclaredOnly
+	}
+}
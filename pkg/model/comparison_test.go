@@ -0,0 +1,40 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareGraphs(t *testing.T) {
+	module := &Module{
+		Targets: map[string]*Target{
+			"//main:app":  {Label: "//main:app"},
+			"//util:util": {Label: "//util:util"},
+			"//core:core": {Label: "//core:core"},
+			"//log:log":   {Label: "//log:log"},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:util", Type: DependencySymbol},
+			{From: "//main:app", To: "//util:util", Type: DependencyCompile},
+			{From: "//main:app", To: "//log:log", Type: DependencyStatic},
+			{From: "//util:util", To: "//core:core", Type: DependencySymbol},
+		},
+	}
+	module.UpdateProvenance()
+
+	got := module.CompareGraphs()
+	want := &GraphComparison{
+		Declared: []ComparedEdge{
+			{From: "//main:app", To: "//log:log", Types: []DependencyType{DependencyStatic}, Status: ComparisonDeclaredOnly},
+			{From: "//main:app", To: "//util:util", Types: []DependencyType{DependencyStatic}, Status: ComparisonBoth},
+		},
+		Inferred: []ComparedEdge{
+			{From: "//main:app", To: "//util:util", Types: []DependencyType{DependencyCompile, DependencySymbol}, Status: ComparisonBoth},
+			{From: "//util:util", To: "//core:core", Types: []DependencyType{DependencySymbol}, Status: ComparisonInferredOnly},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareGraphs() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package model
+
+import "strings"
+
+// The analyzer has two representations of the dependency data:
+//
+//   - Module is the typed, target-level model produced by bazel.QueryWorkspace and
+//     enriched by the analysis runner. The web server, policy checks and reports use it.
+//   - Graph is the untyped node/edge model produced by the api.Source implementations
+//     (bazel.TargetSource, deps.CompileDepsSource, symbols.SymbolSource) and merged by
+//     the runner into AnalysisRunner.Graph.
+//
+// Module is the source of truth. ToGraph and ModuleFromGraph convert between the two so
+// that target nodes and edges keep the same shape in both, instead of each consumer
+// keeping its own mapping in sync.
+
+// Metadata keys used on target nodes in a Graph
+const (
+	MetadataPackage = "package"
+	MetadataKind    = "kind"
+	MetadataSources = "sources"
+	MetadataHeaders = "headers"
+)
+
+// ToGraph converts the module's targets and dependencies to a Graph. Each target becomes a
+// node with the target kind as type and its package, kind, sources and headers as metadata;
+// each dependency becomes an edge with the dependency type as type.
+func (m *Module) ToGraph() *Graph {
+	graph := NewGraph()
+
+	for _, target := range m.Targets {
+		node := &Node{
+			ID:    target.Label,
+			Label: target.Label,
+			Type:  string(target.Kind),
+			Metadata: map[string]interface{}{
+				MetadataPackage: target.Package,
+				MetadataKind:    string(target.Kind),
+			},
+		}
+		if len(target.Sources) > 0 {
+			node.Metadata[MetadataSources] = append([]string(nil), target.Sources...)
+		}
+		if len(target.Headers) > 0 {
+			node.Metadata[MetadataHeaders] = append([]string(nil), target.Headers...)
+		}
+		graph.AddNode(node)
+	}
+
+	for _, dep := range m.Dependencies {
+		graph.AddEdge(&Edge{
+			Source: dep.From,
+			Target: dep.To,
+			Type:   string(dep.Type),
+		})
+	}
+
+	return graph
+}
+
+// ModuleFromGraph builds a Module from the target nodes of a Graph. Nodes that are not
+// targets (files, packages, system libraries) are ignored, as are edges that do not
+// connect two targets, such as file-level compile and symbol edges.
+func ModuleFromGraph(graph *Graph) *Module {
+	module := &Module{
+		Targets:      make(map[string]*Target),
+		Dependencies: make([]Dependency, 0),
+		Issues:       make([]DependencyIssue, 0),
+	}
+	if graph == nil {
+		return module
+	}
+
+	for _, node := range graph.Nodes {
+		kind := TargetKind(node.Type)
+		if !isTargetKind(kind) {
+			continue
+		}
+
+		pkg, name, _ := strings.Cut(node.ID, ":")
+		if p, ok := node.Metadata[MetadataPackage].(string); ok && p != "" {
+			pkg = p
+		}
+
+		module.Targets[node.ID] = &Target{
+			Label:    node.ID,
+			Kind:     kind,
+			Package:  pkg,
+			Name:     name,
+			Sources:  metadataStrings(node.Metadata[MetadataSources]),
+			Headers:  metadataStrings(node.Metadata[MetadataHeaders]),
+			External: IsExternalLabel(node.ID),
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		if module.Targets[edge.Source] == nil || module.Targets[edge.Target] == nil {
+			continue
+		}
+		module.Dependencies = append(module.Dependencies, Dependency{
+			From: edge.Source,
+			To:   edge.Target,
+			Type: DependencyType(edge.Type),
+		})
+	}
+
+	return module
+}
+
+// isTargetKind reports whether kind is one of the known target kinds
+func isTargetKind(kind TargetKind) bool {
+	switch kind {
+	case TargetKindBinary, TargetKindSharedLibrary, TargetKindLibrary, TargetKindTest, TargetKindExternal:
+		return true
+	}
+	return false
+}
+
+// metadataStrings converts a metadata value to a string slice. Values decoded from JSON
+// are []interface{} rather than []string.
+func metadataStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return append([]string(nil), v...)
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func newConvertTestModule() *Module {
+	return &Module{
+		Targets: map[string]*Target{
+			"//main:app": {
+				Label: "//main:app", Kind: TargetKindBinary, Package: "//main", Name: "app",
+				Sources: []string{"main/main.cc"},
+			},
+			"//util:util": {
+				Label: "//util:util", Kind: TargetKindLibrary, Package: "//util", Name: "util",
+				Sources: []string{"util/util.cc"}, Headers: []string{"util/util.h"},
+			},
+			"//plugin:so": {Label: "//plugin:so", Kind: TargetKindSharedLibrary, Package: "//plugin", Name: "so"},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//plugin:so", Type: DependencyDynamic},
+			{From: "//main:app", To: "//util:util", Type: DependencyCompile},
+		},
+	}
+}
+
+func TestModuleToGraph(t *testing.T) {
+	graph := newConvertTestModule().ToGraph()
+
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 3 {
+		t.Fatalf("ToGraph() produced %d nodes and %d edges, want 3 and 3", len(graph.Nodes), len(graph.Edges))
+	}
+
+	util := graph.Nodes["//util:util"]
+	if util == nil || util.Type != "cc_library" || util.Metadata[MetadataPackage] != "//util" {
+		t.Errorf("unexpected //util:util node: %+v", util)
+	}
+	if got := util.Metadata[MetadataHeaders]; !reflect.DeepEqual(got, []string{"util/util.h"}) {
+		t.Errorf("headers metadata = %v", got)
+	}
+	if _, ok := graph.Nodes["//plugin:so"].Metadata[MetadataSources]; ok {
+		t.Error("expected no sources metadata for a target without sources")
+	}
+
+	if edge := graph.Edges[1]; edge.Source != "//main:app" || edge.Target != "//plugin:so" || edge.Type != "dynamic" {
+		t.Errorf("unexpected edge: %+v", edge)
+	}
+}
+
+func TestModuleGraphRoundTrip(t *testing.T) {
+	module := newConvertTestModule()
+
+	// Round trip through JSON as well, as graphs are exchanged with the frontend
+	data, err := json.Marshal(module.ToGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var graph Graph
+	if err := json.Unmarshal(data, &graph); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ModuleFromGraph(&graph)
+	if !reflect.DeepEqual(got.Targets, module.Targets) {
+		t.Errorf("targets changed in round trip:\n got %+v\nwant %+v", got.Targets, module.Targets)
+	}
+	if !reflect.DeepEqual(sortedDeps(got.Dependencies), sortedDeps(module.Dependencies)) {
+		t.Errorf("dependencies changed in round trip:\n got %v\nwant %v", got.Dependencies, module.Dependencies)
+	}
+}
+
+func TestModuleFromGraphSkipsNonTargets(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode(&Node{ID: "//util:util", Label: "//util:util", Type: "cc_library"})
+	graph.AddNode(&Node{ID: "util/util.cc", Label: "util/util.cc", Type: "file"})
+	graph.AddNode(&Node{ID: "util/util.h", Label: "util/util.h", Type: "file"})
+	graph.AddEdge(&Edge{Source: "util/util.cc", Target: "util/util.h", Type: "compile"})
+	graph.AddEdge(&Edge{Source: "//util:util", Target: "@zlib//:zlib", Type: "static"})
+
+	module := ModuleFromGraph(graph)
+	if len(module.Targets) != 1 {
+		t.Errorf("expected only the target node, got %v", module.Targets)
+	}
+	if target := module.Targets["//util:util"]; target == nil || target.Package != "//util" || target.Name != "util" {
+		t.Errorf("unexpected target: %+v", target)
+	}
+	if len(module.Dependencies) != 0 {
+		t.Errorf("expected no dependencies, got %v", module.Dependencies)
+	}
+}
+
+func sortedDeps(deps []Dependency) []Dependency {
+	result := append([]Dependency(nil), deps...)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].From != result[j].From {
+			return result[i].From < result[j].From
+		}
+		if result[i].To != result[j].To {
+			return result[i].To < result[j].To
+		}
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
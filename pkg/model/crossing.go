@@ -0,0 +1,58 @@
+package model
+
+import "sort"
+
+// SymbolCrossingSampleSize is the maximum number of symbols kept as a sample per crossing
+const SymbolCrossingSampleSize = 10
+
+// SymbolCrossing summarizes the symbols one target uses from another, as found by nm
+// analysis. It measures how tightly two targets are coupled at link time.
+type SymbolCrossing struct {
+	From    string   `json:"from"`    // Target using the symbols
+	To      string   `json:"to"`      // Target defining the symbols
+	Count   int      `json:"count"`   // Number of distinct symbols crossing the boundary
+	Symbols []string `json:"symbols"` // Sorted sample of at most SymbolCrossingSampleSize symbols
+}
+
+// NewSymbolCrossings aggregates the symbols used across each target pair into crossings,
+// sorted by source and destination target. Duplicate symbols are counted once.
+func NewSymbolCrossings(symbolsByEdge map[InternalEdge][]string) []SymbolCrossing {
+	crossings := make([]SymbolCrossing, 0, len(symbolsByEdge))
+	for edge, syms := range symbolsByEdge {
+		unique := make(map[string]bool, len(syms))
+		for _, sym := range syms {
+			unique[sym] = true
+		}
+		sorted := make([]string, 0, len(unique))
+		for sym := range unique {
+			sorted = append(sorted, sym)
+		}
+		sort.Strings(sorted)
+
+		crossings = append(crossings, SymbolCrossing{
+			From:    edge.FromTarget,
+			To:      edge.ToTarget,
+			Count:   len(sorted),
+			Symbols: sorted[:min(len(sorted), SymbolCrossingSampleSize)],
+		})
+	}
+
+	sort.Slice(crossings, func(i, j int) bool {
+		if crossings[i].From != crossings[j].From {
+			return crossings[i].From < crossings[j].From
+		}
+		return crossings[i].To < crossings[j].To
+	})
+	return crossings
+}
+
+// GetSymbolCrossing returns the symbol crossing from one target to another, or nil if
+// no symbols cross between them
+func (m *Module) GetSymbolCrossing(from, to string) *SymbolCrossing {
+	for i := range m.SymbolCrossings {
+		if m.SymbolCrossings[i].From == from && m.SymbolCrossings[i].To == to {
+			return &m.SymbolCrossings[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNewSymbolCrossings(t *testing.T) {
+	many := make([]string, 0, SymbolCrossingSampleSize+5)
+	for i := SymbolCrossingSampleSize + 4; i >= 0; i-- {
+		many = append(many, fmt.Sprintf("sym%02d", i))
+	}
+
+	crossings := NewSymbolCrossings(map[InternalEdge][]string{
+		{FromTarget: "//main:app", ToTarget: "//util:util"}:  {"util::Join()", "util::Split()", "util::Join()"},
+		{FromTarget: "//core:core", ToTarget: "//util:util"}: many,
+	})
+
+	if len(crossings) != 2 {
+		t.Fatalf("expected 2 crossings, got %+v", crossings)
+	}
+
+	core := crossings[0]
+	if core.From != "//core:core" || core.Count != SymbolCrossingSampleSize+5 {
+		t.Errorf("unexpected first crossing: %+v", core)
+	}
+	if len(core.Symbols) != SymbolCrossingSampleSize || core.Symbols[0] != "sym00" {
+		t.Errorf("expected a sorted sample of %d symbols, got %v", SymbolCrossingSampleSize, core.Symbols)
+	}
+
+	want := SymbolCrossing{
+		From:    "//main:app",
+		To:      "//util:util",
+		Count:   2,
+		Symbols: []string{"util::Join()", "util::Split()"},
+	}
+	if !reflect.DeepEqual(crossings[1], want) {
+		t.Errorf("crossing = %+v, want %+v", crossings[1], want)
+	}
+}
+
+func TestGetSymbolCrossing(t *testing.T) {
+	module := &Module{
+		SymbolCrossings: []SymbolCrossing{
+			{From: "//main:app", To: "//util:util", Count: 1, Symbols: []string{"util::Join()"}},
+		},
+	}
+
+	if got := module.GetSymbolCrossing("//main:app", "//util:util"); got == nil || got.Count != 1 {
+		t.Errorf("GetSymbolCrossing() = %+v", got)
+	}
+	if got := module.GetSymbolCrossing("//util:util", "//main:app"); got != nil {
+		t.Errorf("expected no crossing in the reverse direction, got %+v", got)
+	}
+}
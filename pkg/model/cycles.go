@@ -0,0 +1,216 @@
+package model
+
+import (
+	"slices"
+	"sort"
+)
+
+// FindCycles returns the groups of targets that depend on each other in a cycle, over all
+// dependency types: the strongly connected components of more than one target. Bazel
+// rejects cycles in deps, but compile and symbol dependencies can still close one, e.g.
+// two libraries calling into each other. Each cycle is sorted by label; cycles are sorted
+// by size, largest first.
+func (m *Module) FindCycles() [][]string {
+	return m.findCycles(nil)
+}
+
+// TargetCycle is a group of targets that depend on each other in a cycle
+type TargetCycle struct {
+	Labels []string         `json:"labels"` // Targets of the cycle, sorted
+	Types  []DependencyType `json:"types"`  // Types of the dependencies between them, sorted
+}
+
+// LinkCycle reports whether the cycle has a dependency other than compile: a cycle of
+// #includes alone does not stop the targets from being linked separately.
+func (c TargetCycle) LinkCycle() bool {
+	return slices.ContainsFunc(c.Types, func(t DependencyType) bool { return t != DependencyCompile })
+}
+
+// FindTargetCycles returns the cycles of FindCycles over dependencies of the given types,
+// or all types if none are given, with the types of the dependencies within each cycle.
+// Passing DependencyStatic and DependencyDynamic finds the circular library dependencies
+// that layering violations let through Bazel.
+func (m *Module) FindTargetCycles(types ...DependencyType) []TargetCycle {
+	include := func(dep Dependency) bool {
+		return len(types) == 0 || slices.Contains(types, dep.Type)
+	}
+
+	components := m.findCycles(include)
+	cycles := make([]TargetCycle, 0, len(components))
+	for _, component := range components {
+		cycle := TargetCycle{Labels: component, Types: []DependencyType{}}
+		for _, dep := range m.Dependencies {
+			if dep.From != dep.To && include(dep) && !slices.Contains(cycle.Types, dep.Type) &&
+				slices.Contains(component, dep.From) && slices.Contains(component, dep.To) {
+				cycle.Types = append(cycle.Types, dep.Type)
+			}
+		}
+		slices.Sort(cycle.Types)
+		cycles = append(cycles, cycle)
+	}
+	return cycles
+}
+
+// findCycles returns the strongly connected components of more than one target over the
+// dependencies accepted by include (all if nil), sorted as described by FindCycles
+func (m *Module) findCycles(include func(Dependency) bool) [][]string {
+	adjacency := make(map[string][]string)
+	seen := make(map[[2]string]bool)
+	for _, dep := range m.Dependencies {
+		edge := [2]string{dep.From, dep.To}
+		if dep.From == dep.To || seen[edge] || m.Targets[dep.From] == nil || m.Targets[dep.To] == nil {
+			continue
+		}
+		if include != nil && !include(dep) {
+			continue
+		}
+		seen[edge] = true
+		adjacency[dep.From] = append(adjacency[dep.From], dep.To)
+	}
+
+	labels := make([]string, 0, len(m.Targets))
+	for label := range m.Targets {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return stronglyConnected(labels, adjacency)
+}
+
+// PackageCycle is a group of packages that depend on each other in a cycle
+type PackageCycle struct {
+	Packages []string           `json:"packages"` // Packages of the cycle, sorted
+	Types    []DependencyType   `json:"types"`    // Types of the dependencies between them, sorted
+	Edges    []PackageCycleEdge `json:"edges"`    // Package dependencies within the cycle, sorted
+}
+
+// PackageCycleEdge is a package dependency within a PackageCycle
+type PackageCycleEdge struct {
+	From  string           `json:"from"`
+	To    string           `json:"to"`
+	Types []DependencyType `json:"types"` // Types of the target dependencies behind it, sorted
+}
+
+// LinkCycle reports whether the cycle has a dependency other than compile (see
+// TargetCycle.LinkCycle)
+func (c PackageCycle) LinkCycle() bool {
+	return slices.ContainsFunc(c.Types, func(t DependencyType) bool { return t != DependencyCompile })
+}
+
+// FindPackageCycles returns the groups of packages that depend on each other in a cycle
+// through the dependencies between their targets (see GetAllPackageDependencies) of the
+// given types, or all types if none are given. Unlike target cycles, package cycles pass
+// Bazel whenever no two targets form a cycle, yet they still tangle the architecture. The
+// edges and their types tell e.g. an #include cycle from a cycle of runtime data. Cycles
+// are sorted by size, largest first.
+func (m *Module) FindPackageCycles(types ...DependencyType) []PackageCycle {
+	edgeTypes := make(map[[2]string][]DependencyType)
+	adjacency := make(map[string][]string)
+	packages := make(map[string]bool)
+	for _, pkgDep := range m.GetAllPackageDependencies() {
+		for depType := range pkgDep.Dependencies {
+			if len(types) > 0 && !slices.Contains(types, depType) {
+				continue
+			}
+			edge := [2]string{pkgDep.From, pkgDep.To}
+			if _, ok := edgeTypes[edge]; !ok {
+				adjacency[pkgDep.From] = append(adjacency[pkgDep.From], pkgDep.To)
+			}
+			edgeTypes[edge] = append(edgeTypes[edge], depType)
+			packages[pkgDep.From] = true
+			packages[pkgDep.To] = true
+		}
+	}
+
+	paths := make([]string, 0, len(packages))
+	for path := range packages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, next := range adjacency {
+		sort.Strings(next)
+	}
+
+	components := stronglyConnected(paths, adjacency)
+	cycles := make([]PackageCycle, 0, len(components))
+	for _, component := range components {
+		cycle := PackageCycle{Packages: component, Types: []DependencyType{}, Edges: []PackageCycleEdge{}}
+		for _, from := range component {
+			for _, to := range adjacency[from] {
+				if !slices.Contains(component, to) {
+					continue
+				}
+				edge := PackageCycleEdge{From: from, To: to, Types: slices.Sorted(slices.Values(edgeTypes[[2]string{from, to}]))}
+				for _, depType := range edge.Types {
+					if !slices.Contains(cycle.Types, depType) {
+						cycle.Types = append(cycle.Types, depType)
+					}
+				}
+				cycle.Edges = append(cycle.Edges, edge)
+			}
+		}
+		slices.Sort(cycle.Types)
+		cycles = append(cycles, cycle)
+	}
+	return cycles
+}
+
+// stronglyConnected returns the strongly connected components of more than one node of a
+// graph, each sorted, sorted by size (largest first) and then by their first node. The
+// nodes are visited in the given order.
+func stronglyConnected(nodes []string, adjacency map[string][]string) [][]string {
+	// Tarjan's strongly connected components algorithm
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	cycles := make([][]string, 0)
+
+	var visit func(label string)
+	visit = func(label string) {
+		index[label] = len(index)
+		lowlink[label] = index[label]
+		stack = append(stack, label)
+		onStack[label] = true
+
+		for _, next := range adjacency[label] {
+			if _, visited := index[next]; !visited {
+				visit(next)
+				lowlink[label] = min(lowlink[label], lowlink[next])
+			} else if onStack[next] {
+				lowlink[label] = min(lowlink[label], index[next])
+			}
+		}
+
+		if lowlink[label] != index[label] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == label {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, label := range nodes {
+		if _, visited := index[label]; !visited {
+			visit(label)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		if len(cycles[i]) != len(cycles[j]) {
+			return len(cycles[i]) > len(cycles[j])
+		}
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindCycles(t *testing.T) {
+	targets := map[string]*Target{}
+	for _, label := range []string{"//a:a", "//b:b", "//c:c", "//d:d", "//e:e", "//f:f"} {
+		targets[label] = &Target{Label: label}
+	}
+	module := &Module{
+		Targets: targets,
+		Dependencies: []Dependency{
+			// a -> b -> c -> a through static and symbol dependencies
+			{From: "//a:a", To: "//b:b", Type: DependencyStatic},
+			{From: "//b:b", To: "//c:c", Type: DependencyStatic},
+			{From: "//c:c", To: "//a:a", Type: DependencySymbol},
+			// d <-> e through compile dependencies
+			{From: "//d:d", To: "//e:e", Type: DependencyCompile},
+			{From: "//e:e", To: "//d:d", Type: DependencyCompile},
+			// f depends on a cycle and on itself without being part of one
+			{From: "//f:f", To: "//a:a", Type: DependencyStatic},
+			{From: "//f:f", To: "//f:f", Type: DependencySymbol},
+			{From: "//f:f", To: "@external//:lib", Type: DependencyStatic},
+		},
+	}
+
+	want := [][]string{
+		{"//a:a", "//b:b", "//c:c"},
+		{"//d:d", "//e:e"},
+	}
+	if got := module.FindCycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindCycles() = %v, want %v", got, want)
+	}
+
+	if got := newMetricsTestModule().FindCycles(); len(got) != 0 {
+		t.Errorf("FindCycles() of an acyclic module = %v, want none", got)
+	}
+}
+
+func TestFindTargetCycles(t *testing.T) {
+	targets := map[string]*Target{}
+	for _, label := range []string{"//a:a", "//b:b", "//c:c", "//d:d"} {
+		targets[label] = &Target{Label: label}
+	}
+	module := &Module{
+		Targets: targets,
+		Dependencies: []Dependency{
+			// a <-> b through static and dynamic dependencies, with a compile edge as well
+			{From: "//a:a", To: "//b:b", Type: DependencyStatic},
+			{From: "//a:a", To: "//b:b", Type: DependencyCompile},
+			{From: "//b:b", To: "//a:a", Type: DependencyDynamic},
+			// c <-> d through compile dependencies only
+			{From: "//c:c", To: "//d:d", Type: DependencyCompile},
+			{From: "//d:d", To: "//c:c", Type: DependencyCompile},
+			// Leaves the cycles
+			{From: "//b:b", To: "//c:c", Type: DependencyStatic},
+		},
+	}
+
+	all := module.FindTargetCycles()
+	want := []TargetCycle{
+		{Labels: []string{"//a:a", "//b:b"}, Types: []DependencyType{DependencyCompile, DependencyDynamic, DependencyStatic}},
+		{Labels: []string{"//c:c", "//d:d"}, Types: []DependencyType{DependencyCompile}},
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("FindTargetCycles() = %v, want %v", all, want)
+	}
+	if !all[0].LinkCycle() || all[1].LinkCycle() {
+		t.Errorf("LinkCycle() = %v, %v, want true, false", all[0].LinkCycle(), all[1].LinkCycle())
+	}
+
+	linked := module.FindTargetCycles(DependencyStatic, DependencyDynamic)
+	want = []TargetCycle{
+		{Labels: []string{"//a:a", "//b:b"}, Types: []DependencyType{DependencyDynamic, DependencyStatic}},
+	}
+	if !reflect.DeepEqual(linked, want) {
+		t.Errorf("FindTargetCycles(static, dynamic) = %v, want %v", linked, want)
+	}
+}
+
+func TestFindPackageCycles(t *testing.T) {
+	targets := map[string]*Target{}
+	for _, label := range []string{"//a:a1", "//a:a2", "//b:b", "//c:c", "//d:d"} {
+		pkg, _, _ := strings.Cut(label, ":")
+		targets[label] = &Target{Label: label, Package: pkg}
+	}
+	module := &Module{
+		Targets: targets,
+		Dependencies: []Dependency{
+			// //a -> //b -> //a without a target cycle: a1 -> b -> a2
+			{From: "//a:a1", To: "//b:b", Type: DependencyStatic},
+			{From: "//b:b", To: "//a:a2", Type: DependencyCompile},
+			{From: "//b:b", To: "//a:a2", Type: DependencyData},
+			// Within a package
+			{From: "//a:a2", To: "//a:a1", Type: DependencyStatic},
+			// //c -> //d, no cycle
+			{From: "//c:c", To: "//d:d", Type: DependencyStatic},
+			{From: "//c:c", To: "//a:a1", Type: DependencyStatic},
+		},
+	}
+
+	if got := module.FindCycles(); len(got) != 1 {
+		t.Fatalf("FindCycles() = %v, want only the a1 -> b -> a2 -> a1 target cycle", got)
+	}
+
+	want := []PackageCycle{{
+		Packages: []string{"//a", "//b"},
+		Types:    []DependencyType{DependencyCompile, DependencyData, DependencyStatic},
+		Edges: []PackageCycleEdge{
+			{From: "//a", To: "//b", Types: []DependencyType{DependencyStatic}},
+			{From: "//b", To: "//a", Types: []DependencyType{DependencyCompile, DependencyData}},
+		},
+	}}
+	got := module.FindPackageCycles()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindPackageCycles() = %+v, want %+v", got, want)
+	}
+	if !got[0].LinkCycle() {
+		t.Error("LinkCycle() = false, want true")
+	}
+
+	compile := module.FindPackageCycles(DependencyStatic, DependencyCompile)
+	if len(compile) != 1 || !reflect.DeepEqual(compile[0].Types, []DependencyType{DependencyCompile, DependencyStatic}) {
+		t.Errorf("FindPackageCycles(static, compile) = %+v, want one cycle of compile and static", compile)
+	}
+	if got := module.FindPackageCycles(DependencyStatic); len(got) != 0 {
+		t.Errorf("FindPackageCycles(static) = %+v, want none", got)
+	}
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// ModuleDiff describes how a module changed between two analyses
+type ModuleDiff struct {
+	AddedTargets        []string     `json:"addedTargets,omitempty"`        // Targets only in the new module
+	RemovedTargets      []string     `json:"removedTargets,omitempty"`      // Targets only in the old module
+	ChangedTargets      []string     `json:"changedTargets,omitempty"`      // Targets whose attributes changed
+	AddedDependencies   []Dependency `json:"addedDependencies,omitempty"`   // Dependencies only in the new module
+	RemovedDependencies []Dependency `json:"removedDependencies,omitempty"` // Dependencies only in the old module
+}
+
+// DiffModules compares two analyses of a module. A nil module is treated as empty.
+// Target lists are sorted by label and dependency lists by From, To and Type.
+func DiffModules(oldModule, newModule *Module) ModuleDiff {
+	var diff ModuleDiff
+	oldTargets := targetsOf(oldModule)
+	newTargets := targetsOf(newModule)
+
+	for label, target := range newTargets {
+		previous, existed := oldTargets[label]
+		switch {
+		case !existed:
+			diff.AddedTargets = append(diff.AddedTargets, label)
+		case !reflect.DeepEqual(previous, target):
+			diff.ChangedTargets = append(diff.ChangedTargets, label)
+		}
+	}
+	for label := range oldTargets {
+		if _, exists := newTargets[label]; !exists {
+			diff.RemovedTargets = append(diff.RemovedTargets, label)
+		}
+	}
+
+	oldDeps := dependencySet(oldModule)
+	newDeps := dependencySet(newModule)
+	for key, dep := range newDeps {
+		if _, existed := oldDeps[key]; !existed {
+			diff.AddedDependencies = append(diff.AddedDependencies, dep)
+		}
+	}
+	for key, dep := range oldDeps {
+		if _, exists := newDeps[key]; !exists {
+			diff.RemovedDependencies = append(diff.RemovedDependencies, dep)
+		}
+	}
+
+	sort.Strings(diff.AddedTargets)
+	sort.Strings(diff.RemovedTargets)
+	sort.Strings(diff.ChangedTargets)
+	sortDependencies(diff.AddedDependencies)
+	sortDependencies(diff.RemovedDependencies)
+	return diff
+}
+
+// Snapshot returns a copy of the module's targets and dependencies that is not affected by
+// later changes to m, for comparing with DiffModules. Returns nil if m is nil.
+func (m *Module) Snapshot() *Module {
+	if m == nil {
+		return nil
+	}
+	snapshot := &Module{
+		Targets:      make(map[string]*Target, len(m.Targets)),
+		Dependencies: slices.Clone(m.Dependencies),
+	}
+	for label, target := range m.Targets {
+		copied := *target
+		snapshot.Targets[label] = &copied
+	}
+	return snapshot
+}
+
+// Empty reports whether nothing changed
+func (d ModuleDiff) Empty() bool {
+	return len(d.AddedTargets) == 0 && len(d.RemovedTargets) == 0 && len(d.ChangedTargets) == 0 &&
+		len(d.AddedDependencies) == 0 && len(d.RemovedDependencies) == 0
+}
+
+// AffectedTargets returns the sorted labels of the targets that were added, removed or
+// changed, or are an end of an added or removed dependency
+func (d ModuleDiff) AffectedTargets() []string {
+	affected := make(map[string]bool)
+	for _, labels := range [][]string{d.AddedTargets, d.RemovedTargets, d.ChangedTargets} {
+		for _, label := range labels {
+			affected[label] = true
+		}
+	}
+	for _, deps := range [][]Dependency{d.AddedDependencies, d.RemovedDependencies} {
+		for _, dep := range deps {
+			affected[dep.From] = true
+			affected[dep.To] = true
+		}
+	}
+
+	result := make([]string, 0, len(affected))
+	for label := range affected {
+		result = append(result, label)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func targetsOf(m *Module) map[string]*Target {
+	if m == nil {
+		return nil
+	}
+	return m.Targets
+}
+
+// dependencyKey identifies a dependency regardless of its provenance
+type dependencyKey struct {
+	from, to string
+	depType  DependencyType
+}
+
+func dependencySet(m *Module) map[dependencyKey]Dependency {
+	set := make(map[dependencyKey]Dependency)
+	if m != nil {
+		for _, dep := range m.Dependencies {
+			set[dependencyKey{dep.From, dep.To, dep.Type}] = dep
+		}
+	}
+	return set
+}
+
+func sortDependencies(deps []Dependency) {
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].From != deps[j].From {
+			return deps[i].From < deps[j].From
+		}
+		if deps[i].To != deps[j].To {
+			return deps[i].To < deps[j].To
+		}
+		return deps[i].Type < deps[j].Type
+	})
+}
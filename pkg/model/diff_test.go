@@ -0,0 +1,62 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffModules(t *testing.T) {
+	oldModule := &Module{
+		Targets: map[string]*Target{
+			"//main:app":  {Label: "//main:app", Sources: []string{"main.cc"}},
+			"//util:util": {Label: "//util:util", Sources: []string{"strings.cc"}},
+			"//old:old":   {Label: "//old:old"},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//old:old", Type: DependencyStatic},
+		},
+	}
+	newModule := &Module{
+		Targets: map[string]*Target{
+			"//main:app":  {Label: "//main:app", Sources: []string{"main.cc"}},
+			"//util:util": {Label: "//util:util", Sources: []string{"strings.cc", "math.cc"}},
+			"//new:new":   {Label: "//new:new"},
+		},
+		Dependencies: []Dependency{
+			// Provenance alone does not make a dependency new
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic, Provenance: []string{"declared"}},
+			{From: "//main:app", To: "//new:new", Type: DependencyStatic},
+		},
+	}
+
+	diff := DiffModules(oldModule, newModule)
+
+	if !reflect.DeepEqual(diff.AddedTargets, []string{"//new:new"}) {
+		t.Errorf("AddedTargets = %v", diff.AddedTargets)
+	}
+	if !reflect.DeepEqual(diff.RemovedTargets, []string{"//old:old"}) {
+		t.Errorf("RemovedTargets = %v", diff.RemovedTargets)
+	}
+	if !reflect.DeepEqual(diff.ChangedTargets, []string{"//util:util"}) {
+		t.Errorf("ChangedTargets = %v", diff.ChangedTargets)
+	}
+	if len(diff.AddedDependencies) != 1 || diff.AddedDependencies[0].To != "//new:new" {
+		t.Errorf("AddedDependencies = %v", diff.AddedDependencies)
+	}
+	if len(diff.RemovedDependencies) != 1 || diff.RemovedDependencies[0].To != "//old:old" {
+		t.Errorf("RemovedDependencies = %v", diff.RemovedDependencies)
+	}
+
+	want := []string{"//main:app", "//new:new", "//old:old", "//util:util"}
+	if got := diff.AffectedTargets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AffectedTargets() = %v, want %v", got, want)
+	}
+
+	if !DiffModules(newModule, newModule).Empty() {
+		t.Errorf("DiffModules of a module with itself is not empty")
+	}
+	if got := DiffModules(nil, newModule).AddedTargets; len(got) != 3 {
+		t.Errorf("DiffModules(nil, module) added %v, want all targets", got)
+	}
+}
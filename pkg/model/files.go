@@ -0,0 +1,61 @@
+package model
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Default file extensions recognized in cc_* targets
+var (
+	DefaultSourceExtensions = []string{".cc", ".cpp", ".cxx"}
+	DefaultHeaderExtensions = []string{".h", ".hh", ".hpp"}
+)
+
+// Opt-in source extensions for toolchains compiling more than C++ in cc_* targets
+var (
+	ObjCSourceExtensions     = []string{".m", ".mm"} // Objective-C and Objective-C++
+	AssemblySourceExtensions = []string{".S", ".s"}  // Preprocessed and plain assembly
+)
+
+// FileExtensions holds the file extensions classified as sources and headers.
+// Extensions include the leading dot and are matched case-insensitively, so .CC is a
+// source file; listing either .S or .s admits both assembly flavours. An empty list means
+// the defaults for that kind of file, so the zero value classifies C++ files.
+type FileExtensions struct {
+	Sources []string
+	Headers []string
+}
+
+// SourceExtensions returns the source extensions, the defaults if none are set
+func (e FileExtensions) SourceExtensions() []string {
+	if len(e.Sources) == 0 {
+		return DefaultSourceExtensions
+	}
+	return e.Sources
+}
+
+// HeaderExtensions returns the header extensions, the defaults if none are set
+func (e FileExtensions) HeaderExtensions() []string {
+	if len(e.Headers) == 0 {
+		return DefaultHeaderExtensions
+	}
+	return e.Headers
+}
+
+// IsSource returns true if the file has a source extension
+func (e FileExtensions) IsSource(file string) bool {
+	return hasExtension(e.SourceExtensions(), file)
+}
+
+// IsHeader returns true if the file has a header extension
+func (e FileExtensions) IsHeader(file string) bool {
+	return hasExtension(e.HeaderExtensions(), file)
+}
+
+// hasExtension returns true if the file's extension, compared case-insensitively, is one
+// of extensions
+func hasExtension(extensions []string, file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	return slices.ContainsFunc(extensions, func(e string) bool { return strings.ToLower(e) == ext })
+}
@@ -0,0 +1,43 @@
+package model
+
+import "testing"
+
+func TestFileExtensions(t *testing.T) {
+	var defaults FileExtensions
+	tests := []struct {
+		file           string
+		source, header bool
+	}{
+		{"util/strings.cc", true, false},
+		{"//util:strings.cpp", true, false},
+		{"util/strings.h", false, true},
+		{"util/strings.hpp", false, true},
+		{"util/Strings.CC", true, false},
+		{"util/Strings.H", false, true},
+		{"ios/view.mm", false, false},
+		{"arch/start.S", false, false},
+		{"BUILD.bazel", false, false},
+	}
+	for _, tt := range tests {
+		if got := defaults.IsSource(tt.file); got != tt.source {
+			t.Errorf("IsSource(%q) = %v, want %v", tt.file, got, tt.source)
+		}
+		if got := defaults.IsHeader(tt.file); got != tt.header {
+			t.Errorf("IsHeader(%q) = %v, want %v", tt.file, got, tt.header)
+		}
+	}
+
+	// Opt-in extensions; headers keep their defaults
+	ext := FileExtensions{Sources: append(append([]string{".cc"}, ObjCSourceExtensions...), AssemblySourceExtensions...)}
+	for _, file := range []string{"ios/view.m", "ios/view.mm", "arch/start.S", "arch/start.s"} {
+		if !ext.IsSource(file) {
+			t.Errorf("IsSource(%q) = false with opt-in extensions", file)
+		}
+	}
+	if ext.IsSource("util/strings.cpp") {
+		t.Error("expected configured sources to replace the defaults")
+	}
+	if !ext.IsHeader("util/strings.h") {
+		t.Error("expected default headers when none are configured")
+	}
+}
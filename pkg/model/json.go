@@ -0,0 +1,57 @@
+package model
+
+import "encoding/json"
+
+// ModuleSchemaVersion is the version of the serialized shape of a Module, served by
+// GET /api/module and written by --format=json. It changes when a field is removed or
+// changes meaning; fields are added without changing it, so clients should ignore fields
+// they do not know.
+const ModuleSchemaVersion = 1
+
+// ModuleSummary holds counts derived from a Module. It is serialized along with the module
+// so that clients do not need to recompute them.
+type ModuleSummary struct {
+	Targets          int            `json:"targets"`          // Number of targets, external ones included
+	Packages         int            `json:"packages"`         // Number of distinct packages of the targets
+	Dependencies     int            `json:"dependencies"`     // Number of target-level dependencies of all types
+	Issues           int            `json:"issues"`           // Number of dependency issues
+	IssuesBySeverity map[string]int `json:"issuesBySeverity"` // Issue count by severity, e.g. {"warning": 3}
+}
+
+// Summary returns the counts derived from the module
+func (m *Module) Summary() ModuleSummary {
+	summary := ModuleSummary{
+		Targets:          len(m.Targets),
+		Dependencies:     len(m.Dependencies),
+		Issues:           len(m.Issues),
+		IssuesBySeverity: make(map[string]int),
+	}
+
+	packages := make(map[string]bool)
+	for _, target := range m.Targets {
+		packages[target.Package] = true
+	}
+	summary.Packages = len(packages)
+
+	for _, issue := range m.Issues {
+		summary.IssuesBySeverity[issue.Severity]++
+	}
+	return summary
+}
+
+// MarshalJSON encodes the module in its canonical shape: a "version" field holding
+// ModuleSchemaVersion, the fields of Module, and a "summary" with the counts of Summary.
+// The derived fields are ignored when the JSON is decoded into a Module again.
+func (m *Module) MarshalJSON() ([]byte, error) {
+	// module has the fields of Module but not its methods, so it does not recurse
+	type module Module
+	return json.Marshal(struct {
+		Version int `json:"version"`
+		*module
+		Summary ModuleSummary `json:"summary"`
+	}{
+		Version: ModuleSchemaVersion,
+		module:  (*module)(m),
+		Summary: m.Summary(),
+	})
+}
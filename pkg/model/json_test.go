@@ -0,0 +1,63 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestModuleMarshalJSON(t *testing.T) {
+	module := &Module{
+		Name: "example",
+		Targets: map[string]*Target{
+			"//main:app":  {Label: "//main:app", Kind: TargetKindBinary, Package: "//main"},
+			"//core:core": {Label: "//core:core", Kind: TargetKindLibrary, Package: "//core"},
+			"//core:impl": {Label: "//core:impl", Kind: TargetKindLibrary, Package: "//core"},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//core:core", To: "//core:impl", Type: DependencyStatic},
+		},
+		Issues: []DependencyIssue{
+			{From: "//main:app", To: "//core:core", Issue: "policy_violation", Severity: SeverityError},
+			{From: "//core:core", To: "//core:impl", Issue: "duplicate_linkage", Severity: SeverityWarning},
+			{From: "//core:impl", To: "//core:core", Issue: "duplicate_linkage", Severity: SeverityWarning},
+		},
+	}
+
+	data, err := json.Marshal(module)
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+
+	var shape struct {
+		Version int                        `json:"version"`
+		Name    string                     `json:"name"`
+		Targets map[string]json.RawMessage `json:"targets"`
+		Summary ModuleSummary              `json:"summary"`
+	}
+	if err := json.Unmarshal(data, &shape); err != nil {
+		t.Fatalf("Unmarshal() of the shape failed: %v", err)
+	}
+	if shape.Version != ModuleSchemaVersion || shape.Name != "example" || len(shape.Targets) != 3 {
+		t.Errorf("version = %d, name = %q, %d targets, want %d, example, 3", shape.Version, shape.Name, len(shape.Targets), ModuleSchemaVersion)
+	}
+	want := ModuleSummary{
+		Targets:          3,
+		Packages:         2,
+		Dependencies:     2,
+		Issues:           3,
+		IssuesBySeverity: map[string]int{SeverityError: 1, SeverityWarning: 2},
+	}
+	if !reflect.DeepEqual(shape.Summary, want) {
+		t.Errorf("summary = %+v, want %+v", shape.Summary, want)
+	}
+
+	var decoded Module
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() into a Module failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Targets, module.Targets) || !reflect.DeepEqual(decoded.Issues, module.Issues) {
+		t.Errorf("decoded module = %+v, want %+v", decoded, *module)
+	}
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"path"
+	"strings"
+)
+
+// IsExternalLabel reports whether a label refers to a target in an external repository
+// (@repo//pkg:name). Labels of the main repository written as @//pkg:name or @@//pkg:name
+// are not external.
+func IsExternalLabel(label string) bool {
+	return strings.HasPrefix(label, "@") && !strings.HasPrefix(label, "@//") && !strings.HasPrefix(label, "@@//")
+}
+
+// CanonicalizeLabel converts a user-supplied Bazel label to the canonical form used as
+// key in Module.Targets:
+//
+//	util          -> //util:util
+//	//util        -> //util:util
+//	util:strings  -> //util:strings
+//	@//util:util  -> //util:util
+//	@repo//pkg    -> @repo//pkg:pkg
+//
+// Relative labels such as ":name" are returned unchanged, use CanonicalizeLabelInPackage
+// to resolve them.
+func CanonicalizeLabel(label string) string {
+	return CanonicalizeLabelInPackage(label, "")
+}
+
+// CanonicalizeLabelInPackage is like CanonicalizeLabel but resolves relative labels
+// (":name") against contextPackage, e.g. "//util"
+func CanonicalizeLabelInPackage(label, contextPackage string) string {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return label
+	}
+
+	// The main repository can be referred to explicitly
+	if rest, ok := strings.CutPrefix(label, "@@//"); ok {
+		label = "//" + rest
+	} else if rest, ok := strings.CutPrefix(label, "@//"); ok {
+		label = "//" + rest
+	}
+
+	if strings.HasPrefix(label, ":") {
+		if contextPackage == "" {
+			return label
+		}
+		return strings.TrimSuffix(contextPackage, ":") + label
+	}
+
+	// Split off the repository so the package can be normalized
+	repo := ""
+	if strings.HasPrefix(label, "@") {
+		idx := strings.Index(label, "//")
+		if idx < 0 {
+			// "@repo" is short for "@repo//:repo"
+			return label + "//:" + strings.TrimLeft(label, "@")
+		}
+		repo, label = label[:idx], label[idx:]
+	} else if !strings.HasPrefix(label, "//") {
+		label = "//" + label
+	}
+
+	pkg, name, found := strings.Cut(label, ":")
+	if pkg != "//" {
+		pkg = strings.TrimSuffix(pkg, "/")
+	}
+	if !found || name == "" {
+		// "//pkg" is short for "//pkg:pkg"
+		name = path.Base(strings.TrimPrefix(pkg, "//"))
+		if name == "." || name == "/" {
+			return repo + pkg
+		}
+	}
+
+	return repo + pkg + ":" + name
+}
@@ -0,0 +1,58 @@
+package model
+
+import "testing"
+
+func TestCanonicalizeLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"//util:util", "//util:util"},
+		{"//util", "//util:util"},
+		{"//util/strings", "//util/strings:strings"},
+		{"//util/", "//util:util"},
+		{"util", "//util:util"},
+		{"util:strings", "//util:strings"},
+		{"@//util:util", "//util:util"},
+		{"@@//util", "//util:util"},
+		{"@abseil//absl/strings", "@abseil//absl/strings:strings"},
+		{"@abseil//absl/strings:str_format", "@abseil//absl/strings:str_format"},
+		{"@zlib", "@zlib//:zlib"},
+		{"//:app", "//:app"},
+		{"//", "//"},
+		{" //util ", "//util:util"},
+		{":strings", ":strings"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := CanonicalizeLabel(tt.label); got != tt.want {
+			t.Errorf("CanonicalizeLabel(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalizeLabelInPackage(t *testing.T) {
+	if got := CanonicalizeLabelInPackage(":strings", "//util"); got != "//util:strings" {
+		t.Errorf("CanonicalizeLabelInPackage(:strings, //util) = %q, want //util:strings", got)
+	}
+	if got := CanonicalizeLabelInPackage("//core", "//util"); got != "//core:core" {
+		t.Errorf("CanonicalizeLabelInPackage(//core, //util) = %q, want //core:core", got)
+	}
+}
+
+func TestIsExternalLabel(t *testing.T) {
+	tests := map[string]bool{
+		"@abseil//absl/strings:strings": true,
+		"@zlib//:zlib":                  true,
+		"//util:util":                   false,
+		"@//util:util":                  false,
+		"@@//util:util":                 false,
+		"util":                          false,
+	}
+	for label, want := range tests {
+		if got := IsExternalLabel(label); got != want {
+			t.Errorf("IsExternalLabel(%q) = %v, want %v", label, got, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package model
+
+import "sort"
+
+// TargetMetrics holds graph metrics for a single target
+type TargetMetrics struct {
+	Label           string `json:"label"`           // Target label
+	InDegree        int    `json:"inDegree"`        // Number of targets that directly depend on this target
+	OutDegree       int    `json:"outDegree"`       // Number of targets this target directly depends on
+	TransitiveRdeps int    `json:"transitiveRdeps"` // Number of targets that depend on this target directly or indirectly
+	IsHub           bool   `json:"isHub"`           // Depended on by many targets (changes ripple widely)
+	IsGodObject     bool   `json:"isGodObject"`     // Depends on many targets
+}
+
+// ComputeTargetMetrics computes degree metrics for all targets in the module.
+// Degrees count distinct neighbor targets regardless of dependency type.
+// A target is flagged as a hub if its in-degree is at least hubThreshold, and
+// as a god object if its out-degree is at least godObjectThreshold.
+// A threshold of zero or less disables the corresponding flag.
+func (m *Module) ComputeTargetMetrics(hubThreshold, godObjectThreshold int) map[string]*TargetMetrics {
+	// Build deduplicated adjacency in both directions
+	forward := make(map[string]map[string]bool)
+	reverse := make(map[string]map[string]bool)
+	for _, dep := range m.Dependencies {
+		if dep.From == dep.To || m.Targets[dep.From] == nil || m.Targets[dep.To] == nil {
+			continue
+		}
+		if forward[dep.From] == nil {
+			forward[dep.From] = make(map[string]bool)
+		}
+		forward[dep.From][dep.To] = true
+		if reverse[dep.To] == nil {
+			reverse[dep.To] = make(map[string]bool)
+		}
+		reverse[dep.To][dep.From] = true
+	}
+
+	metrics := make(map[string]*TargetMetrics, len(m.Targets))
+	for label := range m.Targets {
+		metrics[label] = &TargetMetrics{
+			Label:           label,
+			InDegree:        len(reverse[label]),
+			OutDegree:       len(forward[label]),
+			TransitiveRdeps: countReachable(label, reverse),
+		}
+		metrics[label].IsHub = hubThreshold > 0 && metrics[label].InDegree >= hubThreshold
+		metrics[label].IsGodObject = godObjectThreshold > 0 && metrics[label].OutDegree >= godObjectThreshold
+	}
+
+	return metrics
+}
+
+// FindHubs returns the metrics of all targets flagged as hubs or god objects,
+// sorted by transitive reverse dependency count (largest ripple effect first)
+func (m *Module) FindHubs(hubThreshold, godObjectThreshold int) []TargetMetrics {
+	return Hubs(m.ComputeTargetMetrics(hubThreshold, godObjectThreshold))
+}
+
+// Hubs returns the metrics flagged as hubs or god objects, sorted like FindHubs
+func Hubs(metrics map[string]*TargetMetrics) []TargetMetrics {
+	result := make([]TargetMetrics, 0)
+	for _, tm := range metrics {
+		if tm.IsHub || tm.IsGodObject {
+			result = append(result, *tm)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TransitiveRdeps != result[j].TransitiveRdeps {
+			return result[i].TransitiveRdeps > result[j].TransitiveRdeps
+		}
+		return result[i].Label < result[j].Label
+	})
+
+	return result
+}
+
+// countReachable returns the number of nodes reachable from start (excluding start itself)
+func countReachable(start string, adjacency map[string]map[string]bool) int {
+	visited := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for next := range adjacency[current] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return len(visited) - 1
+}
@@ -0,0 +1,75 @@
+package model
+
+import "testing"
+
+func newMetricsTestModule() *Module {
+	targets := map[string]*Target{}
+	for _, label := range []string{"//main:app", "//main:tool", "//core:core", "//util:util", "//util:log"} {
+		targets[label] = &Target{Label: label}
+	}
+
+	return &Module{
+		Targets: targets,
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//main:app", To: "//core:core", Type: DependencySymbol}, // Same neighbor, counted once
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:log", Type: DependencyStatic},
+			{From: "//main:tool", To: "//util:util", Type: DependencyStatic},
+			{From: "//core:core", To: "//util:util", Type: DependencyCompile},
+			{From: "//util:util", To: "//util:log", Type: DependencyStatic},
+			{From: "//util:util", To: "@external//:lib", Type: DependencyStatic}, // Unknown target, ignored
+		},
+	}
+}
+
+func TestComputeTargetMetrics(t *testing.T) {
+	metrics := newMetricsTestModule().ComputeTargetMetrics(3, 3)
+
+	tests := []struct {
+		label                                string
+		inDegree, outDegree, transitiveRdeps int
+		isHub, isGodObject                   bool
+	}{
+		{"//main:app", 0, 3, 0, false, true},
+		{"//main:tool", 0, 1, 0, false, false},
+		{"//core:core", 1, 1, 1, false, false},
+		{"//util:util", 3, 1, 3, true, false},
+		{"//util:log", 2, 0, 4, false, false},
+	}
+
+	for _, tt := range tests {
+		m := metrics[tt.label]
+		if m == nil {
+			t.Fatalf("no metrics for %s", tt.label)
+		}
+		if m.InDegree != tt.inDegree || m.OutDegree != tt.outDegree || m.TransitiveRdeps != tt.transitiveRdeps {
+			t.Errorf("%s: got in=%d out=%d transitive=%d, want in=%d out=%d transitive=%d", tt.label,
+				m.InDegree, m.OutDegree, m.TransitiveRdeps, tt.inDegree, tt.outDegree, tt.transitiveRdeps)
+		}
+		if m.IsHub != tt.isHub || m.IsGodObject != tt.isGodObject {
+			t.Errorf("%s: got hub=%v god=%v, want hub=%v god=%v", tt.label, m.IsHub, m.IsGodObject, tt.isHub, tt.isGodObject)
+		}
+	}
+}
+
+func TestFindHubs(t *testing.T) {
+	module := newMetricsTestModule()
+
+	hubs := module.FindHubs(2, 3)
+	if len(hubs) != 3 {
+		t.Fatalf("FindHubs() returned %d targets, want 3: %v", len(hubs), hubs)
+	}
+
+	// Sorted by transitive reverse dependencies, largest first
+	want := []string{"//util:log", "//util:util", "//main:app"}
+	for i, label := range want {
+		if hubs[i].Label != label {
+			t.Errorf("hubs[%d] = %s, want %s", i, hubs[i].Label, label)
+		}
+	}
+
+	if got := module.FindHubs(0, 0); len(got) != 0 {
+		t.Errorf("FindHubs(0, 0) = %v, want no targets", got)
+	}
+}
@@ -1,5 +1,13 @@
 package model
 
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
 // TargetKind represents the type of Bazel target
 type TargetKind string
 
@@ -7,6 +15,11 @@ const (
 	TargetKindBinary        TargetKind = "cc_binary"
 	TargetKindSharedLibrary TargetKind = "cc_shared_library"
 	TargetKindLibrary       TargetKind = "cc_library"
+	TargetKindTest          TargetKind = "cc_test"
+
+	// TargetKindExternal is an external repository target (@repo//...) whose rule could not
+	// be queried, known only as a dependency of workspace targets
+	TargetKindExternal TargetKind = "external"
 )
 
 // DependencyType represents the type of dependency between targets
@@ -23,7 +36,7 @@ const (
 // Target represents a Bazel build target
 type Target struct {
 	Label   string     `json:"label"`   // Full label (e.g., "//main:test_app")
-	Kind    TargetKind `json:"kind"`    // cc_binary, cc_shared_library, or cc_library
+	Kind    TargetKind `json:"kind"`    // cc_binary, cc_shared_library, cc_library, or cc_test
 	Package string     `json:"package"` // Package path (e.g., "//main")
 	Name    string     `json:"name"`    // Target name (e.g., "test_app")
 
@@ -36,6 +49,88 @@ type Target struct {
 
 	// System library linking options (not represented as Dependencies)
 	Linkopts []string `json:"linkopts,omitempty"` // linkopts (for system libraries like -ldl)
+
+	// Include path configuration (used to resolve include-relative header paths)
+	Includes []string `json:"includes,omitempty"` // includes attribute, relative to the package (e.g., ["include"])
+	Copts    []string `json:"copts,omitempty"`    // copts (may contain -I, -iquote, -isystem flags)
+
+	// Preprocessor defines (NAME or NAME=VALUE)
+	Defines      []string `json:"defines,omitempty"`      // defines, propagated to all dependents
+	LocalDefines []string `json:"localDefines,omitempty"` // local_defines, only for the target itself
+
+	// Bazel tags (e.g., ["manual", "team:graphics"])
+	Tags []string `json:"tags,omitempty"`
+
+	// Owners of the target's package from CODEOWNERS, space-separated (e.g., "@org/graphics")
+	Owner string `json:"owner,omitempty"`
+
+	// Linking behavior
+	Alwayslink bool `json:"alwayslink,omitempty"` // All object files are linked, even if no symbol is referenced
+	Linkstatic bool `json:"linkstatic,omitempty"` // cc_binary: link deps statically; cc_library: don't build a shared library
+
+	// cc_library with headers but no sources. It produces no object files, so its use only
+	// shows in compile (#include) dependencies.
+	HeaderOnly bool `json:"headerOnly,omitempty"`
+
+	// In an external repository (label starting with @). Its files are not analyzed.
+	External bool `json:"external,omitempty"`
+}
+
+// HasLinkLine reports whether the target is linked on its own (cc_binary, cc_shared_library
+// or cc_test). Its link line carries the linkopts of its whole static closure.
+func (t *Target) HasLinkLine() bool {
+	return t.Kind == TargetKindBinary || t.Kind == TargetKindSharedLibrary || t.Kind == TargetKindTest
+}
+
+// DeclaredSystemLibraries returns the system libraries of the target's own -l linkopts, in
+// order and without duplicates (e.g., "m" for -lm)
+func (t *Target) DeclaredSystemLibraries() []string {
+	var libraries []string
+	for _, opt := range t.Linkopts {
+		if lib := strings.TrimPrefix(opt, "-l"); lib != opt && lib != "" && !slices.Contains(libraries, lib) {
+			libraries = append(libraries, lib)
+		}
+	}
+	return libraries
+}
+
+// ExportedIncludeDirs returns the workspace-relative include directories from the
+// includes attribute. Bazel propagates these to all dependents of the target.
+func (t *Target) ExportedIncludeDirs() []string {
+	pkgDir := strings.TrimPrefix(t.Package, "//")
+	dirs := make([]string, 0, len(t.Includes))
+	for _, inc := range t.Includes {
+		dirs = append(dirs, path.Join(pkgDir, inc))
+	}
+	return dirs
+}
+
+// IncludeDirs returns the workspace-relative include directories used when compiling
+// the target itself, derived from the includes attribute and include flags in copts
+func (t *Target) IncludeDirs() []string {
+	dirs := t.ExportedIncludeDirs()
+
+	// Include flags in copts are relative to the execution root (= workspace root)
+	for i := 0; i < len(t.Copts); i++ {
+		opt := t.Copts[i]
+		for _, flag := range []string{"-I", "-iquote", "-isystem"} {
+			if !strings.HasPrefix(opt, flag) {
+				continue
+			}
+			dir := strings.TrimPrefix(opt, flag)
+			if dir == "" && i+1 < len(t.Copts) {
+				// Flag and directory given as separate options
+				i++
+				dir = t.Copts[i]
+			}
+			if dir != "" && !path.IsAbs(dir) {
+				dirs = append(dirs, path.Clean(dir))
+			}
+			break
+		}
+	}
+
+	return dirs
 }
 
 // IsPublic returns true if the target has public visibility
@@ -66,6 +161,67 @@ type Dependency struct {
 	From string         `json:"from"` // Source target label
 	To   string         `json:"to"`   // Target dependency label
 	Type DependencyType `json:"type"` // Type of dependency
+
+	// All evidence for a dependency between From and To, shared by every dependency of
+	// the pair (e.g., ["declared", "symbol"]). Set by UpdateProvenance.
+	Provenance []string `json:"provenance,omitempty"`
+}
+
+// Provenance values recording why a dependency exists
+const (
+	ProvenanceDeclared = "declared" // Declared in deps, dynamic_deps or data
+	ProvenanceCompile  = "compile"  // Inferred from #include directives in .d files
+	ProvenanceSymbol   = "symbol"   // Inferred from symbol usage in object files (nm)
+)
+
+// provenanceOrder is the order of the values in Dependency.Provenance
+var provenanceOrder = []string{ProvenanceDeclared, ProvenanceCompile, ProvenanceSymbol}
+
+// Provenance returns the kind of evidence a dependency of this type represents
+func (t DependencyType) Provenance() string {
+	switch t {
+	case DependencyCompile:
+		return ProvenanceCompile
+	case DependencySymbol:
+		return ProvenanceSymbol
+	default:
+		return ProvenanceDeclared
+	}
+}
+
+// HasProvenance returns true if the given evidence exists for the dependency
+func (d Dependency) HasProvenance(provenance string) bool {
+	for _, p := range d.Provenance {
+		if p == provenance {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateProvenance sets the provenance of every dependency to all evidence found for its
+// target pair, so a declared dependency that is also used through symbols records both
+func (m *Module) UpdateProvenance() {
+	evidence := make(map[InternalEdge]map[string]bool)
+	for _, dep := range m.Dependencies {
+		edge := InternalEdge{FromTarget: dep.From, ToTarget: dep.To}
+		if evidence[edge] == nil {
+			evidence[edge] = make(map[string]bool)
+		}
+		evidence[edge][dep.Type.Provenance()] = true
+	}
+
+	for i := range m.Dependencies {
+		dep := &m.Dependencies[i]
+		found := evidence[InternalEdge{FromTarget: dep.From, ToTarget: dep.To}]
+
+		dep.Provenance = make([]string, 0, len(found))
+		for _, p := range provenanceOrder {
+			if found[p] {
+				dep.Provenance = append(dep.Provenance, p)
+			}
+		}
+	}
 }
 
 // Package represents a Bazel package with its targets
@@ -81,6 +237,25 @@ type PackageDependency struct {
 	Dependencies map[DependencyType][]InternalEdge `json:"dependencies"` // Grouped by type
 }
 
+// Count returns the number of target-level edges for each dependency type
+func (pd PackageDependency) Count() map[DependencyType]int {
+	counts := make(map[DependencyType]int, len(pd.Dependencies))
+	for depType, edges := range pd.Dependencies {
+		counts[depType] = len(edges)
+	}
+	return counts
+}
+
+// TotalCount returns the total number of target-level edges across all dependency types.
+// This is a measure of how strongly the two packages are coupled.
+func (pd PackageDependency) TotalCount() int {
+	total := 0
+	for _, edges := range pd.Dependencies {
+		total += len(edges)
+	}
+	return total
+}
+
 // InternalEdge represents a single dependency edge between targets
 type InternalEdge struct {
 	FromTarget string `json:"fromTarget"` // Source target label
@@ -93,17 +268,197 @@ type DependencyIssue struct {
 	To          string   `json:"to"`          // Target dependency label
 	Issue       string   `json:"issue"`       // Description of the issue
 	Types       []string `json:"types"`       // Conflicting dependency types
-	Severity    string   `json:"severity"`    // "warning" or "error"
+	Severity    string   `json:"severity"`    // SeverityInfo, SeverityWarning or SeverityError
 	Description string   `json:"description"` // Detailed explanation
 }
 
-// Module represents the complete build graph (a Bazel workspace/module)
+// Module represents the complete build graph (a Bazel workspace/module). It is serialized
+// with a schema version and derived counts (see MarshalJSON).
 type Module struct {
 	Name          string             `json:"name"`          // Workspace/module name
 	WorkspacePath string             `json:"workspacePath"` // Absolute path to workspace directory
+	AnalyzedAt    time.Time          `json:"analyzedAt"`    // When the last analysis of the module completed
 	Targets       map[string]*Target `json:"targets"`       // Map of label -> Target
 	Dependencies  []Dependency       `json:"dependencies"`  // All target-level dependencies
 	Issues        []DependencyIssue  `json:"issues"`        // Dependency issues/warnings
+
+	SymbolCrossings []SymbolCrossing `json:"symbolCrossings,omitempty"` // Symbols used across target boundaries
+}
+
+// GetDependenciesFrom returns all dependencies where the given target is the source
+func (m *Module) GetDependenciesFrom(label string) []Dependency {
+	var result []Dependency
+	for _, dep := range m.Dependencies {
+		if dep.From == label {
+			result = append(result, dep)
+		}
+	}
+	return result
+}
+
+// GetDependenciesTo returns all dependencies where the given target is the destination
+// (i.e., the reverse dependencies of the target)
+func (m *Module) GetDependenciesTo(label string) []Dependency {
+	var result []Dependency
+	for _, dep := range m.Dependencies {
+		if dep.To == label {
+			result = append(result, dep)
+		}
+	}
+	return result
+}
+
+// SystemLibraries returns the system libraries (-l linkopts) linked into anything that
+// links the target: those declared by the target and by its transitive static dependencies.
+// The result is sorted and free of duplicates.
+func (m *Module) SystemLibraries(label string) []string {
+	deps, _ := m.staticAdjacency()
+	return m.systemLibraries(label, deps)
+}
+
+// LinkedSystemLibraries returns the system libraries on the link line of each target that is
+// linked on its own (see HasLinkLine and SystemLibraries), by label. Targets without system
+// libraries are omitted.
+func (m *Module) LinkedSystemLibraries() map[string][]string {
+	deps, _ := m.staticAdjacency()
+	result := make(map[string][]string)
+	for label, target := range m.Targets {
+		if !target.HasLinkLine() {
+			continue
+		}
+		if libraries := m.systemLibraries(label, deps); len(libraries) > 0 {
+			result[label] = libraries
+		}
+	}
+	return result
+}
+
+func (m *Module) systemLibraries(label string, deps map[string][]string) []string {
+	libraries := make(map[string]bool)
+	for current := range reachable(label, deps) {
+		if target := m.Targets[current]; target != nil {
+			for _, lib := range target.DeclaredSystemLibraries() {
+				libraries[lib] = true
+			}
+		}
+	}
+
+	result := make([]string, 0, len(libraries))
+	for lib := range libraries {
+		result = append(result, lib)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// TargetsByTag returns the labels of the targets carrying each tag, sorted
+func (m *Module) TargetsByTag() map[string][]string {
+	byTag := make(map[string][]string)
+	for label, target := range m.Targets {
+		for _, tag := range target.Tags {
+			byTag[tag] = append(byTag[tag], label)
+		}
+	}
+	for _, labels := range byTag {
+		sort.Strings(labels)
+	}
+	return byTag
+}
+
+// LinkClosure returns the target and everything it links: its transitive static and
+// dynamic dependencies. The result is sorted.
+func (m *Module) LinkClosure(label string) []string {
+	adjacency := make(map[string][]string)
+	for _, dep := range m.Dependencies {
+		if dep.Type == DependencyStatic || dep.Type == DependencyDynamic {
+			adjacency[dep.From] = append(adjacency[dep.From], dep.To)
+		}
+	}
+
+	result := make([]string, 0)
+	for target := range reachable(label, adjacency) {
+		result = append(result, target)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// SharedLibraryTargets returns the cc_shared_library targets and everything they link.
+// Symbols defined by these targets may be used by code outside the workspace.
+func (m *Module) SharedLibraryTargets() map[string]bool {
+	result := make(map[string]bool)
+	for label, target := range m.Targets {
+		if target.Kind != TargetKindSharedLibrary {
+			continue
+		}
+		for _, linked := range m.LinkClosure(label) {
+			result[linked] = true
+		}
+	}
+	return result
+}
+
+// OrphanTargets returns the cc_library targets that no other target depends on, sorted by label.
+// These are candidates for removal. If publicAsRoots is true, public libraries are treated as
+// entry points for external users and are not reported. Header-only libraries count as used
+// only if their headers are included (a compile dependency), unless the module has no compile
+// dependencies at all, e.g. before anything was built.
+func (m *Module) OrphanTargets(publicAsRoots bool) []string {
+	used := make(map[string]bool)
+	included := make(map[string]bool)
+	for _, dep := range m.Dependencies {
+		if dep.From != dep.To {
+			used[dep.To] = true
+			if dep.Type == DependencyCompile {
+				included[dep.To] = true
+			}
+		}
+	}
+	hasCompileDeps := len(included) > 0
+
+	result := make([]string, 0)
+	for label, target := range m.Targets {
+		if target.Kind != TargetKindLibrary || target.External {
+			continue
+		}
+		if target.HeaderOnly && hasCompileDeps {
+			if included[label] {
+				continue
+			}
+		} else if used[label] {
+			continue
+		}
+		if publicAsRoots && target.IsPublic() {
+			continue
+		}
+		result = append(result, label)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// GetIssuesFor returns all dependency issues involving the given target
+func (m *Module) GetIssuesFor(label string) []DependencyIssue {
+	var result []DependencyIssue
+	for _, issue := range m.Issues {
+		if issue.From == label || issue.To == label {
+			result = append(result, issue)
+		}
+	}
+	return result
+}
+
+// ReplaceIssues replaces the issues with the given code by issues, keeping all others, so
+// that repeated analysis runs don't accumulate duplicates
+func (m *Module) ReplaceIssues(code string, issues []DependencyIssue) {
+	kept := make([]DependencyIssue, 0, len(m.Issues)+len(issues))
+	for _, issue := range m.Issues {
+		if issue.Issue != code {
+			kept = append(kept, issue)
+		}
+	}
+	m.Issues = append(kept, issues...)
 }
 
 // GetPackages derives the package structure from targets
@@ -125,6 +480,21 @@ func (m *Module) GetPackages() map[string]*Package {
 	return packages
 }
 
+// GetSortedPackages returns all packages in the module sorted by path
+func (m *Module) GetSortedPackages() []*Package {
+	packages := m.GetPackages()
+
+	result := make([]*Package, 0, len(packages))
+	for _, pkg := range packages {
+		result = append(result, pkg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result
+}
+
 // GetPackageCount returns the number of unique packages
 func (m *Module) GetPackageCount() int {
 	packageSet := make(map[string]bool)
@@ -183,6 +553,7 @@ func (m *Module) GetPackageDependencies(packagePath string) []PackageDependency
 		result = append(result, *pkgDep)
 	}
 
+	sortPackageDependencies(result)
 	return result
 }
 
@@ -232,5 +603,28 @@ func (m *Module) GetAllPackageDependencies() []PackageDependency {
 		result = append(result, *pkgDep)
 	}
 
+	sortPackageDependencies(result)
 	return result
 }
+
+// sortPackageDependencies sorts package dependencies by from/to package and the
+// internal edges of each dependency type by from/to target, making output stable
+func sortPackageDependencies(pkgDeps []PackageDependency) {
+	sort.Slice(pkgDeps, func(i, j int) bool {
+		if pkgDeps[i].From != pkgDeps[j].From {
+			return pkgDeps[i].From < pkgDeps[j].From
+		}
+		return pkgDeps[i].To < pkgDeps[j].To
+	})
+
+	for _, pkgDep := range pkgDeps {
+		for _, edges := range pkgDep.Dependencies {
+			sort.Slice(edges, func(i, j int) bool {
+				if edges[i].FromTarget != edges[j].FromTarget {
+					return edges[i].FromTarget < edges[j].FromTarget
+				}
+				return edges[i].ToTarget < edges[j].ToTarget
+			})
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackageDependencyCount(t *testing.T) {
+	pd := PackageDependency{
+		From: "//main",
+		To:   "//util",
+		Dependencies: map[DependencyType][]InternalEdge{
+			DependencyStatic: {
+				{FromTarget: "//main:app", ToTarget: "//util:util"},
+				{FromTarget: "//main:tool", ToTarget: "//util:util"},
+			},
+			DependencyCompile: {
+				{FromTarget: "//main:app", ToTarget: "//util:util"},
+			},
+		},
+	}
+
+	want := map[DependencyType]int{
+		DependencyStatic:  2,
+		DependencyCompile: 1,
+	}
+	if got := pd.Count(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Count() = %v, want %v", got, want)
+	}
+
+	if got := pd.TotalCount(); got != 3 {
+		t.Errorf("TotalCount() = %d, want 3", got)
+	}
+}
+
+func TestPackageDependencyCountEmpty(t *testing.T) {
+	pd := PackageDependency{From: "//a", To: "//b"}
+
+	if got := pd.Count(); len(got) != 0 {
+		t.Errorf("Count() = %v, want empty map", got)
+	}
+	if got := pd.TotalCount(); got != 0 {
+		t.Errorf("TotalCount() = %d, want 0", got)
+	}
+}
+
+func TestGetAllPackageDependenciesTotalCount(t *testing.T) {
+	module := &Module{
+		Targets: map[string]*Target{
+			"//main:app":  {Label: "//main:app", Package: "//main", Name: "app"},
+			"//util:util": {Label: "//util:util", Package: "//util", Name: "util"},
+			"//util:math": {Label: "//util:math", Package: "//util", Name: "math"},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:math", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:util", Type: DependencySymbol},
+			{From: "//util:util", To: "//util:math", Type: DependencyStatic},
+		},
+	}
+
+	pkgDeps := module.GetAllPackageDependencies()
+	if len(pkgDeps) != 1 {
+		t.Fatalf("expected 1 package dependency, got %d", len(pkgDeps))
+	}
+
+	counts := pkgDeps[0].Count()
+	if counts[DependencyStatic] != 2 || counts[DependencySymbol] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+	if pkgDeps[0].TotalCount() != 3 {
+		t.Errorf("TotalCount() = %d, want 3", pkgDeps[0].TotalCount())
+	}
+}
+
+func TestGetDependenciesFromAndTo(t *testing.T) {
+	module := &Module{
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//core:core", To: "//util:util", Type: DependencyCompile},
+		},
+		Issues: []DependencyIssue{
+			{From: "//main:app", To: "//core:core", Issue: "duplicate_linkage"},
+			{From: "//core:core", To: "//util:util", Issue: "policy_violation"},
+		},
+	}
+
+	if got := module.GetDependenciesFrom("//main:app"); len(got) != 2 {
+		t.Errorf("GetDependenciesFrom() returned %d deps, want 2", len(got))
+	}
+
+	reverse := module.GetDependenciesTo("//util:util")
+	if len(reverse) != 2 {
+		t.Fatalf("GetDependenciesTo() returned %d deps, want 2", len(reverse))
+	}
+	if reverse[1].From != "//core:core" || reverse[1].Type != DependencyCompile {
+		t.Errorf("unexpected reverse dependency: %+v", reverse[1])
+	}
+
+	if got := module.GetIssuesFor("//util:util"); len(got) != 1 || got[0].Issue != "policy_violation" {
+		t.Errorf("GetIssuesFor() = %v, want the policy_violation issue", got)
+	}
+}
+
+func TestOrphanTargets(t *testing.T) {
+	public := []string{"//visibility:public"}
+	module := &Module{
+		Targets: map[string]*Target{
+			"//main:app":    {Label: "//main:app", Kind: TargetKindBinary},
+			"//core:core":   {Label: "//core:core", Kind: TargetKindLibrary},
+			"//core:legacy": {Label: "//core:legacy", Kind: TargetKindLibrary},
+			"//util:self":   {Label: "//util:self", Kind: TargetKindLibrary},
+			"//api:api":     {Label: "//api:api", Kind: TargetKindLibrary, Visibility: public},
+			"//plugin:so":   {Label: "//plugin:so", Kind: TargetKindSharedLibrary},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//util:self", To: "//util:self", Type: DependencyCompile}, // Self edges don't count
+		},
+	}
+
+	if got, want := module.OrphanTargets(true), []string{"//core:legacy", "//util:self"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OrphanTargets(true) = %v, want %v", got, want)
+	}
+
+	if got, want := module.OrphanTargets(false), []string{"//api:api", "//core:legacy", "//util:self"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OrphanTargets(false) = %v, want %v", got, want)
+	}
+}
+
+func TestOrphanTargetsHeaderOnly(t *testing.T) {
+	module := &Module{
+		Targets: map[string]*Target{
+			"//main:app":      {Label: "//main:app", Kind: TargetKindBinary},
+			"//util:strings":  {Label: "//util:strings", Kind: TargetKindLibrary, HeaderOnly: true},
+			"//util:math":     {Label: "//util:math", Kind: TargetKindLibrary, HeaderOnly: true},
+			"//util:compiled": {Label: "//util:compiled", Kind: TargetKindLibrary},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//util:strings", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:strings", Type: DependencyCompile},
+			{From: "//main:app", To: "//util:math", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:compiled", Type: DependencyStatic},
+		},
+	}
+
+	// Declared but never included
+	if got, want := module.OrphanTargets(false), []string{"//util:math"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OrphanTargets() = %v, want %v", got, want)
+	}
+
+	// Without any compile dependencies, declared dependencies are all there is
+	module.Dependencies = module.Dependencies[:1]
+	module.Dependencies = append(module.Dependencies, Dependency{From: "//main:app", To: "//util:math", Type: DependencyStatic})
+	if got, want := module.OrphanTargets(false), []string{"//util:compiled"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OrphanTargets() without compile deps = %v, want %v", got, want)
+	}
+}
+
+func TestPackageAggregationOrderIsStable(t *testing.T) {
+	module := &Module{
+		Targets: map[string]*Target{
+			"//main:app":  {Label: "//main:app", Package: "//main", Name: "app"},
+			"//main:tool": {Label: "//main:tool", Package: "//main", Name: "tool"},
+			"//core:core": {Label: "//core:core", Package: "//core", Name: "core"},
+			"//util:util": {Label: "//util:util", Package: "//util", Name: "util"},
+			"//util:math": {Label: "//util:math", Package: "//util", Name: "math"},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:tool", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:math", Type: DependencyStatic},
+			{From: "//main:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic},
+			{From: "//core:core", To: "//util:util", Type: DependencyStatic},
+		},
+	}
+
+	// Map iteration order varies between runs, so repeat to catch nondeterminism
+	for range 20 {
+		var pairs []string
+		for _, pd := range module.GetAllPackageDependencies() {
+			pairs = append(pairs, pd.From+" -> "+pd.To)
+		}
+		if want := []string{"//core -> //util", "//main -> //core", "//main -> //util"}; !reflect.DeepEqual(pairs, want) {
+			t.Fatalf("GetAllPackageDependencies() order = %v, want %v", pairs, want)
+		}
+
+		mainDeps := module.GetPackageDependencies("//main")
+		if len(mainDeps) != 2 || mainDeps[0].To != "//core" || mainDeps[1].To != "//util" {
+			t.Fatalf("GetPackageDependencies(//main) order = %v", mainDeps)
+		}
+		wantEdges := []InternalEdge{
+			{FromTarget: "//main:app", ToTarget: "//util:math"},
+			{FromTarget: "//main:app", ToTarget: "//util:util"},
+			{FromTarget: "//main:tool", ToTarget: "//util:util"},
+		}
+		if got := mainDeps[1].Dependencies[DependencyStatic]; !reflect.DeepEqual(got, wantEdges) {
+			t.Fatalf("internal edges = %v, want %v", got, wantEdges)
+		}
+
+		var paths []string
+		for _, pkg := range module.GetSortedPackages() {
+			paths = append(paths, pkg.Path)
+		}
+		if want := []string{"//core", "//main", "//util"}; !reflect.DeepEqual(paths, want) {
+			t.Fatalf("GetSortedPackages() order = %v, want %v", paths, want)
+		}
+	}
+}
+
+func TestUpdateProvenance(t *testing.T) {
+	module := &Module{
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//util:util", Type: DependencySymbol},
+			{From: "//main:app", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//util:util", Type: DependencyCompile},
+			{From: "//main:app", To: "//plugin:so", Type: DependencyDynamic},
+			{From: "//core:core", To: "//util:util", Type: DependencySymbol},
+		},
+	}
+
+	module.UpdateProvenance()
+
+	want := [][]string{
+		{ProvenanceDeclared, ProvenanceCompile, ProvenanceSymbol},
+		{ProvenanceDeclared, ProvenanceCompile, ProvenanceSymbol},
+		{ProvenanceDeclared, ProvenanceCompile, ProvenanceSymbol},
+		{ProvenanceDeclared},
+		{ProvenanceSymbol},
+	}
+	for i, dep := range module.Dependencies {
+		if !reflect.DeepEqual(dep.Provenance, want[i]) {
+			t.Errorf("%s -> %s (%s): Provenance = %v, want %v", dep.From, dep.To, dep.Type, dep.Provenance, want[i])
+		}
+	}
+
+	// A symbol coupling without a declared dependency is visible at a glance
+	if undeclared := module.Dependencies[4]; undeclared.HasProvenance(ProvenanceDeclared) {
+		t.Errorf("expected %s -> %s to be undeclared", undeclared.From, undeclared.To)
+	}
+}
+
+func TestSystemLibraries(t *testing.T) {
+	m := &Module{
+		Targets: map[string]*Target{
+			"//main:app":  {Label: "//main:app", Linkopts: []string{"-ldl", "-Wl,--as-needed"}},
+			"//core:core": {Label: "//core:core", Linkopts: []string{"-lpthread"}},
+			"//util:util": {Label: "//util:util", Linkopts: []string{"-lm", "-lpthread"}},
+			"//tool:tool": {Label: "//tool:tool", Linkopts: []string{"-lz"}},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//core:core", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//tool:tool", Type: DependencyCompile},
+		},
+	}
+
+	if got, want := m.SystemLibraries("//main:app"), []string{"dl", "m", "pthread"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SystemLibraries(//main:app) = %v, want %v", got, want)
+	}
+	if got, want := m.SystemLibraries("//util:util"), []string{"m", "pthread"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SystemLibraries(//util:util) = %v, want %v", got, want)
+	}
+	if got := m.SystemLibraries("//missing:missing"); len(got) != 0 {
+		t.Errorf("SystemLibraries(//missing:missing) = %v, want none", got)
+	}
+}
+
+func TestLinkedSystemLibraries(t *testing.T) {
+	m := &Module{
+		Targets: map[string]*Target{
+			"//main:app":      {Label: "//main:app", Kind: TargetKindBinary},
+			"//main:app_test": {Label: "//main:app_test", Kind: TargetKindTest, Linkopts: []string{"-lgtest"}},
+			"//math:math":     {Label: "//math:math", Kind: TargetKindLibrary, Linkopts: []string{"-lm", "-lm"}},
+			"//plugin:so":     {Label: "//plugin:so", Kind: TargetKindSharedLibrary},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//math:math", Type: DependencyStatic},
+			{From: "//main:app_test", To: "//math:math", Type: DependencyStatic},
+		},
+	}
+
+	want := map[string][]string{
+		"//main:app":      {"m"},
+		"//main:app_test": {"gtest", "m"},
+	}
+	if got := m.LinkedSystemLibraries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LinkedSystemLibraries() = %v, want %v", got, want)
+	}
+	if got := m.Targets["//math:math"].DeclaredSystemLibraries(); !reflect.DeepEqual(got, []string{"m"}) {
+		t.Errorf("DeclaredSystemLibraries() = %v, want [m]", got)
+	}
+}
+
+func TestLinkClosure(t *testing.T) {
+	m := &Module{
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//main:app", To: "//plugin:plugin", Type: DependencyDynamic},
+			{From: "//core:core", To: "//util:util", Type: DependencyStatic},
+			{From: "//main:app", To: "//tool:tool", Type: DependencyData},
+			{From: "//main:app", To: "//api:api", Type: DependencyCompile},
+		},
+	}
+
+	want := []string{"//core:core", "//main:app", "//plugin:plugin", "//util:util"}
+	if got := m.LinkClosure("//main:app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("LinkClosure(//main:app) = %v, want %v", got, want)
+	}
+	if got, want := m.LinkClosure("//util:util"), []string{"//util:util"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LinkClosure(//util:util) = %v, want %v", got, want)
+	}
+}
+
+func TestSharedLibraryTargets(t *testing.T) {
+	m := &Module{
+		Targets: map[string]*Target{
+			"//main:app":      {Label: "//main:app", Kind: TargetKindBinary},
+			"//plugin:plugin": {Label: "//plugin:plugin", Kind: TargetKindSharedLibrary},
+			"//plugin:impl":   {Label: "//plugin:impl", Kind: TargetKindLibrary},
+			"//util:util":     {Label: "//util:util", Kind: TargetKindLibrary},
+			"//core:core":     {Label: "//core:core", Kind: TargetKindLibrary},
+		},
+		Dependencies: []Dependency{
+			{From: "//main:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//main:app", To: "//plugin:plugin", Type: DependencyDynamic},
+			{From: "//plugin:plugin", To: "//plugin:impl", Type: DependencyStatic},
+			{From: "//plugin:impl", To: "//util:util", Type: DependencyStatic},
+		},
+	}
+
+	want := map[string]bool{"//plugin:plugin": true, "//plugin:impl": true, "//util:util": true}
+	if got := m.SharedLibraryTargets(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SharedLibraryTargets() = %v, want %v", got, want)
+	}
+}
+
+func TestReplaceIssues(t *testing.T) {
+	module := &Module{Issues: []DependencyIssue{
+		{From: "//main:app", To: "//util:util", Issue: IssueDuplicateLinkage},
+		{From: "//ui:ui", To: "//db:db", Issue: "policy_violation"},
+	}}
+
+	module.ReplaceIssues("policy_violation", []DependencyIssue{{From: "//ui:ui", To: "//net:net", Issue: "policy_violation"}})
+
+	want := []DependencyIssue{
+		{From: "//main:app", To: "//util:util", Issue: IssueDuplicateLinkage},
+		{From: "//ui:ui", To: "//net:net", Issue: "policy_violation"},
+	}
+	if !reflect.DeepEqual(module.Issues, want) {
+		t.Errorf("Issues = %+v, want %+v", module.Issues, want)
+	}
+}
+
+func TestApplySeverities(t *testing.T) {
+	module := &Module{Issues: []DependencyIssue{
+		{From: "//main:app", To: "//util:util", Issue: IssueDuplicateLinkage, Severity: SeverityWarning},
+		{From: "//main:app", To: "//core:core", Issue: IssueDuplicateLinkage, Severity: SeverityError},
+		{From: "//util:util", Issue: IssueDefineSkew, Severity: SeverityWarning},
+	}}
+
+	changed := module.ApplySeverities(map[string]string{IssueDuplicateLinkage: SeverityInfo})
+	if changed != 2 {
+		t.Errorf("ApplySeverities() changed %d issues, want 2", changed)
+	}
+	var got []string
+	for _, issue := range module.Issues {
+		got = append(got, issue.Severity)
+	}
+	if want := []string{SeverityInfo, SeverityInfo, SeverityWarning}; !reflect.DeepEqual(got, want) {
+		t.Errorf("severities = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoBuildOutputs is returned by CheckBuildOutputs when the workspace has not been built
+var ErrNoBuildOutputs = errors.New("no build artifacts found; run bazel build")
+
+// OutputRoots returns the directories searched for build outputs (.d and .o files) of a
+// workspace. bazelOut overrides them, e.g. with a bazel-out directory downloaded from CI;
+// if empty, the bazel-out and bazel-bin symlinks in the workspace are searched. bazel-bin
+// usually points into bazel-out, but some configurations give it a separate layout.
+func OutputRoots(workspaceRoot, bazelOut string) []string {
+	if bazelOut != "" {
+		return []string{bazelOut}
+	}
+	return []string{
+		filepath.Join(workspaceRoot, "bazel-out"),
+		filepath.Join(workspaceRoot, "bazel-bin"),
+	}
+}
+
+// CheckBuildOutputs returns an error wrapping ErrNoBuildOutputs when none of the output
+// roots has any content: they are missing, empty, or symlinks left dangling by bazel clean.
+// Without this check an unbuilt workspace looks like one without compile or symbol
+// dependencies. bazelOut overrides the output roots (see OutputRoots).
+func CheckBuildOutputs(workspaceRoot, bazelOut string) error {
+	var problems []string
+	for _, root := range OutputRoots(workspaceRoot, bazelOut) {
+		name := filepath.Base(root)
+		if _, err := os.Lstat(root); err != nil {
+			problems = append(problems, name+" does not exist")
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			target, _ := os.Readlink(root)
+			problems = append(problems, fmt.Sprintf("%s points to missing %s", name, target))
+			continue
+		}
+		entries, err := os.ReadDir(resolved)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s cannot be read: %v", name, err))
+			continue
+		}
+		if len(entries) > 0 {
+			return nil
+		}
+		problems = append(problems, name+" is empty")
+	}
+	return fmt.Errorf("%w (%s)", ErrNoBuildOutputs, strings.Join(problems, ", "))
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckBuildOutputs(t *testing.T) {
+	workspace := t.TempDir()
+	if err := CheckBuildOutputs(workspace, ""); !errors.Is(err, ErrNoBuildOutputs) {
+		t.Errorf("unbuilt workspace: got %v, want ErrNoBuildOutputs", err)
+	}
+
+	// bazel clean removes the output base but leaves the symlinks
+	outputBase := filepath.Join(t.TempDir(), "execroot")
+	for _, name := range []string{"bazel-out", "bazel-bin"} {
+		if err := os.Symlink(filepath.Join(outputBase, name), filepath.Join(workspace, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := CheckBuildOutputs(workspace, "")
+	if !errors.Is(err, ErrNoBuildOutputs) {
+		t.Fatalf("dangling symlinks: got %v, want ErrNoBuildOutputs", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(outputBase, "bazel-out"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckBuildOutputs(workspace, ""); !errors.Is(err, ErrNoBuildOutputs) {
+		t.Errorf("empty output base: got %v, want ErrNoBuildOutputs", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(outputBase, "bazel-out", "k8-fastbuild"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckBuildOutputs(workspace, ""); err != nil {
+		t.Errorf("built workspace: unexpected error %v", err)
+	}
+}
+
+func TestOutputRootsOverride(t *testing.T) {
+	if got := OutputRoots("/ws", ""); len(got) != 2 || got[0] != filepath.Join("/ws", "bazel-out") || got[1] != filepath.Join("/ws", "bazel-bin") {
+		t.Errorf("OutputRoots() = %v, want the workspace symlinks", got)
+	}
+	if got := OutputRoots("/ws", "/ci/bazel-out"); len(got) != 1 || got[0] != "/ci/bazel-out" {
+		t.Errorf("OutputRoots() = %v, want only the override", got)
+	}
+
+	// A built override counts even though the workspace has no outputs
+	bazelOut := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(bazelOut, "k8-fastbuild"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckBuildOutputs(t.TempDir(), bazelOut); err != nil {
+		t.Errorf("built override: unexpected error %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"slices"
+	"sort"
+)
+
+// DependencyPath is a chain of dependencies from one target to another
+type DependencyPath struct {
+	Labels []string   `json:"labels"` // Targets along the path, from first to last
+	Edges  []PathEdge `json:"edges"`  // Edges between consecutive labels
+}
+
+// PathEdge is one step of a DependencyPath
+type PathEdge struct {
+	From  string           `json:"from"`
+	To    string           `json:"to"`
+	Types []DependencyType `json:"types"` // Dependency types between From and To, sorted
+}
+
+// FindPaths returns the simple dependency paths (visiting no target twice) from one target
+// to another, over all dependency types, answering why one target depends on another.
+// At most limit paths are returned (all if limit <= 0). Paths are sorted by length, then by
+// their labels, and the result is empty if there is no path.
+//
+// Paths are enumerated by iterative deepening, one length at a time, so that the search
+// stops once limit paths are found instead of visiting every path of a densely connected
+// graph, where there are exponentially many.
+func (m *Module) FindPaths(from, to string, limit int) []DependencyPath {
+	if from == to {
+		return []DependencyPath{}
+	}
+	adjacency, types := m.pathAdjacency()
+
+	// Number of dependencies from each target that can reach to, to prune branches that are
+	// too short to get there
+	distance := map[string]int{to: 0}
+	reverse := make(map[string][]string)
+	for label, next := range adjacency {
+		for _, n := range next {
+			reverse[n] = append(reverse[n], label)
+		}
+	}
+	queue := []string{to}
+	for len(queue) > 0 {
+		label := queue[0]
+		queue = queue[1:]
+		for _, previous := range reverse[label] {
+			if _, seen := distance[previous]; !seen {
+				distance[previous] = distance[label] + 1
+				queue = append(queue, previous)
+			}
+		}
+	}
+	shortest, ok := distance[from]
+	if !ok {
+		return []DependencyPath{}
+	}
+
+	var found [][]string
+	full := func() bool { return limit > 0 && len(found) >= limit }
+	path := []string{from}
+	onPath := map[string]bool{from: true}
+
+	// visit extends path by exactly remaining dependencies ending in to. Children are
+	// visited in label order, so paths of one length are found in label order.
+	var visit func(label string, remaining int)
+	visit = func(label string, remaining int) {
+		if remaining == 0 {
+			if label == to {
+				found = append(found, slices.Clone(path))
+			}
+			return
+		}
+		for _, next := range adjacency[label] {
+			if full() {
+				return
+			}
+			if d, ok := distance[next]; !ok || d > remaining-1 || onPath[next] || (next == to && remaining > 1) {
+				continue
+			}
+			onPath[next] = true
+			path = append(path, next)
+			visit(next, remaining-1)
+			path = path[:len(path)-1]
+			delete(onPath, next)
+		}
+	}
+	// A simple path only visits targets that can reach to
+	for length := shortest; length < len(distance) && !full(); length++ {
+		visit(from, length)
+	}
+
+	paths := make([]DependencyPath, 0, len(found))
+	for _, labels := range found {
+		paths = append(paths, newDependencyPath(labels, types))
+	}
+	return paths
+}
+
+// ShortestPath returns a path with the fewest dependencies from one target to another, the
+// first in label order among equally short ones, or false if there is none
+func (m *Module) ShortestPath(from, to string) (DependencyPath, bool) {
+	if from == to {
+		return DependencyPath{}, false
+	}
+	adjacency, types := m.pathAdjacency()
+
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		label := queue[0]
+		queue = queue[1:]
+		for _, next := range adjacency[label] {
+			if _, seen := previous[next]; seen {
+				continue
+			}
+			previous[next] = label
+			if next == to {
+				var labels []string
+				for current := to; current != ""; current = previous[current] {
+					labels = append(labels, current)
+				}
+				slices.Reverse(labels)
+				return newDependencyPath(labels, types), true
+			}
+			queue = append(queue, next)
+		}
+	}
+	return DependencyPath{}, false
+}
+
+// pathAdjacency returns the sorted dependencies of each target and the types of each edge
+func (m *Module) pathAdjacency() (map[string][]string, map[InternalEdge][]DependencyType) {
+	adjacency := make(map[string][]string)
+	types := make(map[InternalEdge][]DependencyType)
+	for _, dep := range m.Dependencies {
+		if dep.From == dep.To {
+			continue
+		}
+		edge := InternalEdge{FromTarget: dep.From, ToTarget: dep.To}
+		if _, ok := types[edge]; !ok {
+			adjacency[dep.From] = append(adjacency[dep.From], dep.To)
+		}
+		if !slices.Contains(types[edge], dep.Type) {
+			types[edge] = append(types[edge], dep.Type)
+		}
+	}
+	for _, next := range adjacency {
+		sort.Strings(next)
+	}
+	for _, edgeTypes := range types {
+		slices.Sort(edgeTypes)
+	}
+	return adjacency, types
+}
+
+func newDependencyPath(labels []string, types map[InternalEdge][]DependencyType) DependencyPath {
+	path := DependencyPath{Labels: labels, Edges: make([]PathEdge, 0, len(labels)-1)}
+	for i := 0; i+1 < len(labels); i++ {
+		edge := InternalEdge{FromTarget: labels[i], ToTarget: labels[i+1]}
+		path.Edges = append(path.Edges, PathEdge{From: edge.FromTarget, To: edge.ToTarget, Types: types[edge]})
+	}
+	return path
+}
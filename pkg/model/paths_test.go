@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func newPathTestModule() *Module {
+	return &Module{
+		Targets: map[string]*Target{
+			"//a:a": {Label: "//a:a"},
+			"//b:b": {Label: "//b:b"},
+			"//c:c": {Label: "//c:c"},
+			"//d:d": {Label: "//d:d"},
+		},
+		Dependencies: []Dependency{
+			{From: "//a:a", To: "//b:b", Type: DependencyStatic},
+			{From: "//a:a", To: "//b:b", Type: DependencyCompile},
+			{From: "//b:b", To: "//d:d", Type: DependencyStatic},
+			{From: "//a:a", To: "//c:c", Type: DependencyStatic},
+			{From: "//c:c", To: "//b:b", Type: DependencySymbol},
+			{From: "//c:c", To: "//d:d", Type: DependencyDynamic},
+			// Cycles don't give paths that visit a target twice
+			{From: "//d:d", To: "//a:a", Type: DependencyData},
+		},
+	}
+}
+
+func TestFindPaths(t *testing.T) {
+	module := newPathTestModule()
+
+	var got [][]string
+	for _, path := range module.FindPaths("//a:a", "//d:d", 0) {
+		got = append(got, path.Labels)
+	}
+	want := [][]string{
+		{"//a:a", "//b:b", "//d:d"},
+		{"//a:a", "//c:c", "//d:d"},
+		{"//a:a", "//c:c", "//b:b", "//d:d"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindPaths() = %v, want %v", got, want)
+	}
+
+	first := module.FindPaths("//a:a", "//d:d", 1)
+	if len(first) != 1 {
+		t.Fatalf("FindPaths() with limit 1 returned %d paths", len(first))
+	}
+	wantEdges := []PathEdge{
+		{From: "//a:a", To: "//b:b", Types: []DependencyType{DependencyCompile, DependencyStatic}},
+		{From: "//b:b", To: "//d:d", Types: []DependencyType{DependencyStatic}},
+	}
+	if !reflect.DeepEqual(first[0].Edges, wantEdges) {
+		t.Errorf("FindPaths() edges = %+v, want %+v", first[0].Edges, wantEdges)
+	}
+
+	if got := module.FindPaths("//a:a", "//a:a", 0); len(got) != 0 {
+		t.Errorf("FindPaths() to itself = %v, want none", got)
+	}
+}
+
+func TestFindPathsDenseGraph(t *testing.T) {
+	// 12 layers of 6 targets, each depending on every target of the next layer, have 6^12
+	// paths from //src to //sink, too many to enumerate before applying the limit
+	const layers, width = 12, 6
+	module := &Module{Targets: map[string]*Target{}}
+	layer := func(i int) []string {
+		var labels []string
+		for j := range width {
+			labels = append(labels, fmt.Sprintf("//l%02d:t%d", i, j))
+		}
+		return labels
+	}
+	link := func(from []string, to []string) {
+		for _, f := range from {
+			for _, dep := range to {
+				module.Dependencies = append(module.Dependencies, Dependency{From: f, To: dep, Type: DependencyStatic})
+			}
+		}
+	}
+	link([]string{"//src:src"}, layer(0))
+	for i := 0; i+1 < layers; i++ {
+		link(layer(i), layer(i+1))
+	}
+	link(layer(layers-1), []string{"//sink:sink"})
+	// A shortcut into the last layer gives one short path
+	link([]string{"//src:src"}, []string{layer(layers - 1)[0]})
+
+	paths := module.FindPaths("//src:src", "//sink:sink", 10)
+	if len(paths) != 10 {
+		t.Fatalf("FindPaths() returned %d paths, want 10", len(paths))
+	}
+	if got, want := paths[0].Labels, []string{"//src:src", "//l11:t0", "//sink:sink"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first path = %v, want %v", got, want)
+	}
+	for i, path := range paths {
+		want := layers + 2
+		if i == 0 {
+			want = 3
+		}
+		if len(path.Labels) != want {
+			t.Errorf("path %d has %d targets, want %d: %v", i, len(path.Labels), want, path.Labels)
+		}
+	}
+	if got := paths[1].Labels[1:3]; !reflect.DeepEqual(got, []string{"//l00:t0", "//l01:t0"}) {
+		t.Errorf("second path starts %v, want the first in label order", got)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	module := newPathTestModule()
+
+	path, ok := module.ShortestPath("//c:c", "//b:b")
+	if !ok || !reflect.DeepEqual(path.Labels, []string{"//c:c", "//b:b"}) {
+		t.Errorf("ShortestPath() = %v, %v, want the direct dependency", path.Labels, ok)
+	}
+	path, ok = module.ShortestPath("//b:b", "//c:c")
+	if !ok || !reflect.DeepEqual(path.Labels, []string{"//b:b", "//d:d", "//a:a", "//c:c"}) {
+		t.Errorf("ShortestPath() = %v, %v, want the path through //d:d and //a:a", path.Labels, ok)
+	}
+
+	module.Dependencies = module.Dependencies[:6]
+	if _, ok := module.ShortestPath("//d:d", "//a:a"); ok {
+		t.Error("ShortestPath() found a path where there is none")
+	}
+}
@@ -0,0 +1,62 @@
+package model
+
+import "slices"
+
+// Severities of a DependencyIssue
+const (
+	SeverityInfo    = "info" // Worth knowing, not a problem to fix
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Severities lists the valid DependencyIssue severities, least severe first
+var Severities = []string{SeverityInfo, SeverityWarning, SeverityError}
+
+// SeverityAtLeast reports whether a severity is at least as severe as threshold (see
+// Severities). Unknown severities are below every threshold.
+func SeverityAtLeast(severity, threshold string) bool {
+	rank := slices.Index(Severities, severity)
+	return rank >= 0 && rank >= slices.Index(Severities, threshold)
+}
+
+// IssuesAtLeast returns the issues whose severity is at least threshold
+func (m *Module) IssuesAtLeast(threshold string) []DependencyIssue {
+	var result []DependencyIssue
+	for _, issue := range m.Issues {
+		if SeverityAtLeast(issue.Severity, threshold) {
+			result = append(result, issue)
+		}
+	}
+	return result
+}
+
+// IssueDuplicateLinkage is the DependencyIssue.Issue code for a target linking another
+// both statically and dynamically
+const IssueDuplicateLinkage = "duplicate_linkage"
+
+// IssueCodes lists the DependencyIssue.Issue codes the analysis reports. The codes of
+// packages that depend on this one (policy.IssueViolation,
+// binaries.IssueRedundantDynamicDep and binaries.IssueOverlappingLinkage) are repeated
+// here, their tests keep them in sync.
+var IssueCodes = []string{
+	IssueDefineSkew,
+	IssueDuplicateLinkage,
+	"overlapping_linkage",
+	"policy_violation",
+	"redundant_dynamic_dep",
+}
+
+// ApplySeverities sets the severity of the issues whose code is in overrides, e.g.
+// {"redundant_dynamic_dep": "info"}, letting teams tune which issues count as errors.
+// Returns the number of issues whose severity changed.
+func (m *Module) ApplySeverities(overrides map[string]string) int {
+	changed := 0
+	for i := range m.Issues {
+		issue := &m.Issues[i]
+		if severity, ok := overrides[issue.Issue]; ok && issue.Severity != severity {
+			issue.Severity = severity
+			changed++
+		}
+	}
+	return changed
+}
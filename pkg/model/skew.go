@@ -0,0 +1,271 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IssueDefineSkew is the DependencyIssue.Issue code for a library reached through
+// dependents compiled with conflicting preprocessor defines
+const IssueDefineSkew = "define_skew"
+
+// undefinedMacro is the value of a macro removed with -U
+const undefinedMacro = "\x00undefined"
+
+// EffectiveDefines returns the preprocessor macros a target's sources are compiled with,
+// mapped to their values: the defines of the target and its transitive static
+// dependencies (Bazel propagates these to dependents), its local_defines and the -D and
+// -U flags in its copts. A macro given without a value has the value "1".
+func (m *Module) EffectiveDefines(label string) map[string]string {
+	deps, _ := m.staticAdjacency()
+	return m.effectiveDefines(label, deps)
+}
+
+// staticAdjacency returns the static dependencies and dependents of each target
+func (m *Module) staticAdjacency() (deps, rdeps map[string][]string) {
+	deps = make(map[string][]string)
+	rdeps = make(map[string][]string)
+	for _, dep := range m.Dependencies {
+		if dep.Type != DependencyStatic || dep.From == dep.To {
+			continue
+		}
+		deps[dep.From] = append(deps[dep.From], dep.To)
+		rdeps[dep.To] = append(rdeps[dep.To], dep.From)
+	}
+	return deps, rdeps
+}
+
+// reachable returns the targets reachable from start in the adjacency, including start
+func reachable(start string, adjacency map[string][]string) map[string]bool {
+	visited := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range adjacency[current] {
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return visited
+}
+
+func (m *Module) effectiveDefines(label string, deps map[string][]string) map[string]string {
+	macros := make(map[string]string)
+	target := m.Targets[label]
+	if target == nil {
+		return macros
+	}
+
+	// Propagated defines come first on the command line, so the target's own flags win
+	var propagated []string
+	for dep := range reachable(label, deps) {
+		if depTarget := m.Targets[dep]; dep != label && depTarget != nil {
+			propagated = append(propagated, depTarget.Defines...)
+		}
+	}
+	sort.Strings(propagated)
+	for _, define := range propagated {
+		setMacro(macros, define)
+	}
+	for _, define := range target.Defines {
+		setMacro(macros, define)
+	}
+	for _, define := range target.LocalDefines {
+		setMacro(macros, define)
+	}
+
+	for i := 0; i < len(target.Copts); i++ {
+		opt := target.Copts[i]
+		if !strings.HasPrefix(opt, "-D") && !strings.HasPrefix(opt, "-U") {
+			continue
+		}
+		flag, arg := opt[:2], opt[2:]
+		if arg == "" && i+1 < len(target.Copts) {
+			i++
+			arg = target.Copts[i]
+		}
+		if flag == "-D" {
+			setMacro(macros, arg)
+		} else if arg != "" {
+			macros[arg] = undefinedMacro
+		}
+	}
+
+	return macros
+}
+
+// setMacro records a define of the form NAME or NAME=VALUE
+func setMacro(macros map[string]string, define string) {
+	name, value, hasValue := strings.Cut(define, "=")
+	if name == "" {
+		return
+	}
+	if !hasValue {
+		value = "1"
+	}
+	macros[name] = value
+}
+
+// formatMacro returns the compiler flag for a macro value
+func formatMacro(name, value string) string {
+	if value == undefinedMacro {
+		return "-U" + name
+	}
+	return "-D" + name + "=" + value
+}
+
+// FindDefineSkew finds diamond dependencies where a library is reached from the same
+// target through two dependents compiled with conflicting defines (the same macro with
+// different values). The library's headers are then compiled differently into one
+// binary, which can break the one definition rule. One warning is returned per library
+// and pair of dependents, reported for the first target (by label) where the paths meet.
+func (m *Module) FindDefineSkew() []DependencyIssue {
+	deps, rdeps := m.staticAdjacency()
+
+	defines := make(map[string]map[string]string)
+	effective := func(label string) map[string]string {
+		d, ok := defines[label]
+		if !ok {
+			d = m.effectiveDefines(label, deps)
+			defines[label] = d
+		}
+		return d
+	}
+
+	keys := make(map[string]string)
+	key := func(label string) string {
+		k, ok := keys[label]
+		if !ok {
+			k = definesKey(effective(label))
+			keys[label] = k
+		}
+		return k
+	}
+
+	ancestorSets := make(map[string]map[string]bool)
+	ancestors := func(label string) map[string]bool {
+		a, ok := ancestorSets[label]
+		if !ok {
+			a = reachable(label, rdeps)
+			ancestorSets[label] = a
+		}
+		return a
+	}
+
+	libraries := make([]string, 0, len(rdeps))
+	for label := range rdeps {
+		libraries = append(libraries, label)
+	}
+	sort.Strings(libraries)
+
+	issues := make([]DependencyIssue, 0)
+	for _, library := range libraries {
+		dependents := slices.Compact(slices.Sorted(slices.Values(rdeps[library])))
+
+		// Dependents with the same defines never conflict, so only the define sets of
+		// different groups are compared
+		group := make(map[string]int, len(dependents))
+		groupIndex := make(map[string]int)
+		for _, dependent := range dependents {
+			index, ok := groupIndex[key(dependent)]
+			if !ok {
+				index = len(groupIndex)
+				groupIndex[key(dependent)] = index
+			}
+			group[dependent] = index
+		}
+		if len(groupIndex) < 2 {
+			continue
+		}
+
+		type conflict struct{ flagsA, flagsB []string }
+		conflicts := make(map[[2]int]conflict)
+		for i, a := range dependents {
+			for _, b := range dependents[i+1:] {
+				groups := [2]int{group[a], group[b]}
+				if groups[0] == groups[1] {
+					continue
+				}
+				c, ok := conflicts[groups]
+				if !ok {
+					c.flagsA, c.flagsB = conflictingMacros(effective(a), effective(b))
+					conflicts[groups] = c
+				}
+				flagsA, flagsB := c.flagsA, c.flagsB
+				if len(flagsA) == 0 {
+					continue
+				}
+
+				// Only a diamond if some target (possibly a or b) depends on both
+				top := firstCommonAncestor(ancestors(a), ancestors(b))
+				if top == "" {
+					continue
+				}
+
+				issues = append(issues, DependencyIssue{
+					From:     top,
+					To:       library,
+					Issue:    IssueDefineSkew,
+					Types:    []string{string(DependencyStatic)},
+					Severity: SeverityWarning,
+					Description: fmt.Sprintf("Target %s reaches %s through %s and %s, which are compiled with "+
+						"conflicting defines (%s: %s; %s: %s). The headers of %s are then compiled differently "+
+						"into the same binary, which can violate the one definition rule.",
+						top, library, a, b, a, strings.Join(flagsA, " "), b, strings.Join(flagsB, " "), library),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// definesKey returns a key that is equal for equal sets of macro values
+func definesKey(macros map[string]string) string {
+	names := slices.Sorted(maps.Keys(macros))
+	var key strings.Builder
+	for _, name := range names {
+		key.WriteString(name)
+		key.WriteByte('=')
+		key.WriteString(strconv.Quote(macros[name]))
+		key.WriteByte(' ')
+	}
+	return key.String()
+}
+
+// firstCommonAncestor returns the first target (by label) in both sets of ancestors (each
+// including the target itself, see reachable), or "" if there is none
+func firstCommonAncestor(ancestorsA, ancestorsB map[string]bool) string {
+	common := ""
+	for ancestor := range ancestorsA {
+		if ancestorsB[ancestor] && (common == "" || ancestor < common) {
+			common = ancestor
+		}
+	}
+	return common
+}
+
+// conflictingMacros returns the flags of the macros defined by both sets with different
+// values, sorted by macro name
+func conflictingMacros(a, b map[string]string) (flagsA, flagsB []string) {
+	names := make([]string, 0)
+	for name, valueA := range a {
+		if valueB, ok := b[name]; ok && valueA != valueB {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flagsA = append(flagsA, formatMacro(name, a[name]))
+		flagsB = append(flagsB, formatMacro(name, b[name]))
+	}
+	return flagsA, flagsB
+}
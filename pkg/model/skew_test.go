@@ -0,0 +1,101 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveDefines(t *testing.T) {
+	m := &Module{
+		Targets: map[string]*Target{
+			"//app:app":   {Label: "//app:app", LocalDefines: []string{"APP"}, Copts: []string{"-DLEVEL=3", "-U", "NDEBUG", "-Wall"}},
+			"//core:core": {Label: "//core:core", Defines: []string{"LEVEL=1", "NDEBUG"}},
+			"//util:util": {Label: "//util:util", Defines: []string{"UTIL_API=1"}, LocalDefines: []string{"UTIL_INTERNAL"}},
+		},
+		Dependencies: []Dependency{
+			{From: "//app:app", To: "//core:core", Type: DependencyStatic},
+			{From: "//core:core", To: "//util:util", Type: DependencyStatic},
+		},
+	}
+
+	got := m.EffectiveDefines("//app:app")
+	want := map[string]string{
+		"APP":      "1",
+		"LEVEL":    "3", // copts override propagated defines
+		"NDEBUG":   undefinedMacro,
+		"UTIL_API": "1", // Propagated from a transitive dependency
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EffectiveDefines(//app:app) = %v, want %v", got, want)
+	}
+}
+
+func TestFindDefineSkew(t *testing.T) {
+	m := &Module{
+		Targets: map[string]*Target{
+			"//app:app":     {Label: "//app:app"},
+			"//fast:fast":   {Label: "//fast:fast", Copts: []string{"-DBUFFER_SIZE=64"}},
+			"//safe:safe":   {Label: "//safe:safe", LocalDefines: []string{"BUFFER_SIZE=16"}},
+			"//plain:plain": {Label: "//plain:plain"},
+			"//buf:buf":     {Label: "//buf:buf"},
+			"//tool:tool":   {Label: "//tool:tool", Copts: []string{"-DBUFFER_SIZE=8"}},
+		},
+		Dependencies: []Dependency{
+			{From: "//app:app", To: "//fast:fast", Type: DependencyStatic},
+			{From: "//app:app", To: "//safe:safe", Type: DependencyStatic},
+			{From: "//app:app", To: "//plain:plain", Type: DependencyStatic},
+			{From: "//fast:fast", To: "//buf:buf", Type: DependencyStatic},
+			{From: "//safe:safe", To: "//buf:buf", Type: DependencyStatic},
+			{From: "//plain:plain", To: "//buf:buf", Type: DependencyStatic},
+			// //tool:tool conflicts too, but nothing links it together with the others
+			{From: "//tool:tool", To: "//buf:buf", Type: DependencyStatic},
+		},
+	}
+
+	issues := m.FindDefineSkew()
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %d: %+v", len(issues), issues)
+	}
+	issue := issues[0]
+	if issue.From != "//app:app" || issue.To != "//buf:buf" || issue.Issue != IssueDefineSkew || issue.Severity != SeverityWarning {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+	for _, want := range []string{"//fast:fast: -DBUFFER_SIZE=64", "//safe:safe: -DBUFFER_SIZE=16"} {
+		if !strings.Contains(issue.Description, want) {
+			t.Errorf("description missing %q: %s", want, issue.Description)
+		}
+	}
+}
+
+func TestFindDefineSkewSameDefines(t *testing.T) {
+	// //fast:fast and //turbo:turbo have the same defines, so each conflicts with //safe:safe
+	// but not with the other
+	m := &Module{
+		Targets: map[string]*Target{
+			"//app:app":     {Label: "//app:app"},
+			"//fast:fast":   {Label: "//fast:fast", Copts: []string{"-DBUFFER_SIZE=64"}},
+			"//turbo:turbo": {Label: "//turbo:turbo", Defines: []string{"BUFFER_SIZE=64"}},
+			"//safe:safe":   {Label: "//safe:safe", LocalDefines: []string{"BUFFER_SIZE=16"}},
+			"//buf:buf":     {Label: "//buf:buf"},
+		},
+		Dependencies: []Dependency{
+			{From: "//app:app", To: "//fast:fast", Type: DependencyStatic},
+			{From: "//app:app", To: "//turbo:turbo", Type: DependencyStatic},
+			{From: "//app:app", To: "//safe:safe", Type: DependencyStatic},
+			{From: "//fast:fast", To: "//buf:buf", Type: DependencyStatic},
+			{From: "//turbo:turbo", To: "//buf:buf", Type: DependencyStatic},
+			{From: "//safe:safe", To: "//buf:buf", Type: DependencyStatic},
+		},
+	}
+
+	issues := m.FindDefineSkew()
+	if len(issues) != 2 {
+		t.Fatalf("expected 2 issues, got %d: %+v", len(issues), issues)
+	}
+	for i, other := range []string{"//fast:fast", "//turbo:turbo"} {
+		if d := issues[i].Description; !strings.Contains(d, other) || !strings.Contains(d, "//safe:safe") {
+			t.Errorf("issue %d is not about %s and //safe:safe: %s", i, other, d)
+		}
+	}
+}
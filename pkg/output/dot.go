@@ -0,0 +1,128 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// DOTOptions controls WriteDOT
+type DOTOptions struct {
+	EdgeLabels bool // Label each edge with its dependency type
+}
+
+// dotEdgeStyles are the color and line style of each dependency type
+var dotEdgeStyles = map[model.DependencyType]struct{ color, style string }{
+	model.DependencyStatic:  {"#2a9d8f", "solid"},
+	model.DependencyDynamic: {"#8e44ad", "dashed"},
+	model.DependencyData:    {"#e76f51", "dotted"},
+	model.DependencyCompile: {"#3a86ff", "solid"},
+	model.DependencySymbol:  {"#e9a820", "bold"},
+}
+
+// dotNodeShapes are the node shapes of each target kind
+var dotNodeShapes = map[model.TargetKind]string{
+	model.TargetKindBinary:        "doubleoctagon",
+	model.TargetKindSharedLibrary: "component",
+	model.TargetKindLibrary:       "box",
+	model.TargetKindTest:          "ellipse",
+}
+
+// dotSystemLibraryPrefix prefixes the node IDs of system libraries, which are not labels
+const dotSystemLibraryPrefix = "system:"
+
+// WriteDOT writes the target-level graph in the Graphviz DOT format, e.g. for
+// `dot -Tsvg`. Targets are shaped by kind and clustered by package, edges are colored by
+// dependency type, and the system libraries of -l linkopts are drawn as separate notes
+// linked from the targets declaring them. Nodes and edges are written in sorted order, so
+// the same module always gives the same output.
+func WriteDOT(w io.Writer, module *model.Module, opts DOTOptions) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph deps {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, `  node [fontname="Helvetica", fontsize=10];`)
+	fmt.Fprintln(bw, `  edge [fontname="Helvetica", fontsize=8];`)
+
+	systemLibraries := make(map[string]bool)
+	for _, pkg := range module.GetSortedPackages() {
+		fmt.Fprintf(bw, "  subgraph %s {\n", dotQuote("cluster_"+pkg.Path))
+		fmt.Fprintf(bw, "    label=%s;\n", dotQuote(pkg.Path))
+		fmt.Fprintln(bw, "    style=rounded;")
+
+		targets := make([]*model.Target, 0, len(pkg.Targets))
+		for _, target := range pkg.Targets {
+			targets = append(targets, target)
+		}
+		sort.Slice(targets, func(i, j int) bool { return targets[i].Label < targets[j].Label })
+		for _, target := range targets {
+			shape := dotNodeShapes[target.Kind]
+			if shape == "" {
+				shape = "box"
+			}
+			fmt.Fprintf(bw, "    %s [label=%s, shape=%s];\n", dotQuote(target.Label), dotQuote(target.Name), shape)
+			for _, lib := range target.DeclaredSystemLibraries() {
+				systemLibraries[lib] = true
+			}
+		}
+		fmt.Fprintln(bw, "  }")
+	}
+
+	libraries := make([]string, 0, len(systemLibraries))
+	for lib := range systemLibraries {
+		libraries = append(libraries, lib)
+	}
+	sort.Strings(libraries)
+	for _, lib := range libraries {
+		fmt.Fprintf(bw, "  %s [label=%s, shape=note, style=dashed, color=\"#808080\"];\n",
+			dotQuote(dotSystemLibraryPrefix+lib), dotQuote("-l"+lib))
+	}
+
+	deps := append([]model.Dependency(nil), module.Dependencies...)
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].From != deps[j].From {
+			return deps[i].From < deps[j].From
+		}
+		if deps[i].To != deps[j].To {
+			return deps[i].To < deps[j].To
+		}
+		return deps[i].Type < deps[j].Type
+	})
+	for _, dep := range deps {
+		style, ok := dotEdgeStyles[dep.Type]
+		if !ok {
+			style.color, style.style = "#808080", "solid"
+		}
+		attrs := fmt.Sprintf("color=%s, style=%s", dotQuote(style.color), style.style)
+		if opts.EdgeLabels {
+			attrs += ", label=" + dotQuote(string(dep.Type))
+		}
+		fmt.Fprintf(bw, "  %s -> %s [%s];\n", dotQuote(dep.From), dotQuote(dep.To), attrs)
+	}
+
+	labels := make([]string, 0, len(module.Targets))
+	for label := range module.Targets {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		for _, lib := range module.Targets[label].DeclaredSystemLibraries() {
+			attrs := `color="#808080", style=dashed`
+			if opts.EdgeLabels {
+				attrs += `, label="system"`
+			}
+			fmt.Fprintf(bw, "  %s -> %s [%s];\n", dotQuote(label), dotQuote(dotSystemLibraryPrefix+lib), attrs)
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotQuote returns s as a quoted DOT ID
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
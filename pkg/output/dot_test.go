@@ -0,0 +1,61 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	module := newTestModule()
+	module.Targets["//core:core"].Linkopts = []string{"-lm", "-pthread"}
+
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, module, DOTOptions{}); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "digraph deps {\n") || !strings.HasSuffix(out, "}\n") {
+		t.Errorf("WriteDOT() is not a digraph:\n%s", out)
+	}
+	for _, want := range []string{
+		"  subgraph \"cluster_//util\" {\n    label=\"//util\";\n",
+		`    "//main:app" [label="app", shape=doubleoctagon];`,
+		`    "//plugin:dyn" [label="dyn", shape=component];`,
+		`    "//util:strs" [label="strs", shape=box];`,
+		`  "system:m" [label="-lm", shape=note, style=dashed, color="#808080"];`,
+		`  "//main:app" -> "//core:core" [color="#2a9d8f", style=solid];`,
+		`  "//main:app" -> "//plugin:dyn" [color="#8e44ad", style=dashed];`,
+		`  "//core:core" -> "//util:util" [color="#3a86ff", style=solid];`,
+		`  "//core:core" -> "system:m" [color="#808080", style=dashed];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteDOT() missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "label=\"static\"") {
+		t.Errorf("WriteDOT() without EdgeLabels labels edges:\n%s", out)
+	}
+	// Clustered targets are only written once
+	if n := strings.Count(out, `    "//util:util" [`); n != 1 {
+		t.Errorf("WriteDOT() wrote //util:util %d times, want 1", n)
+	}
+}
+
+func TestWriteDOTEdgeLabels(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, newTestModule(), DOTOptions{EdgeLabels: true}); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+	want := `  "//core:core" -> "//util:util" [color="#3a86ff", style=solid, label="compile"];`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("WriteDOT() missing %q in:\n%s", want, buf.String())
+	}
+}
+
+func TestDOTQuote(t *testing.T) {
+	if got, want := dotQuote(`a "b" \c`), `"a \"b\" \\c"`; got != want {
+		t.Errorf("dotQuote() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// Record streams selectable with WriteJSONLines
+const (
+	EmitTargets = "targets" // One model.Target per line
+	EmitDeps    = "deps"    // One model.Dependency per line
+	EmitIssues  = "issues"  // One model.DependencyIssue per line
+)
+
+// EmitValues lists the valid record streams
+var EmitValues = []string{EmitTargets, EmitDeps, EmitIssues}
+
+// WriteJSONLines writes one JSON object per line for each record of the selected stream,
+// using the model's JSON field names. Records are encoded one at a time, so consumers can
+// process them without loading the whole list. Targets are written in label order,
+// dependencies and issues in module order.
+func WriteJSONLines(w io.Writer, module *model.Module, emit string) error {
+	encoder := json.NewEncoder(w)
+	switch emit {
+	case EmitTargets:
+		labels := make([]string, 0, len(module.Targets))
+		for label := range module.Targets {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			if err := encoder.Encode(module.Targets[label]); err != nil {
+				return err
+			}
+		}
+	case EmitDeps:
+		for _, dep := range module.Dependencies {
+			if err := encoder.Encode(dep); err != nil {
+				return err
+			}
+		}
+	case EmitIssues:
+		for _, issue := range module.Issues {
+			if err := encoder.Encode(issue); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown record stream %q (use targets, deps or issues)", emit)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestWriteJSONLines(t *testing.T) {
+	module := newTestModule()
+
+	tests := []struct {
+		emit  string
+		lines int
+		first string // Value of the "from" or "label" field of the first record
+	}{
+		{EmitTargets, len(module.Targets), "//core:core"},
+		{EmitDeps, len(module.Dependencies), "//main:app"},
+		{EmitIssues, len(module.Issues), "//main:app"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteJSONLines(&buf, module, tt.emit); err != nil {
+			t.Fatalf("%s: %v", tt.emit, err)
+		}
+
+		var records []map[string]interface{}
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			var record map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("%s: invalid line %q: %v", tt.emit, scanner.Text(), err)
+			}
+			records = append(records, record)
+		}
+		if len(records) != tt.lines {
+			t.Fatalf("%s: got %d lines, want %d", tt.emit, len(records), tt.lines)
+		}
+
+		key := "from"
+		if tt.emit == EmitTargets {
+			key = "label"
+		}
+		if records[0][key] != tt.first {
+			t.Errorf("%s: first record %s = %v, want %s", tt.emit, key, records[0][key], tt.first)
+		}
+	}
+}
+
+func TestWriteJSONLinesUsesModelFieldNames(t *testing.T) {
+	var buf bytes.Buffer
+	dep := model.Dependency{From: "//a:a", To: "//b:b", Type: model.DependencyStatic}
+	if err := WriteJSONLines(&buf, &model.Module{Dependencies: []model.Dependency{dep}}, EmitDeps); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(dep)
+	if got := bytes.TrimSuffix(buf.Bytes(), []byte("\n")); !bytes.Equal(got, want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestWriteJSONLinesRejectsUnknownStream(t *testing.T) {
+	if err := WriteJSONLines(&bytes.Buffer{}, newTestModule(), "packages"); err == nil {
+		t.Error("expected an error for an unknown record stream")
+	}
+}
@@ -0,0 +1,313 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// ANSI escape codes used for colored output
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// maxCoupledPairs is the number of package or target pairs listed in the coupling sections
+const maxCoupledPairs = 10
+
+// maxSymbolSample is the number of example symbols printed per target pair
+const maxSymbolSample = 3
+
+// histogramWidth is the width of the longest bar in the dependency type histogram
+const histogramWidth = 30
+
+// dependencyTypeOrder is the order in which dependency types are printed
+var dependencyTypeOrder = []model.DependencyType{
+	model.DependencyStatic,
+	model.DependencyDynamic,
+	model.DependencyData,
+	model.DependencyCompile,
+	model.DependencySymbol,
+}
+
+// Options controls how reports are rendered
+type Options struct {
+	Color bool // Use ANSI colors
+}
+
+// UseColor reports whether colored output should be written to f.
+// Color is disabled by noColor, by the NO_COLOR environment variable, and when f is not a terminal.
+func UseColor(f *os.File, noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// IsTerminal reports whether f is connected to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// PrintModuleReport renders a summary of the module: targets by kind, the packages, a
+// histogram of dependency types, the most strongly coupled package and target pairs,
+// issues, unused libraries and file coverage
+func PrintModuleReport(w io.Writer, module *model.Module, uncoveredFiles []string, opts Options) {
+	_, _ = fmt.Fprintf(w, "%s\n", opts.paint(colorBold, "Module: "+module.Name))
+	if module.WorkspacePath != "" {
+		_, _ = fmt.Fprintf(w, "Workspace: %s\n", module.WorkspacePath)
+	}
+	if !module.AnalyzedAt.IsZero() {
+		_, _ = fmt.Fprintf(w, "Analyzed: %s\n", module.AnalyzedAt.Format(time.RFC3339))
+	}
+	_, _ = fmt.Fprintf(w, "Targets: %d  Dependencies: %d  Packages: %d\n",
+		len(module.Targets), len(module.Dependencies), module.GetPackageCount())
+
+	printTargetsByKind(w, module, opts)
+	printPackages(w, module, opts)
+	printDependencyHistogram(w, module, opts)
+	printCoupledPackages(w, module, opts)
+	printSymbolCrossings(w, module, opts)
+	printIssues(w, module.Issues, opts)
+	printUnusedLibraries(w, module, opts)
+	PrintCoverageReport(w, uncoveredFiles, opts)
+}
+
+// PrintCoverageReport renders the source files that are not included in any target
+func PrintCoverageReport(w io.Writer, uncoveredFiles []string, opts Options) {
+	opts.printHeading(w, "Coverage")
+	if len(uncoveredFiles) == 0 {
+		_, _ = fmt.Fprintf(w, "  %s\n", opts.paint(colorGreen, "All source files are covered by targets"))
+		return
+	}
+
+	files := append([]string(nil), uncoveredFiles...)
+	sort.Strings(files)
+	_, _ = fmt.Fprintf(w, "  %s\n", opts.paint(colorYellow, fmt.Sprintf("%d files not included in any target:", len(files))))
+	for _, file := range files {
+		_, _ = fmt.Fprintf(w, "    %s\n", file)
+	}
+}
+
+// printTargetsByKind prints the number of targets of each kind, most common first
+func printTargetsByKind(w io.Writer, module *model.Module, opts Options) {
+	opts.printHeading(w, "Targets by kind")
+
+	counts := make(map[string]int)
+	for _, target := range module.Targets {
+		counts[string(target.Kind)]++
+	}
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if counts[kinds[i]] != counts[kinds[j]] {
+			return counts[kinds[i]] > counts[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+
+	width := maxLen(kinds)
+	for _, kind := range kinds {
+		_, _ = fmt.Fprintf(w, "  %-*s %5d\n", width, kind, counts[kind])
+	}
+}
+
+// printPackages prints each package with its number of targets, sorted by path
+func printPackages(w io.Writer, module *model.Module, opts Options) {
+	packages := module.GetSortedPackages()
+	opts.printHeading(w, fmt.Sprintf("Packages (%d)", len(packages)))
+
+	paths := make([]string, len(packages))
+	for i, pkg := range packages {
+		paths[i] = pkg.Path
+	}
+	width := maxLen(paths)
+	for _, pkg := range packages {
+		_, _ = fmt.Fprintf(w, "  %-*s %5d\n", width, pkg.Path, len(pkg.Targets))
+	}
+}
+
+// printDependencyHistogram prints the number of dependencies of each type as a bar chart
+func printDependencyHistogram(w io.Writer, module *model.Module, opts Options) {
+	opts.printHeading(w, "Dependencies by type")
+
+	counts := make(map[model.DependencyType]int)
+	maxCount := 0
+	for _, dep := range module.Dependencies {
+		counts[dep.Type]++
+		maxCount = max(maxCount, counts[dep.Type])
+	}
+
+	names := make([]string, len(dependencyTypeOrder))
+	for i, depType := range dependencyTypeOrder {
+		names[i] = string(depType)
+	}
+	width := maxLen(names)
+
+	for _, depType := range dependencyTypeOrder {
+		count := counts[depType]
+		bar := ""
+		if maxCount > 0 {
+			bar = strings.Repeat("█", (count*histogramWidth+maxCount-1)/maxCount)
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s %5d %s\n", width, depType, count, opts.paint(colorCyan, bar))
+	}
+}
+
+// printCoupledPackages prints the package pairs with the most target-level edges between them
+func printCoupledPackages(w io.Writer, module *model.Module, opts Options) {
+	opts.printHeading(w, "Most coupled packages")
+
+	pairs := module.GetAllPackageDependencies()
+	if len(pairs) == 0 {
+		_, _ = fmt.Fprintln(w, "  (none)")
+		return
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].TotalCount() != pairs[j].TotalCount() {
+			return pairs[i].TotalCount() > pairs[j].TotalCount()
+		}
+		if pairs[i].From != pairs[j].From {
+			return pairs[i].From < pairs[j].From
+		}
+		return pairs[i].To < pairs[j].To
+	})
+	if len(pairs) > maxCoupledPairs {
+		pairs = pairs[:maxCoupledPairs]
+	}
+
+	fromNames := make([]string, len(pairs))
+	toNames := make([]string, len(pairs))
+	for i, pair := range pairs {
+		fromNames[i] = pair.From
+		toNames[i] = pair.To
+	}
+	fromWidth, toWidth := maxLen(fromNames), maxLen(toNames)
+
+	for _, pair := range pairs {
+		_, _ = fmt.Fprintf(w, "  %-*s -> %-*s %5d\n", fromWidth, pair.From, toWidth, pair.To, pair.TotalCount())
+	}
+}
+
+// printSymbolCrossings prints the target pairs with the most symbols crossing between
+// them, with a few of the symbols as examples
+func printSymbolCrossings(w io.Writer, module *model.Module, opts Options) {
+	opts.printHeading(w, "Most symbols across targets")
+
+	crossings := append([]model.SymbolCrossing(nil), module.SymbolCrossings...)
+	if len(crossings) == 0 {
+		_, _ = fmt.Fprintln(w, "  (none)")
+		return
+	}
+
+	sort.SliceStable(crossings, func(i, j int) bool {
+		return crossings[i].Count > crossings[j].Count
+	})
+	if len(crossings) > maxCoupledPairs {
+		crossings = crossings[:maxCoupledPairs]
+	}
+
+	fromNames := make([]string, len(crossings))
+	toNames := make([]string, len(crossings))
+	for i, crossing := range crossings {
+		fromNames[i] = crossing.From
+		toNames[i] = crossing.To
+	}
+	fromWidth, toWidth := maxLen(fromNames), maxLen(toNames)
+
+	for _, crossing := range crossings {
+		sample := crossing.Symbols[:min(len(crossing.Symbols), maxSymbolSample)]
+		if len(sample) < crossing.Count {
+			sample = append(sample[:len(sample):len(sample)], "...")
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s -> %-*s %5d %s\n", fromWidth, crossing.From, toWidth, crossing.To,
+			crossing.Count, opts.paint(colorCyan, strings.Join(sample, ", ")))
+	}
+}
+
+// printUnusedLibraries prints the cc_library targets no other target depends on (see
+// model.Module.OrphanTargets). Public libraries are API for external users and not listed.
+func printUnusedLibraries(w io.Writer, module *model.Module, opts Options) {
+	orphans := module.OrphanTargets(true)
+	opts.printHeading(w, fmt.Sprintf("Unused libraries (%d)", len(orphans)))
+	if len(orphans) == 0 {
+		_, _ = fmt.Fprintln(w, "  (none)")
+		return
+	}
+	for _, label := range orphans {
+		_, _ = fmt.Fprintf(w, "  %s\n", opts.paint(colorYellow, label))
+	}
+}
+
+// printIssues prints all dependency issues, most severe first
+func printIssues(w io.Writer, issues []model.DependencyIssue, opts Options) {
+	opts.printHeading(w, fmt.Sprintf("Issues (%d)", len(issues)))
+	if len(issues) == 0 {
+		_, _ = fmt.Fprintf(w, "  %s\n", opts.paint(colorGreen, "No issues found"))
+		return
+	}
+
+	sorted := append([]model.DependencyIssue(nil), issues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return slices.Index(model.Severities, sorted[i].Severity) > slices.Index(model.Severities, sorted[j].Severity)
+	})
+
+	severities := make([]string, len(sorted))
+	for i, issue := range sorted {
+		severities[i] = issue.Severity
+	}
+	width := maxLen(severities)
+
+	for _, issue := range sorted {
+		color := colorYellow
+		switch issue.Severity {
+		case model.SeverityError:
+			color = colorRed
+		case model.SeverityInfo:
+			color = colorCyan
+		}
+		severity := opts.paint(color, fmt.Sprintf("%-*s", width, issue.Severity))
+		_, _ = fmt.Fprintf(w, "  %s %s: %s -> %s (%s)\n",
+			severity, issue.Issue, issue.From, issue.To, strings.Join(issue.Types, ", "))
+	}
+}
+
+// printHeading prints a section heading preceded by a blank line
+func (o Options) printHeading(w io.Writer, title string) {
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, o.paint(colorBold, title+":"))
+}
+
+// paint wraps s in the given color if colors are enabled
+func (o Options) paint(color, s string) string {
+	if !o.Color || s == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// maxLen returns the length of the longest string
+func maxLen(values []string) int {
+	width := 0
+	for _, v := range values {
+		width = max(width, len(v))
+	}
+	return width
+}
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func newTestModule() *model.Module {
+	return &model.Module{
+		Name: "example",
+		Targets: map[string]*model.Target{
+			"//main:app":   {Label: "//main:app", Kind: model.TargetKindBinary, Package: "//main", Name: "app"},
+			"//core:core":  {Label: "//core:core", Kind: model.TargetKindLibrary, Package: "//core", Name: "core"},
+			"//util:util":  {Label: "//util:util", Kind: model.TargetKindLibrary, Package: "//util", Name: "util"},
+			"//util:strs":  {Label: "//util:strs", Kind: model.TargetKindLibrary, Package: "//util", Name: "strs"},
+			"//plugin:dyn": {Label: "//plugin:dyn", Kind: model.TargetKindSharedLibrary, Package: "//plugin", Name: "dyn"},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//core:core", Type: model.DependencyStatic},
+			{From: "//main:app", To: "//plugin:dyn", Type: model.DependencyDynamic},
+			{From: "//core:core", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//core:core", To: "//util:strs", Type: model.DependencyStatic},
+			{From: "//core:core", To: "//util:util", Type: model.DependencyCompile},
+		},
+		Issues: []model.DependencyIssue{
+			{From: "//main:app", To: "//core:core", Issue: "duplicate_linkage", Types: []string{"static", "dynamic"}, Severity: "warning"},
+			{From: "//core:core", To: "//util:util", Issue: "policy_violation", Types: []string{"static"}, Severity: "error"},
+		},
+	}
+}
+
+func TestPrintModuleReport(t *testing.T) {
+	var buf bytes.Buffer
+	PrintModuleReport(&buf, newTestModule(), []string{"util/orphan.cc"}, Options{})
+	out := buf.String()
+
+	for _, want := range []string{
+		"Module: example",
+		"Targets: 5  Dependencies: 5  Packages: 4",
+		"  cc_library            3\n",
+		"  cc_binary             1\n",
+		"Packages (4)",
+		"  //util       2\n",
+		"  static      3 ",
+		"  compile     1 ",
+		"  //core -> //util       3\n",
+		"  //main -> //plugin     1\n",
+		"Issues (2):",
+		"Unused libraries (0):\n  (none)\n",
+		"1 files not included in any target:",
+		"    util/orphan.cc",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q\n%s", want, out)
+		}
+	}
+
+	// Errors are listed before warnings
+	if strings.Index(out, "policy_violation") > strings.Index(out, "duplicate_linkage") {
+		t.Errorf("expected errors before warnings\n%s", out)
+	}
+
+	if strings.Contains(out, "\033[") {
+		t.Errorf("expected no ANSI codes without color\n%s", out)
+	}
+}
+
+func TestPrintModuleReportUnusedLibraries(t *testing.T) {
+	module := newTestModule()
+	module.Targets["//util:legacy"] = &model.Target{Label: "//util:legacy", Kind: model.TargetKindLibrary, Package: "//util", Name: "legacy"}
+	module.Targets["//util:api"] = &model.Target{Label: "//util:api", Kind: model.TargetKindLibrary, Package: "//util", Name: "api",
+		Visibility: []string{"//visibility:public"}}
+
+	var buf bytes.Buffer
+	PrintModuleReport(&buf, module, nil, Options{})
+	out := buf.String()
+
+	if !strings.Contains(out, "Unused libraries (1):\n  //util:legacy\n") {
+		t.Errorf("expected //util:legacy as the only unused library\n%s", out)
+	}
+}
+
+func TestPrintModuleReportColor(t *testing.T) {
+	var buf bytes.Buffer
+	PrintModuleReport(&buf, newTestModule(), nil, Options{Color: true})
+	out := buf.String()
+
+	if !strings.Contains(out, colorRed+"error  "+colorReset) {
+		t.Errorf("expected padded, red error severity\n%q", out)
+	}
+	if !strings.Contains(out, colorGreen+"All source files are covered by targets"+colorReset) {
+		t.Errorf("expected green coverage message\n%q", out)
+	}
+}
+
+func TestPrintCoverageReportSorted(t *testing.T) {
+	var buf bytes.Buffer
+	PrintCoverageReport(&buf, []string{"b.cc", "a.cc"}, Options{})
+
+	if !strings.Contains(buf.String(), "    a.cc\n    b.cc\n") {
+		t.Errorf("expected sorted file list\n%s", buf.String())
+	}
+}
+
+func TestPrintModuleReportSymbolCrossings(t *testing.T) {
+	module := newTestModule()
+	module.SymbolCrossings = []model.SymbolCrossing{
+		{From: "//core:core", To: "//util:strs", Count: 1, Symbols: []string{"util::Split()"}},
+		{From: "//core:core", To: "//util:util", Count: 5, Symbols: []string{"a()", "b()", "c()", "d()", "e()"}},
+	}
+
+	var buf bytes.Buffer
+	PrintModuleReport(&buf, module, nil, Options{})
+	out := buf.String()
+
+	for _, want := range []string{
+		"Most symbols across targets:",
+		"  //core:core -> //util:util     5 a(), b(), c(), ...\n",
+		"  //core:core -> //util:strs     1 util::Split()\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q\n%s", want, out)
+		}
+	}
+
+	// Pairs with the most symbols are listed first
+	if strings.Index(out, "//util:util     5") > strings.Index(out, "//util:strs     1") {
+		t.Errorf("expected pairs ordered by symbol count\n%s", out)
+	}
+}
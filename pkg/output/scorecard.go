@@ -0,0 +1,149 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"path"
+	"strconv"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// ScorecardColumns are the columns of the package scorecard, in order. Columns are only
+// ever added at the end, so that scorecards of different runs can be compared.
+var ScorecardColumns = []string{
+	"package",           // Package path, e.g. //util
+	"targets",           // Number of targets
+	"public_targets",    // Targets with public visibility
+	"inbound_packages",  // Other packages depending on the package
+	"outbound_packages", // Other packages the package depends on
+	"in_cycle",          // A target of the package is in a dependency cycle (true or false)
+	"covered_files",     // Source files of the package owned by a target
+	"uncovered_files",   // Source files in the package's directory owned by no target
+	"coverage_percent",  // Covered share of the files, 100 when there are none
+	"hub_score",         // Most transitive dependents of any target of the package
+}
+
+// PackageScore is one row of the package scorecard, see ScorecardColumns
+type PackageScore struct {
+	Package          string
+	Targets          int
+	PublicTargets    int
+	InboundPackages  int
+	OutboundPackages int
+	InCycle          bool
+	CoveredFiles     int
+	UncoveredFiles   int
+	CoveragePercent  float64
+	HubScore         int
+}
+
+// PackageScorecard computes the modularity metrics of each package, sorted by path.
+// fileToTarget maps the files owned by targets to their labels; each uncovered file is
+// counted in the package of the nearest directory containing one.
+func PackageScorecard(module *model.Module, fileToTarget map[string]string, uncoveredFiles []string) []PackageScore {
+	packages := module.GetSortedPackages()
+	scores := make(map[string]*PackageScore, len(packages))
+	rows := make([]PackageScore, len(packages))
+	for i, pkg := range packages {
+		rows[i].Package = pkg.Path
+		scores[pkg.Path] = &rows[i]
+		for _, target := range pkg.Targets {
+			rows[i].Targets++
+			if target.IsPublic() {
+				rows[i].PublicTargets++
+			}
+		}
+	}
+
+	for _, pkgDep := range module.GetAllPackageDependencies() {
+		if score := scores[pkgDep.From]; score != nil {
+			score.OutboundPackages++
+		}
+		if score := scores[pkgDep.To]; score != nil {
+			score.InboundPackages++
+		}
+	}
+
+	for _, cycle := range module.FindCycles() {
+		for _, label := range cycle {
+			if score := scores[module.Targets[label].Package]; score != nil {
+				score.InCycle = true
+			}
+		}
+	}
+
+	for _, label := range fileToTarget {
+		if target := module.Targets[label]; target != nil {
+			if score := scores[target.Package]; score != nil {
+				score.CoveredFiles++
+			}
+		}
+	}
+	for _, file := range uncoveredFiles {
+		if score := scores[enclosingPackage(file, scores)]; score != nil {
+			score.UncoveredFiles++
+		}
+	}
+
+	// Only the transitive dependents are used, so the hub thresholds do not matter
+	for label, metrics := range module.ComputeTargetMetrics(0, 0) {
+		if target := module.Targets[label]; target != nil {
+			if score := scores[target.Package]; score != nil {
+				score.HubScore = max(score.HubScore, metrics.TransitiveRdeps)
+			}
+		}
+	}
+
+	for i := range rows {
+		rows[i].CoveragePercent = 100
+		if total := rows[i].CoveredFiles + rows[i].UncoveredFiles; total > 0 {
+			rows[i].CoveragePercent = 100 * float64(rows[i].CoveredFiles) / float64(total)
+		}
+	}
+	return rows
+}
+
+// enclosingPackage returns the package of the nearest directory of file that has one, or ""
+func enclosingPackage(file string, scores map[string]*PackageScore) string {
+	for dir := path.Dir(file); ; dir = path.Dir(dir) {
+		pkg := "//" + dir
+		if dir == "." {
+			pkg = "//"
+		}
+		if scores[pkg] != nil {
+			return pkg
+		}
+		if dir == "." || dir == "/" {
+			return ""
+		}
+	}
+}
+
+// WriteScorecardCSV writes the package scorecard as CSV with a header row of
+// ScorecardColumns
+func WriteScorecardCSV(w io.Writer, scores []PackageScore) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(ScorecardColumns); err != nil {
+		return err
+	}
+	for _, score := range scores {
+		record := []string{
+			score.Package,
+			strconv.Itoa(score.Targets),
+			strconv.Itoa(score.PublicTargets),
+			strconv.Itoa(score.InboundPackages),
+			strconv.Itoa(score.OutboundPackages),
+			strconv.FormatBool(score.InCycle),
+			strconv.Itoa(score.CoveredFiles),
+			strconv.Itoa(score.UncoveredFiles),
+			strconv.FormatFloat(score.CoveragePercent, 'f', 1, 64),
+			strconv.Itoa(score.HubScore),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
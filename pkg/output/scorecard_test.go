@@ -0,0 +1,49 @@
+package output
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func TestPackageScorecard(t *testing.T) {
+	module := newTestModule()
+	module.Targets["//util:util"].Visibility = []string{"//visibility:public"}
+	// Closes a cycle between //core:core and //util:strs
+	module.Dependencies = append(module.Dependencies, model.Dependency{From: "//util:strs", To: "//core:core", Type: model.DependencySymbol})
+
+	fileToTarget := map[string]string{
+		"core/core.cc": "//core:core",
+		"util/util.cc": "//util:util",
+		"util/strs.cc": "//util:strs",
+		"main/main.cc": "//main:app",
+	}
+	uncovered := []string{"util/orphan.cc", "util/impl/detail.cc", "tools/gen.cc"}
+
+	got := PackageScorecard(module, fileToTarget, uncovered)
+	want := []PackageScore{
+		{Package: "//core", Targets: 1, InboundPackages: 2, OutboundPackages: 1, InCycle: true, CoveredFiles: 1, CoveragePercent: 100, HubScore: 2},
+		{Package: "//main", Targets: 1, OutboundPackages: 2, CoveredFiles: 1, CoveragePercent: 100},
+		{Package: "//plugin", Targets: 1, InboundPackages: 1, CoveragePercent: 100, HubScore: 1},
+		{Package: "//util", Targets: 2, PublicTargets: 1, InboundPackages: 1, OutboundPackages: 1, InCycle: true, CoveredFiles: 2, UncoveredFiles: 2, CoveragePercent: 50, HubScore: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PackageScorecard() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestWriteScorecardCSV(t *testing.T) {
+	var buf bytes.Buffer
+	scores := []PackageScore{{Package: "//util", Targets: 2, PublicTargets: 1, InCycle: true, CoveredFiles: 1, UncoveredFiles: 2, CoveragePercent: 100.0 / 3, HubScore: 4}}
+	if err := WriteScorecardCSV(&buf, scores); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "package,targets,public_targets,inbound_packages,outbound_packages,in_cycle,covered_files,uncovered_files,coverage_percent,hub_score\n" +
+		"//util,2,1,0,0,true,1,2,33.3,4\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteScorecardCSV() =\n%s\nwant\n%s", got, want)
+	}
+}
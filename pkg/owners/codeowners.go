@@ -0,0 +1,199 @@
+package owners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// Locations searched for the CODEOWNERS file, relative to the workspace, in the order
+// GitHub uses them
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule assigns owners to the paths matching a pattern
+type Rule struct {
+	Pattern string   // Pattern as written, e.g. "/graphics/" or "*"
+	Owners  []string // Owners as written, e.g. ["@org/graphics"]
+}
+
+// CodeOwners holds the rules of a CODEOWNERS file
+type CodeOwners struct {
+	Rules []Rule
+}
+
+// Parse reads CODEOWNERS rules: one pattern followed by its owners per line, with blank
+// lines and # comments ignored. Rules without owners are kept, they unset the owner.
+func Parse(r io.Reader) (*CodeOwners, error) {
+	codeOwners := &CodeOwners{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := path.Match(strings.Trim(fields[0], "/"), ""); err != nil {
+			return nil, fmt.Errorf("invalid CODEOWNERS pattern %q: %w", fields[0], err)
+		}
+		codeOwners.Rules = append(codeOwners.Rules, Rule{Pattern: fields[0], Owners: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return codeOwners, nil
+}
+
+// Load reads the first CODEOWNERS file found in the workspace (see Locations).
+// Returns nil without error if the workspace has none.
+func Load(workspace string) (*CodeOwners, error) {
+	for _, location := range Locations {
+		file, err := os.Open(filepath.Join(workspace, location))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = file.Close() }()
+
+		codeOwners, err := Parse(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", location, err)
+		}
+		return codeOwners, nil
+	}
+	return nil, nil
+}
+
+// OwnerOf returns the owners of a package directory (e.g. "graphics/shaders") as a single
+// space-separated string, or "" if no rule assigns any. The most specific matching rule wins:
+// the one with the most path segments, and of those the last one in the file.
+func (c *CodeOwners) OwnerOf(pkgPath string) string {
+	var best *Rule
+	bestSpecificity := -1
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if !matchesDirectory(rule.Pattern, pkgPath) {
+			continue
+		}
+		if specificity := patternSpecificity(rule.Pattern); specificity >= bestSpecificity {
+			best, bestSpecificity = rule, specificity
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return strings.Join(best.Owners, " ")
+}
+
+// Annotate sets the Owner of each workspace target from the rule matching its package.
+// External targets are left unowned.
+func (c *CodeOwners) Annotate(module *model.Module) {
+	for _, target := range module.Targets {
+		if strings.HasPrefix(target.Label, "@") {
+			continue
+		}
+		target.Owner = c.OwnerOf(strings.TrimPrefix(target.Package, "//"))
+	}
+}
+
+// matchesDirectory reports whether a CODEOWNERS pattern covers a directory: the pattern
+// matches the directory or one of its parents. As in .gitignore, a pattern containing a
+// slash other than a trailing one is anchored at the workspace root, others match at any
+// depth.
+func matchesDirectory(pattern, dir string) bool {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.TrimSuffix(strings.Trim(pattern, "/"), "/**")
+	if pattern == "" || pattern == "*" || pattern == "**" {
+		return true
+	}
+
+	var segments []string
+	if dir != "" {
+		segments = strings.Split(dir, "/")
+	}
+	for end := 1; end <= len(segments); end++ {
+		for start := 0; start < end; start++ {
+			if anchored && start > 0 {
+				break
+			}
+			if ok, _ := path.Match(pattern, strings.Join(segments[start:end], "/")); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// patternSpecificity ranks patterns by the number of path segments they name
+func patternSpecificity(pattern string) int {
+	pattern = strings.TrimSuffix(strings.Trim(pattern, "/"), "/**")
+	if pattern == "" || pattern == "*" || pattern == "**" {
+		return 0
+	}
+	return strings.Count(pattern, "/") + 1
+}
+
+// OwnerDependency counts the dependent target pairs from targets of one owner to targets of
+// another
+type OwnerDependency struct {
+	From  string `json:"from"`  // Owner of the depending targets ("" if unowned)
+	To    string `json:"to"`    // Owner of the targets depended on ("" if unowned)
+	Count int    `json:"count"` // Number of depending (from, to) target pairs
+}
+
+// Summary lists the targets of each owner and the dependencies crossing owners
+type Summary struct {
+	Owners       map[string][]string `json:"owners"`       // Owner -> sorted target labels (unowned targets are omitted)
+	Dependencies []OwnerDependency   `json:"dependencies"` // Cross-owner dependencies, most frequent first
+}
+
+// Summarize groups a module's targets and dependencies by the Owner set by Annotate.
+// Dependencies between targets of the same owner and involving external targets are
+// left out.
+func Summarize(module *model.Module) *Summary {
+	summary := &Summary{Owners: make(map[string][]string), Dependencies: make([]OwnerDependency, 0)}
+	for label, target := range module.Targets {
+		if target.Owner != "" {
+			summary.Owners[target.Owner] = append(summary.Owners[target.Owner], label)
+		}
+	}
+	for _, labels := range summary.Owners {
+		sort.Strings(labels)
+	}
+
+	// Count each pair of targets once, whatever the number of dependency types between them
+	counts := make(map[[2]string]int)
+	seen := make(map[[2]string]bool)
+	for _, dep := range module.Dependencies {
+		from, to := module.Targets[dep.From], module.Targets[dep.To]
+		if from == nil || to == nil || strings.HasPrefix(dep.To, "@") || from.Owner == to.Owner {
+			continue
+		}
+		if pair := [2]string{dep.From, dep.To}; !seen[pair] {
+			seen[pair] = true
+			counts[[2]string{from.Owner, to.Owner}]++
+		}
+	}
+	for pair, count := range counts {
+		summary.Dependencies = append(summary.Dependencies, OwnerDependency{From: pair[0], To: pair[1], Count: count})
+	}
+	sort.Slice(summary.Dependencies, func(i, j int) bool {
+		a, b := summary.Dependencies[i], summary.Dependencies[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return summary
+}
@@ -0,0 +1,136 @@
+package owners
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+const testCodeOwners = `# Default owners
+*                   @org/platform
+
+/graphics/          @org/graphics
+/graphics/shaders/  @org/shaders @alice
+docs                @org/writers
+/tools/legacy/      # No owner
+`
+
+func TestOwnerOf(t *testing.T) {
+	codeOwners, err := Parse(strings.NewReader(testCodeOwners))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pkg  string
+		want string
+	}{
+		{"main", "@org/platform"},
+		{"graphics", "@org/graphics"},
+		{"graphics/renderer", "@org/graphics"},
+		{"graphics/shaders", "@org/shaders @alice"},
+		{"graphics/shaders/gl", "@org/shaders @alice"},
+		{"util/docs", "@org/writers"}, // Unanchored patterns match at any depth
+		{"tools/legacy", ""},
+		{"", "@org/platform"},
+	}
+	for _, tt := range tests {
+		if got := codeOwners.OwnerOf(tt.pkg); got != tt.want {
+			t.Errorf("OwnerOf(%q) = %q, want %q", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestOwnerOfPrefersMostSpecificRule(t *testing.T) {
+	// The specific rule comes first, unlike in files written for GitHub's last-match rule
+	codeOwners, err := Parse(strings.NewReader("/graphics/shaders/ @org/shaders\n/graphics/ @org/graphics\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := codeOwners.OwnerOf("graphics/shaders"); got != "@org/shaders" {
+		t.Errorf("OwnerOf(graphics/shaders) = %q, want @org/shaders", got)
+	}
+}
+
+func TestParseRejectsBadPattern(t *testing.T) {
+	if _, err := Parse(strings.NewReader("/graphics/[ @org/graphics\n")); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	workspace := t.TempDir()
+	codeOwners, err := Load(workspace)
+	if err != nil || codeOwners != nil {
+		t.Fatalf("Load() without CODEOWNERS = %v, %v; want nil, nil", codeOwners, err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(workspace, ".github"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workspace, ".github", "CODEOWNERS"), []byte(testCodeOwners), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	codeOwners, err = Load(workspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codeOwners.Rules) != 5 {
+		t.Errorf("expected 5 rules, got %+v", codeOwners.Rules)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	codeOwners, err := Parse(strings.NewReader(testCodeOwners))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":                {Label: "//main:app", Package: "//main"},
+			"//graphics:renderer":       {Label: "//graphics:renderer", Package: "//graphics"},
+			"//graphics:mesh":           {Label: "//graphics:mesh", Package: "//graphics"},
+			"//graphics/shaders:glsl":   {Label: "//graphics/shaders:glsl", Package: "//graphics/shaders"},
+			"//tools/legacy:old":        {Label: "//tools/legacy:old", Package: "//tools/legacy"},
+			"@abseil//absl/strings:str": {Label: "@abseil//absl/strings:str", Package: "@abseil//absl/strings"},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//graphics:renderer", Type: model.DependencyStatic},
+			{From: "//main:app", To: "//graphics:renderer", Type: model.DependencyCompile},
+			{From: "//main:app", To: "//graphics:mesh", Type: model.DependencyStatic},
+			{From: "//graphics:renderer", To: "//graphics:mesh", Type: model.DependencyStatic},
+			{From: "//graphics:renderer", To: "//graphics/shaders:glsl", Type: model.DependencyStatic},
+			{From: "//graphics:renderer", To: "@abseil//absl/strings:str", Type: model.DependencyStatic},
+			{From: "//tools/legacy:old", To: "//main:app", Type: model.DependencyData},
+		},
+	}
+	codeOwners.Annotate(module)
+
+	if owner := module.Targets["@abseil//absl/strings:str"].Owner; owner != "" {
+		t.Errorf("expected external target unowned, got %q", owner)
+	}
+
+	summary := Summarize(module)
+	wantOwners := map[string][]string{
+		"@org/platform":       {"//main:app"},
+		"@org/graphics":       {"//graphics:mesh", "//graphics:renderer"},
+		"@org/shaders @alice": {"//graphics/shaders:glsl"},
+	}
+	if !reflect.DeepEqual(summary.Owners, wantOwners) {
+		t.Errorf("Owners = %v, want %v", summary.Owners, wantOwners)
+	}
+
+	wantDeps := []OwnerDependency{
+		{From: "@org/platform", To: "@org/graphics", Count: 2},
+		{From: "", To: "@org/platform", Count: 1},
+		{From: "@org/graphics", To: "@org/shaders @alice", Count: 1},
+	}
+	if !reflect.DeepEqual(summary.Dependencies, wantDeps) {
+		t.Errorf("Dependencies = %+v, want %+v", summary.Dependencies, wantDeps)
+	}
+}
@@ -0,0 +1,135 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// IssueViolation is the DependencyIssue.Issue code used for policy violations
+const IssueViolation = "policy_violation"
+
+// Check validates all cross-package dependencies in the module (including inferred
+// compile and symbol edges) against the policy rules and returns one issue per
+// offending target pair
+func Check(module *model.Module, policy config.PolicyConfig) []model.DependencyIssue {
+	if module == nil || len(policy.Rules) == 0 {
+		return nil
+	}
+
+	type violation struct {
+		rule  string
+		types map[model.DependencyType]bool
+	}
+	violations := make(map[[2]string]*violation)
+
+	for _, dep := range module.Dependencies {
+		fromTarget := module.Targets[dep.From]
+		toTarget := module.Targets[dep.To]
+		if fromTarget == nil || toTarget == nil {
+			continue
+		}
+
+		// Dependencies within a package are never restricted
+		if fromTarget.Package == toTarget.Package {
+			continue
+		}
+
+		rule := findViolatedRule(policy.Rules, fromTarget.Package, toTarget.Package)
+		if rule == nil {
+			continue
+		}
+
+		key := [2]string{dep.From, dep.To}
+		v, exists := violations[key]
+		if !exists {
+			v = &violation{rule: describeRule(rule), types: make(map[model.DependencyType]bool)}
+			violations[key] = v
+		}
+		v.types[dep.Type] = true
+	}
+
+	issues := make([]model.DependencyIssue, 0, len(violations))
+	for key, v := range violations {
+		types := make([]string, 0, len(v.types))
+		for t := range v.types {
+			types = append(types, string(t))
+		}
+		sort.Strings(types)
+
+		issues = append(issues, model.DependencyIssue{
+			From:     key[0],
+			To:       key[1],
+			Issue:    IssueViolation,
+			Types:    types,
+			Severity: "error",
+			Description: fmt.Sprintf("Target %s depends on %s, which is not allowed by the dependency policy (%s).",
+				key[0], key[1], v.rule),
+		})
+	}
+
+	// Sort for consistent output
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].From != issues[j].From {
+			return issues[i].From < issues[j].From
+		}
+		return issues[i].To < issues[j].To
+	})
+
+	return issues
+}
+
+// Apply replaces any previous policy violations in the module with a fresh check,
+// so repeated analysis runs on the same module don't accumulate duplicates
+func Apply(module *model.Module, policy config.PolicyConfig) []model.DependencyIssue {
+	if module == nil {
+		return nil
+	}
+
+	violations := Check(module, policy)
+	module.ReplaceIssues(IssueViolation, violations)
+	return violations
+}
+
+// findViolatedRule returns the first rule that forbids fromPkg depending on toPkg
+func findViolatedRule(rules []config.PolicyRule, fromPkg, toPkg string) *config.PolicyRule {
+	for i := range rules {
+		rule := &rules[i]
+		if !config.MatchPackage(rule.From, fromPkg) {
+			continue
+		}
+
+		// Deny takes precedence over allow
+		if matchesAny(rule.Deny, toPkg) {
+			return rule
+		}
+		if len(rule.Allow) > 0 && !matchesAny(rule.Allow, toPkg) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether pkg matches at least one of the patterns
+func matchesAny(patterns []string, pkg string) bool {
+	for _, pattern := range patterns {
+		if config.MatchPackage(pattern, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeRule formats a rule for use in issue descriptions
+func describeRule(rule *config.PolicyRule) string {
+	desc := "from " + rule.From
+	if len(rule.Allow) > 0 {
+		desc += fmt.Sprintf(", allow %v", rule.Allow)
+	}
+	if len(rule.Deny) > 0 {
+		desc += fmt.Sprintf(", deny %v", rule.Deny)
+	}
+	return desc
+}
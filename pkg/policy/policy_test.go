@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/config"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+func newTestModule() *model.Module {
+	targets := map[string]*model.Target{}
+	for _, label := range []string{"//ui:ui", "//ui/widgets:widgets", "//db:db", "//core:core", "//util:util", "//core:impl"} {
+		pkg, name, _ := strings.Cut(label, ":")
+		targets[label] = &model.Target{Label: label, Package: pkg, Name: name, Kind: model.TargetKindLibrary}
+	}
+
+	return &model.Module{
+		Targets: targets,
+		Dependencies: []model.Dependency{
+			{From: "//ui:ui", To: "//db:db", Type: model.DependencyStatic},
+			{From: "//ui:ui", To: "//db:db", Type: model.DependencyCompile},
+			{From: "//ui/widgets:widgets", To: "//db:db", Type: model.DependencySymbol},
+			{From: "//ui:ui", To: "//core:core", Type: model.DependencyStatic},
+			{From: "//core:core", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//core:core", To: "//db:db", Type: model.DependencyCompile},
+			{From: "//core:core", To: "//core:impl", Type: model.DependencyStatic},
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	module := newTestModule()
+	policy := config.PolicyConfig{
+		Rules: []config.PolicyRule{
+			{From: "//ui/...", Deny: []string{"//db"}},
+			{From: "//core", Allow: []string{"//util"}},
+		},
+	}
+
+	issues := Check(module, policy)
+
+	type pair struct{ from, to string }
+	got := make(map[pair][]string)
+	for _, issue := range issues {
+		if issue.Issue != IssueViolation || issue.Severity != "error" {
+			t.Errorf("unexpected issue code/severity: %s/%s", issue.Issue, issue.Severity)
+		}
+		got[pair{issue.From, issue.To}] = issue.Types
+	}
+
+	want := map[pair][]string{
+		{"//ui:ui", "//db:db"}:              {"compile", "static"},
+		{"//ui/widgets:widgets", "//db:db"}: {"symbol"},
+		{"//core:core", "//db:db"}:          {"compile"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() violations = %v, want %v", got, want)
+	}
+}
+
+func TestCheckNoRules(t *testing.T) {
+	if issues := Check(newTestModule(), config.PolicyConfig{}); len(issues) != 0 {
+		t.Errorf("expected no issues without rules, got %d", len(issues))
+	}
+}
+
+func TestApplyReplacesPreviousViolations(t *testing.T) {
+	module := newTestModule()
+	module.Issues = []model.DependencyIssue{{From: "//a:a", To: "//b:b", Issue: "duplicate_linkage"}}
+	policy := config.PolicyConfig{
+		Rules: []config.PolicyRule{{From: "//core", Deny: []string{"//db"}}},
+	}
+
+	Apply(module, policy)
+	Apply(module, policy)
+
+	if len(module.Issues) != 2 {
+		t.Fatalf("expected 2 issues after repeated Apply, got %d: %v", len(module.Issues), module.Issues)
+	}
+	if module.Issues[0].Issue != "duplicate_linkage" {
+		t.Errorf("expected unrelated issues to be kept, got %v", module.Issues[0])
+	}
+}
+
+func TestIssueCodeKnown(t *testing.T) {
+	if !slices.Contains(model.IssueCodes, IssueViolation) {
+		t.Errorf("model.IssueCodes %v does not list %s", model.IssueCodes, IssueViolation)
+	}
+}
@@ -0,0 +1,178 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// memorySubscriptionBuffer is the number of events a MemoryPublisher subscription holds
+const memorySubscriptionBuffer = 64
+
+// MemoryPublisher implements Publisher by recording events in memory. It is meant for
+// tests of higher layers: published events can be inspected synchronously with Events,
+// without subscribing or any SSE plumbing. Event data is marshaled to JSON as by the
+// SSEPublisher, so the recorded events match what clients would receive.
+type MemoryPublisher struct {
+	mu            sync.Mutex
+	events        []Event
+	version       map[string]int
+	subscriptions map[string][]*memorySubscription
+	closed        bool
+}
+
+// NewMemoryPublisher creates a publisher that records all published events
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{
+		version:       make(map[string]int),
+		subscriptions: make(map[string][]*memorySubscription),
+	}
+}
+
+// Subscribe creates a subscription receiving events published after it was created.
+// Events are dropped if the subscriber does not keep up.
+func (p *MemoryPublisher) Subscribe(ctx context.Context, topic string) (Subscription, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, fmt.Errorf("publisher is closed")
+	}
+
+	sub := &memorySubscription{
+		topic:     topic,
+		events:    make(chan Event, memorySubscriptionBuffer),
+		publisher: p,
+	}
+	p.subscriptions[topic] = append(p.subscriptions[topic], sub)
+
+	go func() {
+		<-ctx.Done()
+		_ = sub.Close()
+	}()
+
+	return sub, nil
+}
+
+// Publish records the event and sends it to the subscribers of the topic
+func (p *MemoryPublisher) Publish(topic string, eventType string, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return fmt.Errorf("publisher is closed")
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	p.version[topic]++
+	event := Event{
+		Topic:   topic,
+		Type:    eventType,
+		Data:    jsonData,
+		Version: p.version[topic],
+	}
+	p.events = append(p.events, event)
+
+	for _, sub := range p.subscriptions[topic] {
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Close closes all subscriptions. Recorded events remain available.
+func (p *MemoryPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+
+	for _, subs := range p.subscriptions {
+		for _, sub := range subs {
+			close(sub.events)
+		}
+	}
+	p.subscriptions = make(map[string][]*memorySubscription)
+
+	return nil
+}
+
+// Events returns all recorded events in publication order
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
+
+// EventsFor returns the recorded events of one topic in publication order
+func (p *MemoryPublisher) EventsFor(topic string) []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result []Event
+	for _, event := range p.events {
+		if event.Topic == topic {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// Reset forgets all recorded events
+func (p *MemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = nil
+}
+
+// unsubscribe removes a subscription and closes its channel
+func (p *MemoryPublisher) unsubscribe(sub *memorySubscription) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subs := p.subscriptions[sub.topic]
+	for i, s := range subs {
+		if s == sub {
+			p.subscriptions[sub.topic] = append(subs[:i], subs[i+1:]...)
+			close(sub.events)
+			return
+		}
+	}
+}
+
+// memorySubscription is a subscription to a MemoryPublisher topic
+type memorySubscription struct {
+	topic     string
+	events    chan Event
+	publisher *MemoryPublisher
+	once      sync.Once
+}
+
+// Topic returns the subscription topic
+func (s *memorySubscription) Topic() string {
+	return s.topic
+}
+
+// Events returns a channel for receiving events
+func (s *memorySubscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close closes the subscription
+func (s *memorySubscription) Close() error {
+	s.once.Do(func() {
+		s.publisher.unsubscribe(s)
+	})
+	return nil
+}
@@ -0,0 +1,72 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMemoryPublisherRecordsEvents(t *testing.T) {
+	pub := NewMemoryPublisher()
+
+	if err := pub.Publish("workspace_status", "ready", WorkspaceStatus{State: "ready", Message: "done"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish("target_graph", "complete", TargetGraphData{TargetsCount: 3, Complete: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Publish("workspace_status", "watching", WorkspaceStatus{State: "watching"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(pub.Events()); got != 3 {
+		t.Fatalf("recorded %d events, want 3", got)
+	}
+
+	status := pub.EventsFor("workspace_status")
+	if len(status) != 2 || status[0].Type != "ready" || status[1].Version != 2 {
+		t.Fatalf("unexpected workspace_status events: %+v", status)
+	}
+	var data WorkspaceStatus
+	if err := json.Unmarshal(status[0].Data, &data); err != nil || data.Message != "done" {
+		t.Errorf("event data = %+v (err %v)", data, err)
+	}
+
+	pub.Reset()
+	if got := len(pub.Events()); got != 0 {
+		t.Errorf("recorded %d events after Reset, want 0", got)
+	}
+}
+
+func TestMemoryPublisherSubscribe(t *testing.T) {
+	pub := NewMemoryPublisher()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sub, err := pub.Subscribe(ctx, "workspace_status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = pub.Publish("target_graph", "complete", nil)
+	_ = pub.Publish("workspace_status", "ready", nil)
+
+	select {
+	case event := <-sub.Events():
+		if event.Topic != "workspace_status" || event.Type != "ready" {
+			t.Errorf("received %+v, want the workspace_status event", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+
+	// Cancelling the context closes the subscription
+	cancel()
+	select {
+	case _, ok := <-sub.Events():
+		if ok {
+			t.Error("expected no further events")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not closed after context cancellation")
+	}
+}
@@ -7,7 +7,7 @@ import (
 
 // Event represents a pub/sub event
 type Event struct {
-	Topic   string          `json:"topic"`   // Subscription topic (e.g., "workspace_status", "target_graph")
+	Topic   string          `json:"topic"`   // Topic name (e.g., WorkspaceStatusTopic.Name())
 	Type    string          `json:"type"`    // Event type (e.g., "initializing", "bazel_querying", "loading", "partial_data")
 	Data    json.RawMessage `json:"data"`    // Event payload
 	Version int             `json:"version"` // Version number for ordering
@@ -38,7 +38,13 @@ type Publisher interface {
 	Close() error
 }
 
-// WorkspaceStatus represents workspace analysis state
+// StatsProvider is implemented by publishers that report per-topic delivery statistics
+type StatsProvider interface {
+	// Stats returns statistics keyed by topic name
+	Stats() map[string]TopicStats
+}
+
+// WorkspaceStatus represents workspace analysis state (the WorkspaceStatusTopic payload)
 type WorkspaceStatus struct {
 	State    string `json:"state"`    // initializing, bazel_querying, binaries_ready, targets_ready, ready, watching
 	Message  string `json:"message"`  // Human-readable status message
@@ -46,11 +52,47 @@ type WorkspaceStatus struct {
 	Total    int    `json:"total"`    // Total number of steps
 	Watching bool   `json:"watching"` // File watching is active
 	Reason   string `json:"reason"`   // Reason for analysis (e.g., "initial analysis", "BUILD changed")
+
+	Watcher  *WatcherHealth `json:"watcher,omitempty"`  // File watcher health, once watching has started
+	Timings  []PhaseTiming  `json:"timings,omitempty"`  // Duration of each analysis phase, when the analysis completed
+	Warnings []string       `json:"warnings,omitempty"` // Why a completed analysis may be incomplete, e.g. no build outputs
+	Error    *AnalysisError `json:"error,omitempty"`    // Why the analysis failed, in the error state
 }
 
-// TargetGraphData represents partial or complete graph data
+// AnalysisError describes the phase an analysis failed in
+type AnalysisError struct {
+	Phase       string `json:"phase"`       // e.g., "bazel_query"
+	Message     string `json:"message"`     // Error details
+	Recoverable bool   `json:"recoverable"` // Retrying may succeed, e.g. after fixing a BUILD file
+}
+
+// PhaseTiming is the wall-clock duration of an analysis phase
+type PhaseTiming struct {
+	Phase      string `json:"phase"`      // e.g., "bazel_query", "symbol_deps"
+	DurationMs int64  `json:"durationMs"` // Duration in milliseconds
+}
+
+// WatcherHealth summarizes how much of the workspace the file watcher covers
+type WatcherHealth struct {
+	WatchedDirs       int      `json:"watchedDirs"`       // Directories successfully watched
+	FailedDirs        []string `json:"failedDirs"`        // Directories that could not be watched
+	BazelOutWatched   bool     `json:"bazelOutWatched"`   // Whether bazel-out is being watched
+	WatchLimitReached bool     `json:"watchLimitReached"` // inotify watch limit exhausted (Linux)
+	Hint              string   `json:"hint,omitempty"`    // How to fix the problem, if known
+}
+
+// TargetGraphData represents partial or complete graph data (the TargetGraphTopic payload)
 type TargetGraphData struct {
 	TargetsCount      int  `json:"targets_count"`
 	DependenciesCount int  `json:"dependencies_count"`
 	Complete          bool `json:"complete"` // True when all data is loaded
 }
+
+// ModuleChanges lists what a re-analysis changed (the ChangesTopic payload)
+type ModuleChanges struct {
+	Reason  string   `json:"reason"`            // Reason for the re-analysis (e.g., "BUILD changed")
+	Nodes   []string `json:"nodes"`             // Graph node IDs (target labels) affected by the changes
+	Added   []string `json:"added,omitempty"`   // Targets that were added
+	Removed []string `json:"removed,omitempty"` // Targets that were removed
+	Changed []string `json:"changed,omitempty"` // Targets whose attributes changed
+}
@@ -14,6 +14,51 @@ import (
 type TopicConfig struct {
 	BufferSize int  // Number of events to buffer (0 = no buffering)
 	ReplayAll  bool // If true, replay all buffered events; if false, only replay last event
+
+	// What to do when a subscriber's channel is full
+	Backpressure Backpressure
+
+	// Capacity of each subscriber's channel (0 = defaultSubscriberBuffer)
+	SubscriberBuffer int
+}
+
+// defaultSubscriberBuffer is the channel capacity of a subscription without a configured size
+const defaultSubscriberBuffer = 100
+
+// Backpressure is the policy for publishing to a subscriber that does not keep up
+type Backpressure int
+
+const (
+	// DropNewest drops the event being published. Queued events are delivered as usual.
+	DropNewest Backpressure = iota
+
+	// DropOldest drops the oldest queued event to make room for the new one, so a slow
+	// subscriber sees the most recent events.
+	DropOldest
+
+	// Coalesce drops all queued events and delivers only the new one. Best for state
+	// topics where each event replaces the previous one.
+	Coalesce
+
+	// CloseSlowSubscriber closes the subscription. An SSE client then reconnects and gets
+	// the buffered events replayed.
+	CloseSlowSubscriber
+)
+
+// String returns the name of the policy
+func (b Backpressure) String() string {
+	switch b {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Coalesce:
+		return "coalesce"
+	case CloseSlowSubscriber:
+		return "close-slow-subscriber"
+	default:
+		return fmt.Sprintf("Backpressure(%d)", int(b))
+	}
 }
 
 // SSEPublisher implements Publisher using Server-Sent Events
@@ -23,9 +68,17 @@ type SSEPublisher struct {
 	version       map[string]int                       // topic -> version counter
 	eventBuffer   map[string][]Event                   // topic -> ring buffer of events
 	topicConfig   map[string]TopicConfig               // topic -> configuration
+	dropped       map[string]int                       // topic -> events not delivered to a subscriber
 	closed        bool
 }
 
+// TopicStats holds delivery statistics for a topic
+type TopicStats struct {
+	Subscribers int `json:"subscribers"` // Current number of subscribers
+	Published   int `json:"published"`   // Events published since start
+	Dropped     int `json:"dropped"`     // Events not delivered to a subscriber because its channel was full
+}
+
 // NewSSEPublisher creates a new SSE-based publisher
 func NewSSEPublisher() *SSEPublisher {
 	return &SSEPublisher{
@@ -33,7 +86,31 @@ func NewSSEPublisher() *SSEPublisher {
 		version:       make(map[string]int),
 		eventBuffer:   make(map[string][]Event),
 		topicConfig:   make(map[string]TopicConfig),
+		dropped:       make(map[string]int),
+	}
+}
+
+// Stats returns delivery statistics for every topic that has been published to or
+// subscribed to. A growing dropped count means subscribers are not keeping up.
+func (p *SSEPublisher) Stats() map[string]TopicStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make(map[string]TopicStats)
+	for topic, published := range p.version {
+		stats[topic] = TopicStats{Published: published}
+	}
+	for topic, dropped := range p.dropped {
+		ts := stats[topic]
+		ts.Dropped = dropped
+		stats[topic] = ts
+	}
+	for topic, subs := range p.subscriptions {
+		ts := stats[topic]
+		ts.Subscribers = len(subs)
+		stats[topic] = ts
 	}
+	return stats
 }
 
 // ConfigureTopic sets buffering configuration for a topic
@@ -53,9 +130,13 @@ func (p *SSEPublisher) Subscribe(ctx context.Context, topic string) (Subscriptio
 	}
 
 	// Create subscription
+	bufferSize := p.topicConfig[topic].SubscriberBuffer
+	if bufferSize <= 0 {
+		bufferSize = defaultSubscriberBuffer
+	}
 	sub := &sseSubscription{
 		topic:     topic,
-		events:    make(chan Event, 100), // Buffered to prevent blocking publishers
+		events:    make(chan Event, bufferSize), // Buffered to prevent blocking publishers
 		publisher: p,
 	}
 
@@ -65,19 +146,14 @@ func (p *SSEPublisher) Subscribe(ctx context.Context, topic string) (Subscriptio
 	}
 	p.subscriptions[topic][sub] = true
 
-	// Get buffered events to replay (copy while holding lock)
-	config := p.topicConfig[topic]
-	bufferedEvents := make([]Event, len(p.eventBuffer[topic]))
-	copy(bufferedEvents, p.eventBuffer[topic])
-
-	p.mu.Unlock()
-
-	// Replay events to new subscriber based on topic configuration
-	if len(bufferedEvents) > 0 {
-		eventsToReplay := bufferedEvents
-		if !config.ReplayAll && len(bufferedEvents) > 0 {
+	// Replay buffered events to the new subscriber based on topic configuration. This
+	// happens under the lock, as Publish may close the subscription's channel (see
+	// CloseSlowSubscriber) as soon as it is registered.
+	if buffered := p.eventBuffer[topic]; len(buffered) > 0 {
+		eventsToReplay := buffered
+		if !p.topicConfig[topic].ReplayAll {
 			// Only replay last event
-			eventsToReplay = bufferedEvents[len(bufferedEvents)-1:]
+			eventsToReplay = buffered[len(buffered)-1:]
 		}
 
 		for _, event := range eventsToReplay {
@@ -85,12 +161,15 @@ func (p *SSEPublisher) Subscribe(ctx context.Context, topic string) (Subscriptio
 			case sub.events <- event:
 				// Event sent successfully
 			default:
+				p.dropped[topic]++
 				logging.Warn("could not replay event to new subscriber", "topic", topic)
 			}
 		}
 		logging.Info("replayed events to new subscriber", "count", len(eventsToReplay), "topic", topic)
 	}
 
+	p.mu.Unlock()
+
 	// Handle context cancellation
 	go func() {
 		<-ctx.Done()
@@ -147,14 +226,60 @@ func (p *SSEPublisher) Publish(topic string, eventType string, data interface{})
 		case sub.events <- event:
 			// Event sent successfully
 		default:
-			// Channel full, log warning but don't block
-			logging.Warn("subscription channel full, dropping event", "topic", topic)
+			// Channel full, apply the topic's backpressure policy without blocking
+			p.handleFullSubscriber(sub, event, config.Backpressure)
 		}
 	}
 
 	return nil
 }
 
+// handleFullSubscriber delivers an event to a subscriber whose channel is full according
+// to the backpressure policy. The caller must hold p.mu.
+func (p *SSEPublisher) handleFullSubscriber(sub *sseSubscription, event Event, policy Backpressure) {
+	topic := sub.topic
+
+	var drained int
+	switch policy {
+	case DropOldest:
+		drained = dropQueued(sub.events, 1)
+	case Coalesce:
+		drained = dropQueued(sub.events, cap(sub.events))
+	case CloseSlowSubscriber:
+		p.dropped[topic]++
+		logging.Warn("subscriber not keeping up, closing subscription", "topic", topic, "dropped", p.dropped[topic])
+		delete(p.subscriptions[topic], sub)
+		close(sub.events)
+		return
+	default:
+		p.dropped[topic]++
+		logging.Warn("subscription channel full, dropping event", "topic", topic, "dropped", p.dropped[topic])
+		return
+	}
+	p.dropped[topic] += drained
+
+	select {
+	case sub.events <- event:
+		logging.Debug("subscription channel full, dropped queued events", "topic", topic, "policy", policy, "dropped", p.dropped[topic])
+	default:
+		p.dropped[topic]++
+		logging.Warn("subscription channel full, dropping event", "topic", topic, "dropped", p.dropped[topic])
+	}
+}
+
+// dropQueued removes up to n queued events from a channel without blocking and
+// returns the number of events removed
+func dropQueued(events chan Event, n int) int {
+	for i := 0; i < n; i++ {
+		select {
+		case <-events:
+		default:
+			return i
+		}
+	}
+	return n
+}
+
 // Close shuts down the publisher and all subscriptions
 func (p *SSEPublisher) Close() error {
 	p.mu.Lock()
@@ -166,6 +291,13 @@ func (p *SSEPublisher) Close() error {
 
 	p.closed = true
 
+	// Log totals so dropped events are visible after the fact
+	for topic, dropped := range p.dropped {
+		if dropped > 0 {
+			logging.Info("publisher closing with dropped events", "topic", topic, "published", p.version[topic], "dropped", dropped)
+		}
+	}
+
 	// Close all subscriptions
 	for _, subs := range p.subscriptions {
 		for sub := range subs {
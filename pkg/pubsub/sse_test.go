@@ -2,6 +2,7 @@ package pubsub
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 )
@@ -157,3 +158,146 @@ func TestNoBuffer(t *testing.T) {
 		t.Fatal("Timeout waiting for new event")
 	}
 }
+
+// fillSubscriber subscribes to a topic with a subscriber buffer of 2 and publishes
+// 5 events without reading, so the last 3 publishes hit a full channel
+func fillSubscriber(t *testing.T, policy Backpressure) (*SSEPublisher, Subscription) {
+	t.Helper()
+
+	pub := NewSSEPublisher()
+	t.Cleanup(func() { _ = pub.Close() })
+	pub.ConfigureTopic("test", TopicConfig{
+		Backpressure:     policy,
+		SubscriberBuffer: 2,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	sub, err := pub.Subscribe(ctx, "test")
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if err := pub.Publish("test", "event", map[string]int{"num": i}); err != nil {
+			t.Fatalf("Failed to publish event %d: %v", i, err)
+		}
+	}
+	return pub, sub
+}
+
+// drainVersions reads all queued events and reports whether the channel was closed
+func drainVersions(sub Subscription) (versions []int, closed bool) {
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return versions, true
+			}
+			versions = append(versions, event.Version)
+		default:
+			return versions, false
+		}
+	}
+}
+
+func TestSubscribeWhileClosingSlowSubscribers(t *testing.T) {
+	pub := NewSSEPublisher()
+	defer func() { _ = pub.Close() }()
+	pub.ConfigureTopic("test", TopicConfig{
+		BufferSize:       5,
+		ReplayAll:        true,
+		Backpressure:     CloseSlowSubscriber,
+		SubscriberBuffer: 1,
+	})
+	for i := 0; i < 5; i++ {
+		if err := pub.Publish("test", "event", nil); err != nil {
+			t.Fatalf("Failed to publish: %v", err)
+		}
+	}
+
+	// New subscribers that never read are closed by concurrent publishes, possibly while
+	// their buffered events are replayed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			_ = pub.Publish("test", "event", nil)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 200; i++ {
+		if _, err := pub.Subscribe(ctx, "test"); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+	}
+	<-done
+}
+
+func TestBackpressure(t *testing.T) {
+	tests := []struct {
+		policy       Backpressure
+		wantVersions []int
+		wantClosed   bool
+	}{
+		{DropNewest, []int{1, 2}, false},
+		{DropOldest, []int{4, 5}, false},
+		{Coalesce, []int{5}, false},
+		{CloseSlowSubscriber, []int{1, 2}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			pub, sub := fillSubscriber(t, tt.policy)
+			defer func() { _ = sub.Close() }()
+
+			versions, closed := drainVersions(sub)
+			if !slices.Equal(versions, tt.wantVersions) {
+				t.Errorf("Expected versions %v, got %v", tt.wantVersions, versions)
+			}
+			if closed != tt.wantClosed {
+				t.Errorf("Expected closed=%v, got %v", tt.wantClosed, closed)
+			}
+
+			// A closed subscriber is removed, the others still get new events
+			if err := pub.Publish("test", "event", nil); err != nil {
+				t.Fatalf("Failed to publish: %v", err)
+			}
+			versions, _ = drainVersions(sub)
+			if tt.wantClosed && len(versions) != 0 {
+				t.Errorf("Closed subscriber received %v", versions)
+			}
+			if !tt.wantClosed && !slices.Equal(versions, []int{6}) {
+				t.Errorf("Expected version [6] after draining, got %v", versions)
+			}
+		})
+	}
+}
+
+func TestStatsCountDroppedEvents(t *testing.T) {
+	tests := []struct {
+		policy      Backpressure
+		wantDropped int
+		wantSubs    int
+	}{
+		{DropNewest, 3, 1},
+		{DropOldest, 3, 1},
+		{Coalesce, 4, 1},
+		{CloseSlowSubscriber, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			pub, sub := fillSubscriber(t, tt.policy)
+			defer func() { _ = sub.Close() }()
+
+			want := TopicStats{Subscribers: tt.wantSubs, Published: 5, Dropped: tt.wantDropped}
+			if got := pub.Stats()["test"]; got != want {
+				t.Errorf("Expected stats %+v, got %+v", want, got)
+			}
+		})
+	}
+}
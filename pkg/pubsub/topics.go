@@ -0,0 +1,61 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Topic is a pub/sub topic whose events carry payloads of type T. Publishing and decoding
+// through a Topic checks the topic name and payload type at compile time. The wire format
+// is the same as publishing with the topic name directly.
+type Topic[T any] struct {
+	name string
+}
+
+// The topics published by the web server
+var (
+	// WorkspaceStatusTopic carries the analysis progress and the file watcher state.
+	// The event type is the analysis state (e.g., "bazel_querying", "ready").
+	WorkspaceStatusTopic = Topic[WorkspaceStatus]{name: "workspace_status"}
+
+	// TargetGraphTopic announces that (partial) target graph data can be fetched.
+	// The event type is "partial_data" or "complete".
+	TargetGraphTopic = Topic[TargetGraphData]{name: "target_graph"}
+
+	// ChangesTopic lists the targets affected by a re-analysis, so clients can highlight
+	// them. The event type is "changed".
+	ChangesTopic = Topic[ModuleChanges]{name: "changes"}
+
+	// GraphDiffTopic carries the changes of a lens-rendered graph after a re-analysis, sent
+	// to each subscriber relative to the graph it last received. The payload is the web
+	// server's lens render response; the event type is "diff" or "full".
+	GraphDiffTopic = Topic[json.RawMessage]{name: "graph_diff"}
+)
+
+// Name returns the topic name used on the wire
+func (t Topic[T]) Name() string {
+	return t.name
+}
+
+// Publish publishes data as an event of the given type on the topic
+func (t Topic[T]) Publish(pub Publisher, eventType string, data T) error {
+	return pub.Publish(t.name, eventType, data)
+}
+
+// Subscribe subscribes to the topic
+func (t Topic[T]) Subscribe(ctx context.Context, pub Publisher) (Subscription, error) {
+	return pub.Subscribe(ctx, t.name)
+}
+
+// Decode decodes the payload of an event published on the topic
+func (t Topic[T]) Decode(event Event) (T, error) {
+	var data T
+	if event.Topic != t.name {
+		return data, fmt.Errorf("event from topic %q decoded as %q", event.Topic, t.name)
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return data, fmt.Errorf("invalid %s event: %w", t.name, err)
+	}
+	return data, nil
+}
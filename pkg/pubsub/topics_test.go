@@ -0,0 +1,52 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTopicPublishAndDecode(t *testing.T) {
+	pub := NewMemoryPublisher()
+
+	status := WorkspaceStatus{State: "ready", Message: "Analysis complete", Step: 6, Total: 6}
+	if err := WorkspaceStatusTopic.Publish(pub, "ready", status); err != nil {
+		t.Fatal(err)
+	}
+
+	// The wire format matches publishing with the topic name
+	events := pub.EventsFor("workspace_status")
+	if len(events) != 1 || events[0].Type != "ready" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	got, err := WorkspaceStatusTopic.Decode(events[0])
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if got.State != status.State || got.Message != status.Message || got.Step != 6 {
+		t.Errorf("Decode() = %+v, want %+v", got, status)
+	}
+
+	// Decoding an event from another topic is an error
+	if _, err := TargetGraphTopic.Decode(events[0]); err == nil {
+		t.Error("expected an error decoding a workspace_status event as target_graph")
+	}
+}
+
+func TestTopicSubscribe(t *testing.T) {
+	pub := NewMemoryPublisher()
+	sub, err := TargetGraphTopic.Subscribe(context.Background(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = sub.Close() }()
+
+	if err := TargetGraphTopic.Publish(pub, "complete", TargetGraphData{TargetsCount: 2, Complete: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := TargetGraphTopic.Decode(<-sub.Events())
+	if err != nil || data.TargetsCount != 2 || !data.Complete {
+		t.Errorf("received %+v (err %v)", data, err)
+	}
+}
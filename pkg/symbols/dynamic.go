@@ -0,0 +1,75 @@
+package symbols
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// RunNMDynamic runs nm -D on a shared library and returns the defined symbols of its
+// dynamic symbol table: those visible to code outside the library
+func (c *DefaultClient) RunNMDynamic(soFile string) ([]Symbol, error) {
+	cmd := exec.Command("nm", "-D", "-C", "--defined-only", soFile)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("nm -D failed for %s: %w", soFile, err)
+	}
+
+	return ParseNMOutput(soFile, string(output)), nil
+}
+
+// RunNMDynamic runs nm -D on a shared library (see DefaultClient.RunNMDynamic)
+func RunNMDynamic(soFile string) ([]Symbol, error) {
+	client := &DefaultClient{}
+	return client.RunNMDynamic(soFile)
+}
+
+// ExportedSymbols returns the names of the global definitions among the symbols of a
+// dynamic symbol table. Symbols hidden by a version script or visibility attribute are
+// local (lowercase type) or missing from it altogether.
+func ExportedSymbols(syms []Symbol) map[string]bool {
+	exported := make(map[string]bool)
+	for _, sym := range syms {
+		if sym.IsDefined() && sym.Type == strings.ToUpper(sym.Type) {
+			exported[sym.Name] = true
+		}
+	}
+	return exported
+}
+
+// SharedLibraryFile returns the output file of a cc_shared_library target in the build
+// outputs of the workspace or bazelOut (lib<name>.so or lib<name>.dylib), or "" if it is
+// not built
+func SharedLibraryFile(workspaceRoot, bazelOut, label string) string {
+	_, name, _ := strings.Cut(label, ":")
+	return findOutputFile(workspaceRoot, bazelOut, label, "lib"+name+".so", "lib"+name+".dylib")
+}
+
+// findOutputFile returns the first of the given files that is built in the package of a
+// target, or "" if none is
+func findOutputFile(workspaceRoot, bazelOut, label string, files ...string) string {
+	pkg, _, ok := strings.Cut(strings.TrimPrefix(label, "//"), ":")
+	if !ok || strings.HasPrefix(label, "@") {
+		return ""
+	}
+
+	for _, root := range model.OutputRoots(workspaceRoot, bazelOut) {
+		for _, file := range files {
+			// bazel-bin holds the package directories, bazel-out one bin directory per configuration
+			candidates := []string{filepath.Join(root, pkg, file)}
+			if matches, _ := filepath.Glob(filepath.Join(root, "*", "bin", pkg, file)); len(matches) > 0 {
+				candidates = append(candidates, matches...)
+			}
+			for _, candidate := range candidates {
+				if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+					return candidate
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// dynamicMockClient is a MockClient that reads dynamic symbol tables
+type dynamicMockClient struct {
+	*MockClient
+	dynamicSymbols map[string][]Symbol // shared library file -> nm -D symbols
+}
+
+func (m *dynamicMockClient) RunNMDynamic(soFile string) ([]Symbol, error) {
+	return m.dynamicSymbols[soFile], nil
+}
+
+func TestExportedSymbols(t *testing.T) {
+	got := ExportedSymbols([]Symbol{
+		{Name: "core::Run()", Type: "T"},
+		{Name: "core::Log()", Type: "t"},
+		{Name: "core::kVersion", Type: "R"},
+		{Name: "std::string::size() const", Type: "W"},
+		{Name: "malloc", Type: "U"},
+	})
+	want := map[string]bool{"core::Run()": true, "core::kVersion": true, "std::string::size() const": true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExportedSymbols() = %v, want %v", got, want)
+	}
+}
+
+func TestSharedLibraryFile(t *testing.T) {
+	workspace := t.TempDir()
+	soFile := filepath.Join(workspace, "bazel-bin", "core", "libcore.so")
+	if err := os.MkdirAll(filepath.Dir(soFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(soFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := SharedLibraryFile(workspace, "", "//core:core"); got != soFile {
+		t.Errorf("SharedLibraryFile() = %q, want %q", got, soFile)
+	}
+	if got := SharedLibraryFile(workspace, "", "//util:util"); got != "" {
+		t.Errorf("SharedLibraryFile() of an unbuilt library = %q, want empty", got)
+	}
+}
+
+func TestSymbolGraphHidesUnexportedSymbols(t *testing.T) {
+	workspace := t.TempDir()
+	soFile := filepath.Join(workspace, "bazel-bin", "core", "libcore.so")
+	if err := os.MkdirAll(filepath.Dir(soFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(soFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// core::Log() is hidden by a version script, core::Run() is exported
+	client := &dynamicMockClient{
+		MockClient:     newGraphTestClient(),
+		dynamicSymbols: map[string][]Symbol{soFile: {{Name: "core::Run()", Type: "T"}}},
+	}
+	targetToKind := map[string]string{
+		"//main:app":  "cc_binary",
+		"//util:util": "cc_library",
+		"//core:core": "cc_shared_library",
+	}
+
+	g := NewSymbolGraph(client, workspace, map[string]string{}, targetToKind, nil)
+	if err := g.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, dep := range g.Dependencies() {
+		got = append(got, dep.SourceTarget+" -> "+dep.TargetTarget+" "+dep.Symbol+" "+string(dep.Linkage))
+	}
+	want := []string{
+		"//main:app -> //util:util util::Join() cross",
+		"//main:app -> //core:core core::Run() dynamic",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies() = %v, want %v", got, want)
+	}
+
+	// Without the dynamic symbol table every symbol counts as exported
+	g = NewSymbolGraph(client.MockClient, workspace, map[string]string{}, targetToKind, nil)
+	if err := g.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if n := len(g.Dependencies()); n != 3 {
+		t.Errorf("Dependencies() without nm -D = %d, want 3", n)
+	}
+}
@@ -0,0 +1,561 @@
+package symbols
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// SymbolGraph holds the symbol tables of a workspace's object files so that a changed object
+// can be re-read on its own: only the dependencies of objects using a symbol the changed
+// object defines, or used to define, are recomputed. Symbols defined by more than one object
+// resolve to the most recently added one, which after Build is the last in scan order.
+//
+// Build also reads the dynamic symbol tables of the built shared libraries. A dynamically
+// linked use of a symbol that a shared library does not export (e.g. hidden by a version
+// script) is then not a dependency. Likewise it reads the dynamic relocations of the built
+// binaries and shared libraries, to tell static from dynamic uses of symbols between other
+// targets.
+type SymbolGraph struct {
+	client        Client
+	workspaceRoot string
+	bazelOut      string // Searched for build outputs instead of the workspace's bazel-out if set
+	fileToTarget  map[string]string
+	targetToKind  map[string]string
+	scope         Scope
+	workers       int                  // Parallel nm runs in Build, runtime.NumCPU() if 0
+	extensions    model.FileExtensions // Tried for the source file of an object file
+
+	objects   map[string]*objectSymbols  // object file -> its symbol tables
+	nextIndex int                        // Scan position given to the next added object
+	definers  map[string]map[string]bool // symbol -> objects defining it
+	users     map[string]map[string]bool // symbol -> objects leaving it undefined
+	resolved  map[string]string          // symbol -> object its uses resolve to
+	exports   map[string]map[string]bool // shared library target -> symbols it exports, if read
+	imported  map[string]map[string]bool // linked target -> symbols it imports from shared libraries, if read
+}
+
+// objectSymbols is what nm reported for one object file, and the dependencies derived from it
+type objectSymbols struct {
+	index      int // Scan position, orders objects and resolves duplicate definitions
+	sourceFile string
+	target     string
+	modTime    time.Time // Zero if the object could not be stat'ed
+	defined    []string
+	global     []string
+	undefined  []string
+	deps       []SymbolDependency // Dependencies of this object on symbols defined elsewhere
+}
+
+// NewSymbolGraph returns an empty symbol graph over the object files of the targets in
+// scope. Call Build to read them.
+func NewSymbolGraph(client Client, workspaceRoot string, fileToTarget map[string]string, targetToKind map[string]string, scope Scope) *SymbolGraph {
+	return &SymbolGraph{
+		client:        client,
+		workspaceRoot: workspaceRoot,
+		fileToTarget:  fileToTarget,
+		targetToKind:  targetToKind,
+		scope:         scope,
+		objects:       make(map[string]*objectSymbols),
+		definers:      make(map[string]map[string]bool),
+		users:         make(map[string]map[string]bool),
+		resolved:      make(map[string]string),
+	}
+}
+
+// SetWorkers sets how many nm processes Build runs in parallel, runtime.NumCPU() if 0
+func (g *SymbolGraph) SetWorkers(workers int) {
+	g.workers = workers
+}
+
+// SetFileExtensions sets the source extensions tried for the source file of an object file
+// (see ObjectFileToSourceFile), the defaults if not set
+func (g *SymbolGraph) SetFileExtensions(extensions model.FileExtensions) {
+	g.extensions = extensions
+}
+
+// SetBazelOutPath sets the directory searched for object files and linked outputs instead
+// of the workspace's bazel-out and bazel-bin (see model.OutputRoots), none if empty
+func (g *SymbolGraph) SetBazelOutPath(bazelOut string) {
+	g.bazelOut = bazelOut
+}
+
+// Build runs nm on every object file in scope, replacing any tables read before. The
+// objects are read in parallel (see SetWorkers) but added in scan order, so the result is
+// the same for any number of workers.
+func (g *SymbolGraph) Build() error {
+	objectFiles, err := g.client.FindObjectFiles(g.workspaceRoot, g.bazelOut)
+	if err != nil {
+		return err
+	}
+	if len(objectFiles) == 0 {
+		return fmt.Errorf("no object files found in %s", g.workspaceRoot)
+	}
+
+	workers, extensions, bazelOut := g.workers, g.extensions, g.bazelOut
+	*g = *NewSymbolGraph(g.client, g.workspaceRoot, g.fileToTarget, g.targetToKind, g.scope)
+	g.workers, g.extensions, g.bazelOut = workers, extensions, bazelOut
+	g.readExports()
+	g.readRelocations()
+	for i, obj := range g.readObjects(objectFiles) {
+		if obj == nil {
+			continue
+		}
+		obj.index = g.nextIndex
+		g.addSymbols(objectFiles[i], obj)
+	}
+
+	// Resolve every symbol once, then derive all dependencies
+	for sym := range g.definers {
+		g.resolve(sym)
+	}
+	for objFile, obj := range g.objects {
+		obj.deps = g.objectDependencies(objFile, obj)
+	}
+	return nil
+}
+
+// Refresh finds the object files again and re-reads those that are new or whose
+// modification time changed, dropping those that are gone. It returns the object files
+// that were re-read or dropped, sorted.
+func (g *SymbolGraph) Refresh() ([]string, error) {
+	objectFiles, err := g.client.FindObjectFiles(g.workspaceRoot, g.bazelOut)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	found := make(map[string]bool, len(objectFiles))
+	for _, objFile := range objectFiles {
+		found[objFile] = true
+		if obj, ok := g.objects[objFile]; ok && !obj.modTime.IsZero() && obj.modTime.Equal(statModTime(objFile)) {
+			continue
+		}
+		g.UpdateObject(objFile)
+		changed = append(changed, objFile)
+	}
+	for objFile := range g.objects {
+		if !found[objFile] {
+			g.RemoveObject(objFile)
+			changed = append(changed, objFile)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// UpdateObject re-reads a single object file, e.g. after it was rebuilt, and recomputes
+// the dependencies it affects. An object that is out of scope or that nm cannot read is
+// removed from the graph.
+func (g *SymbolGraph) UpdateObject(objFile string) {
+	index := g.nextIndex
+	if old, ok := g.objects[objFile]; ok {
+		index = old.index
+	}
+
+	obj := g.readObject(objFile)
+	if obj == nil {
+		g.RemoveObject(objFile)
+		return
+	}
+	obj.index = index
+	g.replaceObject(objFile, obj)
+}
+
+// RemoveObject drops an object file from the graph, e.g. after it was deleted
+func (g *SymbolGraph) RemoveObject(objFile string) {
+	if _, ok := g.objects[objFile]; ok {
+		g.replaceObject(objFile, nil)
+	}
+}
+
+// HasObject reports whether an object file, as found by the client, is in the graph
+func (g *SymbolGraph) HasObject(objFile string) bool {
+	_, ok := g.objects[objFile]
+	return ok
+}
+
+// BuiltFor reports whether the graph maps object files to targets as the given maps and
+// reads the object files of the same scope, so that it can be updated for a new analysis
+// instead of being built again
+func (g *SymbolGraph) BuiltFor(fileToTarget, targetToKind map[string]string, scope Scope) bool {
+	return maps.Equal(g.fileToTarget, fileToTarget) && maps.Equal(g.targetToKind, targetToKind) &&
+		(g.scope == nil) == (scope == nil) && maps.Equal(g.scope, scope)
+}
+
+// Dependencies returns the symbol dependencies between files, ordered by object file in
+// scan order and then by the order nm listed the undefined symbols
+func (g *SymbolGraph) Dependencies() []SymbolDependency {
+	objectFiles := g.orderedObjects()
+	count := 0
+	for _, objFile := range objectFiles {
+		count += len(g.objects[objFile].deps)
+	}
+	if count == 0 {
+		return nil
+	}
+
+	symbolDeps := make([]SymbolDependency, 0, count)
+	for _, objFile := range objectFiles {
+		symbolDeps = append(symbolDeps, g.objects[objFile].deps...)
+	}
+	return symbolDeps
+}
+
+// FileSymbols returns the symbol table of each analyzed source file, keyed by source file
+func (g *SymbolGraph) FileSymbols() map[string]*FileSymbols {
+	symbolDefinitions := make(map[string]string, len(g.resolved))
+	symbolTargets := make(map[string]string, len(g.resolved))
+	for sym, objFile := range g.resolved {
+		symbolDefinitions[sym] = g.objects[objFile].sourceFile
+		symbolTargets[sym] = g.objects[objFile].target
+	}
+
+	fileDefinedSymbols := make(map[string][]string)
+	fileUndefinedSymbols := make(map[string][]string)
+	fileGlobalSymbols := make(map[string][]string)
+	sourceTargets := make(map[string]string)
+	for _, objFile := range g.orderedObjects() {
+		obj := g.objects[objFile]
+		if obj.target != "" {
+			sourceTargets[obj.sourceFile] = obj.target
+		}
+		if len(obj.defined) > 0 {
+			fileDefinedSymbols[obj.sourceFile] = append(fileDefinedSymbols[obj.sourceFile], obj.defined...)
+		}
+		if len(obj.undefined) > 0 {
+			fileUndefinedSymbols[obj.sourceFile] = append(fileUndefinedSymbols[obj.sourceFile], obj.undefined...)
+		}
+		if len(obj.global) > 0 {
+			fileGlobalSymbols[obj.sourceFile] = append(fileGlobalSymbols[obj.sourceFile], obj.global...)
+		}
+	}
+
+	fileSymbols := buildFileSymbols(fileDefinedSymbols, fileUndefinedSymbols, symbolDefinitions, symbolTargets, sourceTargets)
+	for file, syms := range fileGlobalSymbols {
+		fileSymbols[file].Global = uniqueSorted(syms)
+	}
+	return fileSymbols
+}
+
+// readExports reads the dynamic symbol table of each built cc_shared_library target.
+// Libraries that are not built or whose table the client cannot read are left out, so all
+// of their symbols count as exported.
+func (g *SymbolGraph) readExports() {
+	g.exports = make(map[string]map[string]bool)
+	for label, kind := range g.targetToKind {
+		if kind != string(model.TargetKindSharedLibrary) {
+			continue
+		}
+		soFile := SharedLibraryFile(g.workspaceRoot, g.bazelOut, label)
+		if soFile == "" {
+			continue
+		}
+		syms, err := g.client.RunNMDynamic(soFile)
+		if err != nil {
+			logging.Debug("could not read dynamic symbols", "library", soFile, "error", err)
+			continue
+		}
+		g.exports[label] = ExportedSymbols(syms)
+	}
+}
+
+// hidden reports whether a shared library target is known not to export a symbol
+func (g *SymbolGraph) hidden(label, sym string) bool {
+	exported, ok := g.exports[label]
+	return ok && !exported[sym]
+}
+
+// readRelocations reads the dynamic relocations of each built binary and shared library
+// target. Outputs that are not built or whose relocations the client cannot read are left
+// out. Relocated symbols that the output defines itself, e.g. the interposable
+// exported functions of a shared library, are contained in the output and not imported.
+func (g *SymbolGraph) readRelocations() {
+	g.imported = make(map[string]map[string]bool)
+	for label, kind := range g.targetToKind {
+		linkedFile := LinkedOutputFile(g.workspaceRoot, g.bazelOut, label, kind)
+		if linkedFile == "" {
+			continue
+		}
+		relocations, err := g.client.RunRelocations(linkedFile)
+		if err != nil {
+			logging.Debug("could not read dynamic relocations", "output", linkedFile, "error", err)
+			continue
+		}
+		defined := g.definedDynamicSymbols(label, linkedFile)
+		imported := make(map[string]bool, len(relocations))
+		for _, relocation := range relocations {
+			if !defined[relocation.Symbol] {
+				imported[relocation.Symbol] = true
+			}
+		}
+		g.imported[label] = imported
+	}
+}
+
+// definedDynamicSymbols returns the symbols a linked output defines in its dynamic symbol
+// table, reusing the exports read for shared libraries, or nil if the client cannot read
+// it
+func (g *SymbolGraph) definedDynamicSymbols(label, linkedFile string) map[string]bool {
+	if exported, ok := g.exports[label]; ok {
+		return exported
+	}
+	syms, err := g.client.RunNMDynamic(linkedFile)
+	if err != nil {
+		logging.Debug("could not read dynamic symbols", "output", linkedFile, "error", err)
+		return nil
+	}
+	return ExportedSymbols(syms)
+}
+
+// crossLinkage classifies a use of a symbol between targets that are not shared libraries
+// by the dynamic relocations of the source target's own output: a symbol the output imports
+// from a shared library is linked dynamically, others were resolved by the static linker.
+// A target without an output of its own, such as a library that any number of binaries
+// may link, or without relocations gives an unknown linkage (LinkageCross).
+func (g *SymbolGraph) crossLinkage(sourceTarget, sym string) LinkageType {
+	imported, ok := g.imported[sourceTarget]
+	if !ok {
+		return LinkageCross
+	}
+	if imported[sym] {
+		return LinkageDynamic
+	}
+	return LinkageStatic
+}
+
+// readObjects runs readObject on the object files with up to g.workers in parallel and
+// returns the results in the order of the files. The indices of the results are not set.
+func (g *SymbolGraph) readObjects(objectFiles []string) []*objectSymbols {
+	workers := g.workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	objects := make([]*objectSymbols, len(objectFiles))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, objFile := range objectFiles {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			objects[i] = g.readObject(objFile)
+		}()
+	}
+	wg.Wait()
+	return objects
+}
+
+// readObject runs nm on an object file in scope, returning nil if it is out of scope or
+// cannot be read
+func (g *SymbolGraph) readObject(objFile string) *objectSymbols {
+	sourceFile := ObjectFileToSourceFile(objFile, g.workspaceRoot, g.fileToTarget, g.extensions)
+	target := resolveObjectTarget(objFile, sourceFile, g.fileToTarget)
+	if !g.scope.Contains(target) {
+		return nil
+	}
+
+	symbols, err := g.client.RunNM(objFile)
+	if err != nil {
+		// Skip files we can't process
+		return nil
+	}
+
+	obj := &objectSymbols{
+		index:      g.nextIndex,
+		sourceFile: sourceFile,
+		target:     target,
+		modTime:    statModTime(objFile),
+	}
+	for _, sym := range symbols {
+		if sym.Type == "U" {
+			// Undefined symbol - this file needs it
+			obj.undefined = append(obj.undefined, sym.Name)
+		} else if isDefinedSymbol(sym.Type) {
+			// Defined symbol - this file provides it
+			obj.defined = append(obj.defined, sym.Name)
+			if isGlobalSymbol(sym.Type) {
+				obj.global = append(obj.global, sym.Name)
+			}
+		}
+	}
+	return obj
+}
+
+// addSymbols indexes the symbols of an object without resolving them
+func (g *SymbolGraph) addSymbols(objFile string, obj *objectSymbols) {
+	if obj.index >= g.nextIndex {
+		g.nextIndex = obj.index + 1
+	}
+	g.objects[objFile] = obj
+	for _, sym := range obj.defined {
+		addToSet(g.definers, sym, objFile)
+	}
+	for _, sym := range obj.undefined {
+		addToSet(g.users, sym, objFile)
+	}
+}
+
+// removeSymbols undoes addSymbols
+func (g *SymbolGraph) removeSymbols(objFile string, obj *objectSymbols) {
+	delete(g.objects, objFile)
+	for _, sym := range obj.defined {
+		removeFromSet(g.definers, sym, objFile)
+	}
+	for _, sym := range obj.undefined {
+		removeFromSet(g.users, sym, objFile)
+	}
+}
+
+// replaceObject swaps the tables of an object (nil to remove it) and recomputes the
+// dependencies of the object and of every object using a symbol it defines or defined
+func (g *SymbolGraph) replaceObject(objFile string, obj *objectSymbols) {
+	affectedSymbols := make(map[string]bool)
+	if old, ok := g.objects[objFile]; ok {
+		for _, sym := range old.defined {
+			affectedSymbols[sym] = true
+		}
+		g.removeSymbols(objFile, old)
+	}
+	if obj != nil {
+		for _, sym := range obj.defined {
+			affectedSymbols[sym] = true
+		}
+		g.addSymbols(objFile, obj)
+	}
+
+	affectedObjects := make(map[string]bool)
+	if obj != nil {
+		affectedObjects[objFile] = true
+	}
+	for sym := range affectedSymbols {
+		before := g.resolved[sym]
+		if g.resolve(sym) == before {
+			continue
+		}
+		for user := range g.users[sym] {
+			affectedObjects[user] = true
+		}
+	}
+	for user := range affectedObjects {
+		g.objects[user].deps = g.objectDependencies(user, g.objects[user])
+	}
+}
+
+// resolve picks the object a symbol's uses resolve to: the defining object added last
+func (g *SymbolGraph) resolve(sym string) string {
+	definer, definerIndex := "", -1
+	for objFile := range g.definers[sym] {
+		if index := g.objects[objFile].index; index > definerIndex {
+			definer, definerIndex = objFile, index
+		}
+	}
+	if definer == "" {
+		delete(g.resolved, sym)
+	} else {
+		g.resolved[sym] = definer
+	}
+	return definer
+}
+
+// objectDependencies derives the dependencies of an object: file A depends on file B if
+// A uses a symbol defined in B
+func (g *SymbolGraph) objectDependencies(objFile string, obj *objectSymbols) []SymbolDependency {
+	var symbolDeps []SymbolDependency
+	for _, symName := range obj.undefined {
+		definingObject, ok := g.resolved[symName]
+		if !ok || definingObject == objFile {
+			continue
+		}
+		definingFile := g.objects[definingObject].sourceFile
+		srcTarget := obj.target
+		tgtTarget := g.objects[definingObject].target
+		if obj.sourceFile == definingFile && srcTarget == tgtTarget {
+			continue
+		}
+
+		dep := SymbolDependency{
+			SourceFile:   obj.sourceFile,
+			TargetFile:   definingFile,
+			Symbol:       symName,
+			SourceTarget: srcTarget,
+			SourceBinary: srcTarget, // Use target as binary identifier
+			TargetTarget: tgtTarget,
+			TargetBinary: tgtTarget, // Use target as binary identifier
+		}
+
+		// Determine linkage type when the targets are known
+		if g.fileToTarget != nil || srcTarget != "" || tgtTarget != "" {
+			if dep.SourceTarget == dep.TargetTarget {
+				// Same target = static linkage within same binary
+				dep.Linkage = LinkageStatic
+			} else if g.targetToKind != nil {
+				// Different targets - check if target is a shared library
+				sourceKind := g.targetToKind[dep.SourceTarget]
+				targetKind := g.targetToKind[dep.TargetTarget]
+
+				if targetKind == "cc_shared_library" || sourceKind == "cc_shared_library" {
+					// A symbol the shared library does not export can't be used across its boundary
+					if targetKind == "cc_shared_library" && g.hidden(dep.TargetTarget, symName) {
+						continue
+					}
+					dep.Linkage = LinkageDynamic
+				} else {
+					// Different binaries, not shared library
+					dep.Linkage = g.crossLinkage(dep.SourceTarget, symName)
+				}
+			} else {
+				dep.Linkage = LinkageCross
+			}
+		}
+
+		symbolDeps = append(symbolDeps, dep)
+	}
+	return symbolDeps
+}
+
+// orderedObjects returns the object files in the graph in scan order
+func (g *SymbolGraph) orderedObjects() []string {
+	objectFiles := make([]string, 0, len(g.objects))
+	for objFile := range g.objects {
+		objectFiles = append(objectFiles, objFile)
+	}
+	sort.Slice(objectFiles, func(i, j int) bool {
+		return g.objects[objectFiles[i]].index < g.objects[objectFiles[j]].index
+	})
+	return objectFiles
+}
+
+// statModTime returns the modification time of a file, or the zero time if it cannot be
+// stat'ed
+func statModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func addToSet(sets map[string]map[string]bool, key, value string) {
+	if sets[key] == nil {
+		sets[key] = make(map[string]bool)
+	}
+	sets[key][value] = true
+}
+
+func removeFromSet(sets map[string]map[string]bool, key, value string) {
+	delete(sets[key], value)
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
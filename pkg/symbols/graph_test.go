@@ -0,0 +1,207 @@
+package symbols
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	graphMainObj = "bazel-out/k8-fastbuild/bin/main/_objs/app/main.o"
+	graphUtilObj = "bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o"
+	graphCoreObj = "bazel-out/k8-fastbuild/bin/core/_objs/core/core.o"
+)
+
+func newGraphTestClient() *MockClient {
+	return &MockClient{
+		MockObjectFiles: []string{graphMainObj, graphUtilObj, graphCoreObj},
+		MockSymbols: map[string][]Symbol{
+			graphMainObj: {
+				{Name: "main", Type: "T"},
+				{Name: "util::Join()", Type: "U"},
+				{Name: "core::Run()", Type: "U"},
+			},
+			graphUtilObj: {
+				{Name: "util::Join()", Type: "T"},
+				{Name: "core::Log()", Type: "U"},
+			},
+			graphCoreObj: {
+				{Name: "core::Run()", Type: "T"},
+				{Name: "core::Log()", Type: "T"},
+			},
+		},
+	}
+}
+
+// assertMatchesRebuild checks that an incrementally updated graph equals a graph built
+// from scratch over the same objects
+func assertMatchesRebuild(t *testing.T, client *MockClient, g *SymbolGraph) {
+	t.Helper()
+	full := NewSymbolGraph(client, "", nil, nil, nil)
+	if err := full.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if got, want := g.Dependencies(), full.Dependencies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies() = %+v, want %+v", got, want)
+	}
+	if got, want := g.FileSymbols(), full.FileSymbols(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FileSymbols() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSymbolGraphUpdateObject(t *testing.T) {
+	client := newGraphTestClient()
+	g := NewSymbolGraph(client, "", nil, nil, nil)
+	if err := g.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	if got := len(g.Dependencies()); got != 3 {
+		t.Fatalf("expected 3 dependencies, got %d: %+v", got, g.Dependencies())
+	}
+
+	// util stops using core and starts defining core::Run(), taking over main's use of it
+	// as the later definition
+	client.MockSymbols[graphUtilObj] = []Symbol{
+		{Name: "util::Join()", Type: "T"},
+		{Name: "core::Run()", Type: "T"},
+	}
+	g.UpdateObject(graphUtilObj)
+	assertMatchesRebuild(t, client, g)
+
+	// Dropping the definition again hands core::Run() back to core
+	client.MockSymbols[graphUtilObj] = []Symbol{{Name: "util::Join()", Type: "T"}}
+	g.UpdateObject(graphUtilObj)
+	assertMatchesRebuild(t, client, g)
+}
+
+func TestSymbolGraphRemoveObject(t *testing.T) {
+	client := newGraphTestClient()
+	g := NewSymbolGraph(client, "", nil, nil, nil)
+	if err := g.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	g.RemoveObject(graphCoreObj)
+	client.MockObjectFiles = []string{graphMainObj, graphUtilObj}
+	delete(client.MockSymbols, graphCoreObj)
+	assertMatchesRebuild(t, client, g)
+
+	undefined := g.FileSymbols()["main/main.cc"].Undefined
+	want := []UndefinedSymbol{{Symbol: "core::Run()"}, {Symbol: "util::Join()", ResolvedFile: "util/strings.cc", ResolvedTarget: "//util:util"}}
+	if !reflect.DeepEqual(undefined, want) {
+		t.Errorf("Undefined = %+v, want %+v", undefined, want)
+	}
+}
+
+func TestSymbolGraphRefresh(t *testing.T) {
+	client := newGraphTestClient()
+	client.MockObjectFiles = []string{graphMainObj, graphUtilObj}
+	g := NewSymbolGraph(client, "", nil, nil, nil)
+	if err := g.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	// The mock objects do not exist on disk, so lacking a modification time every object
+	// found is re-read, and objects no longer found are dropped
+	client.MockObjectFiles = []string{graphMainObj, graphCoreObj}
+	changed, err := g.Refresh()
+	if err != nil {
+		t.Fatalf("Refresh() unexpected error: %v", err)
+	}
+	if want := []string{graphCoreObj, graphMainObj, graphUtilObj}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("Refresh() = %v, want %v", changed, want)
+	}
+	assertMatchesRebuild(t, client, g)
+}
+
+// newBenchmarkClient returns a workspace of n objects, each defining a function and
+// calling the functions of the ten objects before it
+func newBenchmarkClient(n int) *MockClient {
+	client := &MockClient{MockSymbols: make(map[string][]Symbol, n)}
+	for i := 0; i < n; i++ {
+		obj := fmt.Sprintf("bazel-out/k8-fastbuild/bin/pkg%d/_objs/lib%d/file%d.o", i/10, i/10, i)
+		client.MockObjectFiles = append(client.MockObjectFiles, obj)
+		syms := []Symbol{{Name: fmt.Sprintf("fn%d()", i), Type: "T"}}
+		for j := max(0, i-10); j < i; j++ {
+			syms = append(syms, Symbol{Name: fmt.Sprintf("fn%d()", j), Type: "U"})
+		}
+		client.MockSymbols[obj] = syms
+	}
+	return client
+}
+
+// slowMockClient is a MockClient whose nm runs take a while, like real nm processes
+type slowMockClient struct {
+	*MockClient
+	delay time.Duration
+}
+
+func (m *slowMockClient) RunNM(objectFile string) ([]Symbol, error) {
+	time.Sleep(m.delay)
+	return m.MockClient.RunNM(objectFile)
+}
+
+func TestSymbolGraphBuildIsIndependentOfWorkers(t *testing.T) {
+	client := newBenchmarkClient(300)
+	sequential := NewSymbolGraph(client, "", nil, nil, nil)
+	sequential.SetWorkers(1)
+	if err := sequential.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	for _, workers := range []int{4, 0} {
+		parallel := NewSymbolGraph(client, "", nil, nil, nil)
+		parallel.SetWorkers(workers)
+		if err := parallel.Build(); err != nil {
+			t.Fatalf("Build() unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(parallel.Dependencies(), sequential.Dependencies()) {
+			t.Errorf("Dependencies() with %d workers differ from a sequential build", workers)
+		}
+		if !reflect.DeepEqual(parallel.FileSymbols(), sequential.FileSymbols()) {
+			t.Errorf("FileSymbols() with %d workers differ from a sequential build", workers)
+		}
+	}
+}
+
+// BenchmarkSymbolGraphParallelNM compares sequential and parallel nm runs over 500 objects.
+// The simulated nm runs wait rather than compute, so they overlap on any number of CPUs.
+func BenchmarkSymbolGraphParallelNM(b *testing.B) {
+	client := &slowMockClient{MockClient: newBenchmarkClient(500), delay: 200 * time.Microsecond}
+	for _, workers := range []int{1, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				g := NewSymbolGraph(client, "", nil, nil, nil)
+				g.SetWorkers(workers)
+				if err := g.Build(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSymbolGraphFullBuild(b *testing.B) {
+	client := newBenchmarkClient(2000)
+	for b.Loop() {
+		g := NewSymbolGraph(client, "", nil, nil, nil)
+		if err := g.Build(); err != nil {
+			b.Fatal(err)
+		}
+		_ = g.Dependencies()
+	}
+}
+
+func BenchmarkSymbolGraphUpdateObject(b *testing.B) {
+	client := newBenchmarkClient(2000)
+	g := NewSymbolGraph(client, "", nil, nil, nil)
+	if err := g.Build(); err != nil {
+		b.Fatal(err)
+	}
+	changed := client.MockObjectFiles[1000]
+	for b.Loop() {
+		g.UpdateObject(changed)
+		_ = g.Dependencies()
+	}
+}
@@ -3,10 +3,14 @@ package symbols
 import (
 	"bufio"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
 // Symbol represents a symbol extracted from an object file
@@ -39,6 +43,23 @@ type SymbolDependency struct {
 	TargetBinary string      `json:"targetBinary"` // Which binary/library defines it
 }
 
+// FileSymbols lists the symbols defined and used by a single source file's object
+type FileSymbols struct {
+	File      string            `json:"file"`             // Source file, e.g. "util/strings.cc"
+	Target    string            `json:"target,omitempty"` // Bazel target owning the file (if known)
+	Defined   []string          `json:"defined"`          // Symbols defined by the object, demangled
+	Global    []string          `json:"global,omitempty"` // Defined symbols that other objects can link against (strong and not local)
+	Undefined []UndefinedSymbol `json:"undefined"`        // Symbols the object needs from elsewhere
+}
+
+// UndefinedSymbol is a symbol used by an object file and where it is resolved, if anywhere
+type UndefinedSymbol struct {
+	Symbol          string `json:"symbol"`                    // Demangled symbol name
+	ResolvedFile    string `json:"resolvedFile,omitempty"`    // File defining the symbol (empty if external)
+	ResolvedTarget  string `json:"resolvedTarget,omitempty"`  // Target owning the defining file (if known)
+	ResolvedLibrary string `json:"resolvedLibrary,omitempty"` // System library (-l name) providing the symbol, if not defined in the workspace
+}
+
 // isHexAddress checks if a string looks like a hexadecimal address
 func isHexAddress(s string) bool {
 	if len(s) < 8 {
@@ -101,11 +122,16 @@ func ParseNMOutput(objectFile string, nmOutput string) []Symbol {
 	return symbols
 }
 
-// Client handles interaction with the build system and nm
+// Client handles interaction with the build system and the tools reading build outputs:
+// nm for the symbol tables of object files and shared libraries, objdump for the dynamic
+// relocations of linked outputs. The object files are searched in bazelOut if set, else in
+// the workspace's bazel-out and bazel-bin (see model.OutputRoots).
 type Client interface {
-	FindObjectFiles(workspaceRoot string) ([]string, error)
+	FindObjectFiles(workspaceRoot, bazelOut string) ([]string, error)
 	RunNM(objectFile string) ([]Symbol, error)
-	BuildSymbolGraph(workspaceRoot string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error)
+	RunNMDynamic(soFile string) ([]Symbol, error)
+	RunRelocations(linkedFile string) ([]Relocation, error)
+	BuildSymbolGraph(workspaceRoot, bazelOut string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error)
 }
 
 // DefaultClient uses actual filesystem and nm command
@@ -129,16 +155,11 @@ func (c *DefaultClient) RunNM(objectFile string) ([]Symbol, error) {
 }
 
 // FindObjectFiles searches for .o files in the bazel output directories
-func (c *DefaultClient) FindObjectFiles(workspaceRoot string) ([]string, error) {
+// (see model.OutputRoots)
+func (c *DefaultClient) FindObjectFiles(workspaceRoot, bazelOut string) ([]string, error) {
 	var objectFiles []string
 
-	// Common Bazel output paths
-	bazelOutDirs := []string{
-		filepath.Join(workspaceRoot, "bazel-out"),
-		filepath.Join(workspaceRoot, "bazel-bin"),
-	}
-
-	for _, dir := range bazelOutDirs {
+	for _, dir := range model.OutputRoots(workspaceRoot, bazelOut) {
 		// Use find command to locate .o files
 		// Use -L to follow symlinks (Bazel uses symlinks for bazel-out)
 		cmd := exec.Command("find", "-L", dir, "-name", "*.o")
@@ -171,159 +192,230 @@ func RunNM(objectFile string) ([]Symbol, error) {
 	return client.RunNM(objectFile)
 }
 
-func FindObjectFiles(workspaceRoot string) ([]string, error) {
+func FindObjectFiles(workspaceRoot, bazelOut string) ([]string, error) {
 	client := &DefaultClient{}
-	return client.FindObjectFiles(workspaceRoot)
+	return client.FindObjectFiles(workspaceRoot, bazelOut)
 }
 
 // BuildSymbolGraph analyzes all object files and builds symbol dependencies
 // It also determines which binary/library each object file belongs to and the linkage type
 func BuildSymbolGraph(workspaceRoot string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error) {
 	client := NewClient()
-	return client.BuildSymbolGraph(workspaceRoot, fileToTarget, targetToKind)
+	return client.BuildSymbolGraph(workspaceRoot, "", fileToTarget, targetToKind)
+}
+
+// BuildSymbolGraphWithFiles is like BuildSymbolGraph but also returns the symbol table of each
+// analyzed source file, keyed by source file path
+func BuildSymbolGraphWithFiles(workspaceRoot string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, map[string]*FileSymbols, error) {
+	return buildSymbolTables(NewClient(), workspaceRoot, "", fileToTarget, targetToKind, nil, 0)
+}
+
+// Scope is a set of target labels limiting symbol analysis to their object files.
+// A nil Scope includes every target.
+type Scope map[string]bool
+
+// NewScope returns a scope containing the given targets
+func NewScope(labels []string) Scope {
+	scope := make(Scope, len(labels))
+	for _, label := range labels {
+		scope[label] = true
+	}
+	return scope
+}
+
+// Contains reports whether the object files of a target are analyzed
+func (s Scope) Contains(label string) bool {
+	return s == nil || s[label]
+}
+
+// BuildSymbolGraphInScope is like BuildSymbolGraphWithFiles but only runs nm on the object
+// files of targets in scope. Symbols used by these objects but defined by targets outside
+// the scope are reported as unresolved. Up to workers nm processes run in parallel
+// (runtime.NumCPU() if 0).
+func BuildSymbolGraphInScope(workspaceRoot string, fileToTarget map[string]string, targetToKind map[string]string, scope Scope, workers int) ([]SymbolDependency, map[string]*FileSymbols, error) {
+	return buildSymbolTables(NewClient(), workspaceRoot, "", fileToTarget, targetToKind, scope, workers)
 }
 
 // BuildSymbolGraph on Client allows mocking
-func (c *DefaultClient) BuildSymbolGraph(workspaceRoot string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error) {
-	return buildSymbolGraphInternal(c, workspaceRoot, fileToTarget, targetToKind)
+func (c *DefaultClient) BuildSymbolGraph(workspaceRoot, bazelOut string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error) {
+	return buildSymbolGraphInternal(c, workspaceRoot, bazelOut, fileToTarget, targetToKind)
 }
 
 // buildSymbolGraphInternal is the core logic decoupled from implementation
-func buildSymbolGraphInternal(client Client, workspaceRoot string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error) {
-	// Find all .o files
-	objectFiles, err := client.FindObjectFiles(workspaceRoot)
-	if err != nil {
-		return nil, err
-	}
+func buildSymbolGraphInternal(client Client, workspaceRoot, bazelOut string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error) {
+	symbolDeps, _, err := buildSymbolTables(client, workspaceRoot, bazelOut, fileToTarget, targetToKind, nil, 0)
+	return symbolDeps, err
+}
 
-	if len(objectFiles) == 0 {
-		return nil, fmt.Errorf("no object files found in %s", workspaceRoot)
+// buildSymbolTables runs nm on the object files of the targets in scope and returns both the
+// symbol dependencies between files and the symbol table of each file
+func buildSymbolTables(client Client, workspaceRoot, bazelOut string, fileToTarget map[string]string, targetToKind map[string]string, scope Scope, workers int) ([]SymbolDependency, map[string]*FileSymbols, error) {
+	graph := NewSymbolGraph(client, workspaceRoot, fileToTarget, targetToKind, scope)
+	graph.SetWorkers(workers)
+	graph.SetBazelOutPath(bazelOut)
+	if err := graph.Build(); err != nil {
+		return nil, nil, err
 	}
+	return graph.Dependencies(), graph.FileSymbols(), nil
+}
 
-	// Map symbol names to the files that define them
-	symbolDefinitions := make(map[string]string) // symbol -> defining file
-
-	// Map files to their undefined symbols
-	fileUndefinedSymbols := make(map[string][]string) // file -> undefined symbols
+// resolveObjectTarget returns the target owning an object file, taken from its _objs
+// path component, falling back to the target owning its guessed source file
+func resolveObjectTarget(objFile, sourceFile string, fileToTarget map[string]string) string {
+	if target := ObjectFileToTarget(objFile); target != "" {
+		return target
+	}
+	return fileToTarget[sourceFile]
+}
 
-	// Process all object files
-	for _, objFile := range objectFiles {
-		symbols, err := client.RunNM(objFile)
-		if err != nil {
-			// Skip files we can't process
-			continue
+// buildFileSymbols assembles the per-file symbol tables, resolving each undefined symbol
+// to the file and target that define it
+func buildFileSymbols(defined, undefined map[string][]string, symbolDefinitions, symbolTargets, sourceTargets map[string]string) map[string]*FileSymbols {
+	result := make(map[string]*FileSymbols)
+	entry := func(file string) *FileSymbols {
+		if fs, ok := result[file]; ok {
+			return fs
 		}
+		fs := &FileSymbols{
+			File:      file,
+			Target:    sourceTargets[file],
+			Defined:   []string{},
+			Undefined: []UndefinedSymbol{},
+		}
+		result[file] = fs
+		return fs
+	}
 
-		// Convert object file path to source file path
-		sourceFile := objectFileToSourceFile(objFile, workspaceRoot)
+	for file, syms := range defined {
+		entry(file).Defined = uniqueSorted(syms)
+	}
 
-		for _, sym := range symbols {
-			if sym.Type == "U" {
-				// Undefined symbol - this file needs it
-				fileUndefinedSymbols[sourceFile] = append(fileUndefinedSymbols[sourceFile], sym.Name)
-			} else if isDefinedSymbol(sym.Type) {
-				// Defined symbol - this file provides it
-				symbolDefinitions[sym.Name] = sourceFile
+	for file, syms := range undefined {
+		fs := entry(file)
+		for _, sym := range uniqueSorted(syms) {
+			undef := UndefinedSymbol{Symbol: sym}
+			if definingFile, ok := symbolDefinitions[sym]; ok {
+				undef.ResolvedFile = definingFile
+				undef.ResolvedTarget = symbolTargets[sym]
 			}
+			fs.Undefined = append(fs.Undefined, undef)
 		}
 	}
 
-	// Build dependencies: file A depends on file B if A uses symbol defined in B
-	var symbolDeps []SymbolDependency
-
-	for sourceFile, undefinedSyms := range fileUndefinedSymbols {
-		for _, symName := range undefinedSyms {
-			if definingFile, ok := symbolDefinitions[symName]; ok {
-				// Found where this symbol is defined
-				if sourceFile != definingFile {
-					dep := SymbolDependency{
-						SourceFile: sourceFile,
-						TargetFile: definingFile,
-						Symbol:     symName,
-					}
-
-					// Add target labels and determine linkage type
-					if fileToTarget != nil {
-						if srcTarget, ok := fileToTarget[sourceFile]; ok {
-							dep.SourceTarget = srcTarget
-							dep.SourceBinary = srcTarget // Use target as binary identifier
-						}
-						if tgtTarget, ok := fileToTarget[definingFile]; ok {
-							dep.TargetTarget = tgtTarget
-							dep.TargetBinary = tgtTarget // Use target as binary identifier
-						}
-
-						// Determine linkage type
-						if dep.SourceTarget == dep.TargetTarget {
-							// Same target = static linkage within same binary
-							dep.Linkage = LinkageStatic
-						} else if targetToKind != nil {
-							// Different targets - check if target is a shared library
-							sourceKind := targetToKind[dep.SourceTarget]
-							targetKind := targetToKind[dep.TargetTarget]
-
-							if targetKind == "cc_shared_library" || sourceKind == "cc_shared_library" {
-								dep.Linkage = LinkageDynamic
-							} else {
-								// Different binaries, not shared library
-								dep.Linkage = LinkageCross
-							}
-						} else {
-							dep.Linkage = LinkageCross
-						}
-					}
-
-					symbolDeps = append(symbolDeps, dep)
-				}
-			}
+	return result
+}
+
+// uniqueSorted returns the distinct values of values in sorted order
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
 		}
 	}
+	sort.Strings(result)
+	return result
+}
+
+// ObjectFileToTarget returns the label of the target that owns an object file, read from
+// the _objs/<target> component Bazel puts in the output path. It returns "" when the
+// path has no such component.
+// e.g., "bazel-out/darwin-fastbuild/bin/util/_objs/util_lib/strings.o" -> "//util:util_lib"
+//
+//	"bazel-bin/external/zlib/_objs/z/inflate.o" -> "@zlib//:z"
+func ObjectFileToTarget(objPath string) string {
+	pkg, target, ok := splitObjectPath(objPath)
+	if !ok {
+		return ""
+	}
 
-	return symbolDeps, nil
+	repo := ""
+	if len(pkg) >= 2 && pkg[0] == "external" {
+		repo = "@" + pkg[1]
+		pkg = pkg[2:]
+	}
+	return repo + "//" + strings.Join(pkg, "/") + ":" + target
 }
 
-// objectFileToSourceFile converts an object file path to its source file path
+// ObjectFileToSourceFile converts an object file path to its source file path
 // e.g., "bazel-out/darwin-fastbuild/bin/util/_objs/util/strings.o" -> "util/strings.cc"
-func objectFileToSourceFile(objPath string, workspaceRoot string) string {
-	// Extract the relative path and convert .o to source extension
-	// This is a heuristic and may need adjustment based on actual Bazel structure
+// (the same for PIC objects, "strings.pic.o")
+// The object file name drops the source extension, so each recognized source extension
+// is tried in turn: the first one listed in the owning target's sources (fileToTarget),
+// else the first one that exists under workspaceRoot, else the first one of extensions.
+func ObjectFileToSourceFile(objPath string, workspaceRoot string, fileToTarget map[string]string, extensions model.FileExtensions) string {
 	base := filepath.Base(objPath)
-	name := strings.TrimSuffix(base, ".o")
-
-	// Try to extract package path from the object file path
-	// Bazel typically puts objects in paths like:
-	//   bazel-out/.../bin/package/_objs/target/file.o
-	//   bazel-bin/package/_objs/target/file.o
-	parts := strings.Split(objPath, string(filepath.Separator))
-
-	var packagePath string
-	for i, part := range parts {
-		// Look for "bin" directory or "bazel-bin" symlink
-		if (part == "bin" || part == "bazel-bin") && i+1 < len(parts) {
-			// Everything after "bin" until "_objs" is the package path
-			for j := i + 1; j < len(parts); j++ {
-				if parts[j] == "_objs" {
-					break
-				}
-				if packagePath != "" {
-					packagePath += "/"
-				}
-				packagePath += parts[j]
+	name := strings.TrimSuffix(strings.TrimSuffix(base, ".o"), ".pic")
+
+	if pkg, _, ok := splitObjectPath(objPath); ok && len(pkg) > 0 {
+		name = filepath.Join(filepath.Join(pkg...), name)
+	}
+	target := ObjectFileToTarget(objPath)
+
+	sources := extensions.SourceExtensions()
+	for _, ext := range sources {
+		owner, listed := fileToTarget[name+ext]
+		if listed && (target == "" || owner == target) {
+			return name + ext
+		}
+	}
+	if workspaceRoot != "" {
+		for _, ext := range sources {
+			if _, err := os.Stat(filepath.Join(workspaceRoot, name+ext)); err == nil {
+				return name + ext
 			}
+		}
+	}
+	return name + sources[0]
+}
+
+// splitObjectPath splits an object file path of the form <bin>/<package>/_objs/<target>/...
+// into the package path components and the target name. The bin directory is the last
+// bazel-out/<config>/bin before _objs, or else the last bazel-bin or bin, so that a "bin"
+// directory above the workspace, e.g. in /home/u/bin/ws/bazel-out/..., or in a package is
+// not taken for it.
+func splitObjectPath(objPath string) (pkg []string, target string, ok bool) {
+	parts := strings.Split(filepath.ToSlash(objPath), "/")
+
+	objs := -1
+	for j := len(parts) - 3; j >= 0; j-- {
+		if parts[j] == "_objs" {
+			objs = j
 			break
 		}
 	}
+	if objs < 0 {
+		return nil, "", false
+	}
 
-	var result string
-	if packagePath != "" {
-		// Just use .cc extension (most common for Bazel C++)
-		result = filepath.Join(packagePath, name+".cc")
-	} else {
-		// Fallback: just use the base name with .cc
-		result = name + ".cc"
+	// lastBefore returns the index of the last part before _objs that matches, or -1
+	lastBefore := func(match func(i int) bool) int {
+		for i := objs - 1; i >= 0; i-- {
+			if match(i) {
+				return i
+			}
+		}
+		return -1
+	}
+	bin := lastBefore(func(i int) bool { return parts[i] == "bin" && i >= 2 && parts[i-2] == "bazel-out" })
+	if bin < 0 {
+		bin = lastBefore(func(i int) bool { return parts[i] == "bazel-bin" })
 	}
+	if bin < 0 {
+		bin = lastBefore(func(i int) bool { return parts[i] == "bin" })
+	}
+	if bin < 0 {
+		return nil, "", false
+	}
+	return parts[bin+1 : objs], parts[objs+1], true
+}
 
-	return result
+// IsDefined reports whether the object file defines the symbol rather than needing it
+// from elsewhere
+func (s Symbol) IsDefined() bool {
+	return isDefinedSymbol(s.Type)
 }
 
 // isDefinedSymbol returns true if the symbol type indicates a definition
@@ -341,3 +433,15 @@ func isDefinedSymbol(symType string) bool {
 		return false
 	}
 }
+
+// isGlobalSymbol returns true if the symbol type is a strong definition visible to other
+// objects. Weak symbols (inline functions, template instances) are excluded as every object
+// using them carries its own copy.
+func isGlobalSymbol(symType string) bool {
+	switch symType {
+	case "T", "D", "B", "R":
+		return true
+	default:
+		return false
+	}
+}
@@ -1,8 +1,12 @@
 package symbols
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
 )
 
 func TestParseNMOutput(t *testing.T) {
@@ -131,3 +135,218 @@ func TestIsHexAddress(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildSymbolTablesFileSymbols(t *testing.T) {
+	client := &MockClient{
+		MockObjectFiles: []string{
+			"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o",
+			"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o",
+		},
+		MockSymbols: map[string][]Symbol{
+			"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o": {
+				{Name: "main", Type: "T"},
+				{Name: "util::Join()", Type: "U"},
+				{Name: "util::Join()", Type: "U"},
+				{Name: "printf", Type: "U"},
+			},
+			"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o": {
+				{Name: "util::Split()", Type: "T"},
+				{Name: "util::Join()", Type: "T"},
+			},
+		},
+	}
+	fileToTarget := map[string]string{
+		"main/main.cc":    "//main:app",
+		"util/strings.cc": "//util:util",
+	}
+
+	_, fileSymbols, err := buildSymbolTables(client, "", "", fileToTarget, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("buildSymbolTables() unexpected error: %v", err)
+	}
+
+	mainSyms, ok := fileSymbols["main/main.cc"]
+	if !ok {
+		t.Fatalf("missing symbols for main/main.cc: %v", fileSymbols)
+	}
+	if mainSyms.Target != "//main:app" || !reflect.DeepEqual(mainSyms.Defined, []string{"main"}) {
+		t.Errorf("unexpected main/main.cc symbols: %+v", mainSyms)
+	}
+	wantUndefined := []UndefinedSymbol{
+		{Symbol: "printf"},
+		{Symbol: "util::Join()", ResolvedFile: "util/strings.cc", ResolvedTarget: "//util:util"},
+	}
+	if !reflect.DeepEqual(mainSyms.Undefined, wantUndefined) {
+		t.Errorf("Undefined = %+v, want %+v", mainSyms.Undefined, wantUndefined)
+	}
+
+	if got := fileSymbols["util/strings.cc"].Defined; !reflect.DeepEqual(got, []string{"util::Join()", "util::Split()"}) {
+		t.Errorf("util/strings.cc Defined = %v", got)
+	}
+}
+
+func TestObjectFileToTarget(t *testing.T) {
+	tests := []struct {
+		objPath string
+		want    string
+	}{
+		{"bazel-out/k8-fastbuild/bin/util/_objs/util_lib/strings.o", "//util:util_lib"},
+		{"/ws/bazel-out/darwin-fastbuild/bin/a/b/_objs/c/sub/file.o", "//a/b:c"},
+		{"bazel-bin/main/_objs/app/main.o", "//main:app"},
+		{"bazel-out/k8-fastbuild/bin/_objs/root/root.o", "//:root"},
+		{"bazel-out/k8-fastbuild/bin/external/zlib/_objs/z/inflate.o", "@zlib//:z"},
+		{"bazel-out/k8-fastbuild/bin/util/strings.o", ""},
+		{"util/_objs/util/strings.o", ""},
+		// bin directories outside the output tree or in packages
+		{"/home/u/bin/ws/bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o", "//util:util"},
+		{"/home/u/bin/ws/bazel-bin/util/_objs/util/strings.o", "//util:util"},
+		{"bazel-out/k8-fastbuild/bin/tools/bin/_objs/lint/lint.o", "//tools/bin:lint"},
+		{"bazel-bin/tools/bin/_objs/lint/lint.o", "//tools/bin:lint"},
+	}
+
+	for _, tt := range tests {
+		if got := ObjectFileToTarget(tt.objPath); got != tt.want {
+			t.Errorf("ObjectFileToTarget(%q) = %q, want %q", tt.objPath, got, tt.want)
+		}
+	}
+}
+
+func TestObjectFileToSourceFilePIC(t *testing.T) {
+	for _, objPath := range []string{
+		"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o",
+		"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.pic.o",
+		"/home/u/bin/ws/bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o",
+	} {
+		if got := ObjectFileToSourceFile(objPath, "", nil, model.FileExtensions{}); got != "util/strings.cc" {
+			t.Errorf("ObjectFileToSourceFile(%q) = %q, want util/strings.cc", objPath, got)
+		}
+	}
+}
+
+func TestObjectFileToSourceFileExtensions(t *testing.T) {
+	objPath := "bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o"
+
+	// The owning target lists the source
+	fileToTarget := map[string]string{
+		"util/strings.cpp": "//util:util",
+		"util/strings.cxx": "//util:other",
+	}
+	if got := ObjectFileToSourceFile(objPath, "", fileToTarget, model.FileExtensions{}); got != "util/strings.cpp" {
+		t.Errorf("ObjectFileToSourceFile() = %q, want util/strings.cpp", got)
+	}
+
+	// Not in fileToTarget, but present in the workspace
+	ws := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(ws, "util"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws, "util", "strings.cxx"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ObjectFileToSourceFile(objPath, ws, nil, model.FileExtensions{}); got != "util/strings.cxx" {
+		t.Errorf("ObjectFileToSourceFile() = %q, want util/strings.cxx", got)
+	}
+
+	// Neither, the first configured extension
+	cuda := model.FileExtensions{Sources: []string{".cu", ".cc"}}
+	if got := ObjectFileToSourceFile(objPath, "", nil, cuda); got != "util/strings.cu" {
+		t.Errorf("ObjectFileToSourceFile() = %q, want util/strings.cu", got)
+	}
+}
+
+func TestBuildSymbolTablesTargetsFromObjectPaths(t *testing.T) {
+	// Two targets in //util both compile a file named impl.cc, so the source file
+	// guess is ambiguous, but the _objs component identifies each target
+	client := &MockClient{
+		MockObjectFiles: []string{
+			"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o",
+			"bazel-out/k8-fastbuild/bin/util/_objs/strings/impl.o",
+			"bazel-out/k8-fastbuild/bin/util/_objs/math/impl.o",
+		},
+		MockSymbols: map[string][]Symbol{
+			"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o": {
+				{Name: "main", Type: "T"},
+				{Name: "util::Join()", Type: "U"},
+				{Name: "util::Add()", Type: "U"},
+			},
+			"bazel-out/k8-fastbuild/bin/util/_objs/strings/impl.o": {
+				{Name: "util::Join()", Type: "T"},
+				{Name: "util::Add()", Type: "U"},
+			},
+			"bazel-out/k8-fastbuild/bin/util/_objs/math/impl.o": {
+				{Name: "util::Add()", Type: "T"},
+			},
+		},
+	}
+	// The file map can only name one owner for util/impl.cc
+	fileToTarget := map[string]string{
+		"main/main.cc": "//main:app",
+		"util/impl.cc": "//util:math",
+	}
+
+	deps, _, err := buildSymbolTables(client, "", "", fileToTarget, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("buildSymbolTables() unexpected error: %v", err)
+	}
+
+	type edge struct{ from, to, symbol string }
+	got := make([]edge, 0, len(deps))
+	for _, dep := range deps {
+		got = append(got, edge{dep.SourceTarget, dep.TargetTarget, dep.Symbol})
+		if dep.Linkage != LinkageCross {
+			t.Errorf("dependency %+v: Linkage = %q, want %q", dep, dep.Linkage, LinkageCross)
+		}
+	}
+	want := []edge{
+		{"//main:app", "//util:strings", "util::Join()"},
+		{"//main:app", "//util:math", "util::Add()"},
+		{"//util:strings", "//util:math", "util::Add()"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("symbol dependencies = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildSymbolTablesInScope(t *testing.T) {
+	client := &MockClient{
+		MockObjectFiles: []string{
+			"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o",
+			"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o",
+			"bazel-out/k8-fastbuild/bin/tool/_objs/tool/tool.o",
+		},
+		MockSymbols: map[string][]Symbol{
+			"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o": {
+				{Name: "main", Type: "T"},
+				{Name: "util::Join()", Type: "U"},
+				{Name: "tool::Run()", Type: "U"},
+			},
+			"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o": {
+				{Name: "util::Join()", Type: "T"},
+			},
+			"bazel-out/k8-fastbuild/bin/tool/_objs/tool/tool.o": {
+				{Name: "tool::Run()", Type: "T"},
+			},
+		},
+	}
+
+	deps, fileSymbols, err := buildSymbolTables(client, "", "", nil, nil, NewScope([]string{"//main:app", "//util:util"}), 0)
+	if err != nil {
+		t.Fatalf("buildSymbolTables() unexpected error: %v", err)
+	}
+
+	if _, ok := fileSymbols["tool/tool.cc"]; ok {
+		t.Errorf("analyzed tool/tool.cc outside the scope")
+	}
+	if len(deps) != 1 || deps[0].Symbol != "util::Join()" || deps[0].TargetTarget != "//util:util" {
+		t.Errorf("deps = %+v, want only main -> util through util::Join()", deps)
+	}
+
+	// Symbols defined outside the scope are unresolved
+	wantUndefined := []UndefinedSymbol{
+		{Symbol: "tool::Run()"},
+		{Symbol: "util::Join()", ResolvedFile: "util/strings.cc", ResolvedTarget: "//util:util"},
+	}
+	if got := fileSymbols["main/main.cc"].Undefined; !reflect.DeepEqual(got, wantUndefined) {
+		t.Errorf("Undefined = %+v, want %+v", got, wantUndefined)
+	}
+}
@@ -0,0 +1,121 @@
+package symbols
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// Relocation is a dynamic relocation of a linked binary or shared library: a use of a
+// symbol that the dynamic linker resolves at load time, through the PLT or the GOT.
+// Symbols the static linker resolved have no dynamic relocation.
+type Relocation struct {
+	Type   string // e.g. R_X86_64_JUMP_SLOT (PLT), R_X86_64_GLOB_DAT (GOT), or a Mach-O bind type
+	Symbol string // Demangled symbol name without version suffix
+}
+
+// RunRelocations returns the dynamic relocations of a linked binary or shared library,
+// using objdump -R on ELF systems and the bind tables of objdump --macho on macOS
+func (c *DefaultClient) RunRelocations(linkedFile string) ([]Relocation, error) {
+	args := []string{"-R", "-C", linkedFile}
+	parse := ParseObjdumpRelocations
+	if runtime.GOOS == "darwin" {
+		args = []string{"--macho", "--bind", "--lazy-bind", "-C", linkedFile}
+		parse = ParseMachOBinds
+	}
+	output, err := exec.Command("objdump", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("objdump failed for %s: %w", linkedFile, err)
+	}
+	return parse(string(output)), nil
+}
+
+// RunRelocations reads the dynamic relocations of a linked output (see
+// DefaultClient.RunRelocations)
+func RunRelocations(linkedFile string) ([]Relocation, error) {
+	client := &DefaultClient{}
+	return client.RunRelocations(linkedFile)
+}
+
+// ParseObjdumpRelocations parses the output of objdump -R, skipping relative relocations,
+// which refer to no symbol
+func ParseObjdumpRelocations(output string) []Relocation {
+	var relocations []Relocation
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		// OFFSET TYPE VALUE, where a demangled VALUE may contain spaces
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || !isHexAddress(fields[0]) || !strings.HasPrefix(fields[1], "R_") {
+			continue
+		}
+		symbol := strings.Join(fields[2:], " ")
+		if strings.HasPrefix(symbol, "*ABS*") {
+			continue
+		}
+		relocations = append(relocations, Relocation{Type: fields[1], Symbol: relocationSymbol(symbol)})
+	}
+	return relocations
+}
+
+// ParseMachOBinds parses the bind and lazy bind tables printed by objdump --macho, whose
+// rows end with the dylib and the symbol. The leading underscore of Mach-O symbols is
+// dropped, so the names match those of nm -C.
+func ParseMachOBinds(output string) []Relocation {
+	var relocations []Relocation
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		// Bind: segment section address type addend dylib symbol
+		// Lazy bind: segment section address dylib symbol
+		fields := strings.Fields(scanner.Text())
+		addressField := -1
+		for i, field := range fields {
+			if strings.HasPrefix(field, "0x") {
+				addressField = i
+				break
+			}
+		}
+		if addressField != 2 {
+			continue
+		}
+		symbolField, bindType := 4, "lazy"
+		if len(fields) > 6 && (fields[3] == "pointer" || fields[3] == "text32" || fields[3] == "textabs32") {
+			symbolField, bindType = 6, fields[3]
+		}
+		if len(fields) <= symbolField {
+			continue
+		}
+		symbol := strings.Join(fields[symbolField:], " ")
+		symbol = strings.TrimSuffix(strings.TrimPrefix(symbol, "_"), " (weak_import)")
+		relocations = append(relocations, Relocation{Type: bindType, Symbol: symbol})
+	}
+	return relocations
+}
+
+// relocationSymbol strips the version suffix (e.g. @GLIBC_2.2.5) and addend of a
+// relocation value
+func relocationSymbol(value string) string {
+	if i := strings.Index(value, "@"); i > 0 {
+		value = value[:i]
+	}
+	if i := strings.LastIndex(value, "+0x"); i > 0 {
+		value = value[:i]
+	}
+	return value
+}
+
+// LinkedOutputFile returns the linked output of a cc_binary or cc_shared_library target in
+// the build outputs of the workspace or bazelOut, or "" if it is not built or not linked
+func LinkedOutputFile(workspaceRoot, bazelOut, label, kind string) string {
+	switch kind {
+	case string(model.TargetKindBinary), string(model.TargetKindTest):
+		_, name, _ := strings.Cut(label, ":")
+		return findOutputFile(workspaceRoot, bazelOut, label, name)
+	case string(model.TargetKindSharedLibrary):
+		return SharedLibraryFile(workspaceRoot, bazelOut, label)
+	}
+	return ""
+}
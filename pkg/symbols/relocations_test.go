@@ -0,0 +1,175 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// relocationMockClient is a MockClient that reads dynamic relocations and dynamic
+// symbol tables
+type relocationMockClient struct {
+	*MockClient
+	relocations map[string][]Relocation // linked output file -> its dynamic relocations
+	dynamic     map[string][]Symbol     // linked output file -> its defined dynamic symbols
+}
+
+func (m *relocationMockClient) RunRelocations(linkedFile string) ([]Relocation, error) {
+	return m.relocations[linkedFile], nil
+}
+
+func (m *relocationMockClient) RunNMDynamic(linkedFile string) ([]Symbol, error) {
+	return m.dynamic[linkedFile], nil
+}
+
+// writeOutputFile creates an empty linked output in the bazel-bin of a workspace
+func writeOutputFile(t *testing.T, workspace, path string) string {
+	t.Helper()
+	file := filepath.Join(workspace, "bazel-bin", path)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestParseObjdumpRelocations(t *testing.T) {
+	output := `
+app:     file format elf64-x86-64
+
+DYNAMIC RELOCATION RECORDS
+OFFSET           TYPE              VALUE
+0000000000003db8 R_X86_64_RELATIVE  *ABS*+0x0000000000001140
+0000000000003fe0 R_X86_64_GLOB_DAT  __cxa_finalize@GLIBC_2.2.5
+0000000000004018 R_X86_64_JUMP_SLOT  core::Run(std::string const&)
+0000000000004020 R_X86_64_64       vtable for core::Engine@@Base+0x0000000000000010
+`
+	got := ParseObjdumpRelocations(output)
+	want := []Relocation{
+		{Type: "R_X86_64_GLOB_DAT", Symbol: "__cxa_finalize"},
+		{Type: "R_X86_64_JUMP_SLOT", Symbol: "core::Run(std::string const&)"},
+		{Type: "R_X86_64_64", Symbol: "vtable for core::Engine"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseObjdumpRelocations() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseMachOBinds(t *testing.T) {
+	output := `
+app:
+Bind table:
+segment      section   address     type     addend dylib     symbol
+__DATA_CONST __got     0x100004000 pointer       0 libSystem _printf
+__DATA_CONST __got     0x100004008 pointer       0 libcore   core::Log()
+Lazy bind table:
+segment  section          address     dylib     symbol
+__DATA   __la_symbol_ptr  0x100008000 libcore   core::Run()
+`
+	got := ParseMachOBinds(output)
+	want := []Relocation{
+		{Type: "pointer", Symbol: "printf"},
+		{Type: "pointer", Symbol: "core::Log()"},
+		{Type: "lazy", Symbol: "core::Run()"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMachOBinds() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSymbolGraphClassifiesLinkageByRelocations(t *testing.T) {
+	workspace := t.TempDir()
+	appFile := writeOutputFile(t, workspace, "main/app")
+
+	// The app links util statically and resolves core::Run() through the PLT
+	client := &relocationMockClient{
+		MockClient:  newGraphTestClient(),
+		relocations: map[string][]Relocation{appFile: {{Type: "R_X86_64_JUMP_SLOT", Symbol: "core::Run()"}}},
+	}
+	targetToKind := map[string]string{
+		"//main:app":  "cc_binary",
+		"//util:util": "cc_library",
+		"//core:core": "cc_library",
+	}
+
+	g := NewSymbolGraph(client, workspace, map[string]string{}, targetToKind, nil)
+	if err := g.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, dep := range g.Dependencies() {
+		got = append(got, dep.SourceTarget+" -> "+dep.TargetTarget+" "+string(dep.Linkage))
+	}
+	// util has no output of its own, so its linkage depends on the output linking it
+	want := []string{
+		"//main:app -> //util:util static",
+		"//main:app -> //core:core dynamic",
+		"//util:util -> //core:core cross",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies() = %v, want %v", got, want)
+	}
+
+	// Without relocations the linkage between libraries is unknown
+	g = NewSymbolGraph(client.MockClient, workspace, map[string]string{}, targetToKind, nil)
+	if err := g.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	for _, dep := range g.Dependencies() {
+		if dep.Linkage != LinkageCross {
+			t.Errorf("%s -> %s linkage without relocations = %s, want cross", dep.SourceTarget, dep.TargetTarget, dep.Linkage)
+		}
+	}
+}
+
+func TestSymbolGraphIgnoresRelocationsOfOwnSymbols(t *testing.T) {
+	workspace := t.TempDir()
+	appFile := writeOutputFile(t, workspace, "main/app")
+	soFile := writeOutputFile(t, workspace, "core/libcore_shared.so")
+
+	// The PIC shared library relocates the symbols it exports so that they can be
+	// interposed, and the app, linked with --export-dynamic, its own util::Join()
+	client := &relocationMockClient{
+		MockClient: newGraphTestClient(),
+		relocations: map[string][]Relocation{
+			appFile: {{Type: "R_X86_64_JUMP_SLOT", Symbol: "util::Join()"}},
+			soFile: {
+				{Type: "R_X86_64_JUMP_SLOT", Symbol: "core::Run()"},
+				{Type: "R_X86_64_JUMP_SLOT", Symbol: "core::Log()"},
+			},
+		},
+		dynamic: map[string][]Symbol{
+			appFile: {{Name: "main", Type: "T"}, {Name: "util::Join()", Type: "T"}},
+			soFile:  {{Name: "core::Run()", Type: "T"}, {Name: "core::Log()", Type: "T"}},
+		},
+	}
+	targetToKind := map[string]string{
+		"//main:app":         "cc_binary",
+		"//util:util":        "cc_library",
+		"//core:core":        "cc_library",
+		"//core:core_shared": "cc_shared_library",
+	}
+
+	g := NewSymbolGraph(client, workspace, map[string]string{}, targetToKind, nil)
+	if err := g.Build(); err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, dep := range g.Dependencies() {
+		got = append(got, dep.SourceTarget+" -> "+dep.TargetTarget+" "+string(dep.Linkage))
+	}
+	// The library's relocation of core::Log() does not make util's use of it dynamic
+	want := []string{
+		"//main:app -> //util:util static",
+		"//main:app -> //core:core static",
+		"//util:util -> //core:core cross",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies() = %v, want %v", got, want)
+	}
+}
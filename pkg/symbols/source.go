@@ -32,7 +32,7 @@ func (s *SymbolSource) Run(ctx context.Context, cfg *config.Config) (*model.Grap
 	// Note: We currently pass nil/nil for fileToTarget and targetToKind maps.
 	// This means we won't calculate linkage types (Static/Dynamic) in this isolated mode.
 	// To support that, we'd need to share target context between sources.
-	symbolDeps, err := s.client.BuildSymbolGraph(cfg.Workspace, nil, nil)
+	symbolDeps, err := s.client.BuildSymbolGraph(cfg.Workspace, cfg.BazelOutPath, nil, nil)
 	if err != nil {
 		return nil, err
 	}
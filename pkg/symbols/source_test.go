@@ -2,6 +2,7 @@ package symbols
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/ritzau/deps-analyzer/pkg/config"
@@ -18,7 +19,7 @@ type MockClient struct {
 	MockDeps []SymbolDependency
 }
 
-func (m *MockClient) FindObjectFiles(workspaceRoot string) ([]string, error) {
+func (m *MockClient) FindObjectFiles(workspaceRoot, bazelOut string) ([]string, error) {
 	return m.MockObjectFiles, m.MockErr
 }
 
@@ -29,12 +30,24 @@ func (m *MockClient) RunNM(objectFile string) ([]Symbol, error) {
 	return nil, nil
 }
 
-func (m *MockClient) BuildSymbolGraph(workspaceRoot string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error) {
+// RunNMDynamic fails like nm -D on an unreadable library, so that all symbols of shared
+// libraries count as exported (see dynamicMockClient)
+func (m *MockClient) RunNMDynamic(soFile string) ([]Symbol, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// RunRelocations fails like objdump on an unreadable output, so that symbol uses between
+// targets keep an unknown linkage (see relocationMockClient)
+func (m *MockClient) RunRelocations(linkedFile string) ([]Relocation, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (m *MockClient) BuildSymbolGraph(workspaceRoot, bazelOut string, fileToTarget map[string]string, targetToKind map[string]string) ([]SymbolDependency, error) {
 	if m.MockDeps != nil {
 		return m.MockDeps, m.MockErr
 	}
 	// Fallback to internal logic using the mock primitives
-	return buildSymbolGraphInternal(m, workspaceRoot, fileToTarget, targetToKind)
+	return buildSymbolGraphInternal(m, workspaceRoot, bazelOut, fileToTarget, targetToKind)
 }
 
 func TestSymbolSource_Run(t *testing.T) {
@@ -85,7 +98,7 @@ func TestBuildSymbolGraphInternal(t *testing.T) {
 		},
 	}
 
-	deps, err := buildSymbolGraphInternal(mockClient, "/workspace", nil, nil)
+	deps, err := buildSymbolGraphInternal(mockClient, "/workspace", "", nil, nil)
 	if err != nil {
 		t.Fatalf("buildSymbolGraphInternal() error: %v", err)
 	}
@@ -95,7 +108,7 @@ func TestBuildSymbolGraphInternal(t *testing.T) {
 	}
 
 	dep := deps[0]
-	// Note: objectFileToSourceFile conversion:
+	// Note: ObjectFileToSourceFile conversion:
 	// bazel-out/bin/main/_objs/main/main.o -> main/main.cc
 	if dep.Symbol != "foo" {
 		t.Errorf("Expected symbol foo, got %s", dep.Symbol)
@@ -0,0 +1,101 @@
+package symbols
+
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultSystemLibrarySymbols maps common system libraries (by their -l name) to the
+// symbols they provide. Patterns may use path.Match wildcards, e.g. "pthread_*".
+var DefaultSystemLibrarySymbols = map[string][]string{
+	"pthread": {"pthread_*", "sem_*"},
+	"dl":      {"dlopen", "dlclose", "dlsym", "dlvsym", "dlerror", "dladdr", "dladdr1", "dlinfo", "dlmopen"},
+	"rt":      {"clock_gettime", "clock_getres", "clock_nanosleep", "shm_open", "shm_unlink", "timer_*", "mq_*", "aio_*"},
+	"m": {
+		"sin", "sinf", "cos", "cosf", "tan", "tanf", "asin", "acos", "atan", "atan2", "atan2f",
+		"sqrt", "sqrtf", "cbrt", "pow", "powf", "exp", "expf", "exp2", "log", "logf", "log2", "log10",
+		"floor", "floorf", "ceil", "ceilf", "round", "roundf", "trunc", "fmod", "fabs", "hypot",
+	},
+	"z": {"inflate*", "deflate*", "compress", "compress2", "compressBound", "uncompress", "crc32", "adler32", "zlibVersion", "gz*"},
+}
+
+// SystemLibraryResolver finds the system library that provides an undefined symbol
+type SystemLibraryResolver struct {
+	symbols map[string][]string // library -> symbol patterns
+}
+
+// NewSystemLibraryResolver creates a resolver for DefaultSystemLibrarySymbols extended
+// with extra symbol patterns per library
+func NewSystemLibraryResolver(extra map[string][]string) *SystemLibraryResolver {
+	symbols := make(map[string][]string, len(DefaultSystemLibrarySymbols)+len(extra))
+	for lib, patterns := range DefaultSystemLibrarySymbols {
+		symbols[lib] = slices.Clone(patterns)
+	}
+	for lib, patterns := range extra {
+		symbols[lib] = append(symbols[lib], patterns...)
+	}
+	return &SystemLibraryResolver{symbols: symbols}
+}
+
+// Resolve returns the first of the linked libraries (in the given order) that provides
+// the symbol, or "" if none of them does. The Mach-O underscore prefix of C symbols is
+// ignored.
+func (r *SystemLibraryResolver) Resolve(symbol string, libraries []string) string {
+	names := []string{symbol}
+	if name, ok := strings.CutPrefix(symbol, "_"); ok {
+		names = append(names, name)
+	}
+	for _, lib := range libraries {
+		for _, pattern := range r.symbols[lib] {
+			for _, name := range names {
+				if ok, _ := path.Match(pattern, name); ok {
+					return lib
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// ResolveSystemSymbols marks the undefined symbols that no workspace file defines but a
+// system library linked into the file's target provides. systemLibraries returns the
+// libraries linked for a target label (see model.Module.SystemLibraries).
+// It returns the number of symbols resolved.
+func ResolveSystemSymbols(fileSymbols map[string]*FileSymbols, systemLibraries func(target string) []string, resolver *SystemLibraryResolver) int {
+	files := make([]string, 0, len(fileSymbols))
+	for file := range fileSymbols {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	resolved := 0
+	librariesByTarget := make(map[string][]string)
+	for _, file := range files {
+		fs := fileSymbols[file]
+		if fs.Target == "" {
+			continue
+		}
+		libraries, ok := librariesByTarget[fs.Target]
+		if !ok {
+			libraries = systemLibraries(fs.Target)
+			librariesByTarget[fs.Target] = libraries
+		}
+		if len(libraries) == 0 {
+			continue
+		}
+
+		for i := range fs.Undefined {
+			undef := &fs.Undefined[i]
+			if undef.ResolvedFile != "" || undef.ResolvedLibrary != "" {
+				continue
+			}
+			if lib := resolver.Resolve(undef.Symbol, libraries); lib != "" {
+				undef.ResolvedLibrary = lib
+				resolved++
+			}
+		}
+	}
+	return resolved
+}
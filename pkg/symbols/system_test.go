@@ -0,0 +1,71 @@
+package symbols
+
+import (
+	"testing"
+)
+
+func TestSystemLibraryResolver(t *testing.T) {
+	resolver := NewSystemLibraryResolver(map[string][]string{
+		"crypto":  {"EVP_*"},
+		"pthread": {"__pthread_*"},
+	})
+
+	tests := []struct {
+		symbol    string
+		libraries []string
+		want      string
+	}{
+		{"pthread_create", []string{"pthread"}, "pthread"},
+		{"_pthread_create", []string{"pthread"}, "pthread"}, // Mach-O underscore prefix
+		{"pthread_create", []string{"dl"}, ""},              // Library not linked
+		{"dlopen", []string{"pthread", "dl"}, "dl"},
+		{"EVP_DigestInit", []string{"crypto"}, "crypto"},  // Configured library
+		{"__pthread_key", []string{"pthread"}, "pthread"}, // Configured pattern extends the defaults
+		{"util::Trim(std::string const&)", []string{"pthread", "dl", "m"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := resolver.Resolve(tt.symbol, tt.libraries); got != tt.want {
+			t.Errorf("Resolve(%q, %v) = %q, want %q", tt.symbol, tt.libraries, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSystemSymbols(t *testing.T) {
+	fileSymbols := map[string]*FileSymbols{
+		"main/main.cc": {
+			File:   "main/main.cc",
+			Target: "//main:app",
+			Undefined: []UndefinedSymbol{
+				{Symbol: "pthread_create"},
+				{Symbol: "dlopen"},
+				{Symbol: "util::Trim()", ResolvedFile: "util/strings.cc", ResolvedTarget: "//util:util"},
+			},
+		},
+		"tool/tool.cc": {
+			File:      "tool/tool.cc",
+			Target:    "//tool:tool",
+			Undefined: []UndefinedSymbol{{Symbol: "pthread_create"}},
+		},
+	}
+	libraries := map[string][]string{"//main:app": {"pthread"}}
+
+	resolved := ResolveSystemSymbols(fileSymbols, func(target string) []string { return libraries[target] }, NewSystemLibraryResolver(nil))
+	if resolved != 1 {
+		t.Errorf("expected 1 resolved symbol, got %d", resolved)
+	}
+
+	undefined := fileSymbols["main/main.cc"].Undefined
+	if undefined[0].ResolvedLibrary != "pthread" {
+		t.Errorf("expected pthread_create resolved to pthread, got %+v", undefined[0])
+	}
+	if undefined[1].ResolvedLibrary != "" {
+		t.Errorf("expected dlopen unresolved without -ldl, got %+v", undefined[1])
+	}
+	if undefined[2].ResolvedLibrary != "" {
+		t.Errorf("expected workspace symbol untouched, got %+v", undefined[2])
+	}
+	if got := fileSymbols["tool/tool.cc"].Undefined[0].ResolvedLibrary; got != "" {
+		t.Errorf("expected pthread_create unresolved for a target without -lpthread, got %q", got)
+	}
+}
@@ -0,0 +1,70 @@
+package symbols
+
+import (
+	"sort"
+	"strings"
+)
+
+// UnusedSymbol is a global symbol that no other analyzed object references, making its
+// definition a dead-code candidate
+type UnusedSymbol struct {
+	Symbol string `json:"symbol"`           // Demangled symbol name
+	File   string `json:"file"`             // Source file defining the symbol
+	Target string `json:"target,omitempty"` // Target owning the file (if known)
+}
+
+// entryPoints are symbols called by the runtime rather than by other objects
+var entryPoints = map[string]bool{"main": true, "_main": true}
+
+// generatedSymbolPrefixes mark symbols the compiler emits for a class rather than code
+// written by hand (demangled by nm -C)
+var generatedSymbolPrefixes = []string{
+	"vtable for ", "VTT for ", "construction vtable for ", "typeinfo for ", "typeinfo name for ",
+	"guard variable for ", "TLS init function for ", "TLS wrapper function for ",
+}
+
+// FindUnusedSymbols returns the global symbols defined by the analyzed files that no other
+// file references, sorted by file and symbol. Symbols only used within their own file are
+// included, as nm records no reference for them (they could be made static).
+//
+// To stay conservative, entry points, compiler-generated symbols, and symbols of external
+// targets or of targets for which exported returns true (e.g. targets linked into a shared
+// library, whose symbols may be used outside the workspace) are never reported.
+func FindUnusedSymbols(fileSymbols map[string]*FileSymbols, exported func(target string) bool) []UnusedSymbol {
+	referenced := make(map[string]bool)
+	for _, fs := range fileSymbols {
+		for _, undef := range fs.Undefined {
+			referenced[undef.Symbol] = true
+		}
+	}
+
+	result := make([]UnusedSymbol, 0)
+	for file, fs := range fileSymbols {
+		if strings.HasPrefix(fs.Target, "@") || (exported != nil && exported(fs.Target)) {
+			continue
+		}
+		for _, sym := range fs.Global {
+			if referenced[sym] || entryPoints[sym] || isGeneratedSymbol(sym) {
+				continue
+			}
+			result = append(result, UnusedSymbol{Symbol: sym, File: file, Target: fs.Target})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].File != result[j].File {
+			return result[i].File < result[j].File
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+func isGeneratedSymbol(symbol string) bool {
+	for _, prefix := range generatedSymbolPrefixes {
+		if strings.HasPrefix(symbol, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package symbols
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindUnusedSymbols(t *testing.T) {
+	client := &MockClient{
+		MockObjectFiles: []string{
+			"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o",
+			"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o",
+			"bazel-out/k8-fastbuild/bin/plugin/_objs/plugin/api.o",
+		},
+		MockSymbols: map[string][]Symbol{
+			"bazel-out/k8-fastbuild/bin/main/_objs/app/main.o": {
+				{Name: "main", Type: "T"},
+				{Name: "util::Join()", Type: "U"},
+			},
+			"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o": {
+				{Name: "util::Join()", Type: "T"},
+				{Name: "util::Split()", Type: "T"},
+				{Name: "util::kSeparator", Type: "R"},
+				{Name: "(anonymous namespace)::Helper()", Type: "t"}, // Local
+				{Name: "std::string::size() const", Type: "W"},       // Weak
+				{Name: "vtable for util::Tokenizer", Type: "D"},      // Compiler-generated
+			},
+			"bazel-out/k8-fastbuild/bin/plugin/_objs/plugin/api.o": {
+				{Name: "plugin_init", Type: "T"},
+			},
+		},
+	}
+
+	_, fileSymbols, err := buildSymbolTables(client, "", "", nil, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("buildSymbolTables() unexpected error: %v", err)
+	}
+
+	exported := func(target string) bool { return target == "//plugin:plugin" }
+	want := []UnusedSymbol{
+		{Symbol: "util::Split()", File: "util/strings.cc", Target: "//util:util"},
+		{Symbol: "util::kSeparator", File: "util/strings.cc", Target: "//util:util"},
+	}
+	if got := FindUnusedSymbols(fileSymbols, exported); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindUnusedSymbols() = %+v, want %+v", got, want)
+	}
+
+	if got := FindUnusedSymbols(fileSymbols, nil); len(got) != 3 || got[0].Symbol != "plugin_init" {
+		t.Errorf("expected plugin_init reported without exported targets, got %+v", got)
+	}
+}
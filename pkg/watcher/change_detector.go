@@ -1,42 +1,156 @@
 package watcher
 
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
 // ChangeAnalysis describes what changed and which analysis phases need to be re-run
 type ChangeAnalysis struct {
-	NeedFullAnalysis bool
-	NeedCompileDeps  bool
-	NeedSymbolDeps   bool
-	NeedBinaryDeriv  bool
-	ChangedFiles     []string
+	NeedFullAnalysis bool     // Re-run everything, starting with the bazel query (BUILD files changed)
+	NeedCompileDeps  bool     // Re-parse .d files for file-level compile dependencies
+	NeedSymbolDeps   bool     // Re-run nm on object files for symbol dependencies
+	NeedBinaryDeriv  bool     // Re-derive binary information (linked libraries, data deps)
+	ChangedFiles     []string // The files that changed
+}
+
+// ChangePolicy controls which phases beyond the required ones are refreshed for each
+// type of change. BUILD file changes always trigger a full analysis, .d file changes
+// always refresh compile dependencies and .o file changes always refresh symbol
+// dependencies. Refreshing more keeps the results fresher at the cost of slower
+// re-analysis.
+type ChangePolicy struct {
+	DFileSymbolDeps  bool // .d file changes also refresh symbol dependencies
+	DFileBinaryDeriv bool // .d file changes also refresh binary derivation
+	OFileBinaryDeriv bool // .o file changes also refresh binary derivation
 }
 
-// AnalyzeChanges determines which analysis phases need to be re-run based on what changed
+// DefaultChangePolicy returns the policy refreshing every dependent phase
+func DefaultChangePolicy() ChangePolicy {
+	return ChangePolicy{
+		DFileSymbolDeps:  true,
+		DFileBinaryDeriv: true,
+		OFileBinaryDeriv: true,
+	}
+}
+
+// AnalyzeChanges determines which analysis phases need to be re-run based on what changed,
+// using the default change policy
 func AnalyzeChanges(event ChangeEvent, workspace string) *ChangeAnalysis {
+	return AnalyzeChangesWithPolicy(event, workspace, DefaultChangePolicy())
+}
+
+// AnalyzeChangesWithPolicy determines which analysis phases need to be re-run based on
+// what changed and the given change policy
+func AnalyzeChangesWithPolicy(event ChangeEvent, workspace string, policy ChangePolicy) *ChangeAnalysis {
 	analysis := &ChangeAnalysis{
 		ChangedFiles: event.Paths,
 	}
 
-	switch event.Type {
-	case ChangeTypeBuildFile:
-		// BUILD file changes require full re-analysis
-		// Target definitions, dependencies, or visibility changed
-		analysis.NeedFullAnalysis = true
-		analysis.NeedCompileDeps = true
-		analysis.NeedSymbolDeps = true
-		analysis.NeedBinaryDeriv = true
+	// A batch can mix kinds of change: each adds the phases it needs
+	for _, changeType := range event.Types() {
+		switch changeType {
+		case ChangeTypeBuildFile:
+			// BUILD file changes require full re-analysis
+			// Target definitions, dependencies, or visibility changed
+			analysis.NeedFullAnalysis = true
+			analysis.NeedCompileDeps = true
+			analysis.NeedSymbolDeps = true
+			analysis.NeedBinaryDeriv = true
 
-	case ChangeTypeDFile:
-		// .d file changes mean compile dependencies changed
-		// Need to re-parse .d files and update symbol deps
-		analysis.NeedCompileDeps = true
-		analysis.NeedSymbolDeps = true
-		analysis.NeedBinaryDeriv = true
+		case ChangeTypeDFile:
+			// .d file changes mean compile dependencies changed
+			// The object files were usually rebuilt too, so symbol deps may be stale
+			analysis.NeedCompileDeps = true
+			analysis.NeedSymbolDeps = analysis.NeedSymbolDeps || policy.DFileSymbolDeps
+			analysis.NeedBinaryDeriv = analysis.NeedBinaryDeriv || policy.DFileBinaryDeriv
 
-	case ChangeTypeOFile:
-		// .o file changes mean symbol information changed
-		// Only need to re-analyze symbols
-		analysis.NeedSymbolDeps = true
-		analysis.NeedBinaryDeriv = true
+		case ChangeTypeOFile:
+			// .o file changes mean symbol information changed
+			analysis.NeedSymbolDeps = true
+			analysis.NeedBinaryDeriv = analysis.NeedBinaryDeriv || policy.OFileBinaryDeriv
+		}
 	}
 
 	return analysis
 }
+
+// Types returns the kinds of change in an event, most significant (BUILD) first: its Type
+// and the kind of each of its paths. Events merged by MergeChangeEvents hold several kinds.
+func (e ChangeEvent) Types() []ChangeType {
+	present := map[ChangeType]bool{e.Type: true}
+	for _, path := range e.Paths {
+		if changeType, ok := ClassifyPath(path); ok {
+			present[changeType] = true
+		}
+	}
+
+	var types []ChangeType
+	for _, changeType := range []ChangeType{ChangeTypeBuildFile, ChangeTypeDFile, ChangeTypeOFile} {
+		if present[changeType] {
+			types = append(types, changeType)
+		}
+	}
+	return types
+}
+
+// MergeChangeEvents combines events into one batch, so that changes made together are
+// analyzed together: a BUILD change with .o changes is one full analysis. The Type of the
+// result is the most significant kind of change, the paths are ordered by the Type of
+// their event and the timestamp is the latest.
+func MergeChangeEvents(events []ChangeEvent) ChangeEvent {
+	sorted := slices.Clone(events)
+	slices.SortStableFunc(sorted, func(a, b ChangeEvent) int { return cmp.Compare(a.Type, b.Type) })
+
+	var merged ChangeEvent
+	for i, event := range sorted {
+		if i == 0 {
+			merged.Type = event.Type
+		}
+		merged.Paths = append(merged.Paths, event.Paths...)
+		if event.Timestamp.After(merged.Timestamp) {
+			merged.Timestamp = event.Timestamp
+		}
+	}
+	return merged
+}
+
+// ClassifyPath returns the type of change a modified file represents. Files that do not
+// affect the analysis are reported as not ok.
+func ClassifyPath(path string) (ChangeType, bool) {
+	name := filepath.Base(path)
+
+	switch {
+	case name == "BUILD" || name == "BUILD.bazel":
+		return ChangeTypeBuildFile, true
+	case strings.HasSuffix(name, ".d"):
+		return ChangeTypeDFile, true
+	case strings.HasSuffix(name, ".o"):
+		return ChangeTypeOFile, true
+	}
+	return 0, false
+}
+
+// ChangeEventsForPaths batches changed files into events the same way the watcher does:
+// one event per change type, in BUILD, .d, .o order. Files that do not affect the
+// analysis are returned as ignored.
+func ChangeEventsForPaths(paths []string) (events []ChangeEvent, ignored []string) {
+	byType := make(map[ChangeType][]string)
+	for _, path := range paths {
+		changeType, ok := ClassifyPath(path)
+		if !ok {
+			ignored = append(ignored, path)
+			continue
+		}
+		byType[changeType] = append(byType[changeType], path)
+	}
+
+	for _, changeType := range []ChangeType{ChangeTypeBuildFile, ChangeTypeDFile, ChangeTypeOFile} {
+		if len(byType[changeType]) > 0 {
+			events = append(events, ChangeEvent{Type: changeType, Paths: byType[changeType]})
+		}
+	}
+	return events, ignored
+}
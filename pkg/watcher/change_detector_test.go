@@ -0,0 +1,173 @@
+package watcher
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChangeEventsForPaths(t *testing.T) {
+	events, ignored := ChangeEventsForPaths([]string{
+		"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o",
+		"util/strings.cc",
+		"util/BUILD.bazel",
+		"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.d",
+		"main/BUILD",
+	})
+
+	want := []ChangeEvent{
+		{Type: ChangeTypeBuildFile, Paths: []string{"util/BUILD.bazel", "main/BUILD"}},
+		{Type: ChangeTypeDFile, Paths: []string{"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.d"}},
+		{Type: ChangeTypeOFile, Paths: []string{"bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o"}},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %+v, want %+v", events, want)
+	}
+	if !reflect.DeepEqual(ignored, []string{"util/strings.cc"}) {
+		t.Errorf("ignored = %v", ignored)
+	}
+}
+
+func TestAnalyzeChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		event ChangeEvent
+		want  ChangeAnalysis
+	}{
+		{
+			name:  "BUILD file triggers full analysis",
+			event: ChangeEvent{Type: ChangeTypeBuildFile, Paths: []string{"util/BUILD"}},
+			want: ChangeAnalysis{
+				NeedFullAnalysis: true,
+				NeedCompileDeps:  true,
+				NeedSymbolDeps:   true,
+				NeedBinaryDeriv:  true,
+				ChangedFiles:     []string{"util/BUILD"},
+			},
+		},
+		{
+			name:  ".d file triggers compile deps",
+			event: ChangeEvent{Type: ChangeTypeDFile, Paths: []string{"a.d"}},
+			want: ChangeAnalysis{
+				NeedCompileDeps: true,
+				NeedSymbolDeps:  true,
+				NeedBinaryDeriv: true,
+				ChangedFiles:    []string{"a.d"},
+			},
+		},
+		{
+			name:  ".o file triggers symbol deps",
+			event: ChangeEvent{Type: ChangeTypeOFile, Paths: []string{"a.o"}},
+			want: ChangeAnalysis{
+				NeedSymbolDeps:  true,
+				NeedBinaryDeriv: true,
+				ChangedFiles:    []string{"a.o"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AnalyzeChanges(tt.event, "/workspace"); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("AnalyzeChanges() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeChangesWithPolicy(t *testing.T) {
+	// With every optional phase turned off only the required phases run
+	policy := ChangePolicy{}
+
+	got := AnalyzeChangesWithPolicy(ChangeEvent{Type: ChangeTypeDFile}, "/workspace", policy)
+	if !got.NeedCompileDeps || got.NeedSymbolDeps || got.NeedBinaryDeriv || got.NeedFullAnalysis {
+		t.Errorf(".d change with minimal policy = %+v", *got)
+	}
+
+	got = AnalyzeChangesWithPolicy(ChangeEvent{Type: ChangeTypeOFile}, "/workspace", policy)
+	if !got.NeedSymbolDeps || got.NeedCompileDeps || got.NeedBinaryDeriv {
+		t.Errorf(".o change with minimal policy = %+v", *got)
+	}
+
+	// BUILD file changes are not affected by the policy
+	got = AnalyzeChangesWithPolicy(ChangeEvent{Type: ChangeTypeBuildFile}, "/workspace", policy)
+	if !got.NeedFullAnalysis || !got.NeedBinaryDeriv {
+		t.Errorf("BUILD change with minimal policy = %+v", *got)
+	}
+}
+
+func TestAnalyzeChangesMixedBatches(t *testing.T) {
+	const (
+		build = "util/BUILD"
+		dFile = "bazel-out/k8-fastbuild/bin/util/_objs/util/strings.d"
+		oFile = "bazel-out/k8-fastbuild/bin/util/_objs/util/strings.o"
+	)
+	type needs struct{ full, compile, symbols, binaries bool }
+
+	tests := []struct {
+		name   string
+		paths  []string
+		policy ChangePolicy
+		want   needs
+	}{
+		{"BUILD", []string{build}, DefaultChangePolicy(), needs{true, true, true, true}},
+		{".d", []string{dFile}, DefaultChangePolicy(), needs{false, true, true, true}},
+		{".o", []string{oFile}, DefaultChangePolicy(), needs{false, false, true, true}},
+		{"BUILD and .o", []string{oFile, build}, DefaultChangePolicy(), needs{true, true, true, true}},
+		{"BUILD and .d", []string{dFile, build}, ChangePolicy{}, needs{true, true, true, true}},
+		{".d and .o", []string{dFile, oFile}, DefaultChangePolicy(), needs{false, true, true, true}},
+		{"all kinds", []string{oFile, dFile, build}, ChangePolicy{}, needs{true, true, true, true}},
+
+		// The minimal policy refreshes only the phases each kind requires, together
+		{".d with minimal policy", []string{dFile}, ChangePolicy{}, needs{false, true, false, false}},
+		{".o with minimal policy", []string{oFile}, ChangePolicy{}, needs{false, false, true, false}},
+		{".d and .o with minimal policy", []string{dFile, oFile}, ChangePolicy{}, needs{false, true, true, false}},
+		{".d and .o refreshing binaries for .o", []string{dFile, oFile}, ChangePolicy{OFileBinaryDeriv: true}, needs{false, true, true, true}},
+		{".d and .o refreshing binaries for .d", []string{dFile, oFile}, ChangePolicy{DFileBinaryDeriv: true}, needs{false, true, true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The same batch as the watcher's per-kind events merged by the debouncer, and as
+			// a single event whose Type names only its least significant kind
+			events, _ := ChangeEventsForPaths(tt.paths)
+			for _, event := range []ChangeEvent{
+				MergeChangeEvents(events),
+				{Type: events[len(events)-1].Type, Paths: tt.paths},
+			} {
+				got := AnalyzeChangesWithPolicy(event, "/workspace", tt.policy)
+				if have := (needs{got.NeedFullAnalysis, got.NeedCompileDeps, got.NeedSymbolDeps, got.NeedBinaryDeriv}); have != tt.want {
+					t.Errorf("AnalyzeChangesWithPolicy(%+v) = %+v, want %+v", event, have, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestChangeEventTypes(t *testing.T) {
+	event := ChangeEvent{Type: ChangeTypeOFile, Paths: []string{"a.o", "util/BUILD.bazel", "notes.txt"}}
+	if got, want := event.Types(), []ChangeType{ChangeTypeBuildFile, ChangeTypeOFile}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Types() = %v, want %v", got, want)
+	}
+
+	// An event without paths still has its own type
+	if got := (ChangeEvent{Type: ChangeTypeDFile}).Types(); !reflect.DeepEqual(got, []ChangeType{ChangeTypeDFile}) {
+		t.Errorf("Types() of an event without paths = %v", got)
+	}
+}
+
+func TestMergeChangeEvents(t *testing.T) {
+	early := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	late := early.Add(time.Second)
+
+	merged := MergeChangeEvents([]ChangeEvent{
+		{Type: ChangeTypeOFile, Paths: []string{"a.o"}, Timestamp: late},
+		{Type: ChangeTypeBuildFile, Paths: []string{"BUILD"}, Timestamp: early},
+		{Type: ChangeTypeOFile, Paths: []string{"b.o"}, Timestamp: early},
+	})
+
+	want := ChangeEvent{Type: ChangeTypeBuildFile, Paths: []string{"BUILD", "a.o", "b.o"}, Timestamp: late}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeChangeEvents() = %+v, want %+v", merged, want)
+	}
+}
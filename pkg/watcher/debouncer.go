@@ -30,56 +30,31 @@ func (d *Debouncer) Start(ctx context.Context) {
 	go d.run(ctx)
 }
 
-// run processes events and applies debouncing logic
+// run processes events and applies debouncing logic. Events arriving within the quiet
+// period of each other, or at most maxWait after the first, are merged into one event
+// (see MergeChangeEvents).
 func (d *Debouncer) run(ctx context.Context) {
 	var (
-		timer        *time.Timer
+		pending      []ChangeEvent
+		quietTimer   *time.Timer
 		maxWaitTimer *time.Timer
-		accumulated  = make(map[ChangeType][]string)
-		eventCount   int
+		quietC       <-chan time.Time // nil while no events are pending
+		maxWaitC     <-chan time.Time
 	)
 
 	flush := func() {
-		if eventCount == 0 {
+		if len(pending) == 0 {
 			return
 		}
 
-		logging.Debug("flushing accumulated events", "count", eventCount)
+		logging.Debug("flushing accumulated events", "count", len(pending))
+		d.output <- MergeChangeEvents(pending)
 
-		// Send events in order: BUILD files first (need full analysis), then others
-		if paths, ok := accumulated[ChangeTypeBuildFile]; ok && len(paths) > 0 {
-			d.output <- ChangeEvent{
-				Type:      ChangeTypeBuildFile,
-				Paths:     paths,
-				Timestamp: time.Now(),
-			}
-		}
-		if paths, ok := accumulated[ChangeTypeDFile]; ok && len(paths) > 0 {
-			d.output <- ChangeEvent{
-				Type:      ChangeTypeDFile,
-				Paths:     paths,
-				Timestamp: time.Now(),
-			}
-		}
-		if paths, ok := accumulated[ChangeTypeOFile]; ok && len(paths) > 0 {
-			d.output <- ChangeEvent{
-				Type:      ChangeTypeOFile,
-				Paths:     paths,
-				Timestamp: time.Now(),
-			}
-		}
-
-		// Reset accumulators
-		accumulated = make(map[ChangeType][]string)
-		eventCount = 0
-
-		// Stop timers
-		if timer != nil {
-			timer.Stop()
-		}
-		if maxWaitTimer != nil {
-			maxWaitTimer.Stop()
-		}
+		// Reset accumulators and stop timers
+		pending = nil
+		quietTimer.Stop()
+		maxWaitTimer.Stop()
+		quietC, maxWaitC = nil, nil
 	}
 
 	for {
@@ -97,35 +72,30 @@ func (d *Debouncer) run(ctx context.Context) {
 			}
 
 			// Accumulate event
-			accumulated[event.Type] = append(accumulated[event.Type], event.Paths...)
-			eventCount++
+			pending = append(pending, event)
 
 			// Reset quiet period timer
-			if timer == nil {
-				timer = time.AfterFunc(d.quietPeriod, flush)
+			if quietTimer == nil {
+				quietTimer = time.NewTimer(d.quietPeriod)
 			} else {
-				timer.Reset(d.quietPeriod)
+				quietTimer.Reset(d.quietPeriod)
 			}
+			quietC = quietTimer.C
 
 			// Start max wait timer on first event
-			if maxWaitTimer == nil {
-				maxWaitTimer = time.AfterFunc(d.maxWait, flush)
+			if maxWaitC == nil {
+				if maxWaitTimer == nil {
+					maxWaitTimer = time.NewTimer(d.maxWait)
+				} else {
+					maxWaitTimer.Reset(d.maxWait)
+				}
+				maxWaitC = maxWaitTimer.C
 			}
 
-		case <-func() <-chan time.Time {
-			if timer != nil {
-				return timer.C
-			}
-			return nil
-		}():
+		case <-quietC:
 			flush()
 
-		case <-func() <-chan time.Time {
-			if maxWaitTimer != nil {
-				return maxWaitTimer.C
-			}
-			return nil
-		}():
+		case <-maxWaitC:
 			flush()
 		}
 	}
@@ -0,0 +1,64 @@
+package watcher
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDebouncerMergesEvents(t *testing.T) {
+	input := make(chan ChangeEvent)
+	debouncer := NewDebouncer(input, 50*time.Millisecond, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	debouncer.Start(ctx)
+
+	// A BUILD change and an object file change arriving together are one batch
+	input <- ChangeEvent{Type: ChangeTypeOFile, Paths: []string{"a.o"}}
+	input <- ChangeEvent{Type: ChangeTypeBuildFile, Paths: []string{"BUILD"}}
+
+	select {
+	case event := <-debouncer.Output():
+		if event.Type != ChangeTypeBuildFile || !reflect.DeepEqual(event.Paths, []string{"BUILD", "a.o"}) {
+			t.Errorf("merged event = %+v, want BUILD change with paths [BUILD a.o]", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event after the quiet period")
+	}
+
+	// Later changes form a new batch
+	input <- ChangeEvent{Type: ChangeTypeDFile, Paths: []string{"a.d"}}
+	select {
+	case event := <-debouncer.Output():
+		if event.Type != ChangeTypeDFile || !reflect.DeepEqual(event.Paths, []string{"a.d"}) {
+			t.Errorf("second event = %+v, want .d change with path a.d", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no second event after the quiet period")
+	}
+
+	close(input)
+	if _, ok := <-debouncer.Output(); ok {
+		t.Error("expected the output to close after the input closed")
+	}
+}
+
+func TestDebouncerFlushesAfterMaxWait(t *testing.T) {
+	input := make(chan ChangeEvent)
+	debouncer := NewDebouncer(input, time.Hour, 50*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	debouncer.Start(ctx)
+
+	// The quiet period never passes, but the batch is flushed after maxWait
+	input <- ChangeEvent{Type: ChangeTypeOFile, Paths: []string{"a.o"}}
+	select {
+	case event := <-debouncer.Output():
+		if !reflect.DeepEqual(event.Paths, []string{"a.o"}) {
+			t.Errorf("event = %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event after maxWait")
+	}
+}
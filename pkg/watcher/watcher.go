@@ -2,13 +2,18 @@ package watcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
 
 	"github.com/fsnotify/fsnotify"
 )
@@ -24,21 +29,48 @@ const (
 
 // ChangeEvent represents a batch of file system changes
 type ChangeEvent struct {
-	Type      ChangeType
+	Type      ChangeType // Most significant kind of change; merged batches also hold paths of other kinds (see Types)
 	Paths     []string
 	Timestamp time.Time
 }
 
+// WatchLimitHint tells the user how to raise the inotify watch limit on Linux
+const WatchLimitHint = "sudo sysctl fs.inotify.max_user_watches=524288"
+
+// WatchFailure records a directory that could not be added to the watcher
+type WatchFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// Health summarizes how much of the workspace the watcher actually covers
+type Health struct {
+	WatchedDirs       int            `json:"watchedDirs"`       // Directories successfully added
+	Failures          []WatchFailure `json:"failures"`          // Directories that could not be added
+	BazelOutWatched   bool           `json:"bazelOutWatched"`   // Whether bazel-out is being watched
+	WatchLimitReached bool           `json:"watchLimitReached"` // Linux only: inotify watch limit exhausted
+}
+
+// Healthy reports whether every directory was watched successfully
+func (h Health) Healthy() bool {
+	return len(h.Failures) == 0 && !h.WatchLimitReached
+}
+
 // FileWatcher watches a Bazel workspace for file changes
 type FileWatcher struct {
 	watcher   *fsnotify.Watcher
 	workspace string
+	bazelOut  string // Overrides the workspace's bazel-out, "" if not set
 	events    chan ChangeEvent
 	done      chan struct{}
+
+	mu     sync.Mutex
+	health Health
 }
 
-// NewFileWatcher creates a new file system watcher for a Bazel workspace
-func NewFileWatcher(workspace string) (*FileWatcher, error) {
+// NewFileWatcher creates a new file system watcher for a Bazel workspace. The artifacts are
+// watched in bazelOut if set, else in the workspace's bazel-out (see model.OutputRoots).
+func NewFileWatcher(workspace, bazelOut string) (*FileWatcher, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create fsnotify watcher: %w", err)
@@ -47,6 +79,7 @@ func NewFileWatcher(workspace string) (*FileWatcher, error) {
 	fw := &FileWatcher{
 		watcher:   watcher,
 		workspace: workspace,
+		bazelOut:  bazelOut,
 		events:    make(chan ChangeEvent, 100),
 		done:      make(chan struct{}),
 	}
@@ -67,6 +100,7 @@ func (fw *FileWatcher) Start(ctx context.Context) error {
 	}
 
 	logging.Info("started watching workspace", "path", fw.workspace)
+	fw.logHealth()
 
 	// Process events
 	go fw.processEvents(ctx)
@@ -103,8 +137,8 @@ func (fw *FileWatcher) watchBuildFiles() error {
 
 	// Add all directories to watcher
 	for dir := range buildDirs {
-		if err := fw.watcher.Add(dir); err != nil {
-			logging.Warn("failed to watch directory", "path", dir, "error", err)
+		if err := fw.addWatch(dir); err != nil {
+			logging.Debug("failed to watch directory", "path", dir, "error", err)
 		}
 	}
 
@@ -112,9 +146,9 @@ func (fw *FileWatcher) watchBuildFiles() error {
 	return nil
 }
 
-// watchBazelOut watches the bazel-out directory for artifact changes
+// watchBazelOut watches the bazel-out directory (or its override) for artifact changes
 func (fw *FileWatcher) watchBazelOut() error {
-	bazelOut := filepath.Join(fw.workspace, "bazel-out")
+	bazelOut := model.OutputRoots(fw.workspace, fw.bazelOut)[0]
 
 	// Check if bazel-out exists
 	if _, err := os.Stat(bazelOut); os.IsNotExist(err) {
@@ -129,14 +163,72 @@ func (fw *FileWatcher) watchBazelOut() error {
 	}
 
 	// Watch the resolved directory non-recursively
-	if err := fw.watcher.Add(resolvedPath); err != nil {
+	if err := fw.addWatch(resolvedPath); err != nil {
 		return fmt.Errorf("failed to watch bazel-out: %w", err)
 	}
 
+	fw.mu.Lock()
+	fw.health.BazelOutWatched = true
+	fw.mu.Unlock()
+
 	logging.Info("monitoring bazel-out", "path", resolvedPath)
 	return nil
 }
 
+// addWatch adds a directory to the watcher and records the outcome in the health report
+func (fw *FileWatcher) addWatch(dir string) error {
+	err := fw.watcher.Add(dir)
+
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	if err != nil {
+		fw.health.Failures = append(fw.health.Failures, WatchFailure{Path: dir, Error: err.Error()})
+		if isWatchLimitError(err) {
+			fw.health.WatchLimitReached = true
+		}
+		return err
+	}
+	fw.health.WatchedDirs++
+	return nil
+}
+
+// isWatchLimitError reports whether err means the inotify watch limit is exhausted.
+// inotify_add_watch fails with ENOSPC when fs.inotify.max_user_watches is reached.
+func isWatchLimitError(err error) bool {
+	return runtime.GOOS == "linux" && errors.Is(err, syscall.ENOSPC)
+}
+
+// Health returns a snapshot of how many directories are watched and which ones failed
+func (fw *FileWatcher) Health() Health {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	health := fw.health
+	health.Failures = append([]WatchFailure(nil), fw.health.Failures...)
+	return health
+}
+
+// logHealth logs a summary of the watcher health, warning about any failures
+func (fw *FileWatcher) logHealth() {
+	health := fw.Health()
+
+	logging.Info("watcher health",
+		"watchedDirs", health.WatchedDirs,
+		"failedDirs", len(health.Failures),
+		"bazelOutWatched", health.BazelOutWatched)
+
+	if len(health.Failures) > 0 {
+		logging.Warn("some directories could not be watched; changes in them will not trigger re-analysis",
+			"count", len(health.Failures),
+			"first", health.Failures[0].Path,
+			"error", health.Failures[0].Error)
+	}
+	if health.WatchLimitReached {
+		logging.Warn("inotify watch limit reached; raise it with: "+WatchLimitHint,
+			"watchedDirs", health.WatchedDirs)
+	}
+}
+
 // processEvents processes file system events and batches them by type
 func (fw *FileWatcher) processEvents(ctx context.Context) {
 	// Batch events to avoid sending one event per file
@@ -188,18 +280,20 @@ func (fw *FileWatcher) processEvents(ctx context.Context) {
 			}
 
 			// Filter to only relevant file types
-			name := filepath.Base(event.Name)
+			changeType, ok := ClassifyPath(event.Name)
+			if !ok {
+				continue
+			}
 
-			if name == "BUILD" || name == "BUILD.bazel" {
+			switch changeType {
+			case ChangeTypeBuildFile:
 				buildFiles = append(buildFiles, event.Name)
-				flushTimer.Reset(100 * time.Millisecond)
-			} else if strings.HasSuffix(name, ".d") {
+			case ChangeTypeDFile:
 				dFiles = append(dFiles, event.Name)
-				flushTimer.Reset(100 * time.Millisecond)
-			} else if strings.HasSuffix(name, ".o") {
+			case ChangeTypeOFile:
 				oFiles = append(oFiles, event.Name)
-				flushTimer.Reset(100 * time.Millisecond)
 			}
+			flushTimer.Reset(100 * time.Millisecond)
 
 		case <-flushTimer.C:
 			flush()
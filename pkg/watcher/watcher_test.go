@@ -0,0 +1,65 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthReportsWatchedDirectories(t *testing.T) {
+	workspace := t.TempDir()
+	for _, dir := range []string{"core", "util"} {
+		if err := os.MkdirAll(filepath.Join(workspace, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(workspace, dir, "BUILD"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fw, err := NewFileWatcher(workspace, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := fw.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	health := fw.Health()
+	if health.WatchedDirs != 2 {
+		t.Errorf("WatchedDirs = %d, want 2", health.WatchedDirs)
+	}
+	if health.BazelOutWatched {
+		t.Error("expected bazel-out not to be watched when it does not exist")
+	}
+	if !health.Healthy() {
+		t.Errorf("expected healthy watcher, got %+v", health)
+	}
+}
+
+func TestHealthRecordsFailures(t *testing.T) {
+	fw, err := NewFileWatcher(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = fw.watcher.Close() }()
+
+	missing := filepath.Join(t.TempDir(), "missing")
+	if err := fw.addWatch(missing); err == nil {
+		t.Fatal("expected error watching a missing directory")
+	}
+
+	health := fw.Health()
+	if len(health.Failures) != 1 || health.Failures[0].Path != missing {
+		t.Errorf("Failures = %+v, want one failure for %s", health.Failures, missing)
+	}
+	if health.WatchLimitReached {
+		t.Error("a missing directory should not be reported as watch limit exhaustion")
+	}
+	if health.Healthy() {
+		t.Error("expected unhealthy watcher")
+	}
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// contentETag returns a strong ETag for a response body
+func contentETag(data []byte) string {
+	return fmt.Sprintf(`"%x"`, sha256.Sum256(data))
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCached writes a response body with an ETag derived from its content, or 304 Not
+// Modified when the client already has it. Clients must revalidate before reusing it.
+func writeCached(w http.ResponseWriter, r *http.Request, data []byte) {
+	etag := contentETag(data)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+// staticETags computes content ETags for all files in the embedded static file system,
+// keyed by URL path
+func staticETags(fsys fs.FS) (map[string]string, error) {
+	etags := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		etags["/"+p] = contentETag(data)
+		return nil
+	})
+	return etags, err
+}
+
+// cachedStatic serves embedded static files with content ETags. http.FileServer answers
+// If-None-Match with 304 Not Modified when the ETag header is set.
+func cachedStatic(fsys fs.FS) (http.Handler, error) {
+	etags, err := staticETags(fsys)
+	if err != nil {
+		return nil, err
+	}
+	files := http.FileServer(http.FS(fsys))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := path.Clean("/" + r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/") {
+			p = path.Join(p, "index.html")
+		}
+		if etag, ok := etags[p]; ok {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		files.ServeHTTP(w, r)
+	}), nil
+}
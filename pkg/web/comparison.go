@@ -0,0 +1,25 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+)
+
+// handleGraphComparison returns the declared and the inferred target graph, with each edge
+// tagged declared-only, inferred-only or both (see model.Module.CompareGraphs)
+func (s *Server) handleGraphComparison(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		writeNotReady(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(s.module.CompareGraphs()); err != nil {
+		logging.ErrorContext(r.Context(), "failed to encode graph comparison", "error", err)
+	}
+}
@@ -0,0 +1,89 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultAllowedOrigins are the CORS origins allowed when none are configured: pages
+// served from the local machine on any port
+var DefaultAllowedOrigins = []string{"localhost", "127.0.0.1", "::1"}
+
+// SetAllowedOrigins sets the origins allowed to make cross-origin requests to the API.
+// An entry is either a full origin ("https://dashboard.example.com:8443"), a host name
+// that matches any scheme and port ("localhost"), or "*" to allow every origin.
+// An empty list uses DefaultAllowedOrigins.
+func (s *Server) SetAllowedOrigins(origins []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allowedOrigins = slices.Clone(origins)
+}
+
+// originAllowed reports whether a request Origin header matches one of the allowed origins
+func originAllowed(origin string, allowed []string) bool {
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedOrigins
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	for _, entry := range allowed {
+		switch {
+		case entry == "*":
+			return true
+		case strings.Contains(entry, "://"):
+			if strings.EqualFold(strings.TrimSuffix(entry, "/"), origin) {
+				return true
+			}
+		case strings.EqualFold(entry, u.Hostname()):
+			return true
+		}
+	}
+	return false
+}
+
+// corsMiddleware echoes the request origin in Access-Control-Allow-Origin when it is
+// allowed and answers CORS preflight requests. Safe requests (GET, HEAD) from other origins
+// get no CORS headers, so browsers refuse to expose the responses to their pages. Other
+// requests from them are refused with 403: a simple cross-origin POST needs no preflight,
+// so it would otherwise reach the handler, e.g. start an analysis, before the browser
+// hides the response.
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s.mu.RLock()
+		allowed := originAllowed(origin, s.allowedOrigins)
+		s.mu.RUnlock()
+
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !allowed {
+			safe := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+			if preflight || !safe {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		if preflight {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,162 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+)
+
+// CytoscapeGraph is a graph in the Cytoscape.js JSON format, ready for cy.add() or the
+// elements option of cytoscape()
+type CytoscapeGraph struct {
+	Elements CytoscapeElements `json:"elements"`
+}
+
+// CytoscapeElements holds the nodes and edges of a CytoscapeGraph
+type CytoscapeElements struct {
+	Nodes []CytoscapeNode `json:"nodes"`
+	Edges []CytoscapeEdge `json:"edges"`
+}
+
+// CytoscapeNode is a node element. Classes is the node type, e.g. "cc_library", so that
+// stylesheets can select on it.
+type CytoscapeNode struct {
+	Data    CytoscapeNodeData `json:"data"`
+	Classes string            `json:"classes"`
+}
+
+// CytoscapeNodeData holds the data of a node. Parent makes the node a child of a compound
+// node: files are children of their target, targets of their package.
+type CytoscapeNodeData struct {
+	ID       string   `json:"id"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Parent   string   `json:"parent,omitempty"`
+	IsPublic bool     `json:"isPublic,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Owner    string   `json:"owner,omitempty"`
+}
+
+// CytoscapeEdge is an edge element. Classes is the edge type, plus "transitive" for
+// compile edges only included through another header.
+type CytoscapeEdge struct {
+	Data    CytoscapeEdgeData `json:"data"`
+	Classes string            `json:"classes"`
+}
+
+// CytoscapeEdgeData holds the data of an edge
+type CytoscapeEdgeData struct {
+	ID      string   `json:"id"`
+	Source  string   `json:"source"`
+	Target  string   `json:"target"`
+	Type    string   `json:"type"`
+	Linkage string   `json:"linkage,omitempty"`
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// cytoscapePackageType is the type of the package nodes added as compound parents
+const cytoscapePackageType = "package"
+
+// ToCytoscape converts graph data to the Cytoscape.js format. Targets without a parent
+// are grouped under a node for their package, which is added along with any other parent
+// that is not a node of the graph. Edges with an endpoint missing from the graph are
+// dropped, as Cytoscape rejects them. Nodes and edges are sorted by ID, so the same graph
+// always gives the same output.
+func ToCytoscape(graph *GraphData) *CytoscapeGraph {
+	nodeIDs := make(map[string]bool, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		nodeIDs[node.ID] = true
+	}
+
+	nodes := make([]CytoscapeNode, 0, len(graph.Nodes))
+	packages := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		parent := node.Parent
+		if parent == "" && strings.HasPrefix(node.ID, "//") {
+			if idx := strings.LastIndex(node.ID, ":"); idx >= 0 {
+				parent = node.ID[:idx]
+			}
+		}
+		if parent != "" && !nodeIDs[parent] {
+			packages[parent] = true
+		}
+
+		nodes = append(nodes, CytoscapeNode{
+			Data: CytoscapeNodeData{
+				ID:       node.ID,
+				Label:    node.Label,
+				Type:     node.Type,
+				Parent:   parent,
+				IsPublic: node.IsPublic,
+				Tags:     node.Tags,
+				Owner:    node.Owner,
+			},
+			Classes: node.Type,
+		})
+	}
+
+	for pkg := range packages {
+		nodes = append(nodes, CytoscapeNode{
+			Data:    CytoscapeNodeData{ID: pkg, Label: pkg, Type: cytoscapePackageType},
+			Classes: cytoscapePackageType,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Data.ID < nodes[j].Data.ID })
+
+	edges := make([]CytoscapeEdge, 0, len(graph.Edges))
+	for _, edge := range graph.Edges {
+		if !nodeIDs[edge.Source] || !nodeIDs[edge.Target] {
+			continue
+		}
+		classes := edge.Type
+		if edge.Transitive {
+			classes += " transitive"
+		}
+
+		symbols := append([]string(nil), edge.Symbols...)
+		sort.Strings(symbols)
+		edges = append(edges, CytoscapeEdge{
+			Data: CytoscapeEdgeData{
+				ID:      edge.ID,
+				Source:  edge.Source,
+				Target:  edge.Target,
+				Type:    edge.Type,
+				Linkage: edge.Linkage,
+				Symbols: symbols,
+			},
+			Classes: classes,
+		})
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].Data.ID < edges[j].Data.ID })
+
+	return &CytoscapeGraph{Elements: CytoscapeElements{Nodes: nodes, Edges: edges}}
+}
+
+// CytoscapeGraph returns the module graph in the Cytoscape.js format, or nil before the
+// first analysis
+func (s *Server) CytoscapeGraph() *CytoscapeGraph {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		return nil
+	}
+	return ToCytoscape(buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries, s.targetMetrics(), s.extensions))
+}
+
+// handleModuleGraphCytoscape returns the module graph like /api/module/graph, in the
+// Cytoscape.js format
+func (s *Server) handleModuleGraphCytoscape(w http.ResponseWriter, r *http.Request) {
+	graph := s.CytoscapeGraph()
+	if graph == nil {
+		writeNotReady(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(graph); err != nil {
+		logging.ErrorContext(r.Context(), "failed to encode cytoscape graph", "error", err)
+	}
+}
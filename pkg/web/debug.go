@@ -0,0 +1,98 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/ritzau/deps-analyzer/pkg/deps"
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/symbols"
+)
+
+// DebugArtifacts lists the build artifacts the analyzer finds in the workspace
+type DebugArtifacts struct {
+	DFileCount      int             `json:"dFileCount"`
+	ObjectFileCount int             `json:"objectFileCount"`
+	DFiles          []DebugArtifact `json:"dFiles"`
+	ObjectFiles     []DebugArtifact `json:"objectFiles"`
+}
+
+// DebugArtifact is a .d or object file and what the analyzer makes of it
+type DebugArtifact struct {
+	Path       string `json:"path"`
+	SourceFile string `json:"sourceFile,omitempty"` // Source file the artifact was compiled from
+	Target     string `json:"target,omitempty"`     // Target owning SourceFile, if known
+	PathTarget string `json:"pathTarget,omitempty"` // Target derived from an object file's _objs path
+	Error      string `json:"error,omitempty"`      // Why a .d file could not be parsed
+}
+
+// EnableDebug serves the debugging endpoints (/api/debug/...) for a workspace, whose build
+// outputs are in bazelOut if set (see model.OutputRoots). They are not found unless enabled.
+func (s *Server) EnableDebug(workspace, bazelOut string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.debugWorkspace = workspace
+	s.debugBazelOut = bazelOut
+}
+
+// handleDebugArtifacts lists the .d and object files found in the workspace's build
+// outputs, with the source file and target each one is attributed to. It searches the
+// same directories as the analysis, at the time of the request.
+func (s *Server) handleDebugArtifacts(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	workspace := s.debugWorkspace
+	bazelOut := s.debugBazelOut
+	fileToTarget := s.fileToTarget
+	ext := s.extensions
+	s.mu.RUnlock()
+
+	if workspace == "" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	dFiles, err := deps.FindDFiles(workspace, bazelOut)
+	if err != nil {
+		http.Error(w, "Failed to find .d files: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	objectFiles, err := symbols.FindObjectFiles(workspace, bazelOut)
+	if err != nil {
+		http.Error(w, "Failed to find object files: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Strings(dFiles)
+	sort.Strings(objectFiles)
+
+	result := DebugArtifacts{
+		DFileCount:      len(dFiles),
+		ObjectFileCount: len(objectFiles),
+		DFiles:          make([]DebugArtifact, 0, len(dFiles)),
+		ObjectFiles:     make([]DebugArtifact, 0, len(objectFiles)),
+	}
+	for _, path := range dFiles {
+		artifact := DebugArtifact{Path: path}
+		if fileDep, err := deps.ParseDFile(path, ext); err != nil {
+			artifact.Error = err.Error()
+		} else {
+			artifact.SourceFile = fileDep.SourceFile
+			artifact.Target = fileToTarget[fileDep.SourceFile]
+		}
+		result.DFiles = append(result.DFiles, artifact)
+	}
+	for _, path := range objectFiles {
+		sourceFile := symbols.ObjectFileToSourceFile(path, workspace, fileToTarget, ext)
+		result.ObjectFiles = append(result.ObjectFiles, DebugArtifact{
+			Path:       path,
+			SourceFile: sourceFile,
+			Target:     fileToTarget[sourceFile],
+			PathTarget: symbols.ObjectFileToTarget(path),
+		})
+	}
+
+	if err := json.NewEncoder(w).Encode(&result); err != nil {
+		logging.ErrorContext(r.Context(), "failed to encode debug artifacts", "error", err)
+	}
+}
@@ -0,0 +1,115 @@
+package web
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/lens"
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+)
+
+//go:embed templates/export.html
+var exportTemplateText string
+
+// exportTemplate renders a rendered graph as a standalone page
+var exportTemplate = template.Must(template.New("export").Parse(exportTemplateText))
+
+// exportPage holds the data of exportTemplate
+type exportPage struct {
+	Title     string
+	Lens      string       // Name of the default lens used for rendering
+	CreatedAt string       // When the page was exported
+	Styles    template.CSS // The web UI's stylesheet, inlined
+	Graph     *GraphData
+}
+
+// handleExportHTML renders the module graph into a single HTML file that shows it without a
+// running server. The graph is rendered like POST /api/module/graph/lens, with the lenses and
+// selection taken from the query parameters (see parseExportRequest).
+func (s *Server) handleExportHTML(w http.ResponseWriter, r *http.Request) {
+	req, err := parseExportRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid export request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	styles, err := staticFiles.ReadFile("static/styles.css")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to read styles: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	s.mu.RLock()
+	if s.module == nil {
+		s.mu.RUnlock()
+		writeNotReady(w)
+		return
+	}
+	rawGraphData := buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries, s.targetMetrics(), s.extensions)
+	title := s.module.Name
+	s.mu.RUnlock()
+
+	renderedGraph, err := lens.RenderGraph(convertToLensGraphData(rawGraphData), req.DefaultLens, req.DetailLens, req.SelectedNodes, req.FocusMode)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Lens rendering failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if title == "" {
+		title = "Dependency Graph"
+	}
+	page := exportPage{
+		Title:     title,
+		Lens:      req.DefaultLens.Name,
+		CreatedAt: time.Now().Format("2006-01-02 15:04"),
+		Styles:    template.CSS(styles),
+		Graph:     convertFromLensGraphData(renderedGraph, rawGraphData),
+	}
+
+	// Render fully before writing, so a template error can still be reported
+	var buf bytes.Buffer
+	if err := exportTemplate.Execute(&buf, page); err != nil {
+		logging.ErrorContext(r.Context(), "failed to render export", "error", err)
+		http.Error(w, "Failed to render export", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="deps-graph.html"`)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// parseExportRequest reads the lens rendering settings of an export from query parameters:
+// defaultLens and detailLens as JSON lens configurations (lens.OverviewLens if omitted),
+// selected once per selected node, and focusMode
+func parseExportRequest(query url.Values) (*LensRenderRequest, error) {
+	req := &LensRenderRequest{
+		DefaultLens:   lens.OverviewLens(),
+		DetailLens:    lens.OverviewLens(),
+		SelectedNodes: query["selected"],
+		FocusMode:     lens.FocusMode(query.Get("focusMode")),
+	}
+
+	for param, target := range map[string]**lens.LensConfig{"defaultLens": &req.DefaultLens, "detailLens": &req.DetailLens} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		var config lens.LensConfig
+		if err := json.Unmarshal([]byte(value), &config); err != nil {
+			return nil, fmt.Errorf("%s: %w", param, err)
+		}
+		*target = &config
+	}
+
+	if req.FocusMode != "" && req.FocusMode != lens.FocusUnion && req.FocusMode != lens.FocusIntersection {
+		return nil, fmt.Errorf("unknown focus mode %q", req.FocusMode)
+	}
+	return req, nil
+}
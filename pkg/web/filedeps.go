@@ -0,0 +1,94 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/ritzau/deps-analyzer/pkg/deps"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// Where an included file lives, relative to the target including it
+const (
+	IncludeInTarget = "target"   // Owned by the same target
+	IncludeInRepo   = "repo"     // In the workspace, owned by another target or by none
+	IncludeExternal = "external" // In an external repository or outside the workspace
+)
+
+// TargetFileDeps lists the compile-time dependencies of the source files owned by a target
+type TargetFileDeps struct {
+	Target string           `json:"target"`
+	Files  []SourceFileDeps `json:"files"`
+}
+
+// SourceFileDeps lists the files a source file includes, as recorded in its .d file
+type SourceFileDeps struct {
+	File     string         `json:"file"`
+	Includes []IncludedFile `json:"includes"`
+}
+
+// IncludedFile is a file included (directly or transitively) by a source file
+type IncludedFile struct {
+	File     string `json:"file"`
+	Target   string `json:"target,omitempty"` // Target owning the file, if known
+	Location string `json:"location"`         // IncludeInTarget, IncludeInRepo or IncludeExternal
+}
+
+// handleTargetFileDeps returns the .d-derived compile dependencies of each source file
+// owned by a target
+func (s *Server) handleTargetFileDeps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil || s.fileDeps == nil {
+		http.Error(w, "File dependency data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Accept short forms such as "util" or "//util" for "//util:util"
+	label := model.CanonicalizeLabel(mux.Vars(r)["label"])
+	if _, exists := s.module.Targets[label]; !exists {
+		http.Error(w, fmt.Sprintf("Target not found: %s", label), http.StatusNotFound)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(targetFileDeps(label, s.fileDeps, s.fileToTarget))
+}
+
+// targetFileDeps collects the compile dependencies of the source files owned by a target,
+// sorted by file
+func targetFileDeps(label string, fileDeps []*deps.FileDependency, fileToTarget map[string]string) *TargetFileDeps {
+	result := &TargetFileDeps{Target: label, Files: make([]SourceFileDeps, 0)}
+
+	for _, fileDep := range fileDeps {
+		if fileToTarget[fileDep.SourceFile] != label {
+			continue
+		}
+
+		includes := make([]IncludedFile, 0, len(fileDep.Dependencies))
+		for _, dep := range fileDep.Dependencies {
+			owner := fileToTarget[dep]
+			included := IncludedFile{File: dep, Target: owner, Location: IncludeInRepo}
+			switch {
+			case owner == label:
+				included.Location = IncludeInTarget
+			case strings.HasPrefix(dep, "external/") || filepath.IsAbs(dep):
+				included.Location = IncludeExternal
+			}
+			includes = append(includes, included)
+		}
+		sort.Slice(includes, func(i, j int) bool { return includes[i].File < includes[j].File })
+
+		result.Files = append(result.Files, SourceFileDeps{File: fileDep.SourceFile, Includes: includes})
+	}
+
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].File < result.Files[j].File })
+	return result
+}
@@ -0,0 +1,122 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ritzau/deps-analyzer/pkg/lens"
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/pubsub"
+)
+
+// renderLensGraph applies the lenses of a request to the raw module graph
+func renderLensGraph(req *LensRenderRequest, rawGraphData *GraphData) (*GraphData, error) {
+	renderedGraph, err := lens.RenderGraph(convertToLensGraphData(rawGraphData), req.DefaultLens, req.DetailLens, req.SelectedNodes, req.FocusMode)
+	if err != nil {
+		return nil, err
+	}
+	return convertFromLensGraphData(renderedGraph, rawGraphData), nil
+}
+
+// lensGraphUpdate returns the response taking a client from the previous snapshot to the
+// rendered graph: a diff, or the full graph if there is no previous snapshot or the diff
+// is larger than half the graph
+func lensGraphUpdate(ctx context.Context, previous *lens.GraphSnapshot, requestHash, hash string, result, rawGraphData *GraphData) *LensRenderResponse {
+	if previous == nil {
+		logging.InfoContext(ctx, "sending full graph", "nodes", len(result.Nodes), "edges", len(result.Edges))
+		return &LensRenderResponse{Hash: hash, RequestHash: requestHash, FullGraph: result}
+	}
+
+	lensDiff := lens.ComputeDiff(previous, convertToLensGraphData(result))
+	webDiff := &GraphDiff{
+		AddedNodes:    convertLensNodesToWeb(lensDiff.AddedNodes, rawGraphData),
+		RemovedNodes:  lensDiff.RemovedNodes,
+		ModifiedNodes: convertLensNodesToWeb(lensDiff.ModifiedNodes, rawGraphData),
+		AddedEdges:    convertLensEdgesToWeb(lensDiff.AddedEdges, rawGraphData),
+		RemovedEdges:  lensDiff.RemovedEdges,
+	}
+
+	diffSize := len(webDiff.AddedNodes) + len(webDiff.RemovedNodes) + len(webDiff.ModifiedNodes) +
+		len(webDiff.AddedEdges) + len(webDiff.RemovedEdges)
+	fullSize := len(result.Nodes) + len(result.Edges)
+	if diffSize > fullSize/2 {
+		logging.DebugContext(ctx, "diff too large, sending full graph", "diffSize", diffSize, "fullSize", fullSize)
+		return &LensRenderResponse{Hash: hash, RequestHash: requestHash, FullGraph: result}
+	}
+
+	logging.DebugContext(ctx, "sending diff",
+		"addedNodes", len(webDiff.AddedNodes),
+		"removedNodes", len(webDiff.RemovedNodes),
+		"modifiedNodes", len(webDiff.ModifiedNodes),
+		"addedEdges", len(webDiff.AddedEdges),
+		"removedEdges", len(webDiff.RemovedEdges))
+	return &LensRenderResponse{Hash: hash, RequestHash: requestHash, Diff: webDiff}
+}
+
+// handleSubscribeGraphDiff streams graph_diff events for a lens request rendered before by
+// POST /api/module/graph/lens, identified by the requestHash of its response. Whenever an
+// analysis completes the request is rendered again and the subscriber is sent the diff from
+// the graph it last received, or the full graph if there is none to diff against. Analyses
+// that leave the rendered graph unchanged send nothing.
+func (s *Server) handleSubscribeGraphDiff(w http.ResponseWriter, r *http.Request) {
+	requestHash := r.URL.Query().Get("hash")
+	if requestHash == "" {
+		http.Error(w, "Lens request hash required", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.RLock()
+	req, ok := s.lensRequests[requestHash]
+	previous := s.lensCache[requestHash]
+	s.mu.RUnlock()
+	if !ok {
+		http.Error(w, "Unknown lens request hash: "+requestHash, http.StatusNotFound)
+		return
+	}
+
+	version := 0
+	s.streamConvertedEvents(w, r, pubsub.TargetGraphTopic.Subscribe, func(event pubsub.Event) (pubsub.Event, bool) {
+		if event.Type != "complete" {
+			return event, false
+		}
+
+		s.mu.RLock()
+		var rawGraphData *GraphData
+		if s.module != nil {
+			rawGraphData = buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries, s.targetMetrics(), s.extensions)
+		}
+		s.mu.RUnlock()
+		if rawGraphData == nil {
+			return event, false
+		}
+
+		result, err := renderLensGraph(&req, rawGraphData)
+		if err != nil {
+			logging.WarnContext(r.Context(), "lens rendering for graph_diff failed", "error", err)
+			return event, false
+		}
+		snapshot := lens.CreateSnapshot(convertToLensGraphData(result))
+		if previous != nil && snapshot.Hash == previous.Hash {
+			return event, false
+		}
+
+		update := lensGraphUpdate(r.Context(), previous, requestHash, snapshot.Hash, result, rawGraphData)
+		data, err := json.Marshal(update)
+		if err != nil {
+			logging.WarnContext(r.Context(), "encoding graph_diff failed", "error", err)
+			return event, false
+		}
+		previous = snapshot
+		s.mu.Lock()
+		s.lensCache[requestHash] = snapshot
+		s.mu.Unlock()
+
+		eventType := "diff"
+		if update.FullGraph != nil {
+			eventType = "full"
+		}
+		version++
+		return pubsub.Event{Topic: pubsub.GraphDiffTopic.Name(), Type: eventType, Data: data, Version: version}, true
+	})
+}
@@ -0,0 +1,65 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+)
+
+// DefaultHistorySize is the number of analyses kept in the metrics history unless changed
+// with SetHistorySize
+const DefaultHistorySize = 100
+
+// HistoryEntry records the size of the module and the issues found by one analysis
+type HistoryEntry struct {
+	Time         time.Time `json:"time"`             // When the analysis completed
+	Reason       string    `json:"reason,omitempty"` // Why it ran, e.g. "BUILD changed"
+	Targets      int       `json:"targets"`
+	Dependencies int       `json:"dependencies"`
+	Issues       int       `json:"issues"`
+	DurationMs   int64     `json:"durationMs"` // Duration of the analysis in milliseconds
+}
+
+// SetHistorySize sets the number of analyses kept in the metrics history, dropping the
+// oldest entries if there are more. Zero or less disables the history.
+func (s *Server) SetHistorySize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.historySize = max(size, 0)
+	if len(s.history) > s.historySize {
+		s.history = slices.Clone(s.history[len(s.history)-s.historySize:])
+	}
+}
+
+// RecordHistory appends an entry to the metrics history, dropping the oldest entry when
+// the history is full
+func (s *Server) RecordHistory(entry HistoryEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.historySize == 0 {
+		return
+	}
+	if len(s.history) >= s.historySize {
+		s.history = slices.Delete(s.history, 0, len(s.history)-s.historySize+1)
+	}
+	s.history = append(s.history, entry)
+}
+
+// handleHistory lists the metrics of recent analyses, oldest first
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	history := slices.Clone(s.history)
+	s.mu.RUnlock()
+
+	if history == nil {
+		history = make([]HistoryEntry, 0)
+	}
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		logging.ErrorContext(r.Context(), "failed to encode history", "error", err)
+	}
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// overviewTopHubs is the number of hubs listed in the overview
+const overviewTopHubs = 5
+
+// Overview summarizes the analysis for the dashboard, so that it renders from one request
+type Overview struct {
+	Targets       int                   `json:"targets"`
+	Packages      int                   `json:"packages"`
+	Dependencies  int                   `json:"dependencies"`
+	Binaries      int                   `json:"binaries"`
+	TargetsByKind map[string]int        `json:"targetsByKind"` // e.g. {"cc_library": 12}
+	Coverage      OverviewCoverage      `json:"coverage"`
+	Issues        map[string]int        `json:"issues"`  // Issue count by severity, e.g. {"warning": 3}
+	TopHubs       []model.TargetMetrics `json:"topHubs"` // Hubs and god objects with the largest ripple effect
+	Cycles        [][]string            `json:"cycles"`  // See model.Module.FindCycles
+}
+
+// OverviewCoverage counts the workspace files owned by targets and those that are not
+type OverviewCoverage struct {
+	CoveredFiles   int     `json:"coveredFiles"`
+	UncoveredFiles int     `json:"uncoveredFiles"`
+	Percent        float64 `json:"percent"` // Covered share of all files, 100 when there are none
+}
+
+// handleOverview returns the summary of the current analysis: counts, file coverage, issues
+// by severity, the top hubs and dependency cycles
+func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		writeNotReady(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	summary := s.module.Summary()
+	overview := Overview{
+		Targets:       summary.Targets,
+		Packages:      summary.Packages,
+		Dependencies:  summary.Dependencies,
+		Binaries:      len(s.binaries),
+		TargetsByKind: make(map[string]int),
+		Coverage: OverviewCoverage{
+			CoveredFiles:   len(s.fileToTarget),
+			UncoveredFiles: len(s.uncoveredFiles),
+			Percent:        100,
+		},
+		Issues: summary.IssuesBySeverity,
+		Cycles: s.module.FindCycles(),
+	}
+
+	for _, target := range s.module.Targets {
+		overview.TargetsByKind[string(target.Kind)]++
+	}
+
+	if total := overview.Coverage.CoveredFiles + overview.Coverage.UncoveredFiles; total > 0 {
+		overview.Coverage.Percent = 100 * float64(overview.Coverage.CoveredFiles) / float64(total)
+	}
+
+	hubs := model.Hubs(s.targetMetrics())
+	overview.TopHubs = hubs[:min(len(hubs), overviewTopHubs)]
+
+	if err := json.NewEncoder(w).Encode(&overview); err != nil {
+		logging.ErrorContext(r.Context(), "failed to encode overview", "error", err)
+	}
+}
@@ -0,0 +1,67 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ritzau/deps-analyzer/pkg/logging"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+)
+
+// defaultPathLimit caps the number of paths returned by /api/path, as densely connected
+// graphs have exponentially many
+const defaultPathLimit = 100
+
+// handlePath returns the dependency paths from the target in the from parameter to the one
+// in to, shortest first, as a JSON array that is empty if there is no path. shortest=true
+// returns only one shortest path, and limit caps the number of paths (default 100).
+func (s *Server) handlePath(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("from") == "" || query.Get("to") == "" {
+		http.Error(w, "Missing from or to parameter", http.StatusBadRequest)
+		return
+	}
+	limit := defaultPathLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, "Invalid limit parameter", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		writeNotReady(w)
+		return
+	}
+
+	// Accept short forms such as "util" or "//util" for "//util:util"
+	from := model.CanonicalizeLabel(query.Get("from"))
+	to := model.CanonicalizeLabel(query.Get("to"))
+	for _, label := range []string{from, to} {
+		if s.module.Targets[label] == nil {
+			http.Error(w, fmt.Sprintf("Target not found: %s", label), http.StatusNotFound)
+			return
+		}
+	}
+
+	paths := []model.DependencyPath{}
+	if query.Get("shortest") == "true" {
+		if path, ok := s.module.ShortestPath(from, to); ok {
+			paths = append(paths, path)
+		}
+	} else {
+		paths = s.module.FindPaths(from, to, limit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(paths); err != nil {
+		logging.ErrorContext(r.Context(), "failed to encode dependency paths", "error", err)
+	}
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// wantRanks reports whether a graph request opted in to server-computed ranks (?ranks=true)
+func wantRanks(r *http.Request) bool {
+	ranks, _ := strconv.ParseBool(r.URL.Query().Get("ranks"))
+	return ranks
+}
+
+// assignRanks sets the Rank of each node to the length of the longest path of edges
+// leading to it, so every edge goes from a lower to a higher rank. This is the layering
+// a top-to-bottom layout needs. Nodes are ranked in topological order (Kahn's algorithm);
+// when only cycles are left, the first remaining node by ID is ranked next and the edges
+// closing its cycles are ignored.
+func assignRanks(data *GraphData) {
+	successors := make(map[string][]string)
+	inDegree := make(map[string]int)
+	known := make(map[string]bool)
+	for _, node := range data.Nodes {
+		known[node.ID] = true
+	}
+	for _, edge := range data.Edges {
+		known[edge.Source] = true
+		known[edge.Target] = true
+		if edge.Source != edge.Target {
+			successors[edge.Source] = append(successors[edge.Source], edge.Target)
+			inDegree[edge.Target]++
+		}
+	}
+
+	// Break cycles in ID order so they are broken the same way on every request
+	ids := make([]string, 0, len(known))
+	for id := range known {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var queue []string
+	for _, id := range ids {
+		if inDegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	ranks := make(map[string]int)
+	done := make(map[string]bool)
+	next := 0 // Index in ids before which all nodes are done
+	for ranked := 0; ranked < len(ids); ranked++ {
+		if len(queue) == 0 {
+			for done[ids[next]] {
+				next++
+			}
+			queue = append(queue, ids[next])
+		}
+		id := queue[0]
+		queue = queue[1:]
+		done[id] = true
+
+		for _, succ := range successors[id] {
+			if done[succ] {
+				continue // Closes a cycle
+			}
+			ranks[succ] = max(ranks[succ], ranks[id]+1)
+			inDegree[succ]--
+			if inDegree[succ] == 0 {
+				queue = append(queue, succ)
+			}
+		}
+	}
+
+	for i := range data.Nodes {
+		data.Nodes[i].Rank = ranks[data.Nodes[i].ID]
+	}
+}
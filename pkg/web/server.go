@@ -4,18 +4,27 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/ritzau/deps-analyzer/pkg/binaries"
 	"github.com/ritzau/deps-analyzer/pkg/deps"
+	"github.com/ritzau/deps-analyzer/pkg/graph"
 	"github.com/ritzau/deps-analyzer/pkg/lens"
 	"github.com/ritzau/deps-analyzer/pkg/logging"
 	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/owners"
 	"github.com/ritzau/deps-analyzer/pkg/pubsub"
 	"github.com/ritzau/deps-analyzer/pkg/symbols"
 )
@@ -27,22 +36,38 @@ var staticFiles embed.FS
 type GraphNode struct {
 	ID              string   `json:"id"`
 	Label           string   `json:"label"`
-	Type            string   `json:"type"`     // "cc_library", "cc_binary", "source", "header", "external"
+	Type            string   `json:"type"`     // "cc_library", "cc_binary", "cc_test", "source", "header", "external"
 	Parent          string   `json:"parent"`   // Parent node ID for grouping (optional)
 	IsPublic        bool     `json:"isPublic"` // Whether target has public visibility
 	LddDependencies []string `json:"lddDependencies,omitempty"`
+	Tags            []string `json:"tags,omitempty"`       // Bazel tags of target nodes
+	Owner           string   `json:"owner,omitempty"`      // CODEOWNERS owner of target nodes
+	HeaderOnly      bool     `json:"headerOnly,omitempty"` // Library with headers but no sources
+	External        bool     `json:"external,omitempty"`   // Target in an external repository
+
+	// Degree metrics for target nodes (used as layout hints and to highlight hubs)
+	InDegree        int  `json:"inDegree,omitempty"`        // Number of direct dependents
+	OutDegree       int  `json:"outDegree,omitempty"`       // Number of direct dependencies
+	TransitiveRdeps int  `json:"transitiveRdeps,omitempty"` // Number of direct and indirect dependents
+	IsHub           bool `json:"isHub,omitempty"`           // Depended on by many targets
+	IsGodObject     bool `json:"isGodObject,omitempty"`     // Depends on many targets
+
+	// Layer for a top-to-bottom layout, only computed when requested with ?ranks=true (omitted when 0)
+	Rank int `json:"rank,omitempty"`
 }
 
 // GraphEdge represents an edge in the dependency graph
 type GraphEdge struct {
+	ID          string            `json:"id"` // Stable identity, see lens.EdgeID
 	Source      string            `json:"source"`
 	Target      string            `json:"target"`
-	Type        string            `json:"type"`        // "file" (from .d files) or "symbol" (from nm)
-	Linkage     string            `json:"linkage"`     // For symbol edges: "static", "dynamic", or "cross"
-	Symbols     []string          `json:"symbols"`     // For symbol edges: list of symbol names
-	SourceLabel string            `json:"sourceLabel"` // Human-readable label for source node
-	TargetLabel string            `json:"targetLabel"` // Human-readable label for target node
-	FileDetails map[string]string `json:"fileDetails"` // File-level details: source file -> target file(s)
+	Type        string            `json:"type"`                 // "file" (from .d files) or "symbol" (from nm)
+	Linkage     string            `json:"linkage"`              // For symbol edges: "static", "dynamic", or "cross"
+	Symbols     []string          `json:"symbols"`              // For symbol edges: list of symbol names
+	SourceLabel string            `json:"sourceLabel"`          // Human-readable label for source node
+	TargetLabel string            `json:"targetLabel"`          // Human-readable label for target node
+	FileDetails map[string]string `json:"fileDetails"`          // File-level details: source file -> target file(s)
+	Transitive  bool              `json:"transitive,omitempty"` // For file compile edges: only included through another header
 }
 
 // GraphData holds the dependency graph for visualization
@@ -57,13 +82,30 @@ type Server struct {
 	binaries       []*binaries.BinaryInfo
 	module         *model.Module
 	publisher      pubsub.Publisher
-	fileDeps       []*deps.FileDependency         // Compile-time file dependencies from .d files
-	symbolDeps     []symbols.SymbolDependency     // Link-time symbol dependencies from nm
-	fileToTarget   map[string]string              // Maps file paths to target labels
-	uncoveredFiles []string                       // Files not included in any target
-	watching       bool                           // File watching active
-	lensCache      map[string]*lens.GraphSnapshot // Cache of rendered graphs by request hash
-	mu             sync.RWMutex                   // Protect all state from concurrent access
+	fileDeps       []*deps.FileDependency          // Compile-time file dependencies from .d files
+	symbolDeps     []symbols.SymbolDependency      // Link-time symbol dependencies from nm
+	fileSymbols    map[string]*symbols.FileSymbols // Per-file defined and undefined symbols from nm
+	unusedSymbols  []symbols.UnusedSymbol          // Global symbols no analyzed object references
+	fileToTarget   map[string]string               // Maps file paths to target labels
+	uncoveredFiles []string                        // Files not included in any target
+	watching       bool                            // File watching active
+	watcherHealth  *pubsub.WatcherHealth           // File watcher coverage, nil until watching starts
+	lensCache      map[string]*lens.GraphSnapshot  // Cache of rendered graphs by request hash
+	lensRequests   map[string]LensRenderRequest    // Lens requests by request hash, re-rendered for graph_diff subscribers
+	hubThreshold   int                             // Minimum in-degree for a target to be flagged as a hub
+	godThreshold   int                             // Minimum out-degree for a target to be flagged as a god object
+	extensions     model.FileExtensions            // Classify files as sources and headers
+	metricsMu      sync.Mutex                      // Protects metrics, computed by readers of the module
+	metrics        map[string]*model.TargetMetrics // Metrics of the module's targets, nil until first needed after a change
+	analyzer       Analyzer                        // Runs analyses for the control endpoints, nil if not available
+	allowedOrigins []string                        // Origins allowed to make cross-origin requests, empty for DefaultAllowedOrigins
+	history        []HistoryEntry                  // Metrics of recent analyses, oldest first
+	historySize    int                             // Maximum number of history entries
+	debugWorkspace string                          // Workspace searched by the debugging endpoints, empty if disabled
+	debugBazelOut  string                          // Searched instead of the workspace's bazel-out if set
+	httpServer     *http.Server                    // Serving requests, nil until Serve is called
+	stopped        bool                            // Shutdown was called
+	mu             sync.RWMutex                    // Protect all state from concurrent access
 }
 
 // NewServer creates a new web server
@@ -72,21 +114,38 @@ func NewServer() *Server {
 
 	// Configure topic buffering
 	// workspace_status: buffer last 10 events, replay only last event to new subscribers
-	ssePublisher.ConfigureTopic("workspace_status", pubsub.TopicConfig{
-		BufferSize: 10,
-		ReplayAll:  false, // Only send current state
+	// Slow subscribers skip stale progress updates rather than missing the latest ones
+	ssePublisher.ConfigureTopic(pubsub.WorkspaceStatusTopic.Name(), pubsub.TopicConfig{
+		BufferSize:   10,
+		ReplayAll:    false, // Only send current state
+		Backpressure: pubsub.DropOldest,
 	})
 
 	// target_graph: buffer last 5 events, replay only last event
-	ssePublisher.ConfigureTopic("target_graph", pubsub.TopicConfig{
-		BufferSize: 5,
-		ReplayAll:  false, // Only send current state
+	// Each graph replaces the previous one, so slow subscribers only get the latest
+	ssePublisher.ConfigureTopic(pubsub.TargetGraphTopic.Name(), pubsub.TopicConfig{
+		BufferSize:   5,
+		ReplayAll:    false, // Only send current state
+		Backpressure: pubsub.Coalesce,
 	})
 
+	// changes: not buffered, so new subscribers are not sent changes from before they connected
+	ssePublisher.ConfigureTopic(pubsub.ChangesTopic.Name(), pubsub.TopicConfig{
+		BufferSize: 0,
+	})
+
+	return NewServerWith(ssePublisher)
+}
+
+// NewServerWith creates a web server publishing its events to the given publisher.
+// Tests use it with a pubsub.MemoryPublisher to inspect the published events.
+func NewServerWith(publisher pubsub.Publisher) *Server {
 	s := &Server{
-		router:    mux.NewRouter(),
-		publisher: ssePublisher,
-		lensCache: make(map[string]*lens.GraphSnapshot),
+		router:       mux.NewRouter(),
+		publisher:    publisher,
+		lensCache:    make(map[string]*lens.GraphSnapshot),
+		lensRequests: make(map[string]LensRenderRequest),
+		historySize:  DefaultHistorySize,
 	}
 	s.setupRoutes()
 	return s
@@ -104,6 +163,35 @@ func (s *Server) SetModule(m *model.Module) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.module = m
+	s.invalidateMetrics()
+}
+
+// UpdateModule applies update to the module and stores it, holding the write lock
+// throughout, so that handlers never read a module that is being changed
+func (s *Server) UpdateModule(m *model.Module, update func(*model.Module)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(m)
+	s.module = m
+	s.invalidateMetrics()
+}
+
+// targetMetrics returns the metrics of the module's targets, computing them once after
+// each change of the module rather than on every graph request
+func (s *Server) targetMetrics() map[string]*model.TargetMetrics {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	if s.metrics == nil && s.module != nil {
+		s.metrics = s.module.ComputeTargetMetrics(s.hubThreshold, s.godThreshold)
+	}
+	return s.metrics
+}
+
+// invalidateMetrics drops the target metrics, to be computed again when next needed
+func (s *Server) invalidateMetrics() {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	s.metrics = nil
 }
 
 // GetModule retrieves the current Module data model
@@ -134,6 +222,20 @@ func (s *Server) SetSymbolDependencies(symbolDeps []symbols.SymbolDependency) {
 	s.symbolDeps = symbolDeps
 }
 
+// SetFileSymbols stores the per-file symbol tables from nm analysis
+func (s *Server) SetFileSymbols(fileSymbols map[string]*symbols.FileSymbols) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fileSymbols = fileSymbols
+}
+
+// SetUnusedSymbols stores the dead-code candidates found by symbol analysis
+func (s *Server) SetUnusedSymbols(unused []symbols.UnusedSymbol) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unusedSymbols = unused
+}
+
 // SetFileToTargetMap stores the mapping from file paths to target labels
 func (s *Server) SetFileToTargetMap(fileToTarget map[string]string) {
 	s.mu.Lock()
@@ -148,6 +250,20 @@ func (s *Server) SetUncoveredFiles(files []string) {
 	s.uncoveredFiles = files
 }
 
+// GetFileToTargetMap retrieves the map of file paths to the labels of the targets owning them
+func (s *Server) GetFileToTargetMap() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fileToTarget
+}
+
+// GetUncoveredFiles retrieves the files that are not included in any target
+func (s *Server) GetUncoveredFiles() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.uncoveredFiles
+}
+
 // SetWatching sets the file watching state
 func (s *Server) SetWatching(watching bool) {
 	s.mu.Lock()
@@ -155,10 +271,48 @@ func (s *Server) SetWatching(watching bool) {
 	s.watching = watching
 }
 
+// SetHubThresholds sets the degree thresholds used to flag hub and god object targets
+func (s *Server) SetHubThresholds(hubThreshold, godObjectThreshold int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hubThreshold = hubThreshold
+	s.godThreshold = godObjectThreshold
+	s.invalidateMetrics()
+}
+
+// SetFileExtensions sets the file extensions that classify files as sources and headers
+func (s *Server) SetFileExtensions(ext model.FileExtensions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extensions = ext
+}
+
+// SetWatcherHealth sets the file watcher health included in subsequent workspace status events
+func (s *Server) SetWatcherHealth(health *pubsub.WatcherHealth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watcherHealth = health
+}
+
+// SetAnalyzer registers the analyzer controlled by /api/analyze, /api/analyze/cancel and /api/state
+func (s *Server) SetAnalyzer(analyzer Analyzer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.analyzer = analyzer
+}
+
+// Subscribe subscribes to one of the server's event topics by name
+// (pubsub.WorkspaceStatusTopic or pubsub.TargetGraphTopic).
+// This allows in-process consumers such as a terminal status display to follow the analysis.
+func (s *Server) Subscribe(ctx context.Context, topic string) (pubsub.Subscription, error) {
+	return s.publisher.Subscribe(ctx, topic)
+}
+
 // PublishWorkspaceStatus publishes a workspace status event
 func (s *Server) PublishWorkspaceStatus(state, message string, step, total int) error {
 	s.mu.RLock()
 	watching := s.watching
+	watcherHealth := s.watcherHealth
 	s.mu.RUnlock()
 
 	status := pubsub.WorkspaceStatus{
@@ -168,14 +322,57 @@ func (s *Server) PublishWorkspaceStatus(state, message string, step, total int)
 		Total:    total,
 		Watching: watching,
 		Reason:   "",
+		Watcher:  watcherHealth,
+	}
+	return pubsub.WorkspaceStatusTopic.Publish(s.publisher, state, status)
+}
+
+// PublishAnalysisComplete publishes the ready workspace status with the duration of each
+// analysis phase and any warnings about missing results
+func (s *Server) PublishAnalysisComplete(message string, timings []pubsub.PhaseTiming, warnings []string) error {
+	s.mu.RLock()
+	watching := s.watching
+	watcherHealth := s.watcherHealth
+	s.mu.RUnlock()
+
+	status := pubsub.WorkspaceStatus{
+		State:    "ready",
+		Message:  message,
+		Step:     6,
+		Total:    6,
+		Watching: watching,
+		Watcher:  watcherHealth,
+		Timings:  timings,
+		Warnings: warnings,
 	}
-	return s.publisher.Publish("workspace_status", state, status)
+	return pubsub.WorkspaceStatusTopic.Publish(s.publisher, status.State, status)
+}
+
+// PublishAnalysisError publishes the error workspace status with the phase that failed.
+// The results of earlier analyses stay available.
+func (s *Server) PublishAnalysisError(message string, analysisErr *pubsub.AnalysisError) error {
+	s.mu.RLock()
+	watching := s.watching
+	watcherHealth := s.watcherHealth
+	s.mu.RUnlock()
+
+	status := pubsub.WorkspaceStatus{
+		State:    "error",
+		Message:  message,
+		Step:     6,
+		Total:    6,
+		Watching: watching,
+		Watcher:  watcherHealth,
+		Error:    analysisErr,
+	}
+	return pubsub.WorkspaceStatusTopic.Publish(s.publisher, status.State, status)
 }
 
 // PublishWorkspaceStatusWithReason publishes a workspace status event with a reason
 func (s *Server) PublishWorkspaceStatusWithReason(state, message, reason string, step, total int) error {
 	s.mu.RLock()
 	watching := s.watching
+	watcherHealth := s.watcherHealth
 	s.mu.RUnlock()
 
 	status := pubsub.WorkspaceStatus{
@@ -185,53 +382,134 @@ func (s *Server) PublishWorkspaceStatusWithReason(state, message, reason string,
 		Total:    total,
 		Watching: watching,
 		Reason:   reason,
+		Watcher:  watcherHealth,
 	}
-	return s.publisher.Publish("workspace_status", state, status)
+	return pubsub.WorkspaceStatusTopic.Publish(s.publisher, state, status)
 }
 
 // PublishTargetGraph publishes a target graph event
 func (s *Server) PublishTargetGraph(eventType string, complete bool) error {
 	var targetsCount, depsCount int
+	s.mu.RLock()
 	if s.module != nil {
 		targetsCount = len(s.module.Targets)
 		depsCount = len(s.module.Dependencies)
 	}
+	s.mu.RUnlock()
 
 	data := pubsub.TargetGraphData{
 		TargetsCount:      targetsCount,
 		DependenciesCount: depsCount,
 		Complete:          complete,
 	}
-	return s.publisher.Publish("target_graph", eventType, data)
+	return pubsub.TargetGraphTopic.Publish(s.publisher, eventType, data)
+}
+
+// PublishModuleChanges publishes the targets affected by a re-analysis.
+// Nothing is published if nothing changed.
+func (s *Server) PublishModuleChanges(reason string, diff model.ModuleDiff) error {
+	if diff.Empty() {
+		return nil
+	}
+
+	data := pubsub.ModuleChanges{
+		Reason:  reason,
+		Nodes:   diff.AffectedTargets(),
+		Added:   diff.AddedTargets,
+		Removed: diff.RemovedTargets,
+		Changed: diff.ChangedTargets,
+	}
+	return pubsub.ChangesTopic.Publish(s.publisher, "changed", data)
 }
 
 func (s *Server) setupRoutes() {
 	// SSE subscription endpoints
 	s.router.HandleFunc("/api/subscribe/workspace_status", s.handleSubscribeWorkspaceStatus).Methods("GET")
 	s.router.HandleFunc("/api/subscribe/target_graph", s.handleSubscribeTargetGraph).Methods("GET")
+	s.router.HandleFunc("/api/subscribe/changes", s.handleSubscribeChanges).Methods("GET")
+	s.router.HandleFunc("/api/subscribe/graph_diff", s.handleSubscribeGraphDiff).Methods("GET")
 
 	// API routes - more specific routes must come first
+	s.router.HandleFunc("/api/overview", s.handleOverview).Methods("GET")
 	s.router.HandleFunc("/api/module", s.handleModule).Methods("GET", "HEAD") // HEAD for health checks
 	s.router.HandleFunc("/api/module/graph", s.handleModuleGraph).Methods("GET")
 	s.router.HandleFunc("/api/module/graph/lens", s.handleModuleGraphWithLens).Methods("POST")
+	s.router.HandleFunc("/api/module/graph/cytoscape", s.handleModuleGraphCytoscape).Methods("GET")
+	s.router.HandleFunc("/api/export/html", s.handleExportHTML).Methods("GET")
+	s.router.HandleFunc("/api/graph/comparison", s.handleGraphComparison).Methods("GET")
+	s.router.HandleFunc("/api/path", s.handlePath).Methods("GET")
 	s.router.HandleFunc("/api/binaries", s.handleBinaries).Methods("GET")
+	s.router.HandleFunc("/api/hubs", s.handleHubs).Methods("GET")
+	s.router.HandleFunc("/api/orphans", s.handleOrphans).Methods("GET")
+	s.router.HandleFunc("/api/cycles/packages", s.handlePackageCycles).Methods("GET")
+	s.router.HandleFunc("/api/tags", s.handleTags).Methods("GET")
+	s.router.HandleFunc("/api/owners", s.handleOwners).Methods("GET")
+	s.router.HandleFunc("/api/files/cross-package", s.handleCrossPackageFiles).Methods("GET")
+	s.router.HandleFunc("/api/split-candidates", s.handleSplitCandidates).Methods("GET")
+	s.router.HandleFunc("/api/file/symbols", s.handleFileSymbols).Methods("GET")
+	s.router.HandleFunc("/api/file/includes", s.handleFileIncludes).Methods("GET")
+	s.router.HandleFunc("/api/symbols/unused", s.handleUnusedSymbols).Methods("GET")
+	s.router.HandleFunc("/api/package/{path:.+}/graph", s.handlePackageGraph).Methods("GET")
+	s.router.HandleFunc("/api/file/{path:.+}/focused", s.handleFileFocused).Methods("GET")
 	s.router.HandleFunc("/api/target/{label}/selected", s.handleTargetSelected).Methods("GET")
+	s.router.HandleFunc("/api/target/{label:.+}/file-deps", s.handleTargetFileDeps).Methods("GET")
 	s.router.HandleFunc("/api/logs", s.handleFrontendLogs).Methods("POST")
+	s.router.HandleFunc("/api/analyze", s.handleAnalyze).Methods("POST")
+	s.router.HandleFunc("/api/analyze/cancel", s.handleCancelAnalysis).Methods("POST")
+	s.router.HandleFunc("/api/state", s.handleState).Methods("GET")
+	s.router.HandleFunc("/api/history", s.handleHistory).Methods("GET")
+	s.router.HandleFunc("/api/debug/artifacts", s.handleDebugArtifacts).Methods("GET")
+
+	// Prometheus metrics
+	s.router.HandleFunc("/metrics", s.handleMetrics).Methods("GET")
 
 	// Serve static files
 	staticFS, err := fs.Sub(staticFiles, "static")
 	if err != nil {
 		logging.Fatal("failed to setup static file server", "error", err)
 	}
-	s.router.PathPrefix("/").Handler(http.FileServer(http.FS(staticFS)))
+	static, err := cachedStatic(staticFS)
+	if err != nil {
+		logging.Fatal("failed to setup static file server", "error", err)
+	}
+	s.router.PathPrefix("/").Handler(static)
 }
 
 func (s *Server) handleSubscribeWorkspaceStatus(w http.ResponseWriter, r *http.Request) {
+	s.streamEvents(w, r, pubsub.WorkspaceStatusTopic.Subscribe)
+}
+
+func (s *Server) handleSubscribeTargetGraph(w http.ResponseWriter, r *http.Request) {
+	s.streamEvents(w, r, pubsub.TargetGraphTopic.Subscribe)
+}
+
+func (s *Server) handleSubscribeChanges(w http.ResponseWriter, r *http.Request) {
+	s.streamEvents(w, r, pubsub.ChangesTopic.Subscribe)
+}
+
+// streamEvents streams the events of a subscription as server-sent events until the
+// client disconnects
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, subscribe func(context.Context, pubsub.Publisher) (pubsub.Subscription, error)) {
+	s.streamConvertedEvents(w, r, subscribe, nil)
+}
+
+// streamConvertedEvents streams the events of a subscription like streamEvents, but sends
+// each event as convert returns it, skipping those it returns false for. A nil convert
+// sends the events as they are.
+func (s *Server) streamConvertedEvents(w http.ResponseWriter, r *http.Request, subscribe func(context.Context, pubsub.Publisher) (pubsub.Subscription, error), convert func(pubsub.Event) (pubsub.Event, bool)) {
+	// Subscribe before the stream is opened, so that no event published after the client
+	// has seen the connection is missed
+	sub, err := subscribe(r.Context(), s.publisher)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = sub.Close() }()
+
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*") // CORS support
 
 	// Send initial comment to establish connection (Safari compatibility)
 	_, _ = fmt.Fprintf(w, ": connected\n\n")
@@ -239,16 +517,14 @@ func (s *Server) handleSubscribeWorkspaceStatus(w http.ResponseWriter, r *http.R
 		flusher.Flush()
 	}
 
-	// Create subscription
-	sub, err := s.publisher.Subscribe(r.Context(), "workspace_status")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer func() { _ = sub.Close() }()
-
 	// Stream events
 	for event := range sub.Events() {
+		if convert != nil {
+			var ok bool
+			if event, ok = convert(event); !ok {
+				continue
+			}
+		}
 		if err := pubsub.WriteSSE(w, event); err != nil {
 			logging.WarnContext(r.Context(), "SSE write failed", "error", err)
 			return
@@ -259,63 +535,235 @@ func (s *Server) handleSubscribeWorkspaceStatus(w http.ResponseWriter, r *http.R
 	}
 }
 
-func (s *Server) handleSubscribeTargetGraph(w http.ResponseWriter, r *http.Request) {
-	// Set SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*") // CORS support
+// ErrAnalysisRunning is returned by an Analyzer asked to start while an analysis is running
+var ErrAnalysisRunning = errors.New("analysis already running")
 
-	// Send initial comment to establish connection (Safari compatibility)
-	_, _ = fmt.Fprintf(w, ": connected\n\n")
-	if flusher, ok := w.(http.Flusher); ok {
-		flusher.Flush()
+// Analysis states reported by GET /api/state
+const (
+	AnalysisIdle    = "idle"
+	AnalysisRunning = "running"
+)
+
+// AnalysisState describes whether an analysis is running and how the last one ended
+type AnalysisState struct {
+	State       string    `json:"state"`               // AnalysisIdle or AnalysisRunning
+	Reason      string    `json:"reason,omitempty"`    // Reason of the running or last analysis
+	StartedAt   time.Time `json:"startedAt"`           // When the running or last analysis started
+	CompletedAt time.Time `json:"completedAt"`         // When the last analysis ended, zero if none has
+	LastError   string    `json:"lastError,omitempty"` // Error of the last analysis, empty if it succeeded
+
+	Error   *pubsub.AnalysisError `json:"error,omitempty"`   // Phase and details of LastError, if a phase failed
+	Timings []pubsub.PhaseTiming  `json:"timings,omitempty"` // Duration of each phase of the running or last analysis
+
+	Warnings []string `json:"warnings,omitempty"` // Why the last analysis may be incomplete, e.g. no build outputs
+}
+
+// Analyzer runs and controls analyses on request of the web UI. It is implemented by
+// analysis.AnalysisRunner, which depends on this package and so cannot be imported here.
+type Analyzer interface {
+	// StartFullAnalysis starts a full analysis in the background. Progress is published
+	// on the workspace_status topic. Returns ErrAnalysisRunning if one is already running.
+	StartFullAnalysis(reason string) error
+
+	// CancelAnalysis cancels the running analysis. Returns false if none is running.
+	CancelAnalysis() bool
+
+	// AnalysisState reports whether an analysis is running and how the last one ended
+	AnalysisState() AnalysisState
+}
+
+// getAnalyzer returns the registered analyzer, writing a 503 response if there is none
+func (s *Server) getAnalyzer(w http.ResponseWriter) Analyzer {
+	s.mu.RLock()
+	analyzer := s.analyzer
+	s.mu.RUnlock()
+
+	if analyzer == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(&AnalyzeResponse{
+			State:   "unavailable",
+			Message: "Analysis cannot be controlled from the web UI",
+		})
 	}
+	return analyzer
+}
 
-	// Create subscription
-	sub, err := s.publisher.Subscribe(r.Context(), "target_graph")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// handleMetrics reports publisher statistics in the Prometheus text exposition format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	provider, ok := s.publisher.(pubsub.StatsProvider)
+	if !ok {
 		return
 	}
-	defer func() { _ = sub.Close() }()
+	stats := provider.Stats()
 
-	// Stream events
-	for event := range sub.Events() {
-		if err := pubsub.WriteSSE(w, event); err != nil {
-			logging.WarnContext(r.Context(), "SSE write failed", "error", err)
-			return
-		}
-		if flusher, ok := w.(http.Flusher); ok {
-			flusher.Flush()
+	topics := make([]string, 0, len(stats))
+	for topic := range stats {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	metrics := []struct {
+		name, kind, help string
+		value            func(pubsub.TopicStats) int
+	}{
+		{"deps_analyzer_pubsub_subscribers", "gauge", "Current number of subscribers per topic.",
+			func(ts pubsub.TopicStats) int { return ts.Subscribers }},
+		{"deps_analyzer_pubsub_published_total", "counter", "Events published per topic.",
+			func(ts pubsub.TopicStats) int { return ts.Published }},
+		{"deps_analyzer_pubsub_dropped_total", "counter", "Events not delivered to a slow subscriber per topic.",
+			func(ts pubsub.TopicStats) int { return ts.Dropped }},
+	}
+
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		for _, topic := range topics {
+			fmt.Fprintf(&b, "%s{topic=%q} %d\n", m.name, topic, m.value(stats[topic]))
 		}
 	}
+	if _, err := w.Write([]byte(b.String())); err != nil {
+		logging.ErrorContext(r.Context(), "failed to write metrics", "error", err)
+	}
+}
+
+// handleState reports the state of the analysis runner
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	analyzer := s.getAnalyzer(w)
+	if analyzer == nil {
+		return
+	}
+
+	state := analyzer.AnalysisState()
+	if err := json.NewEncoder(w).Encode(&state); err != nil {
+		logging.ErrorContext(r.Context(), "failed to encode analysis state", "error", err)
+	}
+}
+
+// handleCancelAnalysis cancels the running analysis. It responds 202 when cancellation
+// was requested and 409 when no analysis is running.
+func (s *Server) handleCancelAnalysis(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	analyzer := s.getAnalyzer(w)
+	if analyzer == nil {
+		return
+	}
+
+	if !analyzer.CancelAnalysis() {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(&AnalyzeResponse{
+			State:   AnalysisIdle,
+			Message: "No analysis is running",
+		})
+		return
+	}
+
+	logging.InfoContext(r.Context(), "analysis cancellation requested")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(&AnalyzeResponse{
+		State:   "cancelling",
+		Message: "Analysis will stop after the current phase",
+	})
+}
+
+// AnalyzeResponse is the response of POST /api/analyze and /api/analyze/cancel
+type AnalyzeResponse struct {
+	State   string `json:"state"`   // "analyzing", "cancelling", "idle" or "unavailable"
+	Message string `json:"message"` // Human-readable explanation
+}
+
+// handleAnalyze starts a full re-analysis. It responds 202 when the analysis was started,
+// 409 when one is already running and 503 when no analyzer is registered.
+func (s *Server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	analyzer := s.getAnalyzer(w)
+	if analyzer == nil {
+		return
+	}
+
+	err := analyzer.StartFullAnalysis("manual re-analysis")
+	switch {
+	case errors.Is(err, ErrAnalysisRunning):
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(&AnalyzeResponse{
+			State:   "analyzing",
+			Message: "An analysis is already running",
+		})
+	case err != nil:
+		logging.ErrorContext(r.Context(), "failed to start analysis", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		logging.InfoContext(r.Context(), "manual re-analysis requested")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(&AnalyzeResponse{
+			State:   "analyzing",
+			Message: "Analysis started",
+		})
+	}
+}
+
+// NotReadyResponse is returned with 503 by the module and graph endpoints while the
+// initial analysis has not completed, so clients can tell "analyzing" from an empty workspace
+type NotReadyResponse struct {
+	State   string `json:"state"`   // Always "analyzing"
+	Message string `json:"message"` // Human-readable explanation
+}
+
+// writeNotReady responds that no analysis data is available yet
+func writeNotReady(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Analysis-State", "analyzing")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_ = json.NewEncoder(w).Encode(&NotReadyResponse{
+		State:   "analyzing",
+		Message: "Analysis has not completed yet",
+	})
 }
 
 func (s *Server) handleModule(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if s.module == nil {
-		http.Error(w, "Module data not available", http.StatusServiceUnavailable)
+	s.mu.RLock()
+	module := s.module
+	var data []byte
+	var err error
+	if module != nil {
+		data, err = json.Marshal(module)
+	}
+	s.mu.RUnlock()
+
+	if module == nil {
+		writeNotReady(w)
+		return
+	}
+	if err != nil {
+		logging.ErrorContext(r.Context(), "failed to encode module", "error", err)
+		http.Error(w, "failed to encode module", http.StatusInternalServerError)
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(s.module)
+	// The ETag changes whenever an analysis changes the module
+	writeCached(w, r, append(data, '\n'))
 }
 
 func (s *Server) handleModuleGraph(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if s.module == nil {
-		_ = json.NewEncoder(w).Encode(&GraphData{
-			Nodes: []GraphNode{},
-			Edges: []GraphEdge{},
-		})
+		writeNotReady(w)
 		return
 	}
 
 	// Build target-level graph from module with file-level details
-	graphData := buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries)
+	graphData := buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries, s.targetMetrics(), s.extensions)
+	if wantRanks(r) {
+		assignRanks(graphData)
+	}
 	_ = json.NewEncoder(w).Encode(graphData)
 }
 
@@ -333,19 +781,351 @@ func (s *Server) handleBinaries(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(s.binaries)
 }
 
+func (s *Server) handleHubs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		http.Error(w, "Module data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(model.Hubs(s.targetMetrics()))
+}
+
+// handleOrphans lists cc_library targets that nothing depends on.
+// Public libraries are treated as entry points unless publicAsRoots=false is given.
+func (s *Server) handleOrphans(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		http.Error(w, "Module data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	publicAsRoots := r.URL.Query().Get("publicAsRoots") != "false"
+	_ = json.NewEncoder(w).Encode(s.module.OrphanTargets(publicAsRoots))
+}
+
+// handlePackageCycles lists the package dependency cycles (see
+// model.Module.FindPackageCycles), over the comma-separated dependency types of the types
+// parameter (e.g. "static,dynamic"), or all types if it is missing
+func (s *Server) handlePackageCycles(w http.ResponseWriter, r *http.Request) {
+	var types []model.DependencyType
+	if param := r.URL.Query().Get("types"); param != "" {
+		for _, name := range strings.Split(param, ",") {
+			depType := model.DependencyType(strings.TrimSpace(name))
+			switch depType {
+			case model.DependencyStatic, model.DependencyDynamic, model.DependencyData, model.DependencyCompile, model.DependencySymbol:
+				types = append(types, depType)
+			default:
+				http.Error(w, "Unknown dependency type: "+string(depType), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		http.Error(w, "Module data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(s.module.FindPackageCycles(types...))
+}
+
+// handleTags lists the targets carrying each Bazel tag. With prefix (e.g. "team:") only
+// tags starting with it are listed, grouping the targets by team or category.
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		http.Error(w, "Module data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	tags := s.module.TargetsByTag()
+	for tag := range tags {
+		if !strings.HasPrefix(tag, prefix) {
+			delete(tags, tag)
+		}
+	}
+	_ = json.NewEncoder(w).Encode(tags)
+}
+
+// handleOwners summarizes the targets of each CODEOWNERS owner and the dependencies
+// between targets of different owners
+func (s *Server) handleOwners(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		http.Error(w, "Module data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(owners.Summarize(s.module))
+}
+
+// handleCrossPackageFiles lists the compile-time file dependencies that cross package boundaries,
+// explaining why one package depends on another at compile time
+func (s *Server) handleCrossPackageFiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.fileDeps == nil {
+		http.Error(w, "File dependency data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	fileGraph := graph.BuildFileGraph(s.fileDeps)
+	_ = json.NewEncoder(w).Encode(graph.FindCrossPackageDepsWithTargets(fileGraph, s.fileToTarget))
+}
+
+// handleSplitCandidates lists the targets whose files form groups without compile
+// dependencies between them. Targets with fewer than minFiles files (default
+// graph.DefaultSplitMinFiles) are skipped. These are informational findings.
+func (s *Server) handleSplitCandidates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	minFiles := graph.DefaultSplitMinFiles
+	if value := r.URL.Query().Get("minFiles"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			http.Error(w, fmt.Sprintf("Invalid minFiles: %s", value), http.StatusBadRequest)
+			return
+		}
+		minFiles = n
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.fileDeps == nil {
+		http.Error(w, "File dependency data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	fileGraph := graph.BuildFileGraph(s.fileDeps)
+	_ = json.NewEncoder(w).Encode(graph.FindSplitCandidates(fileGraph, s.fileToTarget, minFiles))
+}
+
+// handleFileSymbols returns the symbols defined and used by a single file's object,
+// with the file and target that resolve each undefined symbol
+func (s *Server) handleFileSymbols(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	file := r.URL.Query().Get("file")
+	if file == "" {
+		http.Error(w, "Missing file parameter", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fileSymbols, ok := s.fileSymbols[file]
+	if !ok {
+		http.Error(w, "No object file analyzed for "+file, http.StatusNotFound)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(fileSymbols)
+}
+
+// FileIncludes lists the workspace headers a source file depends on according to its .d
+// file. Direct and Transitive are null unless direct includes were determined
+// (--direct-includes).
+type FileIncludes struct {
+	File         string   `json:"file"`
+	Dependencies []string `json:"dependencies"` // All headers, sorted
+	Direct       []string `json:"direct"`       // Headers the file #includes itself
+	Transitive   []string `json:"transitive"`   // Headers only included through other headers
+}
+
+// handleFileIncludes returns the header dependencies of a source file, split into direct
+// and transitive includes when known
+func (s *Server) handleFileIncludes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	file := r.URL.Query().Get("file")
+	if file == "" {
+		http.Error(w, "Missing file parameter", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.fileDeps == nil {
+		http.Error(w, "Compile dependency data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	for _, fileDep := range s.fileDeps {
+		if fileDep.SourceFile != file {
+			continue
+		}
+
+		includes := FileIncludes{File: file, Dependencies: sortedUnique(fileDep.Dependencies)}
+		if fileDep.DirectDependencies != nil {
+			includes.Direct = []string{}
+			includes.Transitive = []string{}
+			for _, dep := range includes.Dependencies {
+				if fileDep.IsDirect(dep) {
+					includes.Direct = append(includes.Direct, dep)
+				} else {
+					includes.Transitive = append(includes.Transitive, dep)
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(includes)
+		return
+	}
+
+	http.Error(w, "No .d file parsed for "+file, http.StatusNotFound)
+}
+
+// handleUnusedSymbols lists the dead-code candidates: global symbols defined in the
+// workspace that no analyzed object references. These are informational findings.
+func (s *Server) handleUnusedSymbols(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.unusedSymbols == nil {
+		http.Error(w, "Symbol data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(s.unusedSymbols)
+}
+
+// handlePackageGraph returns the lens-rendered graph of a single package: its targets,
+// the edges between them and the edges to directly connected targets in other packages
+func (s *Server) handlePackageGraph(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		http.Error(w, "Module data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	packagePath := "//" + strings.Trim(strings.TrimPrefix(mux.Vars(r)["path"], "//"), "/")
+	if !s.hasPackage(packagePath) {
+		http.Error(w, fmt.Sprintf("Package not found: %s", packagePath), http.StatusNotFound)
+		return
+	}
+
+	rawGraphData := buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries, s.targetMetrics(), s.extensions)
+
+	packageLens := lens.PackageLens(packagePath)
+	renderedGraph, err := lens.RenderGraph(convertToLensGraphData(rawGraphData), packageLens, packageLens, []string{packagePath}, lens.FocusUnion)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Lens rendering failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(convertFromLensGraphData(renderedGraph, rawGraphData))
+}
+
+// handleFileFocused returns the lens-rendered graph around a single source file: the file,
+// its target, and the files it includes, is included by or shares symbols with
+func (s *Server) handleFileFocused(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.module == nil {
+		http.Error(w, "Module data not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	filePath := strings.TrimPrefix(mux.Vars(r)["path"], "/")
+	fileID := s.fileNodeID(filePath)
+	if fileID == "" {
+		http.Error(w, fmt.Sprintf("File not found: %s", filePath), http.StatusNotFound)
+		return
+	}
+
+	rawGraphData := buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries, s.targetMetrics(), s.extensions)
+
+	fileLens := lens.FileLens(filePath)
+	renderedGraph, err := lens.RenderGraph(convertToLensGraphData(rawGraphData), fileLens, fileLens, []string{fileID}, lens.FocusUnion)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Lens rendering failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(convertFromLensGraphData(renderedGraph, rawGraphData))
+}
+
+// fileNodeID returns the ID of a file's node in the module graph, or "" if the file is
+// neither owned by a target nor uncovered
+func (s *Server) fileNodeID(filePath string) string {
+	if target, ok := s.fileToTarget[filePath]; ok {
+		return target + ":" + filePath
+	}
+	if slices.Contains(s.uncoveredFiles, filePath) {
+		return "uncovered:" + filePath
+	}
+	return ""
+}
+
+// hasPackage reports whether the package contains any targets or uncovered files
+func (s *Server) hasPackage(packagePath string) bool {
+	for _, target := range s.module.Targets {
+		if target.Package == packagePath {
+			return true
+		}
+	}
+
+	dir := strings.TrimPrefix(packagePath, "//") + "/"
+	for _, file := range s.uncoveredFiles {
+		if strings.HasPrefix(file, dir) && !strings.Contains(strings.TrimPrefix(file, dir), "/") {
+			return true
+		}
+	}
+	return false
+}
+
 // LensRenderRequest represents the request body for lens rendering
 type LensRenderRequest struct {
 	DefaultLens   *lens.LensConfig `json:"defaultLens"`
 	DetailLens    *lens.LensConfig `json:"detailLens"`
 	SelectedNodes []string         `json:"selectedNodes"`
+	FocusMode     lens.FocusMode   `json:"focusMode,omitempty"`    // How multiple selected nodes combine: "union" (default) or "intersection"
 	PreviousHash  string           `json:"previousHash,omitempty"` // Hash of previous graph for diffing
 }
 
 // LensRenderResponse represents the response from lens rendering
 type LensRenderResponse struct {
-	Hash      string     `json:"hash"`                // Hash of this graph state
-	FullGraph *GraphData `json:"fullGraph,omitempty"` // Complete graph (if no previousHash or diff too large)
-	Diff      *GraphDiff `json:"diff,omitempty"`      // Incremental changes (if previousHash provided)
+	Hash        string     `json:"hash"`                // Hash of this graph state
+	RequestHash string     `json:"requestHash"`         // Hash of the lens request, for /api/subscribe/graph_diff
+	FullGraph   *GraphData `json:"fullGraph,omitempty"` // Complete graph (if no previousHash or diff too large)
+	Diff        *GraphDiff `json:"diff,omitempty"`      // Incremental changes (if previousHash provided)
 }
 
 // GraphDiff represents incremental changes to a graph
@@ -354,17 +1134,14 @@ type GraphDiff struct {
 	RemovedNodes  []string    `json:"removedNodes,omitempty"` // Node IDs
 	ModifiedNodes []GraphNode `json:"modifiedNodes,omitempty"`
 	AddedEdges    []GraphEdge `json:"addedEdges,omitempty"`
-	RemovedEdges  []string    `json:"removedEdges,omitempty"` // Edge keys (source|target|type)
+	RemovedEdges  []string    `json:"removedEdges,omitempty"` // Edge IDs
 }
 
 func (s *Server) handleModuleGraphWithLens(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if s.module == nil {
-		_ = json.NewEncoder(w).Encode(&LensRenderResponse{
-			Hash:      "",
-			FullGraph: &GraphData{Nodes: []GraphNode{}, Edges: []GraphEdge{}},
-		})
+		writeNotReady(w)
 		return
 	}
 
@@ -380,9 +1157,13 @@ func (s *Server) handleModuleGraphWithLens(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "Missing required lens configurations", http.StatusBadRequest)
 		return
 	}
+	if req.FocusMode != "" && req.FocusMode != lens.FocusUnion && req.FocusMode != lens.FocusIntersection {
+		http.Error(w, fmt.Sprintf("Invalid focus mode: %s", req.FocusMode), http.StatusBadRequest)
+		return
+	}
 
 	// Compute request hash for cache lookup
-	requestHash := lens.ComputeHash(req.DefaultLens, req.DetailLens, req.SelectedNodes)
+	requestHash := lens.ComputeHash(req.DefaultLens, req.DetailLens, req.SelectedNodes, req.FocusMode)
 
 	// Check cache first (before rendering)
 	s.mu.Lock()
@@ -411,34 +1192,40 @@ func (s *Server) handleModuleGraphWithLens(w http.ResponseWriter, r *http.Reques
 
 		for _, edge := range cachedSnapshot.Edges {
 			cachedGraphData.Edges = append(cachedGraphData.Edges, GraphEdge{
+				ID:     edge.ID,
 				Source: edge.Source,
 				Target: edge.Target,
 				Type:   edge.Type,
 			})
 		}
 
+		if wantRanks(r) {
+			assignRanks(cachedGraphData)
+		}
 		_ = json.NewEncoder(w).Encode(&LensRenderResponse{
-			Hash:      requestHash,
-			FullGraph: cachedGraphData,
+			Hash:        requestHash,
+			RequestHash: requestHash,
+			FullGraph:   cachedGraphData,
 		})
 		return
 	}
 
 	// Build raw graph data
-	rawGraphData := buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries)
-
-	// Convert web.GraphData to lens.GraphData
-	lensGraphData := convertToLensGraphData(rawGraphData)
+	rawGraphData := buildModuleGraphData(s.module, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.binaries, s.targetMetrics(), s.extensions)
 
 	// Apply lens rendering
-	renderedGraph, err := lens.RenderGraph(lensGraphData, req.DefaultLens, req.DetailLens, req.SelectedNodes)
+	resultGraphData, err := renderLensGraph(&req, rawGraphData)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Lens rendering failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Convert lens.GraphData back to web.GraphData
-	resultGraphData := convertFromLensGraphData(renderedGraph, rawGraphData)
+	// Ranks of unchanged nodes shift when nodes are added or removed, so a diff cannot
+	// carry them: send the full graph instead
+	withRanks := wantRanks(r)
+	if withRanks {
+		assignRanks(resultGraphData)
+	}
 
 	// TEMPORARY DEBUG: Log package labels being sent to frontend
 	if len(req.SelectedNodes) > 0 {
@@ -473,55 +1260,18 @@ func (s *Server) handleModuleGraphWithLens(w http.ResponseWriter, r *http.Reques
 		logging.DebugContext(r.Context(), "no previousHash provided in request")
 	}
 
-	// Store new snapshot in cache
+	// Store new snapshot in cache, and the request for re-rendering it on re-analysis
 	s.lensCache[requestHash] = newSnapshot
+	subscribed := req
+	subscribed.PreviousHash = ""
+	s.lensRequests[requestHash] = subscribed
 	logging.DebugContext(r.Context(), "stored snapshot in cache", "requestHash", requestHash[:12], "cacheSize", len(s.lensCache))
 
-	// Compute diff if we have a previous snapshot
-	if previousSnapshot != nil {
-		lensDiff := lens.ComputeDiff(previousSnapshot, convertToLensGraphData(resultGraphData))
-
-		// Convert lens diff to web diff
-		webDiff := &GraphDiff{
-			AddedNodes:    convertLensNodesToWeb(lensDiff.AddedNodes, rawGraphData),
-			RemovedNodes:  lensDiff.RemovedNodes,
-			ModifiedNodes: convertLensNodesToWeb(lensDiff.ModifiedNodes, rawGraphData),
-			AddedEdges:    convertLensEdgesToWeb(lensDiff.AddedEdges, rawGraphData),
-			RemovedEdges:  lensDiff.RemovedEdges,
-		}
-
-		// Calculate diff size
-		diffSize := len(webDiff.AddedNodes) + len(webDiff.RemovedNodes) + len(webDiff.ModifiedNodes) +
-			len(webDiff.AddedEdges) + len(webDiff.RemovedEdges)
-		fullSize := len(resultGraphData.Nodes) + len(resultGraphData.Edges)
-
-		// If diff is larger than 50% of full graph, send full graph instead
-		if diffSize > fullSize/2 {
-			logging.DebugContext(r.Context(), "diff too large, sending full graph", "diffSize", diffSize, "fullSize", fullSize)
-			_ = json.NewEncoder(w).Encode(&LensRenderResponse{
-				Hash:      newSnapshot.Hash,
-				FullGraph: resultGraphData,
-			})
-		} else {
-			logging.DebugContext(r.Context(), "sending diff",
-				"addedNodes", len(webDiff.AddedNodes),
-				"removedNodes", len(webDiff.RemovedNodes),
-				"modifiedNodes", len(webDiff.ModifiedNodes),
-				"addedEdges", len(webDiff.AddedEdges),
-				"removedEdges", len(webDiff.RemovedEdges))
-			_ = json.NewEncoder(w).Encode(&LensRenderResponse{
-				Hash: newSnapshot.Hash,
-				Diff: webDiff,
-			})
-		}
-	} else {
-		// No previous snapshot, send full graph
-		logging.InfoContext(r.Context(), "sending full graph", "nodes", len(resultGraphData.Nodes), "edges", len(resultGraphData.Edges))
-		_ = json.NewEncoder(w).Encode(&LensRenderResponse{
-			Hash:      newSnapshot.Hash,
-			FullGraph: resultGraphData,
-		})
+	// Ranks cannot be diffed, see above
+	if withRanks {
+		previousSnapshot = nil
 	}
+	_ = json.NewEncoder(w).Encode(lensGraphUpdate(r.Context(), previousSnapshot, requestHash, newSnapshot.Hash, resultGraphData, rawGraphData))
 }
 
 func (s *Server) handleTargetSelected(w http.ResponseWriter, r *http.Request) {
@@ -540,10 +1290,8 @@ func (s *Server) handleTargetSelected(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Ensure label starts with //
-	if !strings.HasPrefix(targetLabel, "//") {
-		targetLabel = "//" + targetLabel
-	}
+	// Accept short forms such as "util" or "//util" for "//util:util"
+	targetLabel = model.CanonicalizeLabel(targetLabel)
 
 	// Find the target
 	target, exists := s.module.Targets[targetLabel]
@@ -553,7 +1301,7 @@ func (s *Server) handleTargetSelected(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Build selected target graph data with file-level dependencies
-	graphData := buildTargetSelectedGraph(s.module, target, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles)
+	graphData := buildTargetSelectedGraph(s.module, target, s.fileDeps, s.symbolDeps, s.fileToTarget, s.uncoveredFiles, s.extensions)
 	_ = json.NewEncoder(w).Encode(graphData)
 }
 
@@ -614,7 +1362,7 @@ func (s *Server) handleFrontendLogs(w http.ResponseWriter, r *http.Request) {
 // This would show files within a target and their compile-time dependencies to other targets
 
 // buildModuleGraphData creates a graph visualization from the Module model
-func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency, symbolDeps []symbols.SymbolDependency, fileToTarget map[string]string, uncoveredFiles []string, binaryList []*binaries.BinaryInfo) *GraphData {
+func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency, symbolDeps []symbols.SymbolDependency, fileToTarget map[string]string, uncoveredFiles []string, binaryList []*binaries.BinaryInfo, metrics map[string]*model.TargetMetrics, ext model.FileExtensions) *GraphData {
 	graphData := &GraphData{
 		Nodes: make([]GraphNode, 0),
 		Edges: make([]GraphEdge, 0),
@@ -630,10 +1378,21 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 	// Create nodes for all targets
 	for _, target := range module.Targets {
 		node := GraphNode{
-			ID:       target.Label,
-			Label:    target.Label,
-			Type:     string(target.Kind),
-			IsPublic: target.IsPublic(),
+			ID:         target.Label,
+			Label:      target.Label,
+			Type:       string(target.Kind),
+			IsPublic:   target.IsPublic(),
+			Tags:       target.Tags,
+			Owner:      target.Owner,
+			HeaderOnly: target.HeaderOnly,
+			External:   target.External,
+		}
+		if m, ok := metrics[target.Label]; ok {
+			node.InDegree = m.InDegree
+			node.OutDegree = m.OutDegree
+			node.TransitiveRdeps = m.TransitiveRdeps
+			node.IsHub = m.IsHub
+			node.IsGodObject = m.IsGodObject
 		}
 
 		// Populate LDD dependencies if available
@@ -679,7 +1438,7 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 
 		// Determine file type
 		fileType := "source_file"
-		if strings.HasSuffix(filePath, ".h") || strings.HasSuffix(filePath, ".hpp") {
+		if ext.IsHeader(filePath) {
 			fileType = "header_file"
 		}
 
@@ -713,12 +1472,14 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 
 				// Create edge from source file to dependency file
 				graphData.Edges = append(graphData.Edges, GraphEdge{
+					ID:     lens.EdgeID(sourceFileID, targetFileID, string(model.DependencyCompile)),
 					Source: sourceFileID,
 					Target: targetFileID,
 					Type:   string(model.DependencyCompile),
 					FileDetails: map[string]string{
 						sourceFileName: targetFileName,
 					},
+					Transitive: !fileDep.IsDirect(depFile),
 				})
 			}
 		}
@@ -741,13 +1502,24 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 			symbolsByFilePair[key] = append(symbolsByFilePair[key], symDep.Symbol)
 		}
 
-		// Create edges with aggregated symbols
-		for key, symbols := range symbolsByFilePair {
+		// Create edges with aggregated symbols, in a stable order
+		keys := make([]fileEdgeKey, 0, len(symbolsByFilePair))
+		for key := range symbolsByFilePair {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].sourceFile != keys[j].sourceFile {
+				return keys[i].sourceFile < keys[j].sourceFile
+			}
+			return keys[i].targetFile < keys[j].targetFile
+		})
+		for _, key := range keys {
 			graphData.Edges = append(graphData.Edges, GraphEdge{
+				ID:      lens.EdgeID(key.sourceFile, key.targetFile, string(model.DependencySymbol)),
 				Source:  key.sourceFile,
 				Target:  key.targetFile,
 				Type:    string(model.DependencySymbol),
-				Symbols: symbols,
+				Symbols: sortedUnique(symbolsByFilePair[key]),
 			})
 		}
 	}
@@ -755,22 +1527,27 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 	// Track system libraries to avoid duplicates
 	systemLibs := make(map[string]bool)
 
+	// Binaries, shared libraries and tests link the system libraries of their whole static
+	// closure, libraries only declare their own
+	linkedSystemLibs := module.LinkedSystemLibraries()
+	systemLibrariesOf := func(target *model.Target) []string {
+		if target.HasLinkLine() {
+			return linkedSystemLibs[target.Label]
+		}
+		return target.DeclaredSystemLibraries()
+	}
+
 	// Add system library nodes and edges from linkopts
 	for _, target := range module.Targets {
-		if len(target.Linkopts) > 0 {
-			for _, linkopt := range target.Linkopts {
-				if strings.HasPrefix(linkopt, "-l") {
-					libName := strings.TrimPrefix(linkopt, "-l")
-					if libName != "" && !systemLibs[libName] {
-						systemLibs[libName] = true
-						// Add system library node
-						graphData.Nodes = append(graphData.Nodes, GraphNode{
-							ID:    "system:" + libName,
-							Label: libName,
-							Type:  "system_library",
-						})
-					}
-				}
+		for _, libName := range systemLibrariesOf(target) {
+			if !systemLibs[libName] {
+				systemLibs[libName] = true
+				// Add system library node
+				graphData.Nodes = append(graphData.Nodes, GraphNode{
+					ID:    "system:" + libName,
+					Label: libName,
+					Type:  "system_library",
+				})
 			}
 		}
 	}
@@ -830,7 +1607,7 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 		if details, exists := edgeDetails[key]; exists {
 			for sourceFile, targetFiles := range details {
 				// Store as "source.cc" -> "header1.h, header2.h"
-				fileDetailsMap[sourceFile] = strings.Join(targetFiles, ", ")
+				fileDetailsMap[sourceFile] = strings.Join(sortedUnique(targetFiles), ", ")
 			}
 		}
 
@@ -840,9 +1617,11 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 			for sym := range symMap {
 				symbols = append(symbols, sym)
 			}
+			sort.Strings(symbols)
 		}
 
 		graphData.Edges = append(graphData.Edges, GraphEdge{
+			ID:          lens.EdgeID(dep.From, dep.To, string(dep.Type)),
 			Source:      dep.From,
 			Target:      dep.To,
 			Type:        string(dep.Type),
@@ -855,23 +1634,17 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 
 	// Add edges from targets to their system libraries
 	for _, target := range module.Targets {
-		if len(target.Linkopts) > 0 {
-			for _, linkopt := range target.Linkopts {
-				if strings.HasPrefix(linkopt, "-l") {
-					libName := strings.TrimPrefix(linkopt, "-l")
-					if libName != "" {
-						graphData.Edges = append(graphData.Edges, GraphEdge{
-							Source:      target.Label,
-							Target:      "system:" + libName,
-							Type:        "system_link",
-							Linkage:     "system",
-							Symbols:     []string{},
-							SourceLabel: target.Label,
-							TargetLabel: libName, // Just the library name for display
-						})
-					}
-				}
-			}
+		for _, libName := range systemLibrariesOf(target) {
+			graphData.Edges = append(graphData.Edges, GraphEdge{
+				ID:          lens.EdgeID(target.Label, "system:"+libName, "system_link"),
+				Source:      target.Label,
+				Target:      "system:" + libName,
+				Type:        "system_link",
+				Linkage:     "system",
+				Symbols:     []string{},
+				SourceLabel: target.Label,
+				TargetLabel: libName, // Just the library name for display
+			})
 		}
 	}
 
@@ -918,7 +1691,7 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 		for _, uncoveredFile := range uncoveredFiles {
 			// Determine if source or header
 			nodeType := "uncovered_source"
-			if strings.HasSuffix(uncoveredFile, ".h") || strings.HasSuffix(uncoveredFile, ".hpp") {
+			if ext.IsHeader(uncoveredFile) {
 				nodeType = "uncovered_header"
 			}
 
@@ -966,6 +1739,7 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 				}
 
 				graphData.Edges = append(graphData.Edges, GraphEdge{
+					ID:          lens.EdgeID(bin.Label, targetID, "dynamic"),
 					Source:      bin.Label,
 					Target:      targetID,
 					Type:        "dynamic", // New edge type for LDD
@@ -985,7 +1759,7 @@ func buildModuleGraphData(module *model.Module, fileDeps []*deps.FileDependency,
 // - Outgoing dependencies (targets this one depends on) with their files
 // - All compile-time and link-time dependencies between files and targets
 // - Uncovered files in the selected target's package
-func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target, fileDeps []*deps.FileDependency, symbolDeps []symbols.SymbolDependency, fileToTarget map[string]string, uncoveredFiles []string) *GraphData {
+func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target, fileDeps []*deps.FileDependency, symbolDeps []symbols.SymbolDependency, fileToTarget map[string]string, uncoveredFiles []string, ext model.FileExtensions) *GraphData {
 	graphData := &GraphData{
 		Nodes: make([]GraphNode, 0),
 		Edges: make([]GraphEdge, 0),
@@ -1049,6 +1823,7 @@ func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target
 			targetID := "parent-" + dep.To
 
 			graphData.Edges = append(graphData.Edges, GraphEdge{
+				ID:          lens.EdgeID(sourceID, targetID, string(dep.Type)),
 				Source:      sourceID,
 				Target:      targetID,
 				Type:        string(dep.Type),
@@ -1060,33 +1835,32 @@ func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target
 		}
 	}
 
-	// Add system library nodes and edges for the selected target
-	if len(selectedTarget.Linkopts) > 0 {
-		for _, linkopt := range selectedTarget.Linkopts {
-			if strings.HasPrefix(linkopt, "-l") {
-				libName := strings.TrimPrefix(linkopt, "-l")
-				if libName != "" {
-					// Add system library node
-					libNodeID := "system:" + libName
-					graphData.Nodes = append(graphData.Nodes, GraphNode{
-						ID:    libNodeID,
-						Label: libName,
-						Type:  "system_library",
-					})
+	// Add system library nodes and edges for the selected target, including those of its
+	// static closure if it is linked on its own
+	selectedSystemLibs := selectedTarget.DeclaredSystemLibraries()
+	if selectedTarget.HasLinkLine() {
+		selectedSystemLibs = module.SystemLibraries(selectedTarget.Label)
+	}
+	for _, libName := range selectedSystemLibs {
+		// Add system library node
+		libNodeID := "system:" + libName
+		graphData.Nodes = append(graphData.Nodes, GraphNode{
+			ID:    libNodeID,
+			Label: libName,
+			Type:  "system_library",
+		})
 
-					// Add edge from selected target to system library
-					graphData.Edges = append(graphData.Edges, GraphEdge{
-						Source:      "parent-" + selectedTarget.Label,
-						Target:      libNodeID,
-						Type:        "system_link",
-						Linkage:     "system",
-						Symbols:     []string{},
-						SourceLabel: selectedTarget.Label,
-						TargetLabel: libName,
-					})
-				}
-			}
-		}
+		// Add edge from selected target to system library
+		graphData.Edges = append(graphData.Edges, GraphEdge{
+			ID:          lens.EdgeID("parent-"+selectedTarget.Label, libNodeID, "system_link"),
+			Source:      "parent-" + selectedTarget.Label,
+			Target:      libNodeID,
+			Type:        "system_link",
+			Linkage:     "system",
+			Symbols:     []string{},
+			SourceLabel: selectedTarget.Label,
+			TargetLabel: libName,
+		})
 	}
 
 	// Add file-to-file edges from compile dependencies (.d files)
@@ -1147,6 +1921,7 @@ func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target
 
 				// Add compile dependency edge between files
 				graphData.Edges = append(graphData.Edges, GraphEdge{
+					ID:          lens.EdgeID(sourceFileID, targetFileID, "compile"),
 					Source:      sourceFileID,
 					Target:      targetFileID,
 					Type:        "compile",
@@ -1154,6 +1929,7 @@ func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target
 					Symbols:     []string{},
 					SourceLabel: getFileName(sourceOriginal),
 					TargetLabel: getFileName(depOriginal),
+					Transitive:  !fileDep.IsDirect(depFile),
 				})
 			}
 		}
@@ -1205,6 +1981,7 @@ func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target
 		edge, exists := symbolEdges[key]
 		if !exists {
 			edge = &GraphEdge{
+				ID:          lens.EdgeID(sourceFileID, targetFileID, "symbol"),
 				Source:      sourceFileID,
 				Target:      targetFileID,
 				Type:        "symbol",
@@ -1229,8 +2006,23 @@ func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target
 		}
 	}
 
-	// Add deduplicated symbol edges to graph
+	// Add deduplicated symbol edges to graph, in a stable order
+	symbolEdgeList := make([]*GraphEdge, 0, len(symbolEdges))
 	for _, edge := range symbolEdges {
+		sort.Strings(edge.Symbols)
+		symbolEdgeList = append(symbolEdgeList, edge)
+	}
+	sort.Slice(symbolEdgeList, func(i, j int) bool {
+		a, b := symbolEdgeList[i], symbolEdgeList[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Linkage < b.Linkage
+	})
+	for _, edge := range symbolEdgeList {
 		graphData.Edges = append(graphData.Edges, *edge)
 	}
 
@@ -1280,7 +2072,7 @@ func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target
 		if strings.HasPrefix(filePath, strings.TrimPrefix(selectedPackage, "//")+"/") {
 			// Determine if source or header
 			nodeType := "uncovered_source"
-			if strings.HasSuffix(filePath, ".h") || strings.HasSuffix(filePath, ".hpp") {
+			if ext.IsHeader(filePath) {
 				nodeType = "uncovered_header"
 			}
 
@@ -1311,6 +2103,20 @@ func buildTargetSelectedGraph(module *model.Module, selectedTarget *model.Target
 	return graphData
 }
 
+// sortedUnique returns the distinct values in sorted order
+func sortedUnique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
 // getFileName extracts the file name from a full path or Bazel label
 func getFileName(path string) string {
 	// Handle Bazel label format: //package:file.cc
@@ -1339,12 +2145,16 @@ func convertToLensGraphData(webGraph *GraphData) *lens.GraphData {
 			Type:            node.Type,
 			Parent:          node.Parent,
 			LddDependencies: node.LddDependencies,
+			Tags:            node.Tags,
+			Owner:           node.Owner,
+			External:        node.External,
 		}
 	}
 
 	lensEdges := make([]lens.GraphEdge, len(webGraph.Edges))
 	for i, edge := range webGraph.Edges {
 		lensEdges[i] = lens.GraphEdge{
+			ID:     edge.ID,
 			Source: edge.Source,
 			Target: edge.Target,
 			Type:   edge.Type,
@@ -1391,6 +2201,15 @@ func convertFromLensGraphData(lensGraph *lens.GraphData, rawGraph *GraphData) *G
 		// Copy additional metadata from raw graph if available
 		if rawNode, exists := rawNodeMap[node.ID]; exists {
 			webNodes[i].IsPublic = rawNode.IsPublic
+			webNodes[i].Tags = rawNode.Tags
+			webNodes[i].Owner = rawNode.Owner
+			webNodes[i].HeaderOnly = rawNode.HeaderOnly
+			webNodes[i].External = rawNode.External
+			webNodes[i].InDegree = rawNode.InDegree
+			webNodes[i].OutDegree = rawNode.OutDegree
+			webNodes[i].TransitiveRdeps = rawNode.TransitiveRdeps
+			webNodes[i].IsHub = rawNode.IsHub
+			webNodes[i].IsGodObject = rawNode.IsGodObject
 		}
 	}
 
@@ -1398,6 +2217,7 @@ func convertFromLensGraphData(lensGraph *lens.GraphData, rawGraph *GraphData) *G
 	webEdges := make([]GraphEdge, len(lensGraph.Edges))
 	for i, edge := range lensGraph.Edges {
 		webEdges[i] = GraphEdge{
+			ID:     edge.ID,
 			Source: edge.Source,
 			Target: edge.Target,
 			Type:   edge.Type,
@@ -1411,6 +2231,7 @@ func convertFromLensGraphData(lensGraph *lens.GraphData, rawGraph *GraphData) *G
 			webEdges[i].SourceLabel = rawEdge.SourceLabel
 			webEdges[i].TargetLabel = rawEdge.TargetLabel
 			webEdges[i].FileDetails = rawEdge.FileDetails
+			webEdges[i].Transitive = rawEdge.Transitive
 		}
 	}
 
@@ -1442,6 +2263,15 @@ func convertLensNodesToWeb(lensNodes []lens.GraphNode, rawGraph *GraphData) []Gr
 		// Copy additional metadata from raw graph if available
 		if rawNode, exists := rawNodeMap[node.ID]; exists {
 			webNodes[i].IsPublic = rawNode.IsPublic
+			webNodes[i].Tags = rawNode.Tags
+			webNodes[i].Owner = rawNode.Owner
+			webNodes[i].HeaderOnly = rawNode.HeaderOnly
+			webNodes[i].External = rawNode.External
+			webNodes[i].InDegree = rawNode.InDegree
+			webNodes[i].OutDegree = rawNode.OutDegree
+			webNodes[i].TransitiveRdeps = rawNode.TransitiveRdeps
+			webNodes[i].IsHub = rawNode.IsHub
+			webNodes[i].IsGodObject = rawNode.IsGodObject
 		}
 	}
 
@@ -1467,6 +2297,7 @@ func convertLensEdgesToWeb(lensEdges []lens.GraphEdge, rawGraph *GraphData) []Gr
 	webEdges := make([]GraphEdge, len(lensEdges))
 	for i, edge := range lensEdges {
 		webEdges[i] = GraphEdge{
+			ID:     edge.ID,
 			Source: edge.Source,
 			Target: edge.Target,
 			Type:   edge.Type,
@@ -1488,10 +2319,68 @@ func convertLensEdgesToWeb(lensEdges []lens.GraphEdge, rawGraph *GraphData) []Gr
 
 // Start starts the web server on the specified port
 func (s *Server) Start(port int) error {
-	addr := fmt.Sprintf(":%d", port)
-	logging.Info("starting web server", "url", fmt.Sprintf("http://localhost%s", addr))
+	listener, _, err := Listen(port, 0)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Listen binds a TCP port for the server. If the port is in use, up to extraPorts following
+// ports are tried. Returns the listener and the port actually bound.
+func Listen(port, extraPorts int) (net.Listener, int, error) {
+	for attempt := 0; ; attempt++ {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port+attempt))
+		if err == nil {
+			return listener, listener.Addr().(*net.TCPAddr).Port, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) || attempt >= extraPorts || port == 0 {
+			return nil, 0, err
+		}
+		logging.Info("port in use, trying the next one", "port", port+attempt)
+	}
+}
+
+// Serve handles requests on a listener from Listen until it fails or Shutdown is called.
+// Returns nil after Shutdown.
+func (s *Server) Serve(listener net.Listener) error {
+	srv := &http.Server{Handler: s.handler()}
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return listener.Close()
+	}
+	s.httpServer = srv
+	s.mu.Unlock()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	logging.Info("starting web server", "url", fmt.Sprintf("http://localhost:%d", port))
+
+	if err := srv.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops the server. It closes the event publisher first, which ends the SSE
+// streams, then stops accepting connections and waits for the remaining requests until
+// ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	srv := s.httpServer
+	s.mu.Unlock()
+
+	err := s.publisher.Close()
+	if srv != nil {
+		if shutdownErr := srv.Shutdown(ctx); shutdownErr != nil {
+			return shutdownErr
+		}
+	}
+	return err
+}
 
-	// Wrap router with logging middleware
-	handler := logging.RequestIDMiddleware(s.router)
-	return http.ListenAndServe(addr, handler)
+// handler returns the router wrapped with the CORS and logging middleware
+func (s *Server) handler() http.Handler {
+	return logging.RequestIDMiddleware(s.corsMiddleware(s.router))
 }
@@ -0,0 +1,1733 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ritzau/deps-analyzer/pkg/binaries"
+	"github.com/ritzau/deps-analyzer/pkg/deps"
+	"github.com/ritzau/deps-analyzer/pkg/lens"
+	"github.com/ritzau/deps-analyzer/pkg/model"
+	"github.com/ritzau/deps-analyzer/pkg/owners"
+	"github.com/ritzau/deps-analyzer/pkg/pubsub"
+	"github.com/ritzau/deps-analyzer/pkg/symbols"
+)
+
+func TestSystemLibrariesOfStaticClosure(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//math:math": {Label: "//math:math", Kind: model.TargetKindLibrary, Linkopts: []string{"-lm"}},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//math:math", Type: model.DependencyStatic},
+		},
+	}
+
+	graph := buildModuleGraphData(module, nil, nil, nil, nil, nil, nil, model.FileExtensions{})
+	edges := make(map[string]bool)
+	for _, edge := range graph.Edges {
+		if edge.Type == "system_link" {
+			edges[edge.ID] = true
+		}
+	}
+	for _, id := range []string{"//main:app|system:m|system_link", "//math:math|system:m|system_link"} {
+		if !edges[id] {
+			t.Errorf("missing system library edge %s, got %v", id, edges)
+		}
+	}
+
+	infos := binaries.DeriveBinaryInfoFromModule(module, t.TempDir(), 1)
+	if len(infos) != 1 || !reflect.DeepEqual(infos[0].SystemLibraries, []string{"m"}) {
+		t.Errorf("expected //main:app to link -lm from //math:math, got %+v", infos)
+	}
+}
+
+func TestBinariesExcludesTests(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":      {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//main:app_test": {Label: "//main:app_test", Kind: model.TargetKindTest},
+			"//util:util":     {Label: "//util:util", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//main:app_test", To: "//util:util", Type: model.DependencyStatic},
+		},
+	}
+
+	server := NewServer()
+	server.SetModule(module)
+	server.SetBinaries(binaries.DeriveBinaryInfoFromModule(module, t.TempDir(), 1))
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/binaries", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/binaries returned %d", rec.Code)
+	}
+
+	var result []binaries.BinaryInfo
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(result) != 1 || result[0].Label != "//main:app" {
+		t.Errorf("/api/binaries = %+v, want only //main:app", result)
+	}
+}
+
+func TestModuleGraphEdgeDetailsAreSorted(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary, Package: "//main"},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary, Package: "//util"},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+		},
+	}
+	fileToTarget := map[string]string{
+		"main/main.cc":    "//main:app",
+		"util/strings.h":  "//util:util",
+		"util/math.h":     "//util:util",
+		"util/strings.cc": "//util:util",
+	}
+	fileDeps := []*deps.FileDependency{
+		{SourceFile: "main/main.cc", Dependencies: []string{"util/strings.h", "util/math.h", "util/strings.h"}},
+	}
+	symbolDeps := []symbols.SymbolDependency{
+		{SourceFile: "main/main.cc", TargetFile: "util/strings.cc", Symbol: "util::Split()", SourceTarget: "//main:app", TargetTarget: "//util:util"},
+		{SourceFile: "main/main.cc", TargetFile: "util/strings.cc", Symbol: "util::Join()", SourceTarget: "//main:app", TargetTarget: "//util:util"},
+	}
+
+	for range 20 {
+		graphData := buildModuleGraphData(module, fileDeps, symbolDeps, fileToTarget, nil, nil, nil, model.FileExtensions{})
+
+		found := false
+		for _, edge := range graphData.Edges {
+			if edge.Source != "//main:app" || edge.Target != "//util:util" {
+				continue
+			}
+			found = true
+			if got, want := edge.FileDetails["main.cc"], "math.h, strings.h"; got != want {
+				t.Fatalf("FileDetails[main.cc] = %q, want %q", got, want)
+			}
+			if want := []string{"util::Join()", "util::Split()"}; !reflect.DeepEqual(edge.Symbols, want) {
+				t.Fatalf("Symbols = %v, want %v", edge.Symbols, want)
+			}
+		}
+		if !found {
+			t.Fatal("missing //main:app -> //util:util edge")
+		}
+	}
+}
+
+func TestPackageGraph(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":   {Label: "//main:app", Kind: model.TargetKindBinary, Package: "//main", Name: "app"},
+			"//core:core":  {Label: "//core:core", Kind: model.TargetKindLibrary, Package: "//core", Name: "core"},
+			"//core:impl":  {Label: "//core:impl", Kind: model.TargetKindLibrary, Package: "//core", Name: "impl"},
+			"//util:util":  {Label: "//util:util", Kind: model.TargetKindLibrary, Package: "//util", Name: "util"},
+			"//other:misc": {Label: "//other:misc", Kind: model.TargetKindLibrary, Package: "//other", Name: "misc"},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//core:core", Type: model.DependencyStatic},
+			{From: "//core:core", To: "//core:impl", Type: model.DependencyStatic},
+			{From: "//core:impl", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//other:misc", To: "//main:app", Type: model.DependencyData},
+		},
+	}
+	server := NewServer()
+	server.SetModule(module)
+
+	get := func(path string) (*httptest.ResponseRecorder, *GraphData) {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			return rec, nil
+		}
+		var graphData GraphData
+		if err := json.NewDecoder(rec.Body).Decode(&graphData); err != nil {
+			t.Fatalf("failed to decode %s: %v", path, err)
+		}
+		return rec, &graphData
+	}
+	nodeIDs := func(graphData *GraphData) map[string]bool {
+		ids := make(map[string]bool)
+		for _, node := range graphData.Nodes {
+			ids[node.ID] = true
+		}
+		return ids
+	}
+
+	_, core := get("/api/package/core/graph")
+	if core == nil {
+		t.Fatal("GET /api/package/core/graph failed")
+	}
+	ids := nodeIDs(core)
+	for _, want := range []string{"//core:core", "//core:impl", "//main:app", "//util:util"} {
+		if !ids[want] {
+			t.Errorf("package graph of //core is missing %s: %v", want, ids)
+		}
+	}
+	if ids["//other:misc"] {
+		t.Errorf("package graph of //core should not include unrelated //other:misc")
+	}
+
+	// A single-target package renders its target and its neighbors
+	if _, util := get("/api/package/util/graph"); util == nil || !nodeIDs(util)["//util:util"] || !nodeIDs(util)["//core:impl"] {
+		t.Errorf("unexpected package graph of //util: %+v", util)
+	}
+
+	if rec, _ := get("/api/package/missing/graph"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /api/package/missing/graph returned %d, want 404", rec.Code)
+	}
+}
+
+func TestFileFocusedGraph(t *testing.T) {
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":   {Label: "//main:app", Kind: model.TargetKindBinary, Package: "//main", Name: "app", Sources: []string{"main/main.cc"}},
+			"//util:util":  {Label: "//util:util", Kind: model.TargetKindLibrary, Package: "//util", Name: "util", Sources: []string{"util/strings.cc"}, Headers: []string{"util/strings.h"}},
+			"//other:misc": {Label: "//other:misc", Kind: model.TargetKindLibrary, Package: "//other", Name: "misc", Sources: []string{"other/misc.cc"}},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+		},
+	}
+	server := NewServer()
+	server.SetModule(module)
+	server.SetFileToTargetMap(map[string]string{
+		"main/main.cc":    "//main:app",
+		"util/strings.cc": "//util:util",
+		"util/strings.h":  "//util:util",
+		"other/misc.cc":   "//other:misc",
+	})
+	server.SetFileDependencies([]*deps.FileDependency{
+		{SourceFile: "main/main.cc", Dependencies: []string{"util/strings.h"}},
+	})
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/file/main/main.cc/focused", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/file/main/main.cc/focused returned %d: %s", rec.Code, rec.Body.String())
+	}
+	var graphData GraphData
+	if err := json.NewDecoder(rec.Body).Decode(&graphData); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, node := range graphData.Nodes {
+		ids[node.ID] = true
+	}
+	for _, want := range []string{"//main:app:main/main.cc", "//main:app", "//util:util:util/strings.h"} {
+		if !ids[want] {
+			t.Errorf("focused graph of main/main.cc is missing %s: %v", want, ids)
+		}
+	}
+	if ids["//other:misc"] || ids["//other:misc:other/misc.cc"] {
+		t.Errorf("focused graph of main/main.cc should not include //other:misc: %v", ids)
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/file/missing.cc/focused", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /api/file/missing.cc/focused returned %d, want 404", rec.Code)
+	}
+}
+
+func TestFileIncludes(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{
+		"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary, Package: "//main", Name: "app", Sources: []string{"main/main.cc"}},
+		"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary, Package: "//util", Name: "util", Headers: []string{"util/strings.h", "util/math.h"}},
+	}})
+	server.SetFileToTargetMap(map[string]string{
+		"main/main.cc":   "//main:app",
+		"util/strings.h": "//util:util",
+		"util/math.h":    "//util:util",
+	})
+	server.SetFileDependencies([]*deps.FileDependency{
+		{
+			SourceFile:         "main/main.cc",
+			Dependencies:       []string{"util/strings.h", "util/math.h", "util/strings.h"},
+			DirectDependencies: []string{"util/strings.h"},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/file/includes?file=main/main.cc", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/file/includes returned %d: %s", rec.Code, rec.Body.String())
+	}
+	var includes FileIncludes
+	if err := json.NewDecoder(rec.Body).Decode(&includes); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := FileIncludes{
+		File:         "main/main.cc",
+		Dependencies: []string{"util/math.h", "util/strings.h"},
+		Direct:       []string{"util/strings.h"},
+		Transitive:   []string{"util/math.h"},
+	}
+	if !reflect.DeepEqual(includes, want) {
+		t.Errorf("includes = %+v, want %+v", includes, want)
+	}
+
+	// The file compile edges carry the distinction
+	graphData := buildModuleGraphData(server.module, server.fileDeps, nil, server.fileToTarget, nil, nil, nil, model.FileExtensions{})
+	for _, edge := range graphData.Edges {
+		if edge.Type != string(model.DependencyCompile) || edge.Source != "//main:app:main/main.cc" {
+			continue
+		}
+		if wantTransitive := edge.Target == "//util:util:util/math.h"; edge.Transitive != wantTransitive {
+			t.Errorf("edge %s transitive = %v, want %v", edge.ID, edge.Transitive, wantTransitive)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/file/includes?file=missing.cc", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /api/file/includes?file=missing.cc returned %d, want 404", rec.Code)
+	}
+}
+
+func TestOverview(t *testing.T) {
+	server := NewServer()
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/overview", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /api/overview before analysis returned %d, want 503", rec.Code)
+	}
+
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary, Package: "//main"},
+			"//core:core": {Label: "//core:core", Kind: model.TargetKindLibrary, Package: "//core"},
+			"//core:impl": {Label: "//core:impl", Kind: model.TargetKindLibrary, Package: "//core"},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//core:core", Type: model.DependencyStatic},
+			{From: "//core:core", To: "//core:impl", Type: model.DependencyStatic},
+			{From: "//core:impl", To: "//core:core", Type: model.DependencySymbol},
+		},
+		Issues: []model.DependencyIssue{
+			{From: "//main:app", To: "//core:core", Issue: "policy_violation", Severity: model.SeverityError},
+			{From: "//core:core", To: "//core:impl", Issue: "duplicate_linkage", Severity: model.SeverityWarning},
+			{From: "//core:impl", To: "//core:core", Issue: "duplicate_linkage", Severity: model.SeverityWarning},
+		},
+	})
+	server.SetFileToTargetMap(map[string]string{"main/main.cc": "//main:app", "core/core.cc": "//core:core", "core/impl.cc": "//core:impl"})
+	server.SetUncoveredFiles([]string{"core/orphan.cc"})
+	server.SetHubThresholds(1, 0)
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/overview", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/overview returned %d: %s", rec.Code, rec.Body.String())
+	}
+	var overview Overview
+	if err := json.NewDecoder(rec.Body).Decode(&overview); err != nil {
+		t.Fatalf("failed to decode overview: %v", err)
+	}
+
+	if overview.Targets != 3 || overview.Packages != 2 || overview.Dependencies != 3 {
+		t.Errorf("counts = %d targets, %d packages, %d dependencies, want 3, 2, 3", overview.Targets, overview.Packages, overview.Dependencies)
+	}
+	if want := map[string]int{"cc_binary": 1, "cc_library": 2}; !reflect.DeepEqual(overview.TargetsByKind, want) {
+		t.Errorf("TargetsByKind = %v, want %v", overview.TargetsByKind, want)
+	}
+	if want := (OverviewCoverage{CoveredFiles: 3, UncoveredFiles: 1, Percent: 75}); overview.Coverage != want {
+		t.Errorf("Coverage = %+v, want %+v", overview.Coverage, want)
+	}
+	if want := map[string]int{"error": 1, "warning": 2}; !reflect.DeepEqual(overview.Issues, want) {
+		t.Errorf("Issues = %v, want %v", overview.Issues, want)
+	}
+	if len(overview.TopHubs) == 0 || overview.TopHubs[0].Label != "//core:core" {
+		t.Errorf("TopHubs = %+v, want //core:core first", overview.TopHubs)
+	}
+	if want := [][]string{{"//core:core", "//core:impl"}}; !reflect.DeepEqual(overview.Cycles, want) {
+		t.Errorf("Cycles = %v, want %v", overview.Cycles, want)
+	}
+}
+
+func TestTargetMetricsComputedOncePerModule(t *testing.T) {
+	server := NewServer()
+	server.SetHubThresholds(1, 0)
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//core:core": {Label: "//core:core", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{{From: "//main:app", To: "//core:core", Type: model.DependencyStatic}},
+	}
+	server.SetModule(module)
+
+	metrics := server.targetMetrics()
+	if !metrics["//core:core"].IsHub {
+		t.Fatalf("metrics = %+v, want //core:core flagged as a hub", metrics["//core:core"])
+	}
+	if server.targetMetrics()["//core:core"] != metrics["//core:core"] {
+		t.Error("expected the metrics to be reused while the module is unchanged")
+	}
+
+	// Updating the module computes them again
+	server.UpdateModule(module, func(m *model.Module) {
+		m.Dependencies = nil
+	})
+	if server.targetMetrics()["//core:core"].IsHub {
+		t.Error("expected the metrics of the updated module")
+	}
+}
+
+func TestPackageCycles(t *testing.T) {
+	server := NewServer()
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cycles/packages", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /api/cycles/packages before analysis returned %d, want 503", rec.Code)
+	}
+
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//core:core": {Label: "//core:core", Kind: model.TargetKindLibrary, Package: "//core"},
+			"//core:impl": {Label: "//core:impl", Kind: model.TargetKindLibrary, Package: "//core"},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary, Package: "//util"},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//core:core", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//util:util", To: "//core:impl", Type: model.DependencyCompile},
+		},
+	})
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cycles/packages", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/cycles/packages returned %d: %s", rec.Code, rec.Body.String())
+	}
+	var cycles []model.PackageCycle
+	if err := json.NewDecoder(rec.Body).Decode(&cycles); err != nil {
+		t.Fatalf("failed to decode package cycles: %v", err)
+	}
+	if len(cycles) != 1 || !reflect.DeepEqual(cycles[0].Packages, []string{"//core", "//util"}) {
+		t.Errorf("cycles = %+v, want //core <-> //util", cycles)
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cycles/packages?types=static,dynamic", nil))
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("GET /api/cycles/packages?types=static,dynamic returned %d: %s, want no cycles", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/cycles/packages?types=bogus", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET /api/cycles/packages?types=bogus returned %d, want 400", rec.Code)
+	}
+}
+
+func TestGraphEndpointsReportNotReady(t *testing.T) {
+	server := NewServer()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/module", nil),
+		httptest.NewRequest(http.MethodGet, "/api/module/graph", nil),
+		httptest.NewRequest(http.MethodPost, "/api/module/graph/lens", strings.NewReader(`{}`)),
+	} {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s returned %d, want 503", req.Method, req.URL.Path, rec.Code)
+		}
+		if got := rec.Header().Get("X-Analysis-State"); got != "analyzing" {
+			t.Errorf("%s %s X-Analysis-State = %q, want analyzing", req.Method, req.URL.Path, got)
+		}
+		var body NotReadyResponse
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.State != "analyzing" {
+			t.Errorf("%s %s body = %+v (err %v), want state analyzing", req.Method, req.URL.Path, body, err)
+		}
+	}
+
+	// An analyzed but empty workspace is a regular, complete result
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{}})
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/module/graph", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /api/module/graph for an empty module returned %d, want 200", rec.Code)
+	}
+}
+
+// fakeAnalyzer records analysis requests and reports ErrAnalysisRunning while running is set
+type fakeAnalyzer struct {
+	running bool
+	reasons []string
+}
+
+func (a *fakeAnalyzer) StartFullAnalysis(reason string) error {
+	if a.running {
+		return ErrAnalysisRunning
+	}
+	a.running = true
+	a.reasons = append(a.reasons, reason)
+	return nil
+}
+
+func (a *fakeAnalyzer) CancelAnalysis() bool {
+	wasRunning := a.running
+	a.running = false
+	return wasRunning
+}
+
+func (a *fakeAnalyzer) AnalysisState() AnalysisState {
+	if a.running {
+		return AnalysisState{State: AnalysisRunning, Reason: a.reasons[len(a.reasons)-1]}
+	}
+	return AnalysisState{State: AnalysisIdle}
+}
+
+func TestAnalyzeEndpoint(t *testing.T) {
+	server := NewServer()
+
+	post := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/analyze", nil))
+		return rec
+	}
+
+	// Without a registered analyzer analysis cannot be started
+	if rec := post(); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /api/analyze without analyzer returned %d, want 503", rec.Code)
+	}
+
+	analyzer := &fakeAnalyzer{}
+	server.SetAnalyzer(analyzer)
+
+	rec := post()
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST /api/analyze returned %d, want 202", rec.Code)
+	}
+	var body AnalyzeResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.State != "analyzing" {
+		t.Errorf("body = %+v (err %v), want state analyzing", body, err)
+	}
+	if !reflect.DeepEqual(analyzer.reasons, []string{"manual re-analysis"}) {
+		t.Errorf("analyzer started with reasons %v", analyzer.reasons)
+	}
+
+	// A second request while the first analysis is running conflicts
+	if rec := post(); rec.Code != http.StatusConflict {
+		t.Errorf("POST /api/analyze while running returned %d, want 409", rec.Code)
+	}
+	if len(analyzer.reasons) != 1 {
+		t.Errorf("expected no second analysis, got %v", analyzer.reasons)
+	}
+}
+
+func TestAnalysisStateAndCancel(t *testing.T) {
+	server := NewServer()
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+	state := func() AnalysisState {
+		rec := serve(http.MethodGet, "/api/state")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/state returned %d, want 200", rec.Code)
+		}
+		var state AnalysisState
+		if err := json.NewDecoder(rec.Body).Decode(&state); err != nil {
+			t.Fatal(err)
+		}
+		return state
+	}
+
+	if rec := serve(http.MethodGet, "/api/state"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /api/state without analyzer returned %d, want 503", rec.Code)
+	}
+
+	server.SetAnalyzer(&fakeAnalyzer{})
+	if got := state(); got.State != AnalysisIdle {
+		t.Errorf("state before analysis = %+v, want idle", got)
+	}
+	if rec := serve(http.MethodPost, "/api/analyze/cancel"); rec.Code != http.StatusConflict {
+		t.Errorf("cancel while idle returned %d, want 409", rec.Code)
+	}
+
+	serve(http.MethodPost, "/api/analyze")
+	if got := state(); got.State != AnalysisRunning || got.Reason != "manual re-analysis" {
+		t.Errorf("state during analysis = %+v, want running", got)
+	}
+
+	if rec := serve(http.MethodPost, "/api/analyze/cancel"); rec.Code != http.StatusAccepted {
+		t.Errorf("cancel while running returned %d, want 202", rec.Code)
+	}
+	if got := state(); got.State != AnalysisIdle {
+		t.Errorf("state after cancel = %+v, want idle", got)
+	}
+}
+
+func TestPublishWorkspaceStatus(t *testing.T) {
+	publisher := pubsub.NewMemoryPublisher()
+	server := NewServerWith(publisher)
+	server.SetWatching(true)
+
+	if err := server.PublishWorkspaceStatusWithReason("bazel_querying", "Querying...", "BUILD files changed", 1, 6); err != nil {
+		t.Fatal(err)
+	}
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{"//util:util": {Label: "//util:util"}}})
+	if err := server.PublishTargetGraph("complete", true); err != nil {
+		t.Fatal(err)
+	}
+
+	events := publisher.EventsFor("workspace_status")
+	if len(events) != 1 || events[0].Type != "bazel_querying" {
+		t.Fatalf("unexpected workspace_status events: %+v", events)
+	}
+	var status pubsub.WorkspaceStatus
+	if err := json.Unmarshal(events[0].Data, &status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Watching || status.Reason != "BUILD files changed" || status.Step != 1 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	events = publisher.EventsFor("target_graph")
+	var graph pubsub.TargetGraphData
+	if len(events) != 1 || json.Unmarshal(events[0].Data, &graph) != nil || graph.TargetsCount != 1 || !graph.Complete {
+		t.Errorf("unexpected target_graph events: %+v", events)
+	}
+}
+
+func TestPublishModuleChanges(t *testing.T) {
+	publisher := pubsub.NewMemoryPublisher()
+	server := NewServerWith(publisher)
+
+	previous := &model.Module{Targets: map[string]*model.Target{"//util:util": {Label: "//util:util"}}}
+	current := &model.Module{
+		Targets: map[string]*model.Target{
+			"//util:util": {Label: "//util:util"},
+			"//main:app":  {Label: "//main:app"},
+		},
+		Dependencies: []model.Dependency{{From: "//main:app", To: "//util:util", Type: model.DependencyStatic}},
+	}
+
+	// Unchanged modules publish nothing
+	if err := server.PublishModuleChanges("BUILD files changed", model.DiffModules(previous, previous)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.PublishModuleChanges("BUILD files changed", model.DiffModules(previous, current)); err != nil {
+		t.Fatal(err)
+	}
+
+	events := publisher.EventsFor(pubsub.ChangesTopic.Name())
+	if len(events) != 1 || events[0].Type != "changed" {
+		t.Fatalf("unexpected changes events: %+v", events)
+	}
+	changes, err := pubsub.ChangesTopic.Decode(events[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes.Reason != "BUILD files changed" || !reflect.DeepEqual(changes.Nodes, []string{"//main:app", "//util:util"}) ||
+		!reflect.DeepEqual(changes.Added, []string{"//main:app"}) {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+}
+
+func TestTagsEndpoint(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{
+		"//graphics:renderer": {Label: "//graphics:renderer", Tags: []string{"team:graphics", "no-ide"}},
+		"//graphics:shaders":  {Label: "//graphics:shaders", Tags: []string{"team:graphics"}},
+		"//tools:codegen":     {Label: "//tools:codegen", Tags: []string{"manual", "team:infra"}},
+		"//main:app":          {Label: "//main:app"},
+	}})
+
+	get := func(url string) map[string][]string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d", url, rec.Code)
+		}
+		var tags map[string][]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil {
+			t.Fatal(err)
+		}
+		return tags
+	}
+
+	tags := get("/api/tags")
+	if len(tags) != 4 || !reflect.DeepEqual(tags["team:graphics"], []string{"//graphics:renderer", "//graphics:shaders"}) {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+
+	want := map[string][]string{
+		"team:graphics": {"//graphics:renderer", "//graphics:shaders"},
+		"team:infra":    {"//tools:codegen"},
+	}
+	if got := get("/api/tags?prefix=team:"); !reflect.DeepEqual(got, want) {
+		t.Errorf("tags with prefix team: = %v, want %v", got, want)
+	}
+}
+
+func TestTargetFileDeps(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{
+		"//util/strings:strings": {Label: "//util/strings:strings"},
+		"//core:core":            {Label: "//core:core"},
+	}})
+	server.SetFileToTargetMap(map[string]string{
+		"util/strings/split.cc": "//util/strings:strings",
+		"util/strings/split.h":  "//util/strings:strings",
+		"core/types.h":          "//core:core",
+		"core/types.cc":         "//core:core",
+	})
+	server.SetFileDependencies([]*deps.FileDependency{
+		{SourceFile: "util/strings/split.cc", Dependencies: []string{"util/strings/split.h", "external/abseil/absl/strings/str_cat.h", "core/types.h", "gen/config.h"}},
+		{SourceFile: "core/types.cc", Dependencies: []string{"core/types.h"}},
+	})
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/target/util/strings/file-deps", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var got TargetFileDeps
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := TargetFileDeps{
+		Target: "//util/strings:strings",
+		Files: []SourceFileDeps{{
+			File: "util/strings/split.cc",
+			Includes: []IncludedFile{
+				{File: "core/types.h", Target: "//core:core", Location: IncludeInRepo},
+				{File: "external/abseil/absl/strings/str_cat.h", Location: IncludeExternal},
+				{File: "gen/config.h", Location: IncludeInRepo},
+				{File: "util/strings/split.h", Target: "//util/strings:strings", Location: IncludeInTarget},
+			},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("file deps = %+v, want %+v", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/target/missing/file-deps", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown target: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestLensHidesTaggedTargets(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{
+		"//tools:codegen": {Label: "//tools:codegen", Kind: model.TargetKindLibrary, Tags: []string{"manual"}},
+		"//main:app":      {Label: "//main:app", Kind: model.TargetKindBinary},
+	}})
+
+	lensConfig := `{"name": "default", "baseSet": {"type": "full-graph"},
+		"distanceRules": [{"distance": "infinite", "nodeVisibility": {"targetTypes": ["cc_library", "cc_binary"]}, "collapseLevel": 0, "showEdges": true}],
+		"globalFilters": {"hideTags": ["manual"]}, "edgeRules": {"types": []}}`
+	body := `{"defaultLens": ` + lensConfig + `, "detailLens": ` + lensConfig + `}`
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/module/graph/lens", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp LensRenderResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.FullGraph == nil {
+		t.Fatalf("unexpected response %s: %v", rec.Body.String(), err)
+	}
+	ids := make(map[string]bool)
+	for _, node := range resp.FullGraph.Nodes {
+		ids[node.ID] = true
+	}
+	if ids["//tools:codegen"] || !ids["//main:app"] {
+		t.Errorf("nodes = %v, want //main:app without the manual //tools:codegen", ids)
+	}
+}
+
+func TestLensHidesUnreachableTargets(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":       {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//util:util":      {Label: "//util:util", Kind: model.TargetKindLibrary},
+			"//core:core":      {Label: "//core:core", Kind: model.TargetKindLibrary},
+			"//legacy:old":     {Label: "//legacy:old", Kind: model.TargetKindLibrary},
+			"//legacy:helpers": {Label: "//legacy:helpers", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//util:util", To: "//core:core", Type: model.DependencySymbol},
+			{From: "//legacy:old", To: "//legacy:helpers", Type: model.DependencyStatic},
+		},
+	})
+
+	render := func(hideUnreachable bool) map[string]bool {
+		t.Helper()
+		lensConfig := fmt.Sprintf(`{"name": "default", "baseSet": {"type": "full-graph"},
+			"distanceRules": [{"distance": "infinite", "nodeVisibility": {"targetTypes": ["cc_library", "cc_binary"]}, "collapseLevel": 3, "showEdges": true}],
+			"globalFilters": {"hideUnreachable": %t}, "edgeRules": {"types": []}}`, hideUnreachable)
+		body := `{"defaultLens": ` + lensConfig + `, "detailLens": ` + lensConfig + `}`
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/module/graph/lens", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp LensRenderResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.FullGraph == nil {
+			t.Fatalf("unexpected response %s: %v", rec.Body.String(), err)
+		}
+		ids := make(map[string]bool)
+		for _, node := range resp.FullGraph.Nodes {
+			ids[node.ID] = true
+		}
+		return ids
+	}
+
+	ids := render(true)
+	if !ids["//main:app"] || !ids["//util:util"] || !ids["//core:core"] {
+		t.Errorf("nodes = %v, want the binary and everything it reaches", ids)
+	}
+	if ids["//legacy:old"] || ids["//legacy:helpers"] {
+		t.Errorf("nodes = %v, want the unreachable //legacy targets hidden", ids)
+	}
+
+	// Turned off, orphans can still be found
+	if ids := render(false); !ids["//legacy:old"] {
+		t.Errorf("nodes = %v, want //legacy:old without the filter", ids)
+	}
+}
+
+func TestLensCollapsesOverriddenPackages(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":                {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//third_party/zlib:zlib":   {Label: "//third_party/zlib:zlib", Kind: model.TargetKindLibrary},
+			"//third_party/absl:string": {Label: "//third_party/absl:string", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//third_party/zlib:zlib", Type: model.DependencyStatic},
+		},
+	})
+
+	lensConfig := `{"name": "default", "baseSet": {"type": "full-graph"},
+		"distanceRules": [{"distance": "infinite", "nodeVisibility": {"targetTypes": ["cc_library", "cc_binary"]}, "collapseLevel": 3, "showEdges": true}],
+		"globalFilters": {}, "edgeRules": {"types": ["static"], "aggregateCollapsed": true},
+		"overrides": [{"nodeId": "//third_party", "collapsed": true, "package": true}]}`
+	body := `{"defaultLens": ` + lensConfig + `, "detailLens": ` + lensConfig + `}`
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/module/graph/lens", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp LensRenderResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.FullGraph == nil {
+		t.Fatalf("unexpected response %s: %v", rec.Body.String(), err)
+	}
+	var ids []string
+	for _, node := range resp.FullGraph.Nodes {
+		ids = append(ids, node.ID)
+	}
+	want := []string{"//main", "//main:app", "//third_party/absl", "//third_party/zlib"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("nodes = %v, want %v", ids, want)
+	}
+	if len(resp.FullGraph.Edges) != 1 || resp.FullGraph.Edges[0].Target != "//third_party/zlib" {
+		t.Errorf("expected the edge into zlib to end at its package, got %+v", resp.FullGraph.Edges)
+	}
+}
+
+func TestLensEdgeIDs(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//main:app", To: "//util:util", Type: model.DependencyCompile},
+		},
+	})
+
+	render := func(edgeTypes string) LensRenderResponse {
+		t.Helper()
+		lensConfig := `{"name": "default", "baseSet": {"type": "full-graph"},
+			"distanceRules": [{"distance": "infinite", "nodeVisibility": {"targetTypes": ["cc_library", "cc_binary"]}, "collapseLevel": 0, "showEdges": true}],
+			"globalFilters": {}, "edgeRules": {"types": [` + edgeTypes + `]}}`
+		body := `{"defaultLens": ` + lensConfig + `, "detailLens": ` + lensConfig + `}`
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/module/graph/lens", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp LensRenderResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.FullGraph == nil {
+			t.Fatalf("unexpected response %s: %v", rec.Body.String(), err)
+		}
+		return resp
+	}
+
+	full := render(`"static", "compile"`)
+	var ids []string
+	for _, edge := range full.FullGraph.Edges {
+		ids = append(ids, edge.ID)
+	}
+	want := []string{"//main:app|//util:util|compile", "//main:app|//util:util|static"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("edge IDs = %v, want %v", ids, want)
+	}
+
+	// Removed edges are reported by the IDs the client holds
+	compileOnly := render(`"compile"`)
+	diff := lens.ComputeDiff(lens.CreateSnapshot(convertToLensGraphData(full.FullGraph)), convertToLensGraphData(compileOnly.FullGraph))
+	if !reflect.DeepEqual(diff.RemovedEdges, []string{"//main:app|//util:util|static"}) || len(diff.AddedEdges) != 0 {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+}
+
+func TestLensGroupsByOwner(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{
+		"//graphics:renderer": {Label: "//graphics:renderer", Kind: model.TargetKindLibrary, Owner: "@org/graphics"},
+		"//main:app":          {Label: "//main:app", Kind: model.TargetKindBinary},
+	}})
+
+	lensConfig := `{"name": "default", "baseSet": {"type": "full-graph"},
+		"distanceRules": [{"distance": "infinite", "nodeVisibility": {"targetTypes": ["cc_library", "cc_binary"]}, "collapseLevel": 0, "showEdges": true}],
+		"globalFilters": {}, "edgeRules": {"types": []}, "groupBy": "owner"}`
+	body := `{"defaultLens": ` + lensConfig + `, "detailLens": ` + lensConfig + `}`
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/module/graph/lens", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp LensRenderResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.FullGraph == nil {
+		t.Fatalf("unexpected response %s: %v", rec.Body.String(), err)
+	}
+	nodes := make(map[string]GraphNode)
+	for _, node := range resp.FullGraph.Nodes {
+		nodes[node.ID] = node
+	}
+	if owner, ok := nodes["owner:@org/graphics"]; !ok || owner.Type != "owner" {
+		t.Fatalf("expected an owner node, got %v", nodes)
+	}
+	if parent := nodes["//graphics"].Parent; parent != "owner:@org/graphics" {
+		t.Errorf("//graphics parent = %q, want owner:@org/graphics", parent)
+	}
+	if parent := nodes["//main"].Parent; parent != "" {
+		t.Errorf("unowned //main parent = %q, want none", parent)
+	}
+	if owner := nodes["//graphics:renderer"].Owner; owner != "@org/graphics" {
+		t.Errorf("//graphics:renderer owner = %q, want @org/graphics", owner)
+	}
+}
+
+func TestLensGroupsFilesByDirectory(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{
+		"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary},
+	}})
+	server.SetFileToTargetMap(map[string]string{
+		"util/util.cc":          "//util:util",
+		"util/strings/split.cc": "//util:util",
+		"util/strings/split.h":  "//util:util",
+		"util/fmt/format.cc":    "//util:util",
+	})
+
+	render := func(threshold int) map[string]GraphNode {
+		t.Helper()
+		lensConfig := fmt.Sprintf(`{"name": "default", "baseSet": {"type": "full-graph"},
+			"distanceRules": [{"distance": "infinite", "nodeVisibility": {"targetTypes": ["cc_library"], "fileTypes": ["all"]}, "collapseLevel": 3, "showEdges": true}],
+			"globalFilters": {}, "edgeRules": {"types": []}, "groupFilesAbove": %d}`, threshold)
+		body := `{"defaultLens": ` + lensConfig + `, "detailLens": ` + lensConfig + `}`
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/module/graph/lens", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp LensRenderResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.FullGraph == nil {
+			t.Fatalf("unexpected response %s: %v", rec.Body.String(), err)
+		}
+		nodes := make(map[string]GraphNode)
+		for _, node := range resp.FullGraph.Nodes {
+			nodes[node.ID] = node
+		}
+		return nodes
+	}
+
+	nodes := render(3)
+	dir, ok := nodes["dir://util:util/strings"]
+	if !ok || dir.Type != "directory" || dir.Label != "strings/" || dir.Parent != "//util:util" {
+		t.Fatalf("expected a strings/ directory node in //util:util, got %v", nodes)
+	}
+	wantParents := map[string]string{
+		"//util:util:util/util.cc":          "//util:util",
+		"//util:util:util/strings/split.cc": "dir://util:util/strings",
+		"//util:util:util/strings/split.h":  "dir://util:util/strings",
+		"//util:util:util/fmt/format.cc":    "dir://util:util/fmt",
+	}
+	for id, want := range wantParents {
+		if parent := nodes[id].Parent; parent != want {
+			t.Errorf("%s parent = %q, want %q", id, parent, want)
+		}
+	}
+
+	// At or below the threshold the files stay in the target
+	for id, node := range render(4) {
+		if node.Type == "directory" {
+			t.Errorf("unexpected directory node %s below the threshold", id)
+		}
+	}
+}
+
+func TestOwnersEndpoint(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//graphics:renderer": {Label: "//graphics:renderer", Owner: "@org/graphics"},
+			"//main:app":          {Label: "//main:app", Owner: "@org/platform"},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//graphics:renderer", Type: model.DependencyStatic},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/owners", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var summary owners.Summary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	want := []owners.OwnerDependency{{From: "@org/platform", To: "@org/graphics", Count: 1}}
+	if len(summary.Owners) != 2 || !reflect.DeepEqual(summary.Dependencies, want) {
+		t.Errorf("unexpected owners summary: %+v", summary)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	server := NewServer()
+	if err := server.PublishWorkspaceStatus("ready", "Done", 6, 6); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"# TYPE deps_analyzer_pubsub_dropped_total counter",
+		`deps_analyzer_pubsub_published_total{topic="workspace_status"} 1`,
+		`deps_analyzer_pubsub_dropped_total{topic="workspace_status"} 0`,
+		`deps_analyzer_pubsub_subscribers{topic="workspace_status"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    string
+	}{
+		{"default allows localhost", nil, "http://localhost:3000", "http://localhost:3000"},
+		{"default allows loopback IPv6", nil, "http://[::1]:8080", "http://[::1]:8080"},
+		{"default rejects other hosts", nil, "http://evil.example.com", ""},
+		{"exact origin", []string{"https://dash.example.com"}, "https://dash.example.com", "https://dash.example.com"},
+		{"exact origin checks scheme", []string{"https://dash.example.com"}, "http://dash.example.com", ""},
+		{"configured list replaces default", []string{"dash.example.com"}, "http://localhost:3000", ""},
+		{"wildcard", []string{"*"}, "http://anything.example.com", "http://anything.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer()
+			server.SetAllowedOrigins(tt.allowed)
+
+			req := httptest.NewRequest("GET", "/api/module", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			server.handler().ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	server := NewServer()
+
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("OPTIONS", "/api/analyze", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		server.handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := preflight("http://localhost:5173")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204 for allowed preflight, got %d", w.Code)
+	}
+	if !strings.Contains(w.Header().Get("Access-Control-Allow-Methods"), "POST") {
+		t.Errorf("expected POST in allowed methods, got %q", w.Header().Get("Access-Control-Allow-Methods"))
+	}
+
+	w = preflight("http://evil.example.com")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for disallowed preflight, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no Access-Control-Allow-Origin, got %q", got)
+	}
+}
+
+func TestCORSRejectsUnsafeRequests(t *testing.T) {
+	server := NewServer()
+	analyzer := &fakeAnalyzer{}
+	server.SetAnalyzer(analyzer)
+
+	post := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/analyze", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		server.handler().ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("https://evil.example"); w.Code != http.StatusForbidden {
+		t.Errorf("POST from a disallowed origin returned %d, want 403", w.Code)
+	}
+	if len(analyzer.reasons) != 0 {
+		t.Fatalf("POST from a disallowed origin reached the analyzer: %v", analyzer.reasons)
+	}
+
+	// Same-origin requests (no Origin header) and allowed origins still reach it
+	if w := post(""); w.Code != http.StatusAccepted {
+		t.Errorf("POST without an origin returned %d, want 202", w.Code)
+	}
+	analyzer.running = false
+	if w := post("http://localhost:8080"); w.Code != http.StatusAccepted {
+		t.Errorf("POST from an allowed origin returned %d, want 202", w.Code)
+	}
+
+	// Safe requests from disallowed origins are served, only without CORS headers
+	req := httptest.NewRequest(http.MethodGet, "/api/module", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	w := httptest.NewRecorder()
+	server.handler().ServeHTTP(w, req)
+	if w.Code == http.StatusForbidden {
+		t.Error("GET from a disallowed origin was refused")
+	}
+}
+
+func TestETags(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{"//util:util": {Label: "//util:util"}}})
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, path := range []string{"/api/module", "/", "/app.js"} {
+		t.Run(path, func(t *testing.T) {
+			w := get(path, "")
+			etag := w.Header().Get("ETag")
+			if w.Code != http.StatusOK || etag == "" {
+				t.Fatalf("expected 200 with an ETag, got %d and %q", w.Code, etag)
+			}
+
+			w = get(path, etag)
+			if w.Code != http.StatusNotModified {
+				t.Errorf("expected 304 for matching If-None-Match, got %d", w.Code)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("expected empty body for 304, got %d bytes", w.Body.Len())
+			}
+
+			if w = get(path, `"stale"`); w.Code != http.StatusOK {
+				t.Errorf("expected 200 for stale If-None-Match, got %d", w.Code)
+			}
+		})
+	}
+
+	// A new module changes the ETag
+	etag := get("/api/module", "").Header().Get("ETag")
+	server.SetModule(&model.Module{Targets: map[string]*model.Target{"//core:core": {Label: "//core:core"}}})
+	if w := get("/api/module", etag); w.Code != http.StatusOK {
+		t.Errorf("expected 200 after the module changed, got %d", w.Code)
+	}
+}
+
+func TestAssignRanks(t *testing.T) {
+	data := &GraphData{
+		Nodes: []GraphNode{{ID: "app"}, {ID: "core"}, {ID: "util"}, {ID: "a"}, {ID: "b"}},
+		Edges: []GraphEdge{
+			{Source: "app", Target: "core"},
+			{Source: "core", Target: "util"},
+			{Source: "app", Target: "util"}, // Shortcut does not lower util's rank
+			{Source: "a", Target: "b"},      // Cycle
+			{Source: "b", Target: "a"},
+		},
+	}
+	assignRanks(data)
+
+	ranks := make(map[string]int)
+	for _, node := range data.Nodes {
+		ranks[node.ID] = node.Rank
+	}
+	want := map[string]int{"app": 0, "core": 1, "util": 2, "a": 0, "b": 1} // a comes first by ID, so a -> b is kept
+	if !reflect.DeepEqual(ranks, want) {
+		t.Errorf("ranks = %v, want %v", ranks, want)
+	}
+}
+
+func TestModuleGraphRanksOptIn(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+		},
+	})
+
+	utilRank := func(path string) int {
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		var graphData GraphData
+		if err := json.NewDecoder(w.Body).Decode(&graphData); err != nil {
+			t.Fatalf("failed to decode %s: %v", path, err)
+		}
+		for _, node := range graphData.Nodes {
+			if node.ID == "//util:util" {
+				return node.Rank
+			}
+		}
+		t.Fatalf("//util:util missing from %s", path)
+		return 0
+	}
+
+	if rank := utilRank("/api/module/graph"); rank != 0 {
+		t.Errorf("expected no rank without ?ranks=true, got %d", rank)
+	}
+	if rank := utilRank("/api/module/graph?ranks=true"); rank != 1 {
+		t.Errorf("expected rank 1 with ?ranks=true, got %d", rank)
+	}
+}
+
+func TestModuleGraphCytoscape(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+		},
+	})
+	server.fileToTarget = map[string]string{"util/strings.cc": "//util:util"}
+
+	get := func() string {
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, httptest.NewRequest("GET", "/api/module/graph/cytoscape", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", w.Code)
+		}
+		return w.Body.String()
+	}
+
+	body := get()
+	var graph CytoscapeGraph
+	if err := json.Unmarshal([]byte(body), &graph); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	parents := make(map[string]string)
+	classes := make(map[string]string)
+	var ids []string
+	for _, node := range graph.Elements.Nodes {
+		ids = append(ids, node.Data.ID)
+		parents[node.Data.ID] = node.Data.Parent
+		classes[node.Data.ID] = node.Classes
+	}
+	wantIDs := []string{"//main", "//main:app", "//util", "//util:util", "//util:util:util/strings.cc"}
+	if !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("nodes = %v, want %v", ids, wantIDs)
+	}
+	if parents["//util:util"] != "//util" || parents["//util:util:util/strings.cc"] != "//util:util" {
+		t.Errorf("expected the file inside its target inside its package, got parents %v", parents)
+	}
+	if classes["//util"] != "package" || classes["//util:util"] != string(model.TargetKindLibrary) {
+		t.Errorf("expected the node types as classes, got %v", classes)
+	}
+
+	if len(graph.Elements.Edges) != 1 {
+		t.Fatalf("expected 1 edge, got %+v", graph.Elements.Edges)
+	}
+	if edge := graph.Elements.Edges[0].Data; edge.Source != "//main:app" || edge.Target != "//util:util" {
+		t.Errorf("unexpected edge %+v", edge)
+	}
+
+	if again := get(); again != body {
+		t.Error("expected the same graph to give the same output")
+	}
+}
+
+func TestGraphComparison(t *testing.T) {
+	server := NewServer()
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/graph/comparison", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a module: status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	module := &model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencySymbol},
+		},
+	}
+	module.UpdateProvenance()
+	server.SetModule(module)
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/graph/comparison", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var comparison model.GraphComparison
+	if err := json.Unmarshal(rec.Body.Bytes(), &comparison); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(comparison.Declared) != 0 || len(comparison.Inferred) != 1 || comparison.Inferred[0].Status != model.ComparisonInferredOnly {
+		t.Errorf("expected one inferred-only edge, got %+v", comparison)
+	}
+}
+
+func TestPath(t *testing.T) {
+	server := NewServer()
+	server.SetModule(&model.Module{
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary},
+			"//core:core": {Label: "//core:core", Kind: model.TargetKindLibrary},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//core:core", Type: model.DependencyStatic},
+			{From: "//core:core", To: "//util:util", Type: model.DependencyStatic},
+			{From: "//main:app", To: "//util:util", Type: model.DependencySymbol},
+		},
+	})
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/path?"+query, nil))
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) []model.DependencyPath {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+		var paths []model.DependencyPath
+		if err := json.Unmarshal(rec.Body.Bytes(), &paths); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return paths
+	}
+
+	paths := decode(get("from=//main:app&to=util"))
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 paths, got %+v", paths)
+	}
+	if want := []string{"//main:app", "//util:util"}; !reflect.DeepEqual(paths[0].Labels, want) {
+		t.Errorf("first path = %v, want the direct %v", paths[0].Labels, want)
+	}
+	if paths[0].Edges[0].Types[0] != model.DependencySymbol {
+		t.Errorf("direct edge types = %v, want [symbol]", paths[0].Edges[0].Types)
+	}
+
+	if paths := decode(get("from=//main:app&to=//util:util&shortest=true")); len(paths) != 1 || len(paths[0].Labels) != 2 {
+		t.Errorf("shortest=true: got %+v, want the direct path", paths)
+	}
+
+	rec := get("from=//util:util&to=//main:app")
+	if paths := decode(rec); len(paths) != 0 || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("without a path: got %s, want []", rec.Body.String())
+	}
+
+	if rec := get("from=//main:app&to=//missing:lib"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown target: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := get("from=//main:app"); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing to: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestShutdownEndsEventStreams(t *testing.T) {
+	server := NewServer()
+	listener, port, err := Listen(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	// An SSE subscriber would keep a plain http.Server.Shutdown waiting forever
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/api/subscribe/workspace_status", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	streamEnded := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		close(streamEnded)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() after Shutdown = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after Shutdown")
+	}
+	select {
+	case <-streamEnded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event stream still open after Shutdown")
+	}
+}
+
+func TestListenTriesFollowingPorts(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = busy.Close() }()
+	port := busy.Addr().(*net.TCPAddr).Port
+
+	if _, _, err := Listen(port, 0); err == nil {
+		t.Fatal("expected an error for a busy port without auto-increment")
+	}
+
+	listener, bound, err := Listen(port, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+	if bound <= port || bound > port+5 {
+		t.Errorf("bound port %d, want one of the 5 after %d", bound, port)
+	}
+	if got := listener.Addr().(*net.TCPAddr).Port; got != bound {
+		t.Errorf("listener is on port %d, reported %d", got, bound)
+	}
+}
+
+func TestHistoryEndpoint(t *testing.T) {
+	server := NewServer()
+	server.SetHistorySize(2)
+
+	get := func() []HistoryEntry {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/history", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/history: status %d", rec.Code)
+		}
+		var history []HistoryEntry
+		if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+			t.Fatal(err)
+		}
+		return history
+	}
+
+	if history := get(); len(history) != 0 {
+		t.Fatalf("expected an empty history, got %+v", history)
+	}
+
+	for i := 1; i <= 3; i++ {
+		server.RecordHistory(HistoryEntry{Reason: "run", Targets: i, Dependencies: 10 * i})
+	}
+	history := get()
+	if len(history) != 2 || history[0].Targets != 2 || history[1].Targets != 3 {
+		t.Errorf("expected the 2 latest entries oldest first, got %+v", history)
+	}
+
+	server.SetHistorySize(1)
+	if history := get(); len(history) != 1 || history[0].Targets != 3 {
+		t.Errorf("expected the history truncated to the latest entry, got %+v", history)
+	}
+}
+
+func TestExportHTML(t *testing.T) {
+	server := NewServer()
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export/html", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before analysis, got %d", rec.Code)
+	}
+
+	server.SetModule(&model.Module{
+		Name: "example",
+		Targets: map[string]*model.Target{
+			"//main:app":  {Label: "//main:app", Kind: model.TargetKindBinary, Package: "//main"},
+			"//util:util": {Label: "//util:util", Kind: model.TargetKindLibrary, Package: "//util"},
+		},
+		Dependencies: []model.Dependency{
+			{From: "//main:app", To: "//util:util", Type: model.DependencyStatic},
+		},
+	})
+
+	export := func(query string) (*GraphData, string) {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export/html"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/export/html%s: status %d: %s", query, rec.Code, rec.Body.String())
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Errorf("Content-Type = %q, want text/html", ct)
+		}
+
+		// The graph is inlined as a JavaScript object literal
+		page := rec.Body.String()
+		_, payload, found := strings.Cut(page, "const graph = ")
+		payload, _, _ = strings.Cut(payload, ";\n")
+		var graph GraphData
+		if err := json.Unmarshal([]byte(payload), &graph); !found || err != nil {
+			t.Fatalf("no graph payload in the page (%v): %s", err, page)
+		}
+		return &graph, page
+	}
+
+	graph, page := export("")
+	if !strings.Contains(page, "--bg-primary") {
+		t.Error("expected the stylesheet to be inlined")
+	}
+	ids := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		ids[node.ID] = true
+	}
+	if !ids["//main:app"] || !ids["//util:util"] || len(graph.Edges) != 1 {
+		t.Errorf("expected both targets and their edge with the overview lens, got %+v", graph)
+	}
+
+	// A lens hiding libraries
+	binariesOnly := `{"name": "Binaries", "baseSet": {"type": "full-graph"},
+		"distanceRules": [{"distance": "infinite", "nodeVisibility": {"targetTypes": ["cc_binary"]}, "collapseLevel": 2, "showEdges": true}],
+		"globalFilters": {}, "edgeRules": {"types": ["static"]}}`
+	graph, page = export("?defaultLens=" + url.QueryEscape(binariesOnly))
+	for _, node := range graph.Nodes {
+		if node.ID == "//util:util" {
+			t.Errorf("expected //util:util hidden by the lens, got %+v", graph.Nodes)
+		}
+	}
+	if !strings.Contains(page, "Binaries") {
+		t.Error("expected the lens name in the page")
+	}
+
+	for _, query := range []string{"?defaultLens=%7Bnot-json", "?focusMode=sideways"} {
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export/html"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/export/html%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestDebugArtifacts(t *testing.T) {
+	workspace := t.TempDir()
+	objs := filepath.Join(workspace, "bazel-bin", "util", "_objs", "util")
+	if err := os.MkdirAll(objs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(objs, "strings.d"), []byte("strings.o: util/strings.cc util/strings.h\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(objs, "strings.o"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	server.SetFileToTargetMap(map[string]string{"util/strings.cc": "//util:util"})
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/debug/artifacts", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /api/debug/artifacts without --debug returned %d, want 404", rec.Code)
+	}
+
+	server.EnableDebug(workspace, "")
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/debug/artifacts", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var got DebugArtifacts
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.DFileCount != 1 || got.DFiles[0].SourceFile != "util/strings.cc" || got.DFiles[0].Target != "//util:util" {
+		t.Errorf("dFiles = %+v", got.DFiles)
+	}
+	if got.ObjectFileCount != 1 {
+		t.Fatalf("objectFiles = %+v, want one", got.ObjectFiles)
+	}
+	if object := got.ObjectFiles[0]; object.SourceFile != "util/strings.cc" || object.Target != "//util:util" || object.PathTarget != "//util:util" {
+		t.Errorf("object file = %+v", object)
+	}
+}
+
+func TestSubscribeGraphDiff(t *testing.T) {
+	targets := map[string]*model.Target{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		label := "//lib:" + name
+		targets[label] = &model.Target{Label: label, Kind: model.TargetKindLibrary}
+	}
+	server := NewServer()
+	server.SetModule(&model.Module{Targets: targets})
+
+	lensConfig := `{"name": "default", "baseSet": {"type": "full-graph"},
+		"distanceRules": [{"distance": "infinite", "nodeVisibility": {"targetTypes": ["cc_library", "cc_binary"]}, "collapseLevel": 0, "showEdges": true}],
+		"edgeRules": {"types": []}}`
+	body := `{"defaultLens": ` + lensConfig + `, "detailLens": ` + lensConfig + `}`
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/module/graph/lens", strings.NewReader(body)))
+	var rendered LensRenderResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &rendered); err != nil || rendered.RequestHash == "" {
+		t.Fatalf("unexpected response %s: %v", rec.Body.String(), err)
+	}
+
+	rec = httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/subscribe/graph_diff?hash=unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown hash: status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+	// Closing the publisher ends the event stream, which httpServer.Close waits for
+	defer func() { _ = server.Shutdown(context.Background()) }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/api/subscribe/graph_diff?hash="+rendered.RequestHash, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	events := make(chan pubsub.Event, 10)
+	connected := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			if scanner.Text() == ": connected" {
+				close(connected)
+			}
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				var event pubsub.Event
+				if json.Unmarshal([]byte(data), &event) == nil {
+					events <- event
+				}
+			}
+		}
+	}()
+
+	// The stream opens once subscribed, so the re-analysis event is not missed
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("graph_diff stream did not open")
+	}
+	targets["//lib:g"] = &model.Target{Label: "//lib:g", Kind: model.TargetKindLibrary}
+	server.SetModule(&model.Module{Targets: targets})
+	if err := server.PublishTargetGraph("complete", true); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Topic != pubsub.GraphDiffTopic.Name() || event.Type != "diff" {
+			t.Fatalf("event %s/%s, want %s/diff", event.Topic, event.Type, pubsub.GraphDiffTopic.Name())
+		}
+		var update LensRenderResponse
+		if err := json.Unmarshal(event.Data, &update); err != nil || update.Diff == nil {
+			t.Fatalf("unexpected update %s: %v", event.Data, err)
+		}
+		added := make(map[string]bool)
+		for _, node := range update.Diff.AddedNodes {
+			added[node.ID] = true
+		}
+		if !added["//lib:g"] || len(update.Diff.RemovedNodes) != 0 {
+			t.Errorf("diff = %+v, want //lib:g added", update.Diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no graph_diff event after re-analysis")
+	}
+}
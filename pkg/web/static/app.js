@@ -9,12 +9,14 @@ const GRAPH_COLORS = {
   library: '#4fc1ff',
   binary: '#ff8c00',
   sharedLib: '#c586c0',
+  test: '#dcdcaa',
   systemLib: '#d7ba7d',
   source: '#89d185',
   header: '#4fc1ff',
   uncovered: '#ff6b6b',
   external: '#6a6a6a',
   package: '#4a4a4e',
+  owner: '#3a3d41',
   targetGroup: '#2d2d30',
 
   // Edge colors
@@ -27,6 +29,7 @@ const GRAPH_COLORS = {
 
   // State colors
   selected: '#ff8c00',
+  changed: '#b5f26b',
   overlap: '#ff4444',
   publicVis: '#ffd700',
 
@@ -233,6 +236,70 @@ function markAnalysisUpdate() {
   updateWatchingText();
 }
 
+// Show the duration of each analysis phase as the status bar tooltip
+function showAnalysisTimings(timings) {
+  const lines = timings.map((t) => `${t.phase}: ${t.durationMs} ms`);
+  const total = timings.reduce((sum, t) => sum + t.durationMs, 0);
+  lines.push(`total: ${total} ms`);
+  appLogger.info('Analysis timings:', lines.join(', '));
+
+  const statusBar = document.querySelector('.status-bar');
+  if (statusBar) {
+    statusBar.title = `Last analysis\n${lines.join('\n')}`;
+  }
+}
+
+// Ask the backend to run a full analysis again. Progress arrives on the
+// workspace_status stream like any other analysis.
+async function requestReanalysis() {
+  try {
+    const response = await fetch('/api/analyze', { method: 'POST' });
+    const result = await response.json().catch(() => ({}));
+
+    if (response.status === 409) {
+      showNotification('An analysis is already running');
+    } else if (!response.ok) {
+      showNotification(result.message || `Could not start analysis (${response.status})`);
+    } else {
+      showNotification('Re-analyzing workspace...');
+    }
+  } catch (error) {
+    appLogger.error('Failed to request re-analysis:', error);
+    showNotification('Could not start analysis');
+  }
+}
+
+// Show why the analysis failed until dismissed, with a retry button when retrying may
+// help. The graph of the previous analysis, if any, stays on screen.
+function showAnalysisError(status) {
+  document.querySelector('.notification.error')?.remove();
+
+  const notif = document.createElement('div');
+  notif.className = 'notification error';
+
+  const message = document.createElement('span');
+  message.textContent = status.message || 'Analysis failed';
+  notif.appendChild(message);
+
+  if (status.error?.recoverable) {
+    const retry = document.createElement('button');
+    retry.textContent = 'Retry';
+    retry.addEventListener('click', () => {
+      notif.remove();
+      requestReanalysis();
+    });
+    notif.appendChild(retry);
+  }
+
+  const dismiss = document.createElement('button');
+  dismiss.textContent = '×';
+  dismiss.title = 'Dismiss';
+  dismiss.addEventListener('click', () => notif.remove());
+  notif.appendChild(dismiss);
+
+  document.body.appendChild(notif);
+}
+
 // Update the subtitle with the module/workspace name and path
 function updateModuleName(name, workspacePath) {
   const subtitle = document.querySelector('.subtitle');
@@ -310,6 +377,26 @@ function displayDependencyGraph(graphData) {
         nodeData.isPublic = true;
       }
 
+      // Bazel tags, e.g. for grouping by team
+      if (node.tags && node.tags.length > 0) {
+        nodeData.tags = node.tags;
+      }
+
+      // CODEOWNERS owner of target nodes
+      if (node.owner) {
+        nodeData.owner = node.owner;
+      }
+
+      // Libraries without sources, only used through their headers
+      if (node.headerOnly) {
+        nodeData.headerOnly = true;
+      }
+
+      // Server-computed layer (omitted for rank 0), used as a layout constraint
+      if (graphHasRanks) {
+        nodeData.rank = node.rank || 0;
+      }
+
       // Add overlapping metadata for tooltips
       if (node.overlappingTargets && node.overlappingTargets.length > 0) {
         nodeData.overlappingTargets = node.overlappingTargets;
@@ -323,6 +410,7 @@ function displayDependencyGraph(graphData) {
     // Edges
     ...graphData.edges.map((edge) => {
       const edgeData = {
+        id: edge.id, // "<source>|<target>|<type>", stable across renders and diffs
         source: edge.source,
         target: edge.target,
         type: edge.type,
@@ -332,6 +420,10 @@ function displayDependencyGraph(graphData) {
         targetLabel: edge.targetLabel,
         fileDetails: edge.fileDetails || {},
       };
+      // Only set transitive if it's true, so that edge[?transitive] matches it alone
+      if (edge.transitive === true) {
+        edgeData.transitive = true;
+      }
       // Only set isOverlapping if it's true (don't set it at all if false)
       if (edge.isOverlapping === true) {
         edgeData.isOverlapping = true;
@@ -402,6 +494,10 @@ function displayDependencyGraph(graphData) {
       selector: 'node[type = "cc_shared_library"]',
       style: nodeStyle(GRAPH_COLORS.sharedLib, GRAPH_COLORS.textWhite, '#9d6b99'),
     },
+    {
+      selector: 'node[type = "cc_test"]',
+      style: nodeStyle(GRAPH_COLORS.test, GRAPH_COLORS.textLight, '#b5b58a'),
+    },
     {
       selector: 'node[type = "system_library"]',
       style: {
@@ -445,6 +541,27 @@ function displayDependencyGraph(graphData) {
         padding: '18px',
       },
     },
+    {
+      selector: 'node[type = "owner"]',
+      style: {
+        ...nodeStyle(GRAPH_COLORS.owner, GRAPH_COLORS.textDark, GRAPH_COLORS.borderMedium),
+        shape: 'roundrectangle',
+        'border-style': 'dashed',
+        'font-weight': 'bold',
+        'font-size': '16px',
+        padding: '24px',
+      },
+    },
+    {
+      selector: 'node[type = "directory"]',
+      style: {
+        ...nodeStyle(GRAPH_COLORS.targetGroup, GRAPH_COLORS.textGray, GRAPH_COLORS.borderMedium),
+        shape: 'roundrectangle',
+        'border-style': 'dotted',
+        'font-size': '12px',
+        padding: '12px',
+      },
+    },
     // Node state modifiers
     {
       selector: 'node[type $= "_selected"]',
@@ -552,6 +669,10 @@ function displayDependencyGraph(graphData) {
       selector: 'edge[type = "compile"]',
       style: edgeStyle(GRAPH_COLORS.blue, 2, 'solid'),
     },
+    {
+      selector: 'edge[type = "compile"][?transitive]',
+      style: edgeStyle(GRAPH_COLORS.blue, 1, 'dotted'),
+    },
     {
       selector: 'edge[type = "multi"]',
       style: edgeStyle(GRAPH_COLORS.lightBlue, 3, 'solid'),
@@ -586,7 +707,7 @@ function displayDependencyGraph(graphData) {
     // Overlapping dependencies - MUST come after other border styles to be visible
     {
       selector:
-        'node[hasOverlap][type = "cc_binary"], node[hasOverlap][type = "cc_shared_library"], node[hasOverlap][type = "cc_library"]',
+        'node[hasOverlap][type = "cc_binary"], node[hasOverlap][type = "cc_shared_library"], node[hasOverlap][type = "cc_library"], node[hasOverlap][type = "cc_test"]',
       style: {
         'border-width': '8px',
         'border-color': GRAPH_COLORS.overlap,
@@ -597,6 +718,15 @@ function displayDependencyGraph(graphData) {
       selector: 'edge[isOverlapping]',
       style: edgeStyle(GRAPH_COLORS.overlap, 4, 'solid'),
     },
+    // Targets affected by the last re-analysis (temporary, see highlightChangedNodes)
+    {
+      selector: 'node.changed',
+      style: {
+        'border-width': '6px',
+        'border-color': GRAPH_COLORS.changed,
+        'border-style': 'solid',
+      },
+    },
   ];
 
   if (isInitialLoad) {
@@ -625,17 +755,11 @@ function displayDependencyGraph(graphData) {
 
     // Get current element IDs
     const currentNodeIds = new Set(cy.nodes().map((n) => n.id()));
-    const currentEdgeIds = new Set(
-      cy.edges().map((e) => `${e.data('source')}|${e.data('target')}|${e.data('type')}`)
-    );
+    const currentEdgeIds = new Set(cy.edges().map((e) => e.id()));
 
     // Build sets of new element IDs
     const newNodeIds = new Set(elements.filter((e) => !e.data.source).map((e) => e.data.id));
-    const newEdgeIds = new Set(
-      elements
-        .filter((e) => e.data.source)
-        .map((e) => `${e.data.source}|${e.data.target}|${e.data.type}`)
-    );
+    const newEdgeIds = new Set(elements.filter((e) => e.data.source).map((e) => e.data.id));
 
     // Remove elements that no longer exist
     const nodesToRemove = Array.from(currentNodeIds).filter((id) => !newNodeIds.has(id));
@@ -645,10 +769,7 @@ function displayDependencyGraph(graphData) {
       `[Cytoscape] Removing ${nodesToRemove.length} nodes, ${edgesToRemove.length} edges`
     );
     nodesToRemove.forEach((id) => cy.getElementById(id).remove());
-    edgesToRemove.forEach((id) => {
-      const [source, target, type] = id.split('|');
-      cy.edges(`[source = "${source}"][target = "${target}"][type = "${type}"]`).remove();
-    });
+    edgesToRemove.forEach((id) => cy.getElementById(id).remove());
 
     // Update existing nodes and add new elements
     const elementsToAdd = [];
@@ -657,8 +778,7 @@ function displayDependencyGraph(graphData) {
     elements.forEach((e) => {
       if (e.data.source) {
         // Edge
-        const id = `${e.data.source}|${e.data.target}|${e.data.type}`;
-        if (!currentEdgeIds.has(id)) {
+        if (!currentEdgeIds.has(e.data.id)) {
           elementsToAdd.push(e);
         }
       } else {
@@ -815,6 +935,8 @@ function setupEventHandlers() {
         }
       } else if (edgeType === 'data') {
         tooltipText = `📄 Data Dependency\n\n${sourceLabel}\n  needs at runtime\n${targetLabel}\n\nSpecified in 'data' attribute.`;
+      } else if (edgeType === 'compile' && edge.data('transitive')) {
+        tooltipText = `📝 Transitive Compile Dependency\n\n${sourceLabel}\n  includes header through another header\n${targetLabel}\n\nDetected from .d files; not #included directly by the source.`;
       } else if (edgeType === 'compile') {
         tooltipText = `📝 Compile Dependency\n\n${sourceLabel}\n  #includes header\n${targetLabel}\n\nDetected from .d files (compiler dependency output).`;
       } else if (edgeType === 'system_link') {
@@ -898,15 +1020,25 @@ function setupEventHandlers() {
         if (nodeType === 'cc_binary') {
           tooltipText =
             '📦 Binary (cc_binary)\nExecutable program.\nLinks dependencies into final executable.';
+        } else if (nodeType === 'cc_library' && node.data('headerOnly')) {
+          tooltipText =
+            '📚 Header-only Library (cc_library)\nHeaders without sources.\nUsed through #include, produces no object files.';
         } else if (nodeType === 'cc_library') {
           tooltipText =
             '📚 Library (cc_library)\nStatic library.\nCompiled code reused by other targets.';
         } else if (nodeType === 'cc_shared_library') {
           tooltipText =
             '🔗 Shared Library (cc_shared_library)\nDynamic library (.so/.dylib).\nLoaded at runtime, shared between processes.';
+        } else if (nodeType === 'cc_test') {
+          tooltipText =
+            '🧪 Test (cc_test)\nTest executable.\nRun by bazel test, not shipped.';
         } else if (nodeType === 'system_library') {
           tooltipText =
             '⚙️ System Library\nExternal library from the system.\nProvided by OS or installed separately.';
+        } else if (nodeType === 'owner') {
+          tooltipText = `👥 Owner\n${nodeLabel}\nPackages assigned to this owner in CODEOWNERS.`;
+        } else if (nodeType === 'directory') {
+          tooltipText = `📂 Directory\n${nodeLabel}\nFiles of the target in this subdirectory.`;
         } else if (nodeType === 'target-group') {
           tooltipText =
             '📁 Target Container\nGroups files within a target.\nClick to focus on this target.';
@@ -975,6 +1107,11 @@ function setupEventHandlers() {
     let nodeId = node.data('id');
     const nodeType = node.data('type');
 
+    // Owner nodes only group packages, there is nothing to select
+    if (nodeType === 'owner') {
+      return;
+    }
+
     // If clicking a file node, select its parent target instead
     // Files don't have dependencies - their parent targets do
     const isFileNode =
@@ -983,8 +1120,12 @@ function setupEventHandlers() {
       nodeType === 'uncovered_source' ||
       nodeType === 'uncovered_header';
 
-    if (isFileNode) {
-      const parentId = node.data('parent');
+    if (isFileNode || nodeType === 'directory') {
+      // Files may be grouped in directory nodes within their target
+      let parentId = node.data('parent');
+      while (parentId && cy.getElementById(parentId).data('type') === 'directory') {
+        parentId = cy.getElementById(parentId).data('parent');
+      }
       if (parentId) {
         appLogger.info('File node clicked - redirecting to parent target:', {
           file: nodeId,
@@ -1053,6 +1194,15 @@ function updateCytoscapeSize() {
 // SSE subscriptions
 let workspaceStatusSource = null;
 let targetGraphSource = null;
+let changesSource = null;
+let graphDiffSource = null;
+
+// Request hash of the lens rendering graphDiffSource streams updates for
+let graphDiffRequestHash = null;
+
+// Targets affected by the last re-analysis, highlighted once they are in the graph
+let pendingChangedNodes = null;
+const CHANGE_HIGHLIGHT_MS = 5000;
 
 // State tracking for UI updates
 let graphDataLoaded = false;
@@ -1211,10 +1361,23 @@ function subscribeToWorkspaceStatus() {
         updateLoadingProgress(4, 5);
       } else if (status.state === 'analyzing_binaries') {
         updateLoadingProgress(5, 5); // Keep step 5 active during binary analysis
+      } else if (status.state === 'error') {
+        hideLoadingOverlay();
+        showAnalysisError(status);
       } else if (status.state === 'ready' || status.state === 'watching') {
         updateLoadingProgress(5, null); // Mark step 5 complete
         analysisComplete = true;
 
+        // Show how long each phase took (hover the status bar)
+        if (status.timings) {
+          showAnalysisTimings(status.timings);
+        }
+
+        // Explain missing results, e.g. a workspace that has not been built
+        for (const warning of status.warnings || []) {
+          showNotification(warning, 10000);
+        }
+
         hideLoadingOverlay();
 
         // Load/reload graph data
@@ -1239,6 +1402,11 @@ function subscribeToWorkspaceStatus() {
             targetGraphSource.close();
             targetGraphSource = null;
           }
+          if (changesSource) {
+            changesSource.close();
+            changesSource = null;
+          }
+          closeGraphDiffSubscription();
         }
       }
     } catch (e) {
@@ -1299,6 +1467,137 @@ function subscribeToTargetGraph() {
   };
 }
 
+// Subscribe to the targets changed by re-analyses (watch mode)
+function subscribeToChanges() {
+  changesSource = new EventSource('/api/subscribe/changes');
+
+  changesSource.onmessage = (event) => {
+    try {
+      const sseEvent = JSON.parse(event.data);
+
+      // sseEvent.data is json.RawMessage (already a JSON string), parse it
+      let changes;
+      if (typeof sseEvent.data === 'string') {
+        changes = JSON.parse(sseEvent.data);
+      } else {
+        changes = sseEvent.data;
+      }
+
+      appLogger.info('Re-analysis changed', changes.nodes.length, 'targets:', changes.reason);
+      pendingChangedNodes = changes.nodes;
+      // The graph is reloaded when the analysis is ready, which highlights the changes
+      // again in the new graph
+      highlightChangedNodes();
+    } catch (e) {
+      appLogger.error('Error processing changes event:', e);
+    }
+  };
+
+  changesSource.onerror = (error) => {
+    appLogger.error('Changes SSE error:', error);
+
+    // EventSource readyState: 0 = CONNECTING, 1 = OPEN, 2 = CLOSED
+    if (changesSource.readyState === 2) {
+      handleConnectionLost('changes');
+    }
+  };
+}
+
+// Subscribe to re-renderings of the current lens request after re-analyses. The server
+// sends the diff from the graph last shown, or the full graph, so the view updates without
+// fetching the rendered graph again.
+function subscribeToGraphDiff(requestHash) {
+  if (!requestHash || (requestHash === graphDiffRequestHash && graphDiffSource)) {
+    return;
+  }
+  closeGraphDiffSubscription();
+
+  graphDiffRequestHash = requestHash;
+  graphDiffSource = new EventSource(
+    `/api/subscribe/graph_diff?hash=${encodeURIComponent(requestHash)}`
+  );
+
+  graphDiffSource.onmessage = (event) => {
+    try {
+      const sseEvent = JSON.parse(event.data);
+
+      // sseEvent.data is json.RawMessage (already a JSON string), parse it
+      let update;
+      if (typeof sseEvent.data === 'string') {
+        update = JSON.parse(sseEvent.data);
+      } else {
+        update = sseEvent.data;
+      }
+
+      let renderedGraph;
+      if (sseEvent.type === 'full' && update.fullGraph) {
+        renderedGraph = update.fullGraph;
+      } else if (sseEvent.type === 'diff' && update.diff && currentGraphData) {
+        renderedGraph = applyGraphDiff(currentGraphData, update.diff);
+      } else {
+        appLogger.warn('Ignoring graph diff event:', sseEvent.type);
+        return;
+      }
+      appLogger.info('Graph updated by re-analysis:', sseEvent.type);
+
+      currentGraphHash = update.hash;
+      currentGraphData = renderedGraph;
+      if (binaryData) {
+        enrichGraphWithOverlappingInfo(renderedGraph, binaryData);
+      }
+      displayDependencyGraph(renderedGraph);
+      highlightChangedNodes();
+    } catch (e) {
+      appLogger.error('Error processing graph diff event:', e);
+    }
+  };
+
+  graphDiffSource.onerror = (error) => {
+    appLogger.warn('Graph diff SSE error:', error);
+
+    // EventSource readyState: 0 = CONNECTING, 1 = OPEN, 2 = CLOSED. A closed stream, e.g.
+    // for a request the server no longer knows, is opened again by the next rendering;
+    // lost connections are reported by the workspace status stream.
+    if (graphDiffSource?.readyState === 2) {
+      closeGraphDiffSubscription();
+    }
+  };
+}
+
+// Close the graph_diff stream, if any
+function closeGraphDiffSubscription() {
+  if (graphDiffSource) {
+    graphDiffSource.close();
+    graphDiffSource = null;
+  }
+  graphDiffRequestHash = null;
+}
+
+// Highlight the targets changed by the last re-analysis and focus the view on them.
+// Does nothing until they are in the rendered graph.
+function highlightChangedNodes() {
+  if (!cy || !pendingChangedNodes) return;
+
+  let changed = cy.collection();
+  pendingChangedNodes.forEach((id) => {
+    changed = changed.union(cy.getElementById(id));
+  });
+  if (changed.empty()) return;
+
+  cy.nodes('.changed').removeClass('changed');
+  changed.addClass('changed');
+  cy.animate({ fit: { eles: changed, padding: 80 } }, { duration: 500 });
+
+  const highlighted = pendingChangedNodes;
+  setTimeout(() => {
+    if (!cy) return;
+    changed.removeClass('changed');
+    if (pendingChangedNodes === highlighted) {
+      pendingChangedNodes = null;
+    }
+  }, CHANGE_HIGHLIGHT_MS);
+}
+
 // Enrich graph nodes with overlapping dependency information from binaries
 function enrichGraphWithOverlappingInfo(graph, binaries) {
   // Collect all overlapping targets across all binaries
@@ -1353,18 +1652,28 @@ async function loadGraphData() {
     // Fetch module graph
     const graphResponse = await monitoredFetch('/api/module/graph');
     appLogger.info('Module graph response status:', graphResponse.status);
+    if (graphResponse.status === 503) {
+      // Analysis has not completed yet, the loading overlay stays until it has
+      appLogger.info('Module graph not ready yet, analysis in progress');
+      return;
+    }
     if (graphResponse.ok) {
       packageGraph = await graphResponse.json();
       appLogger.info('Loaded graph with', packageGraph.nodes?.length, 'nodes');
 
-      // Render through backend lens API to ensure proper hierarchy and collapse states
-      if (packageGraph?.nodes && packageGraph.nodes.length > 0) {
+      // Render through backend lens API to ensure proper hierarchy and collapse states.
+      // After a re-analysis the graph_diff stream has already updated the rendering.
+      if (graphDiffSource) {
+        appLogger.debug('Graph updated through graph_diff, skipping the first render');
+      } else if (packageGraph?.nodes && packageGraph.nodes.length > 0) {
         appLogger.info('Graph loaded, rendering through backend lens API');
         try {
           const currentState = viewStateManager.getState();
           const renderedGraph = await fetchRenderedGraphFromBackend(currentState);
           // Note: binaryData not loaded yet at this point, will be enriched in second render
-          displayDependencyGraph(renderedGraph);
+          if (renderedGraph) {
+            displayDependencyGraph(renderedGraph);
+          }
         } catch (error) {
           appLogger.error('Error rendering graph via backend:', error);
         }
@@ -1413,11 +1722,14 @@ async function loadGraphData() {
       // Trigger initial render with backend lens API
       try {
         const renderedGraph = await fetchRenderedGraphFromBackend(viewStateManager.getState());
-        // Enrich with overlapping dependency information if we have binary data
-        if (binaryData && packageGraph) {
-          enrichGraphWithOverlappingInfo(renderedGraph, binaryData);
+        if (renderedGraph) {
+          // Enrich with overlapping dependency information if we have binary data
+          if (binaryData && packageGraph) {
+            enrichGraphWithOverlappingInfo(renderedGraph, binaryData);
+          }
+          displayDependencyGraph(renderedGraph);
+          highlightChangedNodes();
         }
-        displayDependencyGraph(renderedGraph);
       } catch (error) {
         appLogger.error('Error rendering graph via backend:', error);
       }
@@ -1438,6 +1750,11 @@ window.addEventListener('beforeunload', () => {
     targetGraphSource.close();
     targetGraphSource = null;
   }
+  if (changesSource) {
+    changesSource.close();
+    changesSource = null;
+  }
+  closeGraphDiffSubscription();
   if (cy) {
     cy.destroy();
     cy = null;
@@ -1527,6 +1844,12 @@ document.addEventListener('DOMContentLoaded', () => {
   // Set up navigation filter event handlers
   setupNavigationFilters();
 
+  // Manual re-analysis
+  document.getElementById('reanalyzeButton').addEventListener('click', requestReanalysis);
+
+  // Standalone HTML export of the current view
+  document.getElementById('exportButton').addEventListener('click', exportGraphHTML);
+
   // Close any existing connections first (in case of reload)
   if (workspaceStatusSource) {
     appLogger.info('Closing existing workspace_status connection');
@@ -1556,6 +1879,7 @@ document.addEventListener('DOMContentLoaded', () => {
   // Subscribe to both event streams
   subscribeToWorkspaceStatus();
   subscribeToTargetGraph();
+  subscribeToChanges();
 });
 
 // Close modal handlers
@@ -1597,6 +1921,9 @@ const GRAPH_CONFIG = {
     edgeSep: 20,
     rankSep: 120,
     padding: 50,
+    // Graphs with at least this many nodes request server-computed ranks (?ranks=true),
+    // which makes the layout faster and more stable
+    serverRanksMinNodes: 300,
   },
 };
 
@@ -1613,6 +1940,9 @@ let currentGraphData = null;
 // Track pending request for cancellation
 let pendingRequestController = null;
 
+// Whether the nodes of the current graph carry server-computed ranks
+let graphHasRanks = false;
+
 /**
  * Run Dagre layout with stable, deterministic ordering
  * Uses centralized configuration for consistent layout parameters
@@ -1624,11 +1954,19 @@ let pendingRequestController = null;
 function runStableDagreLayout(animate = true, fit = false) {
   if (!cy) return;
 
+  // Server ranks become minimum edge lengths, pinning each node to its layer
+  const rankOptions = graphHasRanks
+    ? {
+        minLen: (edge) => Math.max(1, (edge.target().data('rank') || 0) - (edge.source().data('rank') || 0)),
+      }
+    : {};
+
   // Run layout with configuration
   cy.layout({
     name: 'dagre',
     rankDir: 'TB', // Top to bottom - arrows go down
     ranker: 'network-simplex', // Most deterministic ranker
+    ...rankOptions,
     nodeSep: GRAPH_CONFIG.layout.nodeSep,
     edgeSep: GRAPH_CONFIG.layout.edgeSep,
     rankSep: GRAPH_CONFIG.layout.rankSep,
@@ -1640,10 +1978,43 @@ function runStableDagreLayout(animate = true, fit = false) {
   }).run();
 }
 
+/**
+ * Convert a lens for JSON serialization (edgeRules.types is a Set)
+ * @param {Object} lens - Lens configuration
+ * @returns {Object} Lens with edgeRules.types as an array
+ */
+function serializeLens(lens) {
+  return {
+    ...lens,
+    edgeRules: {
+      ...lens.edgeRules,
+      types: Array.from(lens.edgeRules.types),
+    },
+  };
+}
+
+/**
+ * Download the current view as a standalone HTML file that can be shared and opened
+ * without the server (GET /api/export/html)
+ */
+function exportGraphHTML() {
+  const viewState = viewStateManager.getState();
+  const params = new URLSearchParams();
+  params.set('defaultLens', JSON.stringify(serializeLens(viewState.defaultLens)));
+  params.set('detailLens', JSON.stringify(serializeLens(viewState.detailLens)));
+  for (const node of viewState.selectedNodes) {
+    params.append('selected', node);
+  }
+  params.set('focusMode', viewState.focusMode);
+
+  appLogger.info('[App] Exporting graph as HTML');
+  window.location.href = `/api/export/html?${params}`;
+}
+
 /**
  * Fetch rendered graph from backend lens API
  * @param {Object} viewState - Current view state with lens configurations
- * @returns {Promise<Object>} Rendered graph from backend
+ * @returns {Promise<Object|null>} Rendered graph from backend, or null if analysis has not completed
  */
 async function fetchRenderedGraphFromBackend(viewState) {
   // Cancel any pending request
@@ -1658,25 +2029,22 @@ async function fetchRenderedGraphFromBackend(viewState) {
 
   appLogger.info('[App] Fetching rendered graph from backend lens API');
 
-  // Convert edgeRules.types from Set to Array for JSON serialization
-  const serializeLens = (lens) => ({
-    ...lens,
-    edgeRules: {
-      ...lens.edgeRules,
-      types: Array.from(lens.edgeRules.types),
-    },
-  });
-
   const requestBody = {
     defaultLens: serializeLens(viewState.defaultLens),
     detailLens: serializeLens(viewState.detailLens),
     selectedNodes: Array.from(viewState.selectedNodes),
+    focusMode: viewState.focusMode,
     previousHash: currentGraphHash, // Send previous hash for diff-based updates
   };
 
   appLogger.debug('[App] Request body:', JSON.stringify(requestBody, null, 2));
 
-  const response = await fetch('/api/module/graph/lens', {
+  // Large graphs lay out faster with server-computed ranks
+  const withRanks =
+    currentGraphData !== null && currentGraphData.nodes.length >= GRAPH_CONFIG.layout.serverRanksMinNodes;
+  const url = withRanks ? '/api/module/graph/lens?ranks=true' : '/api/module/graph/lens';
+
+  const response = await fetch(url, {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
@@ -1685,6 +2053,12 @@ async function fetchRenderedGraphFromBackend(viewState) {
     signal: signal, // Attach abort signal
   });
 
+  if (response.status === 503) {
+    // No analysis data yet, nothing to render
+    appLogger.info('[App] Graph not ready yet, analysis in progress');
+    return null;
+  }
+
   if (!response.ok) {
     const errorText = await response.text();
     throw new Error(`Backend lens rendering failed: ${response.statusText} - ${errorText}`);
@@ -1694,6 +2068,10 @@ async function fetchRenderedGraphFromBackend(viewState) {
 
   // Update current hash
   currentGraphHash = responseData.hash;
+  graphHasRanks = withRanks;
+
+  // Re-analyses update this rendering through the graph_diff stream
+  subscribeToGraphDiff(responseData.requestHash);
 
   // Handle diff vs full graph response
   let renderedGraph;
@@ -1741,7 +2119,7 @@ function applyGraphDiff(currentGraph, diff) {
 
   // Create maps for efficient lookup
   const nodeMap = new Map(currentGraph.nodes.map((n) => [n.id, n]));
-  const edgeMap = new Map(currentGraph.edges.map((e) => [`${e.source}|${e.target}|${e.type}`, e]));
+  const edgeMap = new Map(currentGraph.edges.map((e) => [e.id, e]));
 
   // Apply node changes
   if (diff.removedNodes) {
@@ -1758,14 +2136,11 @@ function applyGraphDiff(currentGraph, diff) {
 
   // Apply edge changes
   if (diff.removedEdges) {
-    diff.removedEdges.forEach((edgeKey) => edgeMap.delete(edgeKey));
+    diff.removedEdges.forEach((edgeId) => edgeMap.delete(edgeId));
   }
 
   if (diff.addedEdges) {
-    diff.addedEdges.forEach((edge) => {
-      const key = `${edge.source}|${edge.target}|${edge.type}`;
-      edgeMap.set(key, edge);
-    });
+    diff.addedEdges.forEach((edge) => edgeMap.set(edge.id, edge));
   }
 
   // Convert maps back to arrays
@@ -1826,6 +2201,9 @@ viewStateManager.addListener(async (newState) => {
   try {
     // Fetch rendered graph from backend
     const renderedGraph = await fetchRenderedGraphFromBackend(newState);
+    if (!renderedGraph) {
+      return;
+    }
 
     // Enrich with overlapping dependency information if we have binary data
     if (binaryData) {
@@ -1873,7 +2251,7 @@ function populateTreeBrowser(data) {
 
   // Store all target nodes for client-side filtering
   allTargetNodes = data.graph.nodes.filter((node) => {
-    const allowedTypes = ['cc_binary', 'cc_library', 'cc_shared_library'];
+    const allowedTypes = ['cc_binary', 'cc_library', 'cc_shared_library', 'cc_test'];
     return allowedTypes.includes(node.type);
   });
 
@@ -1892,7 +2270,7 @@ window.filterAndRenderNavigationList = function filterAndRenderNavigationList()
 
   // Get current filters from state
   const filters = viewStateManager.state.navigationFilters;
-  const ruleTypes = filters.ruleTypes || new Set(['cc_binary', 'cc_library', 'cc_shared_library']);
+  const ruleTypes = filters.ruleTypes || new Set(['cc_binary', 'cc_library', 'cc_shared_library', 'cc_test']);
   const searchText = (filters.searchText || '').toLowerCase();
 
   // Apply client-side filtering
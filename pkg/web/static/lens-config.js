@@ -35,6 +35,9 @@
  * @property {boolean} [hideUncovered] - Hide uncovered files
  * @property {boolean} [hideSystemLibs] - Hide system libraries
  * @property {boolean} [hideNonBinaries] - Hide non-binary targets (show only LDD)
+ * @property {boolean} [hideTests] - Hide test targets and their files
+ * @property {boolean} [hideUnreachable] - Hide targets no binary or test depends on, and their files
+ * @property {string[]} [hideTags] - Hide targets with any of these Bazel tags and their files
  */
 
 /**
@@ -51,6 +54,16 @@
  * @property {DistanceRule[]} distanceRules - Rules by distance from focus
  * @property {FilterConfig} globalFilters - Always-applied filters
  * @property {EdgeDisplayRules} edgeRules - Edge visibility rules
+ * @property {''|'owner'} [groupBy] - Cluster top-level nodes by package ('') or CODEOWNERS owner
+ * @property {number} [groupFilesAbove] - Group the files of targets showing more than this many by subdirectory (0 = never)
+ * @property {ManualOverride[]} [overrides] - Collapse states overriding the distance rules
+ */
+
+/**
+ * @typedef {Object} ManualOverride
+ * @property {string} nodeId - Node to collapse or expand
+ * @property {boolean} collapsed - Whether the node is collapsed
+ * @property {boolean} [package] - Apply to the package, its subpackages and everything in them
  */
 
 /**
@@ -66,7 +79,7 @@ const DEFAULT_PACKAGE_LENS = {
     {
       distance: 'infinite',
       nodeVisibility: {
-        targetTypes: ['cc_binary', 'cc_shared_library', 'cc_library'],
+        targetTypes: ['cc_binary', 'cc_shared_library', 'cc_library', 'cc_test'],
         fileTypes: ['none'], // Hide files by default
         showUncovered: false,
         showExternal: true, // Show external dependencies
@@ -77,7 +90,9 @@ const DEFAULT_PACKAGE_LENS = {
       edgeTypes: ['static', 'dynamic', 'system_link', 'data', 'compile', 'symbol'],
     },
   ],
-  globalFilters: {},
+  globalFilters: {
+    hideUnreachable: true, // Focus on code that ships or is tested
+  },
   edgeRules: {
     types: new Set(['static', 'dynamic', 'system_link', 'data', 'compile', 'symbol']),
     aggregateCollapsed: true,
@@ -99,7 +114,7 @@ const DEFAULT_DETAIL_LENS = {
     {
       distance: 0, // Selected nodes
       nodeVisibility: {
-        targetTypes: ['cc_binary', 'cc_shared_library', 'cc_library'],
+        targetTypes: ['cc_binary', 'cc_shared_library', 'cc_library', 'cc_test'],
         fileTypes: ['all'], // Show all files
         showUncovered: true,
         showExternal: true, // Show external dependencies
@@ -112,7 +127,7 @@ const DEFAULT_DETAIL_LENS = {
     {
       distance: 1, // Neighbors (direct dependencies)
       nodeVisibility: {
-        targetTypes: ['cc_binary', 'cc_shared_library', 'cc_library'],
+        targetTypes: ['cc_binary', 'cc_shared_library', 'cc_library', 'cc_test'],
         fileTypes: ['none'], // Hide files by default
         showUncovered: false,
         showExternal: true, // Show external dependencies
@@ -167,5 +182,8 @@ function cloneLens(lens) {
       collapseEdgeTypes: lens.edgeRules.collapseEdgeTypes,
       minimumCount: lens.edgeRules.minimumCount,
     },
+    groupBy: lens.groupBy,
+    groupFilesAbove: lens.groupFilesAbove,
+    overrides: lens.overrides ? lens.overrides.map((override) => ({ ...override })) : undefined,
   };
 }
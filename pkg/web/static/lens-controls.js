@@ -65,6 +65,31 @@ function syncUIWithState() {
     hideSystemLibsCheckbox.checked = filters.hideSystemLibs || false;
   }
 
+  const hideTestsCheckbox = document.getElementById('hideTests');
+  if (hideTestsCheckbox) {
+    hideTestsCheckbox.checked = filters.hideTests || false;
+  }
+
+  const hideUnreachableCheckbox = document.getElementById('hideUnreachable');
+  if (hideUnreachableCheckbox) {
+    hideUnreachableCheckbox.checked = filters.hideUnreachable || false;
+  }
+
+  const hideTagsInput = document.getElementById('hideTags');
+  if (hideTagsInput) {
+    hideTagsInput.value = (filters.hideTags || []).join(', ');
+  }
+
+  const groupBySelect = document.getElementById('groupBy');
+  if (groupBySelect) {
+    groupBySelect.value = state.defaultLens.groupBy || '';
+  }
+
+  const groupFilesAboveInput = document.getElementById('groupFilesAbove');
+  if (groupFilesAboveInput) {
+    groupFilesAboveInput.value = state.defaultLens.groupFilesAbove || 0;
+  }
+
   const showOnlyLddCheckbox = document.getElementById('showOnlyLdd');
   if (showOnlyLddCheckbox) {
     showOnlyLddCheckbox.checked = filters.showOnlyLdd || false;
@@ -158,7 +183,7 @@ function setupResetControls() {
  */
 function setupDefaultLensControls() {
   // Global filters
-  const filterIds = ['hideExternal', 'hideUncovered', 'hideSystemLibs', 'showOnlyLdd'];
+  const filterIds = ['hideExternal', 'hideUncovered', 'hideSystemLibs', 'hideTests', 'hideUnreachable', 'showOnlyLdd'];
   filterIds.forEach((id) => {
     const checkbox = document.getElementById(id);
     if (checkbox) {
@@ -172,11 +197,48 @@ function setupDefaultLensControls() {
           document.getElementById('hideSystemLibs')?.checked || false;
         currentLens.globalFilters.hideNonBinaries =
           document.getElementById('hideNonBinaries')?.checked || false;
+        currentLens.globalFilters.hideTests =
+          document.getElementById('hideTests')?.checked || false;
+        currentLens.globalFilters.hideUnreachable =
+          document.getElementById('hideUnreachable')?.checked || false;
         viewStateManager.updateDefaultLens(currentLens);
       });
     }
   });
 
+  // Tags to hide, comma-separated
+  const hideTagsInput = document.getElementById('hideTags');
+  if (hideTagsInput) {
+    hideTagsInput.addEventListener('change', () => {
+      const currentLens = cloneLens(viewStateManager.getState().defaultLens);
+      currentLens.globalFilters.hideTags = hideTagsInput.value
+        .split(',')
+        .map((tag) => tag.trim())
+        .filter((tag) => tag !== '');
+      viewStateManager.updateDefaultLens(currentLens);
+    });
+  }
+
+  // Cluster top-level nodes by package or owner
+  const groupBySelect = document.getElementById('groupBy');
+  if (groupBySelect) {
+    groupBySelect.addEventListener('change', () => {
+      const currentLens = cloneLens(viewStateManager.getState().defaultLens);
+      currentLens.groupBy = groupBySelect.value;
+      viewStateManager.updateDefaultLens(currentLens);
+    });
+  }
+
+  // Group the files of large targets by subdirectory
+  const groupFilesAboveInput = document.getElementById('groupFilesAbove');
+  if (groupFilesAboveInput) {
+    groupFilesAboveInput.addEventListener('change', () => {
+      const currentLens = cloneLens(viewStateManager.getState().defaultLens);
+      currentLens.groupFilesAbove = Math.max(0, Number.parseInt(groupFilesAboveInput.value, 10) || 0);
+      viewStateManager.updateDefaultLens(currentLens);
+    });
+  }
+
   // Edge type checkboxes
   const edgeTypeIds = ['showStatic', 'showDynamic', 'showData', 'showCompile', 'showSymbol'];
   edgeTypeIds.forEach((id) => {
@@ -323,7 +385,7 @@ function setupDetailLensControls() {
           rule.nodeVisibility.targetTypes = [];
         } else if (e.target.value === 'collapsed') {
           // Show collapsed
-          rule.nodeVisibility.targetTypes = ['cc_binary', 'cc_shared_library', 'cc_library'];
+          rule.nodeVisibility.targetTypes = ['cc_binary', 'cc_shared_library', 'cc_library', 'cc_test'];
           rule.collapseLevel = 1; // Package level
         } else {
           // Same as default
@@ -339,4 +401,12 @@ function setupDetailLensControls() {
       viewStateManager.updateDetailLens(currentLens);
     });
   }
+
+  // How multiple selected nodes combine: near any of them, or near all of them
+  const focusMode = document.getElementById('focusMode');
+  if (focusMode) {
+    focusMode.addEventListener('change', (e) => {
+      viewStateManager.setFocusMode(e.target.value);
+    });
+  }
 }
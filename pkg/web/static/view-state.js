@@ -30,10 +30,11 @@ class ViewStateManager {
       // Layer 2: Detail lens
       detailLens: savedState?.detailLens || cloneLens(DEFAULT_DETAIL_LENS),
       selectedNodes: new Set(), // Never persist selection
+      focusMode: 'union', // 'union' | 'intersection' for multiple selected nodes
 
       // Navigation filters
       navigationFilters: savedState?.navigationFilters || {
-        ruleTypes: new Set(['cc_binary', 'cc_library', 'cc_shared_library']),
+        ruleTypes: new Set(['cc_binary', 'cc_library', 'cc_shared_library', 'cc_test']),
         searchText: '',
       },
 
@@ -140,6 +141,15 @@ class ViewStateManager {
     this.notifyListeners();
   }
 
+  /**
+   * Set how multiple selected nodes combine
+   * @param {'union'|'intersection'} mode - Show nodes near any ('union') or all ('intersection') of them
+   */
+  setFocusMode(mode) {
+    this.state.focusMode = mode;
+    this.notifyListeners();
+  }
+
   /**
    * Clear all selected nodes
    */